@@ -0,0 +1,75 @@
+// cmd/bench.go
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/bench"
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// errCERExceeded indicates the corpus error rate is above the --max-cer limit
+var errCERExceeded = errors.New("character error rate above limit")
+
+var benchCmd = &cobra.Command{
+	Use:   "bench <wav file or directory>...",
+	Short: "Measure decoding accuracy over a corpus of recordings",
+	Long: `Bench decodes each recording offline with the current configuration and compares
+the result with its reference transcript, reporting character and word error rates
+per file and for the whole corpus. If an ensemble is configured, the consensus of
+its members is scored.
+
+Each WAV file needs a reference with the same base name: a .txt file of plain text,
+or a .json transcript as written by "decoder generate". Directories are scanned for
+*.wav files.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runBench,
+}
+
+// runBench decodes the corpus and writes the accuracy report.
+func runBench(cmd *cobra.Command, args []string) error {
+	settings, err := config.Get()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	format, _ := cmd.Flags().GetString("format")
+	if format != "text" && format != "json" {
+		return fmt.Errorf("format must be text or json, got %q", format)
+	}
+	maxCER, _ := cmd.Flags().GetFloat64("max-cer")
+	printWarnings(cmd.ErrOrStderr(), settings)
+
+	entries, err := bench.LoadCorpus(args)
+	if err != nil {
+		return err
+	}
+	report, err := bench.Run(*settings, entries)
+	if err != nil {
+		return fmt.Errorf("bench: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if format == "json" {
+		err = report.WriteJSON(out)
+	} else {
+		err = report.WriteText(out)
+	}
+	if err != nil {
+		return err
+	}
+
+	if cmd.Flags().Changed("max-cer") && report.CER*100 > maxCER {
+		return fmt.Errorf("%w: %.2f%% > %.2f%%", errCERExceeded, report.CER*100, maxCER)
+	}
+	return nil
+}
+
+func init() {
+	benchCmd.Flags().String("format", "text", "report format (text or json)")
+	benchCmd.Flags().Float64("max-cer", 0, "fail if the corpus character error rate exceeds this percentage")
+
+	rootCmd.AddCommand(benchCmd)
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/bench"
+)
+
+// setupBenchCorpus writes a config and a one-file corpus generated by the generate command
+func setupBenchCorpus(t *testing.T) string {
+	t.Helper()
+	resetViperForTest()
+	resetSubcommandFlags()
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	configDir := filepath.Join(tmpDir, ".config", "cwdecoder")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	config := "sample_rate: 8000\nblock_size: 128\nhysteresis: 2\nwpm: 20\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	corpus := filepath.Join(tmpDir, "corpus")
+	if err := os.MkdirAll(corpus, 0755); err != nil {
+		t.Fatalf("failed to create corpus dir: %v", err)
+	}
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetErr(&buf)
+	rootCmd.SetArgs([]string{"generate", "--text", "CQ DE W1AW", "--output", filepath.Join(corpus, "cq.wav")})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("generate error = %v", err)
+	}
+	return corpus
+}
+
+func TestBenchCmd_JSON(t *testing.T) {
+	corpus := setupBenchCorpus(t)
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs([]string{"bench", "--format", "json", corpus})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("bench error = %v", err)
+	}
+
+	var report bench.Report
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("bench output is not JSON: %v\n%s", err, buf.String())
+	}
+	if len(report.Files) != 1 || report.Files[0].Reference != "CQ DE W1AW" {
+		t.Errorf("report = %+v", report)
+	}
+}
+
+func TestBenchCmd_MaxCER(t *testing.T) {
+	corpus := setupBenchCorpus(t)
+
+	// A reference that cannot match forces a high error rate
+	if err := os.WriteFile(filepath.Join(corpus, "cq.txt"), []byte("QRZ"), 0644); err != nil {
+		t.Fatalf("failed to write reference: %v", err)
+	}
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs([]string{"bench", "--format", "text", "--max-cer", "5", corpus})
+	if err := rootCmd.Execute(); !errors.Is(err, errCERExceeded) {
+		t.Errorf("bench error = %v, want errCERExceeded", err)
+	}
+}
+
+func TestBenchCmd_Ensemble(t *testing.T) {
+	corpus := setupBenchCorpus(t)
+
+	// The fixed 40 WPM member cannot decode the 20 WPM recording alone
+	config := "sample_rate: 8000\nblock_size: 128\nhysteresis: 2\nwpm: 20\n" +
+		"ensemble:\n  - {threshold: 0.3, hysteresis: 3}\n  - {wpm: 40, adaptive_timing: false}\n"
+	configPath := filepath.Join(os.Getenv("HOME"), ".config", "cwdecoder", "config.yaml")
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	resetViperForTest()
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs([]string{"bench", "--format", "json", corpus})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("bench error = %v", err)
+	}
+
+	var report bench.Report
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("bench output is not JSON: %v\n%s", err, buf.String())
+	}
+	if len(report.Files) != 1 || report.Files[0].Decoded != "CQ DE W1AW" {
+		t.Errorf("report = %+v", report)
+	}
+}
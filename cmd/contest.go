@@ -0,0 +1,77 @@
+// cmd/contest.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/contest"
+)
+
+// contestLogger collects decoded contest exchanges for the Cabrillo log.
+type contestLogger struct {
+	parser *contest.Parser
+	path   string
+
+	mu  sync.Mutex
+	log contest.Log
+}
+
+// newContestLogger creates a contest exchange parser that prints each exchange
+// and keeps it for the Cabrillo log written on exit.
+func newContestLogger(settings *config.Settings) (*contestLogger, error) {
+	c, err := contest.Lookup(settings.Contest)
+	if err != nil {
+		return nil, err
+	}
+	sent, err := c.ParseSent(settings.ContestExchange)
+	if err != nil {
+		return nil, fmt.Errorf("contest exchange: %w", err)
+	}
+
+	logger := &contestLogger{
+		parser: contest.NewParser(c, settings.ContestCall),
+		path:   settings.ContestLog,
+		log: contest.Log{
+			Contest:   c,
+			Callsign:  settings.ContestCall,
+			Frequency: settings.ContestFrequency,
+			Sent:      sent,
+		},
+	}
+	logger.parser.SetCallback(func(qso contest.QSO) {
+		fmt.Printf("\n[CONTEST] %s %s\n", qso.Call, strings.Join(qso.Exchange, " "))
+		logger.mu.Lock()
+		logger.log.QSOs = append(logger.log.QSOs, qso)
+		logger.mu.Unlock()
+	})
+	return logger, nil
+}
+
+// close flushes the parser and writes the Cabrillo log if a path is configured.
+func (l *contestLogger) close() error {
+	l.parser.Flush()
+	if l.path == "" {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Create(l.path)
+	if err != nil {
+		return fmt.Errorf("create contest log: %w", err)
+	}
+	if err := contest.WriteCabrillo(f, l.log); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close contest log: %w", err)
+	}
+	fmt.Printf("Wrote %d contest QSOs to %s\n", len(l.log.QSOs), l.path)
+	return nil
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/contest"
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+func TestContestLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.cbr")
+	logger, err := newContestLogger(&config.Settings{
+		Contest:         contest.CQWW,
+		ContestCall:     "K1ABC",
+		ContestExchange: "599 5",
+		ContestLog:      path,
+	})
+	if err != nil {
+		t.Fatalf("newContestLogger() error = %v", err)
+	}
+
+	start := time.Date(2024, 11, 30, 0, 1, 0, 0, time.UTC)
+	for i, char := range "DL1ABC 5NN 14 " {
+		output := cw.DecodedOutput{Character: char, Timestamp: start.Add(time.Duration(i) * 100 * time.Millisecond)}
+		output.IsWordSpace = char == ' '
+		logger.parser.HandleOutput(output)
+	}
+	if err := logger.close(); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read contest log: %v", err)
+	}
+	if !strings.Contains(string(data), "DL1ABC") {
+		t.Errorf("contest log has no QSO with DL1ABC:\n%s", data)
+	}
+}
+
+func TestNewContestLogger_InvalidExchange(t *testing.T) {
+	_, err := newContestLogger(&config.Settings{Contest: contest.CQWW, ContestExchange: "599"})
+	if err == nil || !strings.Contains(err.Error(), "contest exchange") {
+		t.Errorf("newContestLogger() error = %v, want a contest exchange error", err)
+	}
+}
@@ -0,0 +1,87 @@
+// cmd/elements.go
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+// elementPrinter prints the element stream the decoder heard, one character per line:
+// its code, then each element's length in milliseconds and in the decoder's dit units.
+// Word spaces are printed as "/". With text, the line ends with the character the
+// decoder made of it.
+type elementPrinter struct {
+	w        io.Writer
+	withText bool
+	dit      time.Duration // the decoder's dit estimate at its last output
+
+	code      strings.Builder
+	durations []time.Duration
+	ended     bool // the character's last element has arrived and waits for its output
+}
+
+// newElementPrinter creates an element printer. Until the decoder's first output,
+// lengths are in dits at wpm, the speed the decoder starts at.
+func newElementPrinter(w io.Writer, wpm int, withText bool) *elementPrinter {
+	return &elementPrinter{w: w, withText: withText, dit: cw.DitDuration(wpm)}
+}
+
+// element records one element of the character being decoded.
+// Signature matches cw.ElementCallback so it can be chained directly.
+func (p *elementPrinter) element(isDah bool, duration, _ time.Duration, isCharEnd, _ bool) {
+	// The decoder made no character of the previous code
+	p.flush()
+
+	if isDah {
+		p.code.WriteByte('-')
+	} else {
+		p.code.WriteByte('.')
+	}
+	p.durations = append(p.durations, duration)
+	p.ended = isCharEnd
+}
+
+// output prints the ended character's line in the decoder's current dit units, or a word space.
+func (p *elementPrinter) output(output cw.DecodedOutput) {
+	if !output.IsFinal() {
+		return
+	}
+	if output.DitDuration > 0 {
+		p.dit = output.DitDuration
+	}
+	switch {
+	case output.IsWordSpace:
+		p.flush()
+		_, _ = fmt.Fprintln(p.w, "/")
+	case output.Character != 0 && p.ended:
+		p.printLine(output.Character)
+	}
+}
+
+// flush prints an ended character's line that no output followed.
+func (p *elementPrinter) flush() {
+	if p.ended {
+		p.printLine(0)
+	}
+}
+
+// printLine prints the code and element lengths, then char if text is printed.
+func (p *elementPrinter) printLine(char rune) {
+	lengths := make([]string, len(p.durations))
+	for i, duration := range p.durations {
+		lengths[i] = fmt.Sprintf("%.0fms/%.1f", duration.Seconds()*1000, duration.Seconds()/p.dit.Seconds())
+	}
+	_, _ = fmt.Fprintf(p.w, "%-8s %s", p.code.String(), strings.Join(lengths, "  "))
+	if p.withText && char != 0 {
+		_, _ = fmt.Fprintf(p.w, "  %c", char)
+	}
+	_, _ = fmt.Fprintln(p.w)
+
+	p.code.Reset()
+	p.durations = p.durations[:0]
+	p.ended = false
+}
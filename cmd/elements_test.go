@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+func TestElementPrinter(t *testing.T) {
+	ms := func(n int) time.Duration { return time.Duration(n) * time.Millisecond }
+	// K at 20 WPM (60 ms dit), then E after the decoder has slowed to a 66 ms dit, a word apart
+	send := func(p *elementPrinter) {
+		p.element(true, ms(180), ms(60), false, false)
+		p.element(false, ms(60), ms(60), false, false)
+		p.element(true, ms(180), ms(420), true, true)
+		p.output(cw.DecodedOutput{Character: 'K', DitDuration: ms(60)})
+		p.output(cw.DecodedOutput{Character: ' ', IsWordSpace: true, DitDuration: ms(60)})
+		p.element(false, ms(66), ms(500), true, true)
+		p.output(cw.DecodedOutput{Character: 'E', DitDuration: ms(66)})
+		p.output(cw.DecodedOutput{Character: ' ', IsWordSpace: true, DitDuration: ms(66)})
+	}
+
+	t.Run("only", func(t *testing.T) {
+		var buf bytes.Buffer
+		send(newElementPrinter(&buf, 20, false))
+		want := "-.-      180ms/3.0  60ms/1.0  180ms/3.0\n/\n.        66ms/1.0\n/\n"
+		if got := buf.String(); got != want {
+			t.Errorf("printed %q, want %q", got, want)
+		}
+	})
+
+	t.Run("both", func(t *testing.T) {
+		var buf bytes.Buffer
+		send(newElementPrinter(&buf, 20, true))
+		want := "-.-      180ms/3.0  60ms/1.0  180ms/3.0  K\n/\n.        66ms/1.0  E\n/\n"
+		if got := buf.String(); got != want {
+			t.Errorf("printed %q, want %q", got, want)
+		}
+	})
+
+	t.Run("undecoded code", func(t *testing.T) {
+		var buf bytes.Buffer
+		p := newElementPrinter(&buf, 20, true)
+		p.element(true, ms(180), ms(180), true, false)
+		// A provisional character does not end the line; the next character does
+		p.output(cw.DecodedOutput{Character: 'T', Provisional: true, DitDuration: ms(90)})
+		p.element(false, ms(60), ms(180), true, false)
+		p.flush()
+		want := "-        180ms/3.0\n.        60ms/1.0\n"
+		if got := buf.String(); got != want {
+			t.Errorf("printed %q, want %q", got, want)
+		}
+	})
+}
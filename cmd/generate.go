@@ -0,0 +1,106 @@
+// cmd/generate.go
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/synth"
+	"github.com/ColonelBlimp/cwdecoder/internal/wav"
+	"github.com/spf13/cobra"
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate synthetic CW audio with a ground-truth transcript",
+	Long: `Generate renders text as CW audio with optional noise, fading, static crashes,
+drift, chirp, timing jitter and a sender fist model. It writes a WAV file and a
+JSON transcript giving the time of every sent character.
+
+Speed, tone frequency and sample rate come from the configuration file and the
+global --wpm and --frequency flags.`,
+	Args: cobra.NoArgs,
+	RunE: runGenerate,
+}
+
+// runGenerate renders the requested signal and writes the WAV file and transcript.
+func runGenerate(cmd *cobra.Command, _ []string) error {
+	settings, err := config.Get()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	flags := cmd.Flags()
+	text, _ := flags.GetString("text")
+	output, _ := flags.GetString("output")
+	truthPath, _ := flags.GetString("truth")
+	if truthPath == "" {
+		truthPath = strings.TrimSuffix(output, ".wav") + ".json"
+	}
+
+	genConfig := synth.GeneratorConfig{
+		Synth: synth.Config{
+			SampleRate:    settings.SampleRate,
+			ToneFrequency: settings.ToneFrequency,
+		},
+		WPM:           settings.WPM,
+		FarnsworthWPM: settings.FarnsworthWPM,
+		Noise:         flags.Changed("snr"),
+	}
+	genConfig.Synth.Amplitude, _ = flags.GetFloat64("amplitude")
+	genConfig.Synth.RiseTime, _ = flags.GetDuration("rise-time")
+	genConfig.SNR, _ = flags.GetFloat64("snr")
+	genConfig.FadeDepth, _ = flags.GetFloat64("fade-depth")
+	genConfig.FadePeriod, _ = flags.GetDuration("fade-period")
+	genConfig.ImpulseRate, _ = flags.GetFloat64("qrn-rate")
+	genConfig.ImpulseAmplitude, _ = flags.GetFloat64("qrn-amplitude")
+	genConfig.DriftRate, _ = flags.GetFloat64("drift")
+	genConfig.ChirpHz, _ = flags.GetFloat64("chirp")
+	genConfig.Jitter, _ = flags.GetFloat64("jitter")
+	genConfig.Seed, _ = flags.GetUint64("seed")
+
+	fistName, _ := flags.GetString("fist")
+	genConfig.Fist, err = synth.LookupFist(fistName)
+	if err != nil {
+		return err
+	}
+
+	signal, err := synth.Generate(genConfig, text)
+	if err != nil {
+		return fmt.Errorf("generate: %w", err)
+	}
+	if err := wav.WriteFile(output, signal.Samples, int(signal.SampleRate)); err != nil {
+		return err
+	}
+	if err := signal.Truth.WriteFile(truthPath); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Wrote %.1fs of audio to %s and transcript to %s\n",
+		signal.Truth.Duration, output, truthPath)
+	return nil
+}
+
+func init() {
+	flags := generateCmd.Flags()
+	flags.StringP("text", "t", "", "text to send (prosigns in angle brackets, e.g. <SK>)")
+	flags.StringP("output", "o", "", "output WAV file")
+	flags.String("truth", "", "output transcript file (default: output with .json extension)")
+	flags.Float64("amplitude", synth.DefaultAmplitude, "peak tone amplitude (0.0-1.0)")
+	flags.Duration("rise-time", synth.DefaultRiseTime, "raised-cosine rise and fall time")
+	flags.Float64("snr", 0, "add white noise at this SNR in dB (2500 Hz bandwidth)")
+	flags.Float64("fade-depth", 0, "QSB fade depth (0.0-1.0)")
+	flags.Duration("fade-period", 0, "QSB fade cycle length")
+	flags.Float64("qrn-rate", 0, "mean static crashes per second")
+	flags.Float64("qrn-amplitude", 0.5, "peak static crash amplitude")
+	flags.Float64("drift", 0, "tone drift in Hz per second")
+	flags.Float64("chirp", 0, "key-down frequency offset in Hz")
+	flags.Float64("jitter", 0, "random timing error as a fraction of each element")
+	flags.String("fist", "electronic", "sender fist model ("+strings.Join(synth.FistNames(), ", ")+")")
+	flags.Uint64("seed", 1, "random seed for reproducible impairments")
+	cobra.CheckErr(generateCmd.MarkFlagRequired("text"))
+	cobra.CheckErr(generateCmd.MarkFlagRequired("output"))
+
+	rootCmd.AddCommand(generateCmd)
+}
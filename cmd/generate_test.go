@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/synth"
+)
+
+func TestGenerateCmd_WritesAudioAndTranscript(t *testing.T) {
+	resetViperForTest()
+	resetSubcommandFlags()
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	configDir := filepath.Join(tmpDir, ".config", "cwdecoder")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("sample_rate: 8000"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	output := filepath.Join(tmpDir, "cq.wav")
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetErr(&buf)
+	rootCmd.SetArgs([]string{"generate", "--text", "CQ DE W1AW", "--output", output,
+		"--snr", "10", "--fist", "bug", "--seed", "7"})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("generate error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "cq.json") {
+		t.Errorf("output %q should name the transcript", buf.String())
+	}
+
+	info, err := os.Stat(output)
+	if err != nil || info.Size() <= 44 {
+		t.Fatalf("WAV file missing or empty: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(tmpDir, "cq.json"))
+	if err != nil {
+		t.Fatalf("transcript missing: %v", err)
+	}
+	var truth synth.Transcript
+	if err := json.Unmarshal(data, &truth); err != nil {
+		t.Fatalf("transcript is not JSON: %v", err)
+	}
+	if truth.Text != "CQ DE W1AW" || truth.SNR == nil || *truth.SNR != 10 {
+		t.Errorf("transcript = %+v", truth)
+	}
+}
+
+func TestGenerateCmd_UnknownFist(t *testing.T) {
+	resetViperForTest()
+	resetSubcommandFlags()
+	t.Setenv("HOME", t.TempDir())
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetErr(&buf)
+	rootCmd.SetArgs([]string{"generate", "--text", "E", "--output", filepath.Join(t.TempDir(), "e.wav"),
+		"--fist", "paddle"})
+
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "fist") {
+		t.Errorf("generate error = %v, want unknown fist", err)
+	}
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+func resetViperForTest() {
+	viper.Reset()
+}
+
+// resetSubcommandFlags restores subcommand flags to their defaults; cobra keeps
+// flag values between Execute calls, so one test's flags would leak into the next
+func resetSubcommandFlags() {
+	for _, cmd := range rootCmd.Commands() {
+		cmd.Flags().VisitAll(func(f *pflag.Flag) {
+			if slice, ok := f.Value.(pflag.SliceValue); ok {
+				_ = slice.Replace(nil)
+			} else {
+				_ = f.Value.Set(f.DefValue)
+			}
+			f.Changed = false
+		})
+	}
+}
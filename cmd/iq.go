@@ -0,0 +1,123 @@
+// cmd/iq.go
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/iq"
+	"github.com/ColonelBlimp/cwdecoder/internal/wav"
+	"github.com/spf13/cobra"
+)
+
+var errNoCentre = errors.New("--rf needs the recording's centre frequency; set it with --centre")
+
+var iqCmd = &cobra.Command{
+	Use:   "iq <wav file>...",
+	Short: "Decode CW from SDR I/Q recordings",
+	Long: `Iq decodes two-channel I/Q recordings from SDR programs such as SDR#, HDSDR and
+SDR Console. A digital down-converter mixes the signal at --offset Hz from the
+centre frequency (or at --rf Hz absolute) to an audio channel at the tone
+frequency, filtered to --bandwidth Hz, and decodes it as the live decoder would.
+
+With --skim every carrier standing --min-snr dB above the noise is given its own
+channel, skimmer style, and each that decodes to text is printed.
+
+The centre frequency is read from the recording's auxi chunk, or failing that from
+its file name (e.g. "SDRSharp_20240101_120000Z_7030000Hz_IQ.wav"); --centre
+overrides both. Use --swap-iq for recordings with I and Q the other way round,
+whose spectrum is inverted. Decoder settings come from the configuration file and
+global flags.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runIQ,
+}
+
+// runIQ decodes the chosen signal, or every signal, in each recording and prints them.
+func runIQ(cmd *cobra.Command, args []string) error {
+	settings, err := config.Get()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	flags := cmd.Flags()
+	skim, _ := flags.GetBool("skim")
+	swap, _ := flags.GetBool("swap-iq")
+	offset, _ := flags.GetFloat64("offset")
+	rf, _ := flags.GetFloat64("rf")
+	centre, _ := flags.GetFloat64("centre")
+	cfg := iq.DefaultConfig(*settings)
+	cfg.Bandwidth, _ = flags.GetFloat64("bandwidth")
+	cfg.MinSNR, _ = flags.GetFloat64("min-snr")
+
+	out := cmd.OutOrStdout()
+	decodeFile := func(path string) error {
+		rec, err := wav.OpenIQFile(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = rec.Close() }()
+		if flags.Changed("centre") {
+			rec.CentreFrequency = centre
+		} else if rec.CentreFrequency == 0 {
+			rec.CentreFrequency, _ = iq.FrequencyFromName(path)
+		}
+		rec.SwapIQ = swap
+
+		if skim {
+			signals, err := iq.Skim(rec, cfg)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			_, _ = fmt.Fprintf(out, "%s: %d signals\n", path, len(signals))
+			for _, signal := range signals {
+				printSignal(out, "  ", signal)
+			}
+			return nil
+		}
+
+		if flags.Changed("rf") {
+			if rec.CentreFrequency == 0 {
+				return fmt.Errorf("%s: %w", path, errNoCentre)
+			}
+			offset = rf - rec.CentreFrequency
+		}
+		signal, err := iq.Decode(rec, offset, cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		printSignal(out, path+": ", signal)
+		return nil
+	}
+	for _, path := range args {
+		if err := decodeFile(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printSignal prints a decoded signal's frequency, level and text; the RF frequency
+// is in kHz as on a band map, or the offset in Hz when the centre is unknown
+func printSignal(w io.Writer, prefix string, signal iq.Signal) {
+	frequency := fmt.Sprintf("%+.0f Hz", signal.Offset)
+	if signal.Frequency != 0 {
+		frequency = fmt.Sprintf("%.2f kHz", signal.Frequency/1000)
+	}
+	_, _ = fmt.Fprintf(w, "%s%s (%.1f dB): %s\n", prefix, frequency, signal.SNR, signal.Text)
+}
+
+func init() {
+	flags := iqCmd.Flags()
+	flags.Float64("offset", 0, "signal frequency relative to the centre in Hz")
+	flags.Float64("rf", 0, "absolute signal frequency in Hz (instead of --offset)")
+	flags.Float64("centre", 0, "recording centre frequency in Hz (default: from the recording)")
+	flags.Bool("skim", false, "decode every signal in the recording")
+	flags.Float64("min-snr", iq.DefaultMinSNR, "level in dB above the noise floor a signal needs to be skimmed")
+	flags.Float64("bandwidth", iq.DefaultBandwidth, "channel filter bandwidth in Hz")
+	flags.Bool("swap-iq", false, "swap the I and Q channels (inverted spectrum)")
+	iqCmd.MarkFlagsMutuallyExclusive("offset", "rf", "skim")
+
+	rootCmd.AddCommand(iqCmd)
+}
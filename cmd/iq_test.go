@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+	"github.com/ColonelBlimp/cwdecoder/internal/wav"
+)
+
+// writeIQ writes a 48 kHz float I/Q recording of text keyed at 20 WPM offset Hz from the centre
+func writeIQ(t *testing.T, dir, name string, offset float64, text string) string {
+	t.Helper()
+	const sampleRate = 48000
+	encoder, err := cw.NewEncoder(cw.EncoderConfig{WPM: 20})
+	if err != nil {
+		t.Fatalf("NewEncoder() error = %v", err)
+	}
+	keying, err := encoder.Encode(text)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	var data bytes.Buffer
+	frame := func(i, q float64) {
+		_ = binary.Write(&data, binary.LittleEndian, [2]float32{float32(i), float32(q)})
+	}
+	lead := sampleRate / 2
+	for range lead {
+		frame(0, 0)
+	}
+	n := lead
+	for _, k := range keying {
+		for end := n + int(k.Duration.Seconds()*sampleRate); n < end; n++ {
+			if !k.ToneOn {
+				frame(0, 0)
+				continue
+			}
+			phase := 2 * math.Pi * offset * float64(n) / sampleRate
+			frame(0.2*math.Cos(phase), 0.2*math.Sin(phase))
+		}
+	}
+	for range lead {
+		frame(0, 0)
+	}
+
+	var body bytes.Buffer
+	body.WriteString("WAVEfmt ")
+	for _, field := range []any{uint32(16), uint16(wav.FormatFloat), uint16(2), uint32(sampleRate),
+		uint32(sampleRate * 8), uint16(8), uint16(32)} {
+		_ = binary.Write(&body, binary.LittleEndian, field)
+	}
+	body.WriteString("data")
+	_ = binary.Write(&body, binary.LittleEndian, uint32(data.Len()))
+	body.Write(data.Bytes())
+
+	var stream bytes.Buffer
+	stream.WriteString("RIFF")
+	_ = binary.Write(&stream, binary.LittleEndian, uint32(body.Len()))
+	stream.Write(body.Bytes())
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, stream.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write I/Q recording: %v", err)
+	}
+	return path
+}
+
+func TestIQCmd_DecodesRF(t *testing.T) {
+	dir := filepath.Dir(setupBenchCorpus(t))
+	path := writeIQ(t, dir, "SDRSharp_20240101_120000Z_7030000Hz_IQ.wav", 1000, "CQ DE W1AW")
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs([]string{"iq", "--rf", "7031000", path})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("iq error = %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, ": 7031.00 kHz (") || !strings.HasSuffix(out, "): CQ DE W1AW\n") {
+		t.Errorf("output missing signal at the frequency from the file name:\n%s", out)
+	}
+}
+
+func TestIQCmd_Skim(t *testing.T) {
+	dir := filepath.Dir(setupBenchCorpus(t))
+	path := writeIQ(t, dir, "capture.wav", -3000, "TEST")
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs([]string{"iq", "--skim", "--centre", "14000000", path})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("iq error = %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, ": 1 signals\n") || !strings.Contains(out, "  13997.00 kHz (") ||
+		!strings.HasSuffix(out, "): TEST\n") {
+		t.Errorf("output missing skimmed signal:\n%s", out)
+	}
+}
+
+func TestIQCmd_RFWithoutCentre(t *testing.T) {
+	dir := filepath.Dir(setupBenchCorpus(t))
+	path := writeIQ(t, dir, "capture.wav", 1000, "E")
+
+	rootCmd.SetArgs([]string{"iq", "--rf", "7031000", path})
+	if err := rootCmd.Execute(); !errors.Is(err, errNoCentre) {
+		t.Errorf("iq error = %v, want %v", err, errNoCentre)
+	}
+}
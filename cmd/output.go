@@ -0,0 +1,37 @@
+// cmd/output.go
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+// outputPrinter prints decoded output to a terminal, overwriting a provisional
+// character in place when it is confirmed or retracted.
+type outputPrinter struct {
+	w           io.Writer
+	provisional bool
+}
+
+// print writes one decoded output.
+func (p *outputPrinter) print(output cw.DecodedOutput) {
+	if p.provisional {
+		// Step back over the provisional character; a retraction also blanks it
+		if output.Retracted {
+			_, _ = fmt.Fprint(p.w, "\b \b")
+		} else {
+			_, _ = fmt.Fprint(p.w, "\b")
+		}
+	}
+	p.provisional = output.Provisional
+
+	switch {
+	case output.Retracted:
+	case output.IsWordSpace:
+		_, _ = fmt.Fprint(p.w, " ")
+	case output.Character != 0:
+		_, _ = fmt.Fprint(p.w, string(output.Character))
+	}
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+func TestOutputPrinter(t *testing.T) {
+	var buf bytes.Buffer
+	printer := &outputPrinter{w: &buf}
+
+	for _, output := range []cw.DecodedOutput{
+		{Character: 'Q', Provisional: true},
+		{Character: 'Q'},
+		{Character: 'F', Provisional: true},
+		{Character: 'F', Retracted: true},
+		{Character: ' ', IsWordSpace: true},
+	} {
+		printer.print(output)
+	}
+
+	if got, want := buf.String(), "Q\bQF\b \b "; got != want {
+		t.Errorf("printed %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+// cmd/qrss.go
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/callsign"
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+	"github.com/ColonelBlimp/cwdecoder/internal/qrss"
+	"github.com/spf13/cobra"
+)
+
+var qrssCmd = &cobra.Command{
+	Use:   "qrss <wav file>...",
+	Short: "Decode very slow CW (QRSS and DFCW beacons) from recordings",
+	Long: `Qrss decodes beacons sending dits of seconds to minutes, often below the noise,
+which the live decoder's block sizes, AGC and flush timer cannot follow. Each
+recording is mixed to baseband at the tone frequency, decimated, and integrated
+coherently over half a dit, giving a bandwidth well under 1 Hz for QRSS3 and slower.
+The beacon is looked for within --search Hz of the tone frequency.
+
+In DFCW mode dits and dahs are the same length and dahs are sent --shift Hz above
+the dits. The tone frequency and alphabet come from the configuration file and the
+global --frequency flag.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runQRSS,
+}
+
+// runQRSS decodes each recording and prints its text and any callsigns in it.
+func runQRSS(cmd *cobra.Command, args []string) error {
+	settings, err := config.Get()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	flags := cmd.Flags()
+	mode, _ := flags.GetString("mode")
+	cfg := qrss.Config{
+		Frequency: settings.ToneFrequency,
+		Mode:      qrss.Mode(mode),
+		Alphabet:  cw.Alphabet(settings.Alphabet),
+	}
+	cfg.Dit, _ = flags.GetDuration("dit")
+	cfg.Shift, _ = flags.GetFloat64("shift")
+	cfg.Search, _ = flags.GetFloat64("search")
+	cfg.Threshold, _ = flags.GetFloat64("threshold")
+
+	out := cmd.OutOrStdout()
+	for _, path := range args {
+		result, err := qrss.DecodeFile(path, cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		_, _ = fmt.Fprintf(out, "%s: %s (%.2f Hz, %d marks)\n", path, result.Text, result.Frequency, len(result.Marks))
+		if calls := callsigns(result.Text); len(calls) > 0 {
+			_, _ = fmt.Fprintf(out, "  callsigns: %s\n", strings.Join(calls, " "))
+		}
+	}
+	return nil
+}
+
+// callsigns returns the words of text that parse as callsigns
+func callsigns(text string) []string {
+	var calls []string
+	for _, word := range strings.Fields(text) {
+		if _, err := callsign.Parse(word); err == nil {
+			calls = append(calls, word)
+		}
+	}
+	return calls
+}
+
+func init() {
+	flags := qrssCmd.Flags()
+	flags.Duration("dit", qrss.DefaultDit, "dit length (3s for QRSS3, 10s for QRSS10)")
+	flags.String("mode", string(qrss.ModeQRSS), "keying mode (qrss or dfcw)")
+	flags.Float64("shift", qrss.DefaultShift, "DFCW dah frequency above the dit frequency in Hz")
+	flags.Float64("search", qrss.DefaultSearch, "how far either side of the tone frequency to look in Hz")
+	flags.Float64("threshold", qrss.DefaultThreshold, "keying threshold from noise floor (0) to signal level (1)")
+
+	rootCmd.AddCommand(qrssCmd)
+}
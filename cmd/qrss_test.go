@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+	"github.com/ColonelBlimp/cwdecoder/internal/synth"
+	"github.com/ColonelBlimp/cwdecoder/internal/wav"
+)
+
+// writeBeacon writes a 1 WPM (1.2s dit) recording of text at frequency to dir
+func writeBeacon(t *testing.T, dir, text string, frequency float64) string {
+	t.Helper()
+	encoder, err := cw.NewEncoder(cw.EncoderConfig{WPM: 1})
+	if err != nil {
+		t.Fatalf("NewEncoder() error = %v", err)
+	}
+	keying, err := encoder.Encode(text)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	silence := cw.Keying{Duration: 6 * time.Second}
+	keying = append(append([]cw.Keying{silence}, keying...), silence)
+
+	synthesizer, err := synth.NewSynthesizer(synth.Config{
+		SampleRate:    8000,
+		ToneFrequency: frequency,
+		Amplitude:     synth.DefaultAmplitude,
+		RiseTime:      synth.DefaultRiseTime,
+	})
+	if err != nil {
+		t.Fatalf("NewSynthesizer() error = %v", err)
+	}
+	path := filepath.Join(dir, "beacon.wav")
+	if err := wav.WriteFile(path, synthesizer.Render(keying), 8000); err != nil {
+		t.Fatalf("failed to write beacon: %v", err)
+	}
+	return path
+}
+
+func TestQRSSCmd_DecodesBeacon(t *testing.T) {
+	resetViperForTest()
+	resetSubcommandFlags()
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	path := writeBeacon(t, tmpDir, "DE W1AW", 601)
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs([]string{"qrss", "--dit", "1.2s", path})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("qrss error = %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, ": DE W1AW (600.8") {
+		t.Errorf("output missing decoded text at the nearest search step to 601 Hz:\n%s", out)
+	}
+	if !strings.Contains(out, "callsigns: W1AW") {
+		t.Errorf("output missing callsign:\n%s", out)
+	}
+}
+
+func TestQRSSCmd_InvalidMode(t *testing.T) {
+	resetViperForTest()
+	resetSubcommandFlags()
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	path := writeBeacon(t, tmpDir, "E", 600)
+
+	rootCmd.SetArgs([]string{"qrss", "--mode", "fsk", path})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("qrss should reject an unknown mode")
+	}
+}
+
+func TestQRSSCmd_MissingFile(t *testing.T) {
+	resetViperForTest()
+	resetSubcommandFlags()
+	t.Setenv("HOME", t.TempDir())
+
+	rootCmd.SetArgs([]string{"qrss", filepath.Join(os.TempDir(), "does-not-exist.wav")})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("qrss should fail on a missing recording")
+	}
+}
@@ -0,0 +1,95 @@
+// cmd/replay.go
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+	"github.com/ColonelBlimp/cwdecoder/internal/keying"
+	"github.com/ColonelBlimp/cwdecoder/internal/pipeline"
+	"github.com/spf13/cobra"
+)
+
+var replayEventsCmd = &cobra.Command{
+	Use:   "replay-events <event file | capture.vcd | capture.csv>",
+	Short: "Decode a recorded tone event stream or keying capture without its audio",
+	Long: `Replay-events feeds the tone events recorded during a live session (see event_log
+in the config) straight into the CW decoder and adaptive pattern decoder, skipping
+the audio and tone detector. Decoder settings come from the configuration file and
+global flags, so a session can be decoded again with different timing settings.
+
+Keying line captures are decoded the same way, so a keyer's timing can be checked
+without a transmitter:
+  .vcd  Value Change Dump from a logic analyser (--signal picks the variable,
+        by default the first one-bit variable)
+  .csv  rows of time in seconds and level (1/0, high/low, on/off), with an
+        optional header row
+
+The key is taken to be down while the line is high; use --active-low for keyers
+that pull the line to ground.
+
+The element_output setting prints the element stream as the decoder heard it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReplayEvents,
+}
+
+// runReplayEvents decodes an event file and prints the text.
+func runReplayEvents(cmd *cobra.Command, args []string) error {
+	settings, err := config.Get()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	events, err := readEvents(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	p, err := pipeline.New(*settings)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	var elements *elementPrinter
+	if settings.ElementOutput != "" {
+		elements = newElementPrinter(out, settings.WPM, settings.ElementOutput == "both")
+		p.OnElement(elements.element)
+		p.OnOutput(elements.output)
+	}
+
+	p.Replay(events)
+	text := p.Finish()
+	if elements != nil {
+		elements.flush()
+	}
+	if settings.ElementOutput != "only" {
+		_, _ = fmt.Fprintln(out, text)
+	}
+	return writeFistReport(out, p, settings.FistReport)
+}
+
+// readEvents reads a tone event file, or converts a keying capture to tone events
+func readEvents(cmd *cobra.Command, path string) ([]dsp.ToneEvent, error) {
+	format := keying.FormatOf(path)
+	if format == "" {
+		return dsp.ReadEventsFile(path)
+	}
+
+	flags := cmd.Flags()
+	signal, _ := flags.GetString("signal")
+	activeLow, _ := flags.GetBool("active-low")
+	transitions, err := keying.ReadFile(path, format, signal)
+	if err != nil {
+		return nil, err
+	}
+	return keying.Events(transitions, pipeline.SampleClockStart, activeLow), nil
+}
+
+func init() {
+	rootCmd.AddCommand(replayEventsCmd)
+
+	flags := replayEventsCmd.Flags()
+	flags.String("signal", "", "VCD variable holding the keying line (default: first one-bit variable)")
+	flags.Bool("active-low", false, "key is down while the captured line is low")
+}
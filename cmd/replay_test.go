@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+	"github.com/ColonelBlimp/cwdecoder/internal/fist"
+	"github.com/ColonelBlimp/cwdecoder/internal/keying"
+	"github.com/ColonelBlimp/cwdecoder/internal/pipeline"
+	"github.com/ColonelBlimp/cwdecoder/internal/wav"
+)
+
+// recordEvents decodes the bench corpus recording and writes its tone events to a file
+func recordEvents(t *testing.T, corpus string) string {
+	t.Helper()
+	settings, err := config.Get()
+	if err != nil {
+		t.Fatalf("config.Get() error = %v", err)
+	}
+	samples, _, err := wav.ReadFile(filepath.Join(corpus, "cq.wav"))
+	if err != nil {
+		t.Fatalf("failed to read recording: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	recorder, err := dsp.CreateEventRecorder(path)
+	if err != nil {
+		t.Fatalf("CreateEventRecorder() error = %v", err)
+	}
+	p, err := pipeline.New(*settings)
+	if err != nil {
+		t.Fatalf("pipeline.New() error = %v", err)
+	}
+	p.UseSampleClock(pipeline.SampleClockStart)
+	p.Detector().SetCallback(func(event dsp.ToneEvent) {
+		recorder.Record(event)
+		p.Decoder().HandleToneEvent(event)
+	})
+	p.Process(samples)
+	p.Flush()
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return path
+}
+
+func TestReplayEventsCmd_DecodesEvents(t *testing.T) {
+	corpus := setupBenchCorpus(t)
+	events := recordEvents(t, corpus)
+	resetViperForTest()
+	resetSubcommandFlags()
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs([]string{"replay-events", events})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("replay-events error = %v", err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "CQ DE W1AW" {
+		t.Errorf("replay-events printed %q, want %q", got, "CQ DE W1AW")
+	}
+}
+
+func TestReplayEventsCmd_ElementOutput(t *testing.T) {
+	corpus := setupBenchCorpus(t)
+	events := recordEvents(t, corpus)
+
+	configFile := filepath.Join(filepath.Dir(corpus), ".config", "cwdecoder", "config.yaml")
+	f, err := os.OpenFile(configFile, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open config: %v", err)
+	}
+	_, _ = f.WriteString("element_output: only\n")
+	_ = f.Close()
+	resetViperForTest()
+	resetSubcommandFlags()
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs([]string{"replay-events", events})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("replay-events error = %v", err)
+	}
+	// C, Q, D, E, W, 1, A and W lines with two word spaces, and no text
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 11 || !strings.HasPrefix(lines[0], "-.-.") || lines[2] != "/" {
+		t.Errorf("element output =\n%s", buf.String())
+	}
+	if strings.Contains(buf.String(), "CQ DE W1AW") {
+		t.Error("element_output only should not print the text")
+	}
+}
+
+func TestReplayEventsCmd_FistReport(t *testing.T) {
+	corpus := setupBenchCorpus(t)
+	events := recordEvents(t, corpus)
+
+	configFile := filepath.Join(filepath.Dir(corpus), ".config", "cwdecoder", "config.yaml")
+	f, err := os.OpenFile(configFile, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open config: %v", err)
+	}
+	_, _ = f.WriteString("fist_report: json\n")
+	_ = f.Close()
+	resetViperForTest()
+	resetSubcommandFlags()
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs([]string{"replay-events", events})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("replay-events error = %v", err)
+	}
+	text, report, _ := strings.Cut(buf.String(), "\n")
+	if text != "CQ DE W1AW" {
+		t.Errorf("replay-events printed %q, want %q", text, "CQ DE W1AW")
+	}
+	var decoded fist.Report
+	if err := json.Unmarshal([]byte(report), &decoded); err != nil {
+		t.Fatalf("fist report is not JSON: %v\n%s", err, report)
+	}
+	// C Q D E W 1 A W is 4+4+3+1+3+5+2+3 elements
+	if decoded.Elements != 25 {
+		t.Errorf("fist report has %d elements, want 25", decoded.Elements)
+	}
+}
+
+func TestReplayEventsCmd_MissingFile(t *testing.T) {
+	resetViperForTest()
+	resetSubcommandFlags()
+	t.Setenv("HOME", t.TempDir())
+
+	rootCmd.SetArgs([]string{"replay-events", filepath.Join(t.TempDir(), "missing.jsonl")})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("replay-events should fail on a missing event file")
+	}
+}
+
+// writeKeyingCSV writes a keying capture of code ("-.-. --.-", space-separated characters)
+// at 20 WPM, with the line low while the key is down if activeLow is set
+func writeKeyingCSV(t *testing.T, code string, activeLow bool) string {
+	t.Helper()
+	const ditSeconds = 0.06
+	down, up := "1", "0"
+	if activeLow {
+		down, up = "0", "1"
+	}
+
+	var csv strings.Builder
+	csv.WriteString("time,level\n")
+	now := 0.5
+	for i, char := range strings.Fields(code) {
+		if i > 0 {
+			now += 2 * ditSeconds // character space after the element space
+		}
+		for _, element := range char {
+			length := ditSeconds
+			if element == '-' {
+				length = 3 * ditSeconds
+			}
+			fmt.Fprintf(&csv, "%.3f,%s\n%.3f,%s\n", now, down, now+length, up)
+			now += length + ditSeconds
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "keying.csv")
+	if err := os.WriteFile(path, []byte(csv.String()), 0644); err != nil {
+		t.Fatalf("failed to write capture: %v", err)
+	}
+	return path
+}
+
+func TestReplayEventsCmd_KeyingCapture(t *testing.T) {
+	tests := []struct {
+		name      string
+		activeLow bool
+	}{
+		{"active high", false},
+		{"active low", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupBenchCorpus(t)
+			capture := writeKeyingCSV(t, "-.-. --.-", tt.activeLow)
+			resetViperForTest()
+			resetSubcommandFlags()
+
+			args := []string{"replay-events", capture}
+			if tt.activeLow {
+				args = append(args, "--active-low")
+			}
+			var buf bytes.Buffer
+			rootCmd.SetOut(&buf)
+			rootCmd.SetArgs(args)
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("replay-events error = %v", err)
+			}
+			if got := strings.TrimSpace(buf.String()); got != "CQ" {
+				t.Errorf("replay-events printed %q, want %q", got, "CQ")
+			}
+		})
+	}
+}
+
+func TestReplayEventsCmd_UnknownSignal(t *testing.T) {
+	setupBenchCorpus(t)
+	capture := filepath.Join(t.TempDir(), "capture.vcd")
+	vcd := "$var wire 1 ! key $end $enddefinitions $end #0 0! #100 1!\n"
+	if err := os.WriteFile(capture, []byte(vcd), 0644); err != nil {
+		t.Fatalf("failed to write capture: %v", err)
+	}
+	resetViperForTest()
+	resetSubcommandFlags()
+
+	rootCmd.SetArgs([]string{"replay-events", capture, "--signal", "paddle"})
+	if err := rootCmd.Execute(); !errors.Is(err, keying.ErrSignalNotFound) {
+		t.Errorf("replay-events error = %v, want %v", err, keying.ErrSignalNotFound)
+	}
+}
@@ -3,16 +3,18 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
-	"github.com/ColonelBlimp/cwdecoder/internal/audio"
+	"github.com/ColonelBlimp/cwdecoder/internal/callsign"
 	"github.com/ColonelBlimp/cwdecoder/internal/config"
-	"github.com/ColonelBlimp/cwdecoder/internal/cw"
-	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+	"github.com/ColonelBlimp/cwdecoder/internal/pipeline"
+	"github.com/ColonelBlimp/cwdecoder/internal/practice"
+	"github.com/ColonelBlimp/cwdecoder/internal/qso"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -24,7 +26,7 @@ var rootCmd = &cobra.Command{
 	RunE:  runDecoder,
 }
 
-// runDecoder is the main entry point that wires all components together.
+// runDecoder is the main entry point: it decodes live audio until interrupted.
 func runDecoder(_ *cobra.Command, _ []string) error {
 	// Get validated settings
 	settings, err := config.Get()
@@ -32,6 +34,8 @@ func runDecoder(_ *cobra.Command, _ []string) error {
 		return fmt.Errorf("load config: %w", err)
 	}
 
+	printWarnings(os.Stderr, settings)
+
 	if settings.Debug {
 		fmt.Printf("Config: sample_rate=%.0f, tone_frequency=%.0f, block_size=%d\n",
 			settings.SampleRate, settings.ToneFrequency, settings.BlockSize)
@@ -46,206 +50,123 @@ func runDecoder(_ *cobra.Command, _ []string) error {
 	// Handle OS signals for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
 	go func() {
-		sig := <-sigChan
-		fmt.Printf("\nReceived signal %v, shutting down...\n", sig)
-		cancel()
-	}()
-
-	// Initialize audio capture
-	audioConfig := audio.Config{
-		DeviceIndex: settings.DeviceIndex,
-		SampleRate:  uint32(settings.SampleRate),
-		Channels:    uint32(settings.Channels),
-		BufferSize:  uint32(settings.BufferSize),
-	}
-	capture := audio.New(audioConfig)
-
-	if err := capture.Init(); err != nil {
-		return fmt.Errorf("init audio: %w", err)
-	}
-	defer func() {
-		if err := capture.Close(); err != nil {
-			if _, printErr := fmt.Fprintf(os.Stderr, "error closing audio capture: %v\n", err); printErr != nil {
-				// Fall back to standard print if Fprintf fails
-				fmt.Println("error closing audio capture:", err)
-			}
+		select {
+		case sig := <-sigChan:
+			fmt.Printf("\nReceived signal %v, shutting down...\n", sig)
+			cancel()
+		case <-ctx.Done():
 		}
 	}()
 
-	// List available devices if in debug mode
-	if settings.Debug {
-		devices, err := capture.ListDevices()
-		if err != nil {
-			if _, printErr := fmt.Fprintf(os.Stderr, "warning: could not list audio devices: %v\n", err); printErr != nil {
-				fmt.Println("warning: could not list audio devices:", err)
-			}
-		} else {
-			fmt.Printf("Available audio devices:\n")
-			for i, dev := range devices {
-				fmt.Printf("  [%d] %s\n", i, dev.Name())
-			}
-		}
-	}
-
-	// Initialize Goertzel filter for tone detection
-	goertzelConfig := dsp.GoertzelConfig{
-		TargetFrequency: settings.ToneFrequency,
-		SampleRate:      settings.SampleRate,
-		BlockSize:       settings.BlockSize,
-	}
-	goertzel, err := dsp.NewGoertzel(goertzelConfig)
+	s, err := newSession(settings)
 	if err != nil {
-		return fmt.Errorf("init goertzel: %w", err)
-	}
-
-	// Initialize tone detector
-	detectorConfig := dsp.DetectorConfig{
-		Threshold:       settings.Threshold,
-		Hysteresis:      settings.Hysteresis,
-		OverlapPct:      settings.OverlapPct,
-		AGCEnabled:      settings.AGCEnabled,
-		AGCDecay:        settings.AGCDecay,
-		AGCAttack:       settings.AGCAttack,
-		AGCWarmupBlocks: settings.AGCWarmupBlocks,
+		return err
 	}
-	detector, err := dsp.NewDetector(detectorConfig, goertzel)
-	if err != nil {
-		return fmt.Errorf("init detector: %w", err)
+	defer s.close()
+	if err := s.start(ctx); err != nil {
+		return err
 	}
 
-	// Initialize CW decoder
-	cwDecoderConfig := cw.DecoderConfig{
-		InitialWPM:        settings.WPM,
-		AdaptiveTiming:    settings.AdaptiveTiming,
-		AdaptiveSmoothing: settings.AdaptiveSmoothing,
-		DitDahBoundary:    settings.DitDahBoundary,
-		InterCharBoundary: settings.InterCharBoundary,
-		CharWordBoundary:  settings.CharWordBoundary,
-		FarnsworthWPM:     settings.FarnsworthWPM,
-	}
-	cwDecoder, err := cw.NewDecoder(cwDecoderConfig)
-	if err != nil {
-		return fmt.Errorf("init cw decoder: %w", err)
-	}
+	// Wait for context cancellation
+	<-ctx.Done()
+	s.finish()
+	fmt.Println("CW decoder stopped.")
+	return nil
+}
 
-	// Initialize adaptive decoder if enabled
-	var adaptiveDecoder *cw.AdaptiveDecoder
-	if settings.AdaptivePatternEnabled {
-		adaptiveConfig := cw.AdaptiveConfig{
-			Enabled:             true,
-			MinConfidence:       settings.AdaptiveMinConfidence,
-			AdjustmentRate:      settings.AdaptiveAdjustmentRate,
-			MinMatchesForAdjust: settings.AdaptiveMinMatches,
+// newCallsignExtractor creates a callsign extractor that prints each detection as JSON.
+func newCallsignExtractor(settings *config.Settings) (*callsign.Extractor, error) {
+	var countries *callsign.CountryFile
+	if settings.CTYFile != "" {
+		var err error
+		countries, err = callsign.LoadCTYFile(settings.CTYFile)
+		if err != nil {
+			return nil, fmt.Errorf("load country file: %w", err)
 		}
-		adaptiveDecoder = cw.NewAdaptiveDecoder(cwDecoder, adaptiveConfig)
-
-		// Set up element recording callback
-		cwDecoder.SetElementCallback(adaptiveDecoder.RecordElement)
-
-		// Set up pattern correction callback (for debug output)
 		if settings.Debug {
-			adaptiveDecoder.SetCorrectedCallback(func(output cw.CorrectedOutput) {
-				if output.Corrected != "" && output.Corrected != output.Original {
-					fmt.Printf("\n[PATTERN] %q -> %q (confidence=%.2f, adjusted=%v)\n",
-						output.Original, output.Corrected, output.Confidence, output.TimingAdjusted)
-				}
-			})
+			fmt.Printf("Loaded %d DXCC entities from %s\n", countries.Len(), settings.CTYFile)
 		}
 	}
 
-	// Set up decoded output callback
-	cwDecoder.SetCallback(func(output cw.DecodedOutput) {
-		if output.IsWordSpace {
-			fmt.Print(" ")
-		} else if output.Character != 0 {
-			fmt.Print(string(output.Character))
-		}
-		// Flush output for real-time display
-		if err := os.Stdout.Sync(); err != nil {
-			// Sync can fail on some terminals, ignore non-critical error
-			_ = err
+	extractor := callsign.NewExtractor(countries, settings.ToneFrequency)
+	extractor.SetCallback(func(detection callsign.Detection) {
+		event, err := json.Marshal(detection)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "error encoding callsign event: %v\n", err)
+			return
 		}
+		fmt.Printf("\n[CALL] %s\n", event)
 	})
+	return extractor, nil
+}
 
-	// Wire detector to CW decoder
-	detector.SetCallback(func(event dsp.ToneEvent) {
-		if settings.Debug {
-			if event.ToneOn {
-				fmt.Printf("[TONE ON]  magnitude=%.3f\n", event.Magnitude)
-			} else {
-				fmt.Printf("[TONE OFF] duration=%v magnitude=%.3f\n",
-					event.Duration, event.Magnitude)
-			}
+// newQSOParser creates a QSO parser that appends each completed contact to an ADIF log.
+func newQSOParser(path string) *qso.Parser {
+	parser := qso.NewParser(qso.DefaultIdleTimeout)
+	parser.SetCallback(func(record qso.QSO) {
+		fmt.Printf("\n[QSO] %s\n", qso.FormatSummary(record))
+		if err := appendADIF(path, record); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "error writing qso log: %v\n", err)
 		}
-		cwDecoder.HandleToneEvent(event)
 	})
+	return parser
+}
 
-	// Wire audio capture to detector (direct callback for lowest latency)
-	capture.SetCallback(func(samples []float32) {
-		detector.Process(samples)
-	})
+// appendADIF appends a contact to an ADIF file, writing the header if the file is new.
+func appendADIF(path string, record qso.QSO) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open qso log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
 
-	// Start audio capture
-	if settings.AdaptivePatternEnabled {
-		fmt.Println("Starting CW decoder with adaptive pattern matching... Press Ctrl+C to stop.")
-	} else {
-		fmt.Println("Starting CW decoder... Press Ctrl+C to stop.")
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat qso log: %w", err)
 	}
-	if err := capture.Start(ctx); err != nil {
-		return fmt.Errorf("start audio capture: %w", err)
+	if info.Size() == 0 {
+		if err := qso.WriteADIFHeader(f); err != nil {
+			return err
+		}
 	}
+	return qso.WriteADIFRecord(f, record)
+}
 
-	// Start periodic WPM display if debug mode is enabled
-	var wpmTicker *time.Ticker
-	var wpmDone chan bool
-	if settings.Debug {
-		wpmTicker = time.NewTicker(5 * time.Second)
-		wpmDone = make(chan bool)
-		go func() {
-			for {
-				select {
-				case <-wpmDone:
-					return
-				case <-wpmTicker.C:
-					fmt.Printf("\n[WPM: %d]\n", cwDecoder.CurrentWPM())
-				}
-			}
-		}()
+// printWarnings writes the settings' warnings, one per line.
+func printWarnings(w io.Writer, settings *config.Settings) {
+	for _, warning := range settings.Warnings() {
+		_, _ = fmt.Fprintf(w, "warning: %s\n", warning)
 	}
+}
 
-	// Wait for context cancellation
-	<-ctx.Done()
-
-	// Stop WPM ticker if running
-	if wpmTicker != nil {
-		wpmTicker.Stop()
-		close(wpmDone)
+// writeFistReport writes the pipeline's fist report in the configured format ("text"
+// or "json"). It writes nothing if no fist_report is configured.
+func writeFistReport(w io.Writer, p *pipeline.Pipeline, format string) error {
+	analyzer := p.Fist()
+	if analyzer == nil {
+		return nil
 	}
-
-	// Print pattern match statistics if adaptive decoder was used
-	if adaptiveDecoder != nil && settings.Debug {
-		counts := adaptiveDecoder.GetPatternMatchCounts()
-		if len(counts) > 0 {
-			fmt.Println("\nPattern match statistics:")
-			for pattern, count := range counts {
-				fmt.Printf("  %s: %d matches\n", pattern, count)
-			}
-		}
+	report := analyzer.Report()
+	if _, err := fmt.Fprintln(w); err != nil {
+		return fmt.Errorf("write fist report: %w", err)
 	}
-
-	// Stop CW decoder (cleans up flush timer)
-	cwDecoder.Stop()
-
-	// Stop capture gracefully
-	if err := capture.Stop(); err != nil && err != audio.ErrNotRunning {
-		if _, printErr := fmt.Fprintf(os.Stderr, "error stopping audio capture: %v\n", err); printErr != nil {
-			fmt.Println("error stopping audio capture:", err)
-		}
+	if format == "json" {
+		return report.WriteJSON(w)
 	}
+	return report.WriteText(w)
+}
 
-	fmt.Println("CW decoder stopped.")
-	return nil
+// writePracticeResult writes a practice score in the configured format ("text" or "json").
+func writePracticeResult(w io.Writer, result practice.Result, format string) error {
+	if _, err := fmt.Fprintln(w); err != nil {
+		return fmt.Errorf("write practice score: %w", err)
+	}
+	if format == "json" {
+		return result.WriteJSON(w)
+	}
+	return result.WriteText(w)
 }
 
 func Execute() {
@@ -7,13 +7,10 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ColonelBlimp/cwdecoder/internal/netaudio"
 	"github.com/spf13/viper"
 )
 
-func resetViperForTest() {
-	viper.Reset()
-}
-
 func TestRootCmd_HasExpectedFlags(t *testing.T) {
 	flags := rootCmd.PersistentFlags()
 
@@ -292,3 +289,39 @@ func TestRunDecoder_InvalidThreshold(t *testing.T) {
 	}
 	// If no error, this may indicate viper defaults are being used
 }
+
+func TestRunDecoder_NetworkAudioBadAddress(t *testing.T) {
+	resetViperForTest()
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	configDir := filepath.Join(tmpDir, ".config", "cwdecoder")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	config := "audio_source: udp\nnetwork_address: \"no-port\"\n"
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	// An earlier --help would otherwise still be set
+	_ = rootCmd.Flags().Set("help", "false")
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetErr(&buf)
+	rootCmd.SetArgs([]string{})
+	err := rootCmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "start network audio") {
+		t.Errorf("Execute() error = %v, want a network audio start error", err)
+	}
+}
+
+func TestPrintNetworkStats(t *testing.T) {
+	var buf bytes.Buffer
+	printNetworkStats(&buf, netaudio.Stats{Packets: 3, Bytes: 600, Samples: 300, Concealed: 48, Underruns: 1})
+	want := "Network audio: 3 packets, 600 bytes, 300 samples received\n" +
+		"  concealed=48 dropped=0 underruns=1 malformed bytes=0\n"
+	if buf.String() != want {
+		t.Errorf("printNetworkStats() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,413 @@
+// cmd/session.go
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/audio"
+	"github.com/ColonelBlimp/cwdecoder/internal/callsign"
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+	"github.com/ColonelBlimp/cwdecoder/internal/ensemble"
+	"github.com/ColonelBlimp/cwdecoder/internal/netaudio"
+	"github.com/ColonelBlimp/cwdecoder/internal/pipeline"
+	"github.com/ColonelBlimp/cwdecoder/internal/practice"
+	"github.com/ColonelBlimp/cwdecoder/internal/qso"
+)
+
+// speedReportInterval is how often the decoder's speed is printed in debug mode
+const speedReportInterval = 5 * time.Second
+
+// session is a live decoding session: an audio source feeding the decoding
+// pipeline, and everything listening to what it decodes.
+type session struct {
+	settings *config.Settings
+	pipeline *pipeline.Pipeline
+	ensemble *ensemble.Ensemble // nil without ensemble decoding
+
+	// Audio comes from a capture device or a network stream
+	capture *audio.Capture
+	network *netaudio.Source
+
+	printer    *outputPrinter
+	recorder   *dsp.EventRecorder
+	scorer     *practice.Scorer
+	elements   *elementPrinter
+	extractor  *callsign.Extractor
+	qsoParser  *qso.Parser
+	contestLog *contestLogger
+}
+
+// newSession builds the pipeline and its listeners, then opens the audio source.
+// The session must be closed.
+func newSession(settings *config.Settings) (*session, error) {
+	p, err := pipeline.New(*settings)
+	if err != nil {
+		return nil, err
+	}
+	s := &session{settings: settings, pipeline: p, printer: &outputPrinter{w: os.Stdout}}
+	if settings.Debug && settings.ClassifierModel != "" {
+		fmt.Printf("Loaded element classifier from %s\n", settings.ClassifierModel)
+	}
+
+	if err := s.addAnalysis(); err != nil {
+		s.close()
+		return nil, err
+	}
+	if err := s.addOutput(); err != nil {
+		s.close()
+		return nil, err
+	}
+	if err := s.openAudio(); err != nil {
+		s.close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// addAnalysis adds the listeners that study the tone events and elements:
+// event recording, debug output, corrections, fist analysis and practice scoring
+func (s *session) addAnalysis() error {
+	settings := s.settings
+	if settings.EventLog != "" {
+		recorder, err := dsp.CreateEventRecorder(settings.EventLog)
+		if err != nil {
+			return err
+		}
+		s.recorder = recorder
+		s.pipeline.OnToneEvent(recorder.Record)
+	}
+	if settings.Debug {
+		s.pipeline.OnToneEvent(printToneEvent)
+	}
+
+	// Callsign corrections are always shown, pattern corrections in debug mode
+	if adaptive := s.pipeline.Adaptive(); adaptive != nil && (settings.Debug || settings.SCPFile != "") {
+		adaptive.SetCorrectedCallback(func(output cw.CorrectedOutput) {
+			printCorrection(output, settings.Debug)
+		})
+	}
+
+	if settings.PracticeText != "" {
+		scorer, err := practice.NewScorer(settings.PracticeText)
+		if err != nil {
+			return fmt.Errorf("init practice scorer: %w", err)
+		}
+		s.scorer = scorer
+		s.pipeline.OnElement(scorer.RecordElement)
+		fmt.Printf("Practice text: %s\n", strings.ToUpper(settings.PracticeText))
+	}
+	return nil
+}
+
+// addOutput adds the listeners for decoded text: the element stream, callsign,
+// QSO and contest logging, and the terminal. With an ensemble, the pipeline votes
+// and the consensus is what they see.
+func (s *session) addOutput() error {
+	settings := s.settings
+	if len(settings.Ensemble) > 0 {
+		ens, err := newEnsemble(settings, s.pipeline)
+		if err != nil {
+			return err
+		}
+		s.ensemble = ens
+	}
+
+	// The element stream shows the characters of the decoder that heard the elements
+	if settings.ElementOutput != "" {
+		s.elements = newElementPrinter(os.Stdout, settings.WPM, settings.ElementOutput == "both")
+		s.pipeline.OnElement(s.elements.element)
+		s.pipeline.OnOutput(s.elements.output)
+	}
+
+	var err error
+	if settings.CallsignEvents {
+		if s.extractor, err = newCallsignExtractor(settings); err != nil {
+			return err
+		}
+	}
+	if settings.QSOLog != "" {
+		s.qsoParser = newQSOParser(settings.QSOLog)
+	}
+	if settings.Contest != "" {
+		if s.contestLog, err = newContestLogger(settings); err != nil {
+			return err
+		}
+	}
+
+	if s.ensemble != nil {
+		s.ensemble.SetCallback(s.handleOutput)
+	} else {
+		s.pipeline.OnOutput(s.handleOutput)
+	}
+	return nil
+}
+
+// handleOutput passes decoded text to the loggers and prints it
+func (s *session) handleOutput(output cw.DecodedOutput) {
+	if s.extractor != nil {
+		s.extractor.HandleOutput(output)
+	}
+	if s.qsoParser != nil {
+		s.qsoParser.HandleOutput(output)
+	}
+	if s.contestLog != nil {
+		s.contestLog.parser.HandleOutput(output)
+	}
+	if s.elements == nil {
+		s.printer.print(output)
+	}
+	// Flush output for real-time display; Sync fails on some terminals, which is harmless
+	_ = os.Stdout.Sync()
+}
+
+// openAudio initializes the audio source: a capture device, or a network stream from SDR software
+func (s *session) openAudio() error {
+	settings := s.settings
+	if settings.AudioSource == "device" {
+		capture, err := newCapture(settings)
+		if err != nil {
+			return err
+		}
+		s.capture = capture
+		return nil
+	}
+
+	network, err := netaudio.New(netaudio.Config{
+		Protocol:    settings.AudioSource,
+		Address:     settings.NetworkAddress,
+		Format:      settings.Format,
+		SampleRate:  settings.SampleRate,
+		Channels:    settings.Channels,
+		BufferSize:  settings.BufferSize,
+		JitterDelay: time.Duration(settings.NetworkJitterMs) * time.Millisecond,
+	})
+	if err != nil {
+		return fmt.Errorf("init network audio: %w", err)
+	}
+	s.network = network
+	return nil
+}
+
+// start wires the audio source to the pipeline, or every ensemble member, and starts it.
+// In debug mode the decoder's speed is printed until ctx is done.
+func (s *session) start(ctx context.Context) error {
+	// Direct callback for lowest latency
+	process := s.pipeline.Process
+	if s.ensemble != nil {
+		process = s.ensemble.Process
+	}
+
+	if s.settings.AdaptivePatternEnabled {
+		fmt.Println("Starting CW decoder with adaptive pattern matching... Press Ctrl+C to stop.")
+	} else {
+		fmt.Println("Starting CW decoder... Press Ctrl+C to stop.")
+	}
+	if s.network != nil {
+		s.network.SetCallback(process)
+		if err := s.network.Start(ctx); err != nil {
+			return fmt.Errorf("start network audio: %w", err)
+		}
+		if s.settings.Debug {
+			fmt.Printf("Receiving %s audio on %s (%s, %.0f Hz, %d channel(s))\n", s.settings.AudioSource,
+				s.settings.NetworkAddress, s.settings.Format, s.settings.SampleRate, s.settings.Channels)
+		}
+	} else {
+		s.capture.SetCallback(process)
+		if err := s.capture.Start(ctx); err != nil {
+			return fmt.Errorf("start audio capture: %w", err)
+		}
+	}
+
+	if s.settings.Debug {
+		go s.reportSpeed(ctx)
+	}
+	return nil
+}
+
+// reportSpeed prints the decoder's speed, and the sender's fist for hand keys, until ctx is done
+func (s *session) reportSpeed(ctx context.Context) {
+	ticker := time.NewTicker(speedReportInterval)
+	defer ticker.Stop()
+	decoder := s.pipeline.Decoder()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if decoder.IsFarnsworth() {
+				fmt.Printf("\n[WPM: %d, spacing %d]\n", decoder.CurrentWPM(), decoder.SpacingWPM())
+			} else {
+				fmt.Printf("\n[WPM: %d]\n", decoder.CurrentWPM())
+			}
+			if s.settings.KeyMode != string(cw.KeyModeElectronic) {
+				fist := decoder.Fist()
+				fmt.Printf("[FIST: dah/dit=%.1f weighting=%.2f]\n", fist.DahDitRatio, fist.Weighting)
+			}
+		}
+	}
+}
+
+// finish stops decoding, flushes the loggers and prints the session's reports
+func (s *session) finish() {
+	s.printStatistics()
+
+	s.pipeline.Flush()
+	if s.elements != nil {
+		s.elements.flush()
+	}
+	if s.extractor != nil {
+		s.extractor.Flush()
+	}
+	if s.qsoParser != nil {
+		s.qsoParser.Flush()
+	}
+	if s.contestLog != nil {
+		if err := s.contestLog.close(); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "error writing contest log: %v\n", err)
+		}
+	}
+
+	if err := writeFistReport(os.Stdout, s.pipeline, s.settings.FistReport); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "error writing fist report: %v\n", err)
+	}
+	if s.scorer != nil {
+		if err := writePracticeResult(os.Stdout, s.scorer.Score(), s.settings.PracticeReport); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "error writing practice score: %v\n", err)
+		}
+	}
+
+	// Stop the audio source gracefully
+	if s.network != nil {
+		if err := s.network.Stop(); err != nil && err != netaudio.ErrNotRunning {
+			_, _ = fmt.Fprintf(os.Stderr, "error stopping network audio: %v\n", err)
+		}
+		printNetworkStats(os.Stdout, s.network.Stats())
+	} else if err := s.capture.Stop(); err != nil && err != audio.ErrNotRunning {
+		_, _ = fmt.Fprintf(os.Stderr, "error stopping audio capture: %v\n", err)
+	}
+}
+
+// printStatistics emits the ensemble's remaining votes and, in debug mode, prints
+// the pattern matches and how each ensemble member fared
+func (s *session) printStatistics() {
+	if adaptive := s.pipeline.Adaptive(); adaptive != nil && s.settings.Debug {
+		counts := adaptive.GetPatternMatchCounts()
+		if len(counts) > 0 {
+			fmt.Println("\nPattern match statistics:")
+			for pattern, count := range counts {
+				fmt.Printf("  %s: %d matches\n", pattern, count)
+			}
+		}
+	}
+
+	if s.ensemble != nil {
+		s.ensemble.Flush()
+		if s.settings.Debug {
+			fmt.Println("\nEnsemble members:")
+			for _, member := range s.ensemble.Members() {
+				fmt.Printf("  %s: reliability=%.2f, agreed %d of %d votes\n",
+					member.Name, member.Reliability, member.Agreed, member.Votes)
+			}
+		}
+	}
+}
+
+// close releases the audio device and closes the event log
+func (s *session) close() {
+	if s.capture != nil {
+		if err := s.capture.Close(); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "error closing audio capture: %v\n", err)
+		}
+	}
+	if s.recorder != nil {
+		if err := s.recorder.Close(); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "error writing event log: %v\n", err)
+		}
+	}
+}
+
+// printToneEvent prints a tone event in debug mode
+func printToneEvent(event dsp.ToneEvent) {
+	if event.ToneOn {
+		fmt.Printf("[TONE ON]  magnitude=%.3f\n", event.Magnitude)
+	} else {
+		fmt.Printf("[TONE OFF] duration=%v magnitude=%.3f\n", event.Duration, event.Magnitude)
+	}
+}
+
+// printCorrection prints a callsign correction, or in debug mode a pattern correction
+func printCorrection(output cw.CorrectedOutput, debug bool) {
+	if output.Corrected == "" || output.Corrected == output.Original {
+		return
+	}
+	if output.Candidates != nil {
+		fmt.Printf(" [CALL? %s (confidence=%.2f)] ", output.Corrected, output.Confidence)
+	} else if debug {
+		fmt.Printf("\n[PATTERN] %q -> %q (confidence=%.2f, adjusted=%v)\n",
+			output.Original, output.Corrected, output.Confidence, output.TimingAdjusted)
+	}
+}
+
+// newCapture initializes the audio capture device, listing the devices in debug mode
+func newCapture(settings *config.Settings) (*audio.Capture, error) {
+	capture := audio.New(audio.Config{
+		DeviceIndex: settings.DeviceIndex,
+		SampleRate:  uint32(settings.SampleRate),
+		Channels:    uint32(settings.Channels),
+		BufferSize:  uint32(settings.BufferSize),
+	})
+	if err := capture.Init(); err != nil {
+		return nil, fmt.Errorf("init audio: %w", err)
+	}
+
+	if settings.Debug {
+		devices, err := capture.ListDevices()
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "warning: could not list audio devices: %v\n", err)
+		} else {
+			fmt.Printf("Available audio devices:\n")
+			for i, dev := range devices {
+				fmt.Printf("  [%d] %s\n", i, dev.Name())
+			}
+		}
+	}
+	return capture, nil
+}
+
+// printNetworkStats reports how much network audio arrived and how playout coped
+func printNetworkStats(w io.Writer, stats netaudio.Stats) {
+	_, _ = fmt.Fprintf(w, "Network audio: %d packets, %d bytes, %d samples received\n",
+		stats.Packets, stats.Bytes, stats.Samples)
+	_, _ = fmt.Fprintf(w, "  concealed=%d dropped=%d underruns=%d malformed bytes=%d\n",
+		stats.Concealed, stats.Dropped, stats.Underruns, stats.Malformed)
+}
+
+// newEnsemble creates an ensemble whose first member is the configured pipeline,
+// followed by a pipeline for each ensemble entry.
+func newEnsemble(settings *config.Settings, primary *pipeline.Pipeline) (*ensemble.Ensemble, error) {
+	members, err := settings.EnsembleSettings()
+	if err != nil {
+		return nil, fmt.Errorf("ensemble: %w", err)
+	}
+
+	ens := ensemble.New(ensemble.Config{})
+	ens.AddPipeline(ensemble.MemberName(0), primary)
+	for i, memberSettings := range members {
+		p, err := pipeline.New(memberSettings)
+		if err != nil {
+			return nil, fmt.Errorf("init %s: %w", ensemble.MemberName(i+1), err)
+		}
+		ens.AddPipeline(ensemble.MemberName(i+1), p)
+	}
+	if settings.Debug {
+		fmt.Printf("Ensemble decoding with %d parameter sets\n", len(members)+1)
+	}
+	return ens, nil
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+)
+
+// sessionSettings loads settings from a config file written to a temporary HOME
+func sessionSettings(t *testing.T, yaml string) *config.Settings {
+	t.Helper()
+	resetViperForTest()
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	configDir := filepath.Join(tmpDir, ".config", "cwdecoder")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(yaml), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := config.Init(); err != nil {
+		t.Fatalf("config.Init() error = %v", err)
+	}
+	settings, err := config.Get()
+	if err != nil {
+		t.Fatalf("config.Get() error = %v", err)
+	}
+	return settings
+}
+
+func TestNewSession_MissingSCPFile(t *testing.T) {
+	settings := sessionSettings(t, "audio_source: udp\nscp_file: /nonexistent/MASTER.SCP\n")
+	if _, err := newSession(settings); err == nil || !strings.Contains(err.Error(), "load scp") {
+		t.Errorf("newSession() error = %v, want an SCP load error", err)
+	}
+}
+
+func TestSession_NetworkAudioRecordsEvents(t *testing.T) {
+	events := filepath.Join(t.TempDir(), "events.jsonl")
+	settings := sessionSettings(t, "audio_source: udp\nnetwork_address: \"127.0.0.1:0\"\n"+
+		"network_jitter_ms: 100\nsample_rate: 8000\nbuffer_size: 256\nblock_size: 128\nhysteresis: 2\nevent_log: "+events+"\n")
+
+	s, err := newSession(settings)
+	if err != nil {
+		t.Fatalf("newSession() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := s.start(ctx); err != nil {
+		s.close()
+		t.Fatalf("start() error = %v", err)
+	}
+
+	// A 100ms tone between silences, as S16_LE datagrams sent in real time
+	conn, err := net.Dial("udp", s.network.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	samples := make([]int16, 16*256)
+	for n := 1600; n < 2400; n++ {
+		samples[n] = int16(30000 * math.Sin(2*math.Pi*settings.ToneFrequency*float64(n)/8000))
+	}
+	for start := 0; start < len(samples); start += 256 {
+		if err := binary.Write(conn, binary.LittleEndian, samples[start:start+256]); err != nil {
+			t.Fatalf("failed to send audio: %v", err)
+		}
+		time.Sleep(32 * time.Millisecond)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	cancel()
+	s.finish()
+	s.close()
+
+	recorded, err := dsp.ReadEventsFile(events)
+	if err != nil {
+		t.Fatalf("ReadEventsFile() error = %v", err)
+	}
+	if len(recorded) < 2 || !recorded[0].ToneOn || recorded[1].ToneOn {
+		t.Errorf("recorded %+v, want the tone's start and end", recorded)
+	}
+}
@@ -0,0 +1,90 @@
+// cmd/train.go
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/bench"
+	"github.com/ColonelBlimp/cwdecoder/internal/classify"
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+	"github.com/ColonelBlimp/cwdecoder/internal/train"
+	"github.com/spf13/cobra"
+)
+
+var trainCmd = &cobra.Command{
+	Use:   "train <wav file or directory>...",
+	Short: "Train an element classifier from labelled recordings",
+	Long: `Train decodes a corpus of recordings with reference transcripts (as used by
+"decoder bench"), aligns every mark the detector measured with the marks of the
+reference text, and fits a classifier that tells dits from dahs and intra-character,
+inter-character and word spaces from their lengths and context.
+
+The model is written as JSON. Set classifier_model in the config to its path to
+use it in place of the dit_dah_boundary, inter_char_boundary and char_word_boundary
+rules.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runTrain,
+}
+
+// runTrain collects labelled elements from a corpus and writes the trained model.
+func runTrain(cmd *cobra.Command, args []string) error {
+	settings, err := config.Get()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	flags := cmd.Flags()
+	output, _ := flags.GetString("output")
+	epochs, _ := flags.GetInt("epochs")
+	learningRate, _ := flags.GetFloat64("learning-rate")
+	l2, _ := flags.GetFloat64("l2")
+
+	entries, err := bench.LoadCorpus(args)
+	if err != nil {
+		return err
+	}
+	data, stats, err := train.Collect(*settings, entries)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	_, _ = fmt.Fprintf(out, "Labelled %d of %d detected marks (%d sent) and %d spaces from %d recordings\n",
+		stats.Labelled, stats.Detected, stats.Sent, len(data.Spaces), stats.Recordings)
+
+	model, err := classify.Train(data, classify.TrainOptions{
+		Epochs:       epochs,
+		LearningRate: learningRate,
+		L2:           l2,
+	})
+	if err != nil {
+		return fmt.Errorf("train: %w", err)
+	}
+
+	rules := cw.RatioClassifier{
+		DitDahBoundary:    settings.DitDahBoundary,
+		InterCharBoundary: settings.InterCharBoundary,
+		CharWordBoundary:  settings.CharWordBoundary,
+	}
+	ruleMarks, ruleSpaces := classify.Accuracy(rules, data)
+	modelMarks, modelSpaces := classify.Accuracy(model, data)
+	_, _ = fmt.Fprintf(out, "  marks:  %.2f%% (ratio rules) -> %.2f%% (model)\n", ruleMarks*100, modelMarks*100)
+	_, _ = fmt.Fprintf(out, "  spaces: %.2f%% (ratio rules) -> %.2f%% (model)\n", ruleSpaces*100, modelSpaces*100)
+
+	if err := model.WriteFile(output); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(out, "Wrote classifier to %s (set classifier_model to use it)\n", output)
+	return nil
+}
+
+func init() {
+	flags := trainCmd.Flags()
+	flags.StringP("output", "o", "classifier.json", "model file to write")
+	flags.Int("epochs", classify.DefaultEpochs, "gradient descent passes over the data")
+	flags.Float64("learning-rate", classify.DefaultLearningRate, "gradient descent step size")
+	flags.Float64("l2", classify.DefaultL2, "weight decay")
+
+	rootCmd.AddCommand(trainCmd)
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/classify"
+)
+
+func TestTrainCmd_WritesModel(t *testing.T) {
+	corpus := setupBenchCorpus(t)
+	output := filepath.Join(t.TempDir(), "classifier.json")
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs([]string{"train", "--epochs", "500", "--output", output, corpus})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("train error = %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "Wrote classifier") {
+		t.Errorf("output missing summary:\n%s", buf.String())
+	}
+	if _, err := classify.LoadFile(output); err != nil {
+		t.Fatalf("trained model unreadable: %v", err)
+	}
+
+	// The trained model decodes the corpus it was trained on
+	configFile := filepath.Join(filepath.Dir(corpus), ".config", "cwdecoder", "config.yaml")
+	f, err := os.OpenFile(configFile, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open config: %v", err)
+	}
+	_, _ = f.WriteString("classifier_model: " + output + "\n")
+	_ = f.Close()
+	resetViperForTest()
+	resetSubcommandFlags()
+
+	buf.Reset()
+	rootCmd.SetArgs([]string{"bench", "--format", "text", "--max-cer", "0", corpus})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("bench with trained model error = %v\n%s", err, buf.String())
+	}
+}
+
+func TestTrainCmd_NoMarks(t *testing.T) {
+	corpus := setupBenchCorpus(t)
+
+	// Silence yields no marks to learn from
+	if err := os.WriteFile(filepath.Join(corpus, "cq.txt"), []byte(""), 0644); err != nil {
+		t.Fatalf("failed to write reference: %v", err)
+	}
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs([]string{"train", "--output", filepath.Join(t.TempDir(), "m.json"), corpus})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("train should fail without labelled examples")
+	}
+}
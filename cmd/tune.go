@@ -0,0 +1,101 @@
+// cmd/tune.go
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/bench"
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/tune"
+	"github.com/spf13/cobra"
+)
+
+var tuneCmd = &cobra.Command{
+	Use:   "tune-params <wav file or directory>...",
+	Short: "Search for the settings that decode a labelled corpus best",
+	Long: `Tune-params decodes a corpus of recordings with reference transcripts (as used by
+"decoder bench") again and again, varying detection and timing settings to minimise
+the character error rate. The best settings are written as a complete config file,
+starting from the current configuration.
+
+Tunable parameters: ` + strings.Join(tune.ParamNames(), ", "),
+	Args: cobra.MinimumNArgs(1),
+	RunE: runTune,
+}
+
+// runTune searches the parameter space and writes the best configuration found.
+func runTune(cmd *cobra.Command, args []string) error {
+	settings, err := config.Get()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	flags := cmd.Flags()
+	output, _ := flags.GetString("output")
+	method, _ := flags.GetString("method")
+	maxEvals, _ := flags.GetInt("max-evals")
+	seed, _ := flags.GetUint64("seed")
+	keys, _ := flags.GetStringSlice("params")
+
+	params, err := tune.SelectParams(keys)
+	if err != nil {
+		return err
+	}
+	entries, err := bench.LoadCorpus(args)
+	if err != nil {
+		return err
+	}
+	objective, err := tune.CorpusObjective(entries)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	_, _ = fmt.Fprintf(out, "Tuning %d parameters over %d recordings (%s search, up to %d decodes)\n",
+		len(params), len(entries), method, maxEvals)
+
+	result, err := tune.Search(*settings, objective, tune.Options{
+		Method:         method,
+		Params:         params,
+		MaxEvaluations: maxEvals,
+		Seed:           seed,
+		Progress: func(evaluations int, score float64, _ config.Settings) {
+			_, _ = fmt.Fprintf(out, "  [%d] CER %.2f%%\n", evaluations, score*100)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("tune: %w", err)
+	}
+
+	values := result.Values(params)
+	keysSorted := make([]string, 0, len(values))
+	for key := range values {
+		keysSorted = append(keysSorted, key)
+	}
+	sort.Strings(keysSorted)
+
+	_, _ = fmt.Fprintf(out, "CER %.2f%% -> %.2f%% after %d decodes\n",
+		result.InitialScore*100, result.Score*100, result.Evaluations)
+	for _, key := range keysSorted {
+		_, _ = fmt.Fprintf(out, "  %s: %v\n", key, values[key])
+	}
+
+	if err := config.WriteFile(output, values); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(out, "Wrote tuned config to %s\n", output)
+	return nil
+}
+
+func init() {
+	flags := tuneCmd.Flags()
+	flags.StringP("output", "o", "tuned.yaml", "config file to write")
+	flags.String("method", tune.MethodCoordinate, "search method (coordinate or random)")
+	flags.Int("max-evals", tune.DefaultMaxEvaluations, "maximum number of corpus decodes")
+	flags.Uint64("seed", 1, "random seed for random search")
+	flags.StringSlice("params", nil, "parameters to tune (default: all)")
+
+	rootCmd.AddCommand(tuneCmd)
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestTuneCmd_WritesConfig(t *testing.T) {
+	corpus := setupBenchCorpus(t)
+	output := filepath.Join(t.TempDir(), "tuned.yaml")
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs([]string{"tune-params", "--params", "threshold,hysteresis", "--max-evals", "10",
+		"--output", output, corpus})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("tune-params error = %v\n%s", err, buf.String())
+	}
+	if !strings.Contains(buf.String(), "Wrote tuned config") {
+		t.Errorf("output missing summary:\n%s", buf.String())
+	}
+
+	written := viper.New()
+	written.SetConfigFile(output)
+	if err := written.ReadInConfig(); err != nil {
+		t.Fatalf("tuned config unreadable: %v", err)
+	}
+	if written.GetInt("wpm") != 20 || written.GetInt("hysteresis") == 0 {
+		t.Errorf("tuned config wpm=%d hysteresis=%d", written.GetInt("wpm"), written.GetInt("hysteresis"))
+	}
+}
+
+func TestTuneCmd_UnknownParam(t *testing.T) {
+	corpus := setupBenchCorpus(t)
+
+	var buf bytes.Buffer
+	rootCmd.SetOut(&buf)
+	rootCmd.SetArgs([]string{"tune-params", "--params", "wpm", corpus})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "wpm") {
+		t.Errorf("tune-params error = %v, want unknown parameter", err)
+	}
+}
@@ -0,0 +1,106 @@
+// cmd/wire.go
+package cmd
+
+import (
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/morsekob"
+	"github.com/ColonelBlimp/cwdecoder/internal/pipeline"
+	"github.com/spf13/cobra"
+)
+
+var wireCmd = &cobra.Command{
+	Use:   "wire",
+	Short: "Decode landline Morse received over the MorseKOB/CWCom wire protocol",
+	Long: `Wire receives the UDP packets MorseKOB and CWCom stations exchange, whose signed
+mark and space durations are turned into tone events for the CW decoder and
+adaptive pattern decoder, skipping the audio and tone detector. Decoding runs until
+interrupted.
+
+With --server the decoder joins a wire on a MorseKOB server, announcing itself as
+--station; otherwise it decodes whatever is sent to --listen (default port 7890).
+Decoder settings, including morse_code for American Morse, come from the
+configuration file and global flags.`,
+	Args: cobra.NoArgs,
+	RunE: runWire,
+}
+
+// runWire decodes wire traffic and prints the text until interrupted.
+func runWire(cmd *cobra.Command, _ []string) error {
+	settings, err := config.Get()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	flags := cmd.Flags()
+	listen, _ := flags.GetString("listen")
+	server, _ := flags.GetString("server")
+	wire, _ := flags.GetInt("wire")
+	station, _ := flags.GetString("station")
+	if listen == "" && server == "" {
+		listen = fmt.Sprintf(":%d", morsekob.DefaultPort)
+	}
+
+	receiver, err := morsekob.Listen(morsekob.Config{
+		Listen:  listen,
+		Server:  server,
+		Wire:    wire,
+		Station: station,
+	})
+	if err != nil {
+		return err
+	}
+	p, err := pipeline.New(*settings)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	var elements *elementPrinter
+	if settings.ElementOutput != "" {
+		elements = newElementPrinter(out, settings.WPM, settings.ElementOutput == "both")
+		p.OnElement(elements.element)
+		p.OnOutput(elements.output)
+	} else {
+		printer := &outputPrinter{w: out}
+		p.OnOutput(printer.print)
+	}
+	// Packets arrive in bursts, so a character is flushed by the space leading the next
+	// packet, or once the wire goes idle, rather than by the decoder's audio flush timer
+	p.Decoder().UseEventClock()
+	receiver.SetCallback(p.Decoder().HandleToneEvent)
+	receiver.SetIdleCallback(p.Decoder().Flush)
+
+	if server != "" {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Connected to wire %d on %s as %q (Ctrl+C to stop)\n",
+			wire, server, station)
+	} else {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Listening on %s (Ctrl+C to stop)\n", receiver.Addr())
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	err = receiver.Run(ctx)
+	p.Flush()
+	if elements != nil {
+		elements.flush()
+	}
+	_, _ = fmt.Fprintln(out)
+	if err != nil {
+		return err
+	}
+	return writeFistReport(out, p, settings.FistReport)
+}
+
+func init() {
+	rootCmd.AddCommand(wireCmd)
+
+	flags := wireCmd.Flags()
+	flags.String("listen", "", "local UDP address to receive on (default \":7890\", or any port with --server)")
+	flags.String("server", "", "MorseKOB server to connect to, as host:port")
+	flags.Int("wire", 1, "wire number to join on the server")
+	flags.String("station", morsekob.DefaultStation, "station ID announced to the server")
+}
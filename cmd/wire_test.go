@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/morsekob"
+)
+
+// syncBuffer is a buffer safe to write from the decoder while the test reads it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// freeUDPAddr returns a local UDP address nothing is listening on
+func freeUDPAddr(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP() error = %v", err)
+	}
+	addr := conn.LocalAddr().String()
+	_ = conn.Close()
+	return addr
+}
+
+func TestWireCmd_DecodesPackets(t *testing.T) {
+	setupBenchCorpus(t)
+	resetViperForTest()
+	resetSubcommandFlags()
+	addr := freeUDPAddr(t)
+
+	var out, status syncBuffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&status)
+	rootCmd.SetArgs([]string{"wire", "--listen", addr})
+	// Cobra keeps a subcommand's context between runs, so set it directly
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wireCmd.SetContext(ctx)
+	t.Cleanup(func() { wireCmd.SetContext(context.Background()) })
+	done := make(chan error, 1)
+	go func() { done <- rootCmd.Execute() }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(status.String(), "Listening") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	sender, err := net.Dial("udp", addr)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer func() { _ = sender.Close() }()
+	packets := []morsekob.Packet{
+		{Command: morsekob.CommandData, Station: "W1AW", Sequence: 1,
+			Code: []int32{morsekob.LongSpace, 180, -60, 60, -60, 180, -60, 60}},
+		{Command: morsekob.CommandData, Station: "W1AW", Sequence: 2,
+			Code: []int32{-180, 180, -60, 180, -60, 60, -60, 180}},
+	}
+
+	for i, packet := range packets {
+		// Packets arrive a character at a time, the second after the 600 ms flush
+		// timeout of 20 WPM audio; it must not split the word
+		if i > 0 {
+			time.Sleep(800 * time.Millisecond)
+		}
+		if _, err := sender.Write(packet.Bytes()); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	for !strings.Contains(out.String(), "CQ") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("wire error = %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); got != "CQ" {
+		t.Errorf("wire printed %q, want %q", got, "CQ")
+	}
+}
+
+func TestWireCmd_InvalidWire(t *testing.T) {
+	setupBenchCorpus(t)
+	resetViperForTest()
+	resetSubcommandFlags()
+
+	rootCmd.SetArgs([]string{"wire", "--listen", "127.0.0.1:0", "--server", "127.0.0.1:7890", "--wire", "0"})
+	if err := rootCmd.Execute(); !errors.Is(err, morsekob.ErrInvalidWire) {
+		t.Errorf("wire error = %v, want %v", err, morsekob.ErrInvalidWire)
+	}
+}
@@ -0,0 +1,134 @@
+// internal/bench/bench.go
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/ensemble"
+	"github.com/ColonelBlimp/cwdecoder/internal/wav"
+)
+
+// FileResult is the accuracy of the decode of one recording
+type FileResult struct {
+	// File is the path of the recording
+	File string `json:"file"`
+	// Duration is the recording length in seconds
+	Duration float64 `json:"duration"`
+	// Reference is the normalized reference text
+	Reference string `json:"reference"`
+	// Decoded is the normalized decoder output
+	Decoded string `json:"decoded"`
+	// Characters counts character errors
+	Characters Errors `json:"characters"`
+	// Words counts word errors
+	Words Errors `json:"words"`
+	// CER is the character error rate
+	CER float64 `json:"cer"`
+	// WER is the word error rate
+	WER float64 `json:"wer"`
+}
+
+// Report is the accuracy over a corpus
+type Report struct {
+	// Files are the per-recording results in corpus order
+	Files []FileResult `json:"files"`
+	// Characters totals character errors over the corpus
+	Characters Errors `json:"characters"`
+	// Words totals word errors over the corpus
+	Words Errors `json:"words"`
+	// CER is the corpus character error rate (total errors over total reference characters)
+	CER float64 `json:"cer"`
+	// WER is the corpus word error rate
+	WER float64 `json:"wer"`
+}
+
+// Score compares decoded text with a normalized reference
+func Score(file, reference, decoded string) FileResult {
+	decoded = NormalizeDecoded(decoded)
+	result := FileResult{
+		File:       file,
+		Reference:  reference,
+		Decoded:    decoded,
+		Characters: CharacterErrors(reference, decoded),
+		Words:      WordErrors(reference, decoded),
+	}
+	result.CER = result.Characters.Rate()
+	result.WER = result.Words.Rate()
+	return result
+}
+
+// Run decodes every recording in the corpus with settings, and any ensemble they define, and scores it
+func Run(settings config.Settings, entries []Entry) (Report, error) {
+	var report Report
+	for _, entry := range entries {
+		samples, sampleRate, err := wav.ReadFile(entry.Audio)
+		if err != nil {
+			return Report{}, fmt.Errorf("%s: %w", entry.Audio, err)
+		}
+		decoded, err := Decode(settings, samples, float64(sampleRate))
+		if err != nil {
+			return Report{}, fmt.Errorf("%s: %w", entry.Audio, err)
+		}
+
+		result := Score(entry.Audio, entry.Reference, decoded)
+		result.Duration = float64(len(samples)) / float64(sampleRate)
+		report.add(result)
+	}
+	return report, nil
+}
+
+// Decode decodes a recording as Run scores it, with settings and any ensemble they define
+func Decode(settings config.Settings, samples []float32, sampleRate float64) (string, error) {
+	return ensemble.Decode(settings, samples, sampleRate)
+}
+
+// add appends a file result and updates the corpus totals
+func (r *Report) add(result FileResult) {
+	r.Files = append(r.Files, result)
+	r.Characters.add(result.Characters)
+	r.Words.add(result.Words)
+	r.CER = r.Characters.Rate()
+	r.WER = r.Words.Rate()
+}
+
+// WriteJSON writes the report as indented JSON
+func (r Report) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode bench report: %w", err)
+	}
+	data = append(data, '\n')
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("write bench report: %w", err)
+	}
+	return nil
+}
+
+// WriteText writes the report as a human-readable table
+func (r Report) WriteText(w io.Writer) error {
+	if _, err := io.WriteString(w, r.Text()); err != nil {
+		return fmt.Errorf("write bench report: %w", err)
+	}
+	return nil
+}
+
+// Text formats the report as a human-readable table
+func (r Report) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-40s %8s %7s %7s\n", "File", "Length", "CER", "WER")
+	for _, f := range r.Files {
+		fmt.Fprintf(&b, "%-40s %7.1fs %6.1f%% %6.1f%%\n", f.File, f.Duration, f.CER*100, f.WER*100)
+		if f.Decoded != f.Reference {
+			fmt.Fprintf(&b, "    ref: %s\n    got: %s\n", f.Reference, f.Decoded)
+		}
+	}
+	fmt.Fprintf(&b, "\n%d files: CER %.2f%% (%d/%d: %d sub, %d del, %d ins)  WER %.2f%% (%d/%d)\n",
+		len(r.Files), r.CER*100, r.Characters.Total(), r.Characters.Reference,
+		r.Characters.Substitutions, r.Characters.Deletions, r.Characters.Insertions,
+		r.WER*100, r.Words.Total(), r.Words.Reference)
+	return b.String()
+}
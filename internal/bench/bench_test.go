@@ -0,0 +1,134 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/synth"
+	"github.com/ColonelBlimp/cwdecoder/internal/wav"
+)
+
+// testSettings returns decoder settings suited to 8 kHz test audio at 20 WPM
+func testSettings() config.Settings {
+	return config.Settings{
+		SampleRate:        8000,
+		BufferSize:        1024,
+		ToneFrequency:     600,
+		BlockSize:         128,
+		OverlapPct:        50,
+		Threshold:         0.4,
+		Hysteresis:        2,
+		AGCEnabled:        true,
+		AGCDecay:          0.9995,
+		AGCAttack:         0.1,
+		AGCWarmupBlocks:   10,
+		WPM:               20,
+		AdaptiveTiming:    true,
+		AdaptiveSmoothing: 0.1,
+		DitDahBoundary:    2.0,
+		InterCharBoundary: 2.0,
+		CharWordBoundary:  5.0,
+		KeyMode:           "electronic",
+		Alphabet:          "latin",
+		MorseCode:         "international",
+	}
+}
+
+// writeRecording generates text as a WAV file with a JSON transcript in dir
+func writeRecording(t *testing.T, dir, name, text string) {
+	t.Helper()
+	signal, err := synth.Generate(synth.GeneratorConfig{
+		Synth: synth.Config{SampleRate: 8000, ToneFrequency: 600, Amplitude: synth.DefaultAmplitude, RiseTime: synth.DefaultRiseTime},
+		WPM:   20,
+	}, text)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if err := wav.WriteFile(filepath.Join(dir, name+".wav"), signal.Samples, 8000); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := signal.Truth.WriteFile(filepath.Join(dir, name+".json")); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestRun_CleanCorpus(t *testing.T) {
+	dir := t.TempDir()
+	writeRecording(t, dir, "cq", "CQ CQ DE W1AW K")
+	writeRecording(t, dir, "tu", "TU 73 <SK>")
+
+	entries, err := LoadCorpus([]string{dir})
+	if err != nil {
+		t.Fatalf("LoadCorpus() error = %v", err)
+	}
+	report, err := Run(testSettings(), entries)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(report.Files) != 2 {
+		t.Fatalf("len(Files) = %d, want 2", len(report.Files))
+	}
+	for _, f := range report.Files {
+		if f.CER != 0 || f.Decoded != f.Reference {
+			t.Errorf("%s: decoded %q, reference %q", f.File, f.Decoded, f.Reference)
+		}
+		if f.Duration <= 0 {
+			t.Errorf("%s: Duration = %v", f.File, f.Duration)
+		}
+	}
+	if report.Characters.Reference != 15+7 || report.CER != 0 || report.WER != 0 {
+		t.Errorf("totals = %+v CER %v WER %v", report.Characters, report.CER, report.WER)
+	}
+}
+
+func TestRun_MismatchedSettingsRaiseErrorRate(t *testing.T) {
+	dir := t.TempDir()
+	writeRecording(t, dir, "cq", "CQ CQ DE W1AW K")
+	entries, _ := LoadCorpus([]string{dir})
+
+	// Without adaptive timing, a 5 WPM estimate reads every 20 WPM dah as a dit
+	settings := testSettings()
+	settings.WPM = 5
+	settings.AdaptiveTiming = false
+	report, err := Run(settings, entries)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if report.CER < 0.5 {
+		t.Errorf("CER = %v, want a high error rate for mismatched speed", report.CER)
+	}
+}
+
+func TestReport_Output(t *testing.T) {
+	var report Report
+	report.add(Score("a.wav", "PARIS", "garis"))
+	report.add(Score("b.wav", "CQ", "CQ"))
+
+	if report.Characters.Total() != 1 || report.Characters.Reference != 7 {
+		t.Errorf("Characters = %+v, want 1 error in 7", report.Characters)
+	}
+
+	text := report.Text()
+	for _, want := range []string{"a.wav", "ref: PARIS", "got: GARIS", "2 files", "CER 14.29%"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text() missing %q:\n%s", want, text)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(decoded.Files) != 2 || decoded.Files[0].Characters.Substitutions != 1 {
+		t.Errorf("decoded report = %+v", decoded)
+	}
+}
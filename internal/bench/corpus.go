@@ -0,0 +1,89 @@
+// internal/bench/corpus.go
+package bench
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrNoReference indicates a recording has no reference transcript beside it
+	ErrNoReference = errors.New("no reference transcript (.txt or .json) for recording")
+	// ErrEmptyCorpus indicates no recordings were found
+	ErrEmptyCorpus = errors.New("corpus contains no WAV files")
+)
+
+// Entry is one recording and its reference text
+type Entry struct {
+	// Audio is the path of the WAV file
+	Audio string
+	// Reference is the normalized reference text
+	Reference string
+}
+
+// LoadCorpus collects recordings from WAV files and directories of WAV files.
+// Each recording needs a reference beside it with the same base name: a .txt file of
+// plain text, or a .json transcript as written by "decoder generate".
+func LoadCorpus(paths []string) ([]Entry, error) {
+	var audio []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("corpus: %w", err)
+		}
+		if !info.IsDir() {
+			audio = append(audio, path)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(path, "*.wav"))
+		if err != nil {
+			return nil, fmt.Errorf("corpus: %w", err)
+		}
+		sort.Strings(matches)
+		audio = append(audio, matches...)
+	}
+	if len(audio) == 0 {
+		return nil, ErrEmptyCorpus
+	}
+
+	entries := make([]Entry, 0, len(audio))
+	for _, path := range audio {
+		reference, err := loadReference(path)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, Entry{Audio: path, Reference: NormalizeReference(reference)})
+	}
+	return entries, nil
+}
+
+// loadReference reads the reference text for a recording
+func loadReference(audioPath string) (string, error) {
+	base := strings.TrimSuffix(audioPath, filepath.Ext(audioPath))
+
+	if data, err := os.ReadFile(base + ".txt"); err == nil {
+		return string(data), nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("read reference: %w", err)
+	}
+
+	data, err := os.ReadFile(base + ".json")
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", ErrNoReference, audioPath)
+	}
+	if err != nil {
+		return "", fmt.Errorf("read reference: %w", err)
+	}
+	var transcript struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &transcript); err != nil {
+		return "", fmt.Errorf("parse reference %s: %w", base+".json", err)
+	}
+	return transcript.Text, nil
+}
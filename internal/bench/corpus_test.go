@@ -0,0 +1,63 @@
+package bench
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeCorpusFile writes a file into dir, failing the test on error
+func writeCorpusFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadCorpus(t *testing.T) {
+	dir := t.TempDir()
+	writeCorpusFile(t, dir, "b.wav", "")
+	writeCorpusFile(t, dir, "b.json", `{"text": "CQ DE W1AW", "wpm": 20}`)
+	writeCorpusFile(t, dir, "a.wav", "")
+	writeCorpusFile(t, dir, "a.txt", "tu <sk>\n")
+	writeCorpusFile(t, dir, "notes.md", "ignored")
+
+	entries, err := LoadCorpus([]string{dir})
+	if err != nil {
+		t.Fatalf("LoadCorpus() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("len(entries) = %d, want 2", len(entries))
+	}
+	if filepath.Base(entries[0].Audio) != "a.wav" || entries[0].Reference != "TU %" {
+		t.Errorf("entries[0] = %+v, want a.wav with TU %%", entries[0])
+	}
+	if entries[1].Reference != "CQ DE W1AW" {
+		t.Errorf("entries[1].Reference = %q, want CQ DE W1AW", entries[1].Reference)
+	}
+
+	// A single file can be named directly
+	single, err := LoadCorpus([]string{filepath.Join(dir, "b.wav")})
+	if err != nil || len(single) != 1 {
+		t.Errorf("LoadCorpus(file) = %v, %v", single, err)
+	}
+}
+
+func TestLoadCorpus_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadCorpus([]string{dir}); !errors.Is(err, ErrEmptyCorpus) {
+		t.Errorf("empty dir error = %v, want ErrEmptyCorpus", err)
+	}
+
+	writeCorpusFile(t, dir, "orphan.wav", "")
+	if _, err := LoadCorpus([]string{dir}); !errors.Is(err, ErrNoReference) {
+		t.Errorf("missing reference error = %v, want ErrNoReference", err)
+	}
+
+	if _, err := LoadCorpus([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Error("missing path should fail")
+	}
+}
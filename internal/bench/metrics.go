@@ -0,0 +1,125 @@
+// internal/bench/metrics.go
+// Package bench measures decoder accuracy against a corpus of recordings with reference text.
+package bench
+
+import (
+	"strings"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+// Errors counts the edit operations that turn a reference into a hypothesis
+type Errors struct {
+	// Substitutions is the number of reference tokens decoded as something else
+	Substitutions int `json:"substitutions"`
+	// Deletions is the number of reference tokens missing from the decode
+	Deletions int `json:"deletions"`
+	// Insertions is the number of extra tokens in the decode
+	Insertions int `json:"insertions"`
+	// Reference is the number of tokens in the reference
+	Reference int `json:"reference"`
+}
+
+// Total returns the edit distance
+func (e Errors) Total() int {
+	return e.Substitutions + e.Deletions + e.Insertions
+}
+
+// Rate returns the error rate as a fraction of the reference length.
+// An empty reference scores 0 if nothing was decoded and 1 otherwise.
+func (e Errors) Rate() float64 {
+	if e.Reference == 0 {
+		if e.Insertions > 0 {
+			return 1
+		}
+		return 0
+	}
+	return float64(e.Total()) / float64(e.Reference)
+}
+
+// add accumulates another count (for corpus totals)
+func (e *Errors) add(other Errors) {
+	e.Substitutions += other.Substitutions
+	e.Deletions += other.Deletions
+	e.Insertions += other.Insertions
+	e.Reference += other.Reference
+}
+
+// Align computes the minimum edit operations between reference and hypothesis (Levenshtein).
+// Ties prefer substitutions, then deletions, so counts are deterministic.
+func Align[T comparable](reference, hypothesis []T) Errors {
+	type cell struct {
+		cost int
+		ops  Errors
+	}
+	prev := make([]cell, len(hypothesis)+1)
+	curr := make([]cell, len(hypothesis)+1)
+	for j := range prev {
+		prev[j] = cell{cost: j, ops: Errors{Insertions: j}}
+	}
+
+	for i := 1; i <= len(reference); i++ {
+		curr[0] = cell{cost: i, ops: Errors{Deletions: i}}
+		for j := 1; j <= len(hypothesis); j++ {
+			if reference[i-1] == hypothesis[j-1] {
+				curr[j] = prev[j-1]
+				continue
+			}
+			best := prev[j-1]
+			best.ops.Substitutions++
+			if prev[j].cost < best.cost {
+				best = prev[j]
+				best.ops.Deletions++
+			}
+			if curr[j-1].cost < best.cost {
+				best = curr[j-1]
+				best.ops.Insertions++
+			}
+			best.cost++
+			curr[j] = best
+		}
+		prev, curr = curr, prev
+	}
+
+	result := prev[len(hypothesis)].ops
+	result.Reference = len(reference)
+	return result
+}
+
+// CharacterErrors scores decoded text against a reference by character, counting word spaces
+func CharacterErrors(reference, decoded string) Errors {
+	return Align([]rune(reference), []rune(decoded))
+}
+
+// WordErrors scores decoded text against a reference by whitespace-separated word
+func WordErrors(reference, decoded string) Errors {
+	return Align(strings.Fields(reference), strings.Fields(decoded))
+}
+
+// NormalizeReference converts reference text to what a perfect decode would print:
+// uppercase, single spaces, and prosigns such as <SK> as the decoder's prosign characters.
+// Text that cannot be parsed is normalized by case and spacing only.
+func NormalizeReference(text string) string {
+	symbols, err := cw.ParseText(text)
+	if err != nil {
+		return NormalizeDecoded(text)
+	}
+	var b strings.Builder
+	for _, symbol := range symbols {
+		if symbol.IsWordSpace() {
+			b.WriteRune(' ')
+			continue
+		}
+		if char, ok := cw.DecodeElements(symbol.Elements); ok {
+			b.WriteRune(char)
+		} else {
+			b.WriteString(symbol.Text)
+		}
+	}
+	return b.String()
+}
+
+// NormalizeDecoded uppercases decoded text and collapses whitespace
+func NormalizeDecoded(text string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(text)), " ")
+}
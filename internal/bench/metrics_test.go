@@ -0,0 +1,64 @@
+package bench
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAlign(t *testing.T) {
+	tests := []struct {
+		reference string
+		decoded   string
+		want      Errors
+	}{
+		{"CQ DE W1AW", "CQ DE W1AW", Errors{Reference: 10}},
+		{"PARIS", "GARIS", Errors{Substitutions: 1, Reference: 5}},
+		{"PARIS", "PRIS", Errors{Deletions: 1, Reference: 5}},
+		{"PARIS", "PEARIS", Errors{Insertions: 1, Reference: 5}},
+		{"TEST", "", Errors{Deletions: 4, Reference: 4}},
+		{"", "EE", Errors{Insertions: 2}},
+		{"KITTEN", "SITTING", Errors{Substitutions: 2, Insertions: 1, Reference: 6}},
+	}
+	for _, tt := range tests {
+		if got := CharacterErrors(tt.reference, tt.decoded); got != tt.want {
+			t.Errorf("CharacterErrors(%q, %q) = %+v, want %+v", tt.reference, tt.decoded, got, tt.want)
+		}
+	}
+}
+
+func TestWordErrors(t *testing.T) {
+	got := WordErrors("CQ CQ DE W1AW K", "CQ DE W1AX K")
+	want := Errors{Substitutions: 1, Deletions: 1, Reference: 5}
+	if got != want {
+		t.Errorf("WordErrors() = %+v, want %+v", got, want)
+	}
+	if rate := got.Rate(); math.Abs(rate-0.4) > 1e-9 {
+		t.Errorf("Rate() = %v, want 0.4", rate)
+	}
+}
+
+func TestErrors_Rate_EmptyReference(t *testing.T) {
+	if rate := (Errors{}).Rate(); rate != 0 {
+		t.Errorf("empty/empty Rate() = %v, want 0", rate)
+	}
+	if rate := (Errors{Insertions: 3}).Rate(); rate != 1 {
+		t.Errorf("empty/inserted Rate() = %v, want 1", rate)
+	}
+}
+
+func TestNormalizeReference(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"  cq  de\nw1aw ", "CQ DE W1AW"},
+		{"TU <SK>", "TU %"},
+		{"<BT> <AR>", "= +"},
+		{"73 #", "73 #"}, // unencodable text is kept as written
+	}
+	for _, tt := range tests {
+		if got := NormalizeReference(tt.text); got != tt.want {
+			t.Errorf("NormalizeReference(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
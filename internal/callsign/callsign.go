@@ -0,0 +1,178 @@
+// internal/callsign/callsign.go
+// Package callsign extracts amateur radio callsigns from decoded CW and
+// resolves them to DXCC entities.
+package callsign
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+// Callsign length limits
+const (
+	// MinCallsignLength is the shortest plausible callsign (e.g. "K1A")
+	MinCallsignLength = 3
+	// MaxCallsignLength is the longest token considered, including portable designators
+	MaxCallsignLength = 16
+)
+
+var (
+	// ErrInvalidCallsign indicates the token is not shaped like a callsign
+	ErrInvalidCallsign = errors.New("token is not a valid callsign")
+	// ErrUnallocatedPrefix indicates the prefix is not in an ITU-allocated series
+	ErrUnallocatedPrefix = errors.New("callsign prefix is not allocated by the ITU")
+)
+
+// prefixShape matches a portable location prefix (e.g. "DL", "VE3", "KH6", "9A")
+var prefixShape = regexp.MustCompile(`^[A-Z0-9]{1,4}$`)
+
+// portableModifiers are suffixes that describe operating conditions, not location
+var portableModifiers = map[string]bool{
+	"P":   true, // portable
+	"M":   true, // mobile
+	"MM":  true, // maritime mobile
+	"AM":  true, // aeronautical mobile
+	"QRP": true, // low power
+	"A":   true, // alternate location
+	"B":   true, // beacon
+}
+
+const letters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// ituSeries lists, for each first character, the second characters that form an
+// ITU-allocated callsign series (ITU Radio Regulations Appendix 42).
+// Single-letter prefixes (B, F, G, I, K, M, N, R, W) accept any second character.
+var ituSeries = map[byte]string{
+	'A': "23456789" + letters,
+	'B': "0123456789" + letters,
+	'C': "23456789" + letters,
+	'D': "23456789" + letters,
+	'E': "234567" + letters,
+	'F': "0123456789" + letters,
+	'G': "0123456789" + letters,
+	'H': "2346789" + letters,
+	'I': "0123456789" + letters,
+	'J': "2345678" + letters,
+	'K': "0123456789" + letters,
+	'L': "23456789" + letters,
+	'M': "0123456789" + letters,
+	'N': "0123456789" + letters,
+	'O': letters,
+	'P': "23456789" + letters,
+	'R': "0123456789" + letters,
+	'S': "2356789" + letters,
+	'T': "2345678" + letters,
+	'U': letters,
+	'V': "2345678" + letters,
+	'W': "0123456789" + letters,
+	'X': letters,
+	'Y': "23456789" + letters,
+	'Z': "238" + letters,
+	'2': letters,
+	'3': letters,
+	'4': letters,
+	'5': letters,
+	'6': letters,
+	'7': letters,
+	'8': letters,
+	'9': letters,
+}
+
+// Callsign is a parsed callsign, including any portable designators
+type Callsign struct {
+	// Call is the full callsign as copied (e.g. "DL/K1ABC/P")
+	Call string
+	// Base is the home callsign without portable designators (e.g. "K1ABC")
+	Base string
+	// Prefix is the prefix that determines the operating location (e.g. "DL")
+	Prefix string
+	// Modifier is the operating-condition suffix, if any (e.g. "P", "MM")
+	Modifier string
+}
+
+// IsAllocated reports whether a prefix starts with an ITU-allocated series
+func IsAllocated(prefix string) bool {
+	if len(prefix) < 2 {
+		return false
+	}
+	allowed, ok := ituSeries[prefix[0]]
+	return ok && strings.IndexByte(allowed, prefix[1]) >= 0
+}
+
+// Parse validates a token as a callsign and resolves its location prefix.
+// Portable forms are supported: "DL/K1ABC", "K1ABC/VE3", "K1ABC/4" and "DL/K1ABC/P".
+func Parse(token string) (Callsign, error) {
+	call := strings.ToUpper(strings.TrimSpace(token))
+	if len(call) < MinCallsignLength || len(call) > MaxCallsignLength {
+		return Callsign{}, ErrInvalidCallsign
+	}
+
+	parsed := Callsign{Call: call}
+	parts := strings.Split(call, "/")
+	if len(parts) > 3 {
+		return Callsign{}, ErrInvalidCallsign
+	}
+
+	// Strip a trailing operating-condition modifier
+	if len(parts) > 1 && portableModifiers[parts[len(parts)-1]] {
+		parsed.Modifier = parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+	}
+
+	var location string
+	switch len(parts) {
+	case 1:
+		parsed.Base = parts[0]
+	case 2:
+		// The home call is the part that looks like one; the other is the location
+		first, second := parts[0], parts[1]
+		switch {
+		case cw.IsBaseCallsign(second) && !cw.IsBaseCallsign(first):
+			parsed.Base, location = second, first
+		case cw.IsBaseCallsign(first) && !cw.IsBaseCallsign(second):
+			parsed.Base, location = first, second
+		case len(first) <= len(second):
+			parsed.Base, location = second, first
+		default:
+			parsed.Base, location = first, second
+		}
+	default:
+		return Callsign{}, ErrInvalidCallsign
+	}
+
+	if !cw.IsBaseCallsign(parsed.Base) {
+		return Callsign{}, ErrInvalidCallsign
+	}
+
+	parsed.Prefix = homePrefix(parsed.Base)
+	if location != "" {
+		if !prefixShape.MatchString(location) {
+			return Callsign{}, ErrInvalidCallsign
+		}
+		if len(location) == 1 && location[0] >= '0' && location[0] <= '9' {
+			// K1ABC/4: same country, different call area
+			parsed.Prefix = strings.TrimRight(parsed.Prefix, "0123456789") + location
+		} else {
+			parsed.Prefix = location
+		}
+	}
+
+	if !IsAllocated(parsed.Prefix) && !IsAllocated(parsed.Prefix+"0") {
+		return Callsign{}, ErrUnallocatedPrefix
+	}
+
+	return parsed, nil
+}
+
+// homePrefix returns the prefix of a home call up to and including the call-area digit
+func homePrefix(base string) string {
+	// The call-area digit is the last digit in the call
+	lastDigit := strings.LastIndexAny(base, "0123456789")
+	if lastDigit < 0 {
+		return base
+	}
+	return base[:lastDigit+1]
+}
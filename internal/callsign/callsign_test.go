@@ -0,0 +1,91 @@
+package callsign
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		token    string
+		base     string
+		prefix   string
+		modifier string
+	}{
+		{"K1ABC", "K1ABC", "K1", ""},
+		{"k1abc", "K1ABC", "K1", ""},
+		{"DL1XYZ", "DL1XYZ", "DL1", ""},
+		{"9A1AA", "9A1AA", "9A1", ""},
+		{"2E0ABC", "2E0ABC", "2E0", ""},
+		{"DL/K1ABC", "K1ABC", "DL", ""},
+		{"K1ABC/VE3", "K1ABC", "VE3", ""},
+		{"K1ABC/4", "K1ABC", "K4", ""},
+		{"K1ABC/P", "K1ABC", "K1", "P"},
+		{"DL/K1ABC/P", "K1ABC", "DL", "P"},
+		{"K1ABC/MM", "K1ABC", "K1", "MM"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			parsed, err := Parse(tt.token)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.token, err)
+			}
+			if parsed.Base != tt.base {
+				t.Errorf("Base = %q, want %q", parsed.Base, tt.base)
+			}
+			if parsed.Prefix != tt.prefix {
+				t.Errorf("Prefix = %q, want %q", parsed.Prefix, tt.prefix)
+			}
+			if parsed.Modifier != tt.modifier {
+				t.Errorf("Modifier = %q, want %q", parsed.Modifier, tt.modifier)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		token string
+		want  error
+	}{
+		{"CQ", ErrInvalidCallsign},
+		{"599", ErrInvalidCallsign},
+		{"TEST", ErrInvalidCallsign},
+		{"K1ABC/P/QRP/X", ErrInvalidCallsign},
+		{"Q1ABC", ErrUnallocatedPrefix},
+		{"0A1BC", ErrUnallocatedPrefix},
+		{"QX/K1ABC", ErrUnallocatedPrefix},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			_, err := Parse(tt.token)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Parse(%q) error = %v, want %v", tt.token, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAllocated(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   bool
+	}{
+		{"K1", true},
+		{"DL", true},
+		{"9A", true},
+		{"VE", true},
+		{"QA", false},
+		{"1A", false},
+		{"Z9", false},
+		{"K", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsAllocated(tt.prefix); got != tt.want {
+			t.Errorf("IsAllocated(%q) = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+// internal/callsign/cty.go
+package callsign
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CTY.DAT record layout: eight colon-separated header fields followed by the alias list
+const (
+	ctyHeaderFields = 8
+	ctyFieldName    = 0
+	ctyFieldCQZone  = 1
+	ctyFieldITUZone = 2
+	ctyFieldCont    = 3
+	ctyFieldLat     = 4
+	ctyFieldLon     = 5
+	ctyFieldUTC     = 6
+	ctyFieldPrefix  = 7
+)
+
+var (
+	// ErrEmptyCTY indicates the country file contained no entities
+	ErrEmptyCTY = errors.New("country file contains no entities")
+	// ErrMalformedCTY indicates a country file record could not be parsed
+	ErrMalformedCTY = errors.New("malformed country file record")
+)
+
+// Entity is a DXCC entity (or a per-prefix override of one) from CTY.DAT
+type Entity struct {
+	// Name is the entity name (e.g. "United States")
+	Name string `json:"name"`
+	// PrimaryPrefix is the entity's primary prefix (e.g. "K")
+	PrimaryPrefix string `json:"primary_prefix"`
+	// Continent is the two-letter continent code (AF, AN, AS, EU, NA, OC, SA)
+	Continent string `json:"continent"`
+	// CQZone is the CQ zone number
+	CQZone int `json:"cq_zone"`
+	// ITUZone is the ITU zone number
+	ITUZone int `json:"itu_zone"`
+	// Latitude in degrees, north positive
+	Latitude float64 `json:"latitude"`
+	// Longitude in degrees, east positive (CTY.DAT stores west positive; converted on load)
+	Longitude float64 `json:"longitude"`
+	// UTCOffset is the local time offset from UTC in hours
+	UTCOffset float64 `json:"utc_offset"`
+}
+
+// CountryFile resolves callsigns to DXCC entities using CTY.DAT prefix data
+type CountryFile struct {
+	entities []Entity
+	prefixes map[string]Entity // prefix -> entity (with any overrides applied)
+	exact    map[string]Entity // full callsign -> entity ("=" entries)
+	longest  int               // longest prefix, bounds the lookup loop
+}
+
+// LoadCTYFile reads a CTY.DAT country file from disk.
+func LoadCTYFile(path string) (*CountryFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open country file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return LoadCTY(f)
+}
+
+// LoadCTY parses CTY.DAT format: each entity is a header line
+// "Name: CQ: ITU: Cont: Lat: Lon: UTC: Prefix:" followed by a comma-separated
+// alias list terminated by ';'. Aliases may carry overrides: (CQ) [ITU] <lat/lon> {cont} ~utc~,
+// and a leading '=' marks an exact callsign rather than a prefix.
+func LoadCTY(r io.Reader) (*CountryFile, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read country file: %w", err)
+	}
+
+	cf := &CountryFile{
+		prefixes: make(map[string]Entity),
+		exact:    make(map[string]Entity),
+	}
+
+	for _, record := range strings.Split(string(raw), ";") {
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+		fields := strings.SplitN(record, ":", ctyHeaderFields+1)
+		if len(fields) != ctyHeaderFields+1 {
+			return nil, fmt.Errorf("%w: %q", ErrMalformedCTY, strings.TrimSpace(record))
+		}
+
+		entity, err := parseEntity(fields)
+		if err != nil {
+			return nil, err
+		}
+		cf.entities = append(cf.entities, entity)
+
+		for _, alias := range strings.Split(fields[ctyHeaderFields], ",") {
+			if err := cf.addAlias(strings.TrimSpace(alias), entity); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(cf.entities) == 0 {
+		return nil, ErrEmptyCTY
+	}
+	return cf, nil
+}
+
+// parseEntity parses the header fields of a CTY.DAT record
+func parseEntity(fields []string) (Entity, error) {
+	for i := range fields[:ctyHeaderFields] {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+
+	entity := Entity{
+		Name:          fields[ctyFieldName],
+		Continent:     fields[ctyFieldCont],
+		PrimaryPrefix: strings.TrimPrefix(fields[ctyFieldPrefix], "*"),
+	}
+
+	var err error
+	if entity.CQZone, err = strconv.Atoi(fields[ctyFieldCQZone]); err != nil {
+		return Entity{}, fmt.Errorf("%w: cq zone for %s: %v", ErrMalformedCTY, entity.Name, err)
+	}
+	if entity.ITUZone, err = strconv.Atoi(fields[ctyFieldITUZone]); err != nil {
+		return Entity{}, fmt.Errorf("%w: itu zone for %s: %v", ErrMalformedCTY, entity.Name, err)
+	}
+	if entity.Latitude, err = strconv.ParseFloat(fields[ctyFieldLat], 64); err != nil {
+		return Entity{}, fmt.Errorf("%w: latitude for %s: %v", ErrMalformedCTY, entity.Name, err)
+	}
+	westLon, err := strconv.ParseFloat(fields[ctyFieldLon], 64)
+	if err != nil {
+		return Entity{}, fmt.Errorf("%w: longitude for %s: %v", ErrMalformedCTY, entity.Name, err)
+	}
+	entity.Longitude = -westLon
+	if entity.UTCOffset, err = strconv.ParseFloat(fields[ctyFieldUTC], 64); err != nil {
+		return Entity{}, fmt.Errorf("%w: utc offset for %s: %v", ErrMalformedCTY, entity.Name, err)
+	}
+
+	return entity, nil
+}
+
+// addAlias registers a prefix or exact callsign, applying any per-alias overrides
+func (cf *CountryFile) addAlias(alias string, entity Entity) error {
+	if alias == "" {
+		return nil
+	}
+
+	isExact := strings.HasPrefix(alias, "=")
+	alias = strings.TrimPrefix(alias, "=")
+
+	end := strings.IndexAny(alias, "([<{~")
+	key := alias
+	if end >= 0 {
+		key = alias[:end]
+		overridden, err := applyOverrides(alias[end:], entity)
+		if err != nil {
+			return fmt.Errorf("%w: alias %q: %v", ErrMalformedCTY, alias, err)
+		}
+		entity = overridden
+	}
+	key = strings.ToUpper(key)
+
+	if isExact {
+		cf.exact[key] = entity
+		return nil
+	}
+	cf.prefixes[key] = entity
+	if len(key) > cf.longest {
+		cf.longest = len(key)
+	}
+	return nil
+}
+
+// applyOverrides applies CTY.DAT alias overrides such as "(5)[8]{NA}"
+func applyOverrides(spec string, entity Entity) (Entity, error) {
+	closers := map[byte]byte{'(': ')', '[': ']', '<': '>', '{': '}', '~': '~'}
+
+	for len(spec) > 0 {
+		closer, ok := closers[spec[0]]
+		if !ok {
+			return Entity{}, fmt.Errorf("unexpected %q", spec[0])
+		}
+		end := strings.IndexByte(spec[1:], closer)
+		if end < 0 {
+			return Entity{}, fmt.Errorf("unterminated %q", spec[0])
+		}
+		value := spec[1 : end+1]
+
+		var err error
+		switch spec[0] {
+		case '(':
+			entity.CQZone, err = strconv.Atoi(value)
+		case '[':
+			entity.ITUZone, err = strconv.Atoi(value)
+		case '{':
+			entity.Continent = value
+		case '~':
+			entity.UTCOffset, err = strconv.ParseFloat(value, 64)
+		case '<':
+			lat, lon, found := strings.Cut(value, "/")
+			if !found {
+				return Entity{}, fmt.Errorf("lat/lon override %q", value)
+			}
+			if entity.Latitude, err = strconv.ParseFloat(lat, 64); err == nil {
+				var westLon float64
+				westLon, err = strconv.ParseFloat(lon, 64)
+				entity.Longitude = -westLon
+			}
+		}
+		if err != nil {
+			return Entity{}, err
+		}
+		spec = spec[end+2:]
+	}
+
+	return entity, nil
+}
+
+// Len returns the number of DXCC entities loaded
+func (cf *CountryFile) Len() int {
+	return len(cf.entities)
+}
+
+// Lookup resolves a callsign to its DXCC entity.
+// Exact-call entries take precedence, then the longest matching prefix
+// of the location prefix for portable calls (DL/K1ABC resolves via "DL").
+func (cf *CountryFile) Lookup(call string) (Entity, bool) {
+	call = strings.ToUpper(call)
+	if entity, ok := cf.exact[call]; ok {
+		return entity, true
+	}
+
+	key := call
+	if parsed, err := Parse(call); err == nil {
+		if entity, ok := cf.exact[parsed.Base]; ok && parsed.Prefix == homePrefix(parsed.Base) {
+			return entity, true
+		}
+		if parsed.Prefix != homePrefix(parsed.Base) {
+			key = parsed.Prefix
+		} else {
+			key = parsed.Base
+		}
+	}
+
+	for length := min(len(key), cf.longest); length > 0; length-- {
+		if entity, ok := cf.prefixes[key[:length]]; ok {
+			return entity, true
+		}
+	}
+	return Entity{}, false
+}
@@ -0,0 +1,125 @@
+package callsign
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testCTY = `United States:             05:  08:  NA:   37.53:    91.67:     5.0:  K:
+    AA,K,N,W,KH6(31)[61]<21.12/157.48>{OC}~10.0~,=W1AW/7;
+Fed. Rep. of Germany:      14:  28:  EU:   51.00:   -10.00:    -1.0:  DL:
+    DA,DB,DC,DD,DE,DF,DG,DH,DI,DJ,DK,DL,DM,DN,DO,DP,DQ,DR;
+Canada:                    05:  09:  NA:   44.35:    78.75:     5.0:  VE:
+    CF,CG,CJ,CK,CY,CZ,VA,VB,VC,VD,VE,VG,VO,VX,VY,XJ,XK,XL,XM,XN,XO,
+    VE3(4)[4];
+`
+
+func loadTestCTY(t *testing.T) *CountryFile {
+	t.Helper()
+	cf, err := LoadCTY(strings.NewReader(testCTY))
+	if err != nil {
+		t.Fatalf("LoadCTY() error = %v", err)
+	}
+	return cf
+}
+
+func TestLoadCTY(t *testing.T) {
+	cf := loadTestCTY(t)
+	if cf.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", cf.Len())
+	}
+}
+
+func TestLoadCTY_Errors(t *testing.T) {
+	if _, err := LoadCTY(strings.NewReader("")); !errors.Is(err, ErrEmptyCTY) {
+		t.Errorf("LoadCTY(empty) error = %v, want %v", err, ErrEmptyCTY)
+	}
+	if _, err := LoadCTY(strings.NewReader("Nowhere: 1: 2;")); !errors.Is(err, ErrMalformedCTY) {
+		t.Errorf("LoadCTY(truncated) error = %v, want %v", err, ErrMalformedCTY)
+	}
+	bad := "Nowhere: xx: 2: EU: 1.0: 2.0: 0.0: NW:\n    NW;"
+	if _, err := LoadCTY(strings.NewReader(bad)); !errors.Is(err, ErrMalformedCTY) {
+		t.Errorf("LoadCTY(bad zone) error = %v, want %v", err, ErrMalformedCTY)
+	}
+}
+
+func TestLoadCTYFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cty.dat")
+	if err := os.WriteFile(path, []byte(testCTY), 0644); err != nil {
+		t.Fatalf("failed to write country file: %v", err)
+	}
+	cf, err := LoadCTYFile(path)
+	if err != nil {
+		t.Fatalf("LoadCTYFile() error = %v", err)
+	}
+	if cf.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", cf.Len())
+	}
+
+	if _, err := LoadCTYFile(filepath.Join(t.TempDir(), "missing.dat")); err == nil {
+		t.Error("LoadCTYFile() with missing file should return error")
+	}
+}
+
+func TestCountryFile_Lookup(t *testing.T) {
+	cf := loadTestCTY(t)
+
+	tests := []struct {
+		call      string
+		name      string
+		continent string
+		cqZone    int
+	}{
+		{"K1ABC", "United States", "NA", 5},
+		{"DL1XYZ", "Fed. Rep. of Germany", "EU", 14},
+		{"DL/K1ABC/P", "Fed. Rep. of Germany", "EU", 14},
+		{"K1ABC/VE3", "Canada", "NA", 4},
+		{"VA3XYZ", "Canada", "NA", 5},
+		{"KH6ABC", "United States", "OC", 31},
+		{"W1AW/7", "United States", "NA", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.call, func(t *testing.T) {
+			entity, ok := cf.Lookup(tt.call)
+			if !ok {
+				t.Fatalf("Lookup(%q) found no entity", tt.call)
+			}
+			if entity.Name != tt.name {
+				t.Errorf("Name = %q, want %q", entity.Name, tt.name)
+			}
+			if entity.Continent != tt.continent {
+				t.Errorf("Continent = %q, want %q", entity.Continent, tt.continent)
+			}
+			if entity.CQZone != tt.cqZone {
+				t.Errorf("CQZone = %d, want %d", entity.CQZone, tt.cqZone)
+			}
+		})
+	}
+
+	if _, ok := cf.Lookup("JA1XYZ"); ok {
+		t.Error("Lookup(JA1XYZ) should find no entity in the test file")
+	}
+}
+
+func TestCountryFile_Lookup_Coordinates(t *testing.T) {
+	cf := loadTestCTY(t)
+
+	// CTY.DAT longitudes are west-positive; entities are east-positive
+	entity, _ := cf.Lookup("K1ABC")
+	if entity.Latitude != 37.53 || entity.Longitude != -91.67 {
+		t.Errorf("lat/lon = %v/%v, want 37.53/-91.67", entity.Latitude, entity.Longitude)
+	}
+
+	// Per-prefix override
+	entity, _ = cf.Lookup("KH6XX")
+	if entity.Latitude != 21.12 || entity.Longitude != -157.48 || entity.UTCOffset != 10.0 {
+		t.Errorf("KH6 override = %v/%v utc %v, want 21.12/-157.48 utc 10", entity.Latitude, entity.Longitude, entity.UTCOffset)
+	}
+	if entity.ITUZone != 61 {
+		t.Errorf("KH6 ITUZone = %d, want 61", entity.ITUZone)
+	}
+}
@@ -0,0 +1,120 @@
+// internal/callsign/extractor.go
+package callsign
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+// Detection is a callsign copied from the decoded character stream
+type Detection struct {
+	// Call is the callsign as copied, including portable designators
+	Call string `json:"call"`
+	// Base is the home callsign
+	Base string `json:"base"`
+	// Prefix is the prefix that determines the operating location
+	Prefix string `json:"prefix"`
+	// Entity is the resolved DXCC entity (nil if no country file or no match)
+	Entity *Entity `json:"entity,omitempty"`
+	// Timestamp is when the first character of the callsign was decoded
+	Timestamp time.Time `json:"timestamp"`
+	// Frequency is the audio frequency the callsign was copied on, in Hz
+	Frequency float64 `json:"frequency"`
+}
+
+// DetectionCallback is called for each callsign found in the decoded stream.
+// Must be non-blocking and fast.
+type DetectionCallback func(detection Detection)
+
+// Extractor finds callsigns in a stream of decoded characters
+type Extractor struct {
+	countries *CountryFile
+	frequency float64
+
+	mu        sync.Mutex
+	word      strings.Builder
+	wordStart time.Time
+
+	callback DetectionCallback
+}
+
+// NewExtractor creates a callsign extractor for a decoder listening on frequency (Hz).
+// countries may be nil, in which case detections carry no DXCC entity.
+func NewExtractor(countries *CountryFile, frequency float64) *Extractor {
+	return &Extractor{
+		countries: countries,
+		frequency: frequency,
+	}
+}
+
+// SetCallback sets the callback for callsign detections
+func (e *Extractor) SetCallback(cb DetectionCallback) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.callback = cb
+}
+
+// HandleOutput consumes one decoded output from the cw decoder.
+// Signature matches cw.DecodedCallback so it can be chained directly.
+func (e *Extractor) HandleOutput(output cw.DecodedOutput) {
+	// Words are built from final characters only
+	if !output.IsFinal() {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if output.IsWordSpace {
+		e.endWord()
+		return
+	}
+	if output.Character == 0 {
+		return
+	}
+	if e.word.Len() == 0 {
+		e.wordStart = output.Timestamp
+	}
+	e.word.WriteRune(output.Character)
+}
+
+// Flush processes any word still being built (e.g. at end of a session)
+func (e *Extractor) Flush() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.endWord()
+}
+
+// endWord checks the completed word for a callsign and resets the buffer
+func (e *Extractor) endWord() {
+	token := e.word.String()
+	e.word.Reset()
+	if token == "" {
+		return
+	}
+
+	parsed, err := Parse(token)
+	if err != nil {
+		return
+	}
+
+	detection := Detection{
+		Call:      parsed.Call,
+		Base:      parsed.Base,
+		Prefix:    parsed.Prefix,
+		Timestamp: e.wordStart,
+		Frequency: e.frequency,
+	}
+	if e.countries != nil {
+		if entity, ok := e.countries.Lookup(parsed.Call); ok {
+			detection.Entity = &entity
+		}
+	}
+
+	if e.callback != nil {
+		e.callback(detection)
+	}
+}
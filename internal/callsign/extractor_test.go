@@ -0,0 +1,94 @@
+package callsign
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+	"github.com/ColonelBlimp/cwdecoder/internal/cw/cwtest"
+)
+
+func TestExtractor_DetectsCallsigns(t *testing.T) {
+	extractor := NewExtractor(loadTestCTY(t), 600)
+
+	var detections []Detection
+	extractor.SetCallback(func(detection Detection) {
+		detections = append(detections, detection)
+	})
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cwtest.FeedText(extractor.HandleOutput, "CQ CQ DE DL/K1ABC/P K ", start)
+
+	if len(detections) != 1 {
+		t.Fatalf("received %d detections, want 1", len(detections))
+	}
+	detection := detections[0]
+	if detection.Call != "DL/K1ABC/P" || detection.Base != "K1ABC" {
+		t.Errorf("detection = %+v, want DL/K1ABC/P with base K1ABC", detection)
+	}
+	if detection.Frequency != 600 {
+		t.Errorf("Frequency = %v, want 600", detection.Frequency)
+	}
+	// The callsign starts at character index 9
+	if want := start.Add(900 * time.Millisecond); !detection.Timestamp.Equal(want) {
+		t.Errorf("Timestamp = %v, want %v", detection.Timestamp, want)
+	}
+	if detection.Entity == nil || detection.Entity.Name != "Fed. Rep. of Germany" {
+		t.Errorf("Entity = %+v, want Fed. Rep. of Germany", detection.Entity)
+	}
+}
+
+func TestExtractor_NoCountryFile(t *testing.T) {
+	extractor := NewExtractor(nil, 700)
+
+	var detections []Detection
+	extractor.SetCallback(func(detection Detection) {
+		detections = append(detections, detection)
+	})
+
+	cwtest.FeedText(extractor.HandleOutput, "W1AW", time.Now())
+	if len(detections) != 0 {
+		t.Fatal("callsign should not be emitted before the word ends")
+	}
+
+	extractor.Flush()
+	if len(detections) != 1 {
+		t.Fatalf("received %d detections after Flush, want 1", len(detections))
+	}
+	if detections[0].Entity != nil {
+		t.Errorf("Entity = %+v, want nil without a country file", detections[0].Entity)
+	}
+}
+
+func TestExtractor_IgnoresNonCallsigns(t *testing.T) {
+	extractor := NewExtractor(nil, 600)
+
+	called := false
+	extractor.SetCallback(func(_ Detection) {
+		called = true
+	})
+
+	cwtest.FeedText(extractor.HandleOutput, "UR RST 599 QTH BOSTON ", time.Now())
+	if called {
+		t.Error("non-callsign words should not produce detections")
+	}
+}
+
+func TestExtractor_IgnoresSpeculativeOutput(t *testing.T) {
+	extractor := NewExtractor(loadTestCTY(t), 600)
+
+	var detections []Detection
+	extractor.SetCallback(func(detection Detection) {
+		detections = append(detections, detection)
+	})
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	// A provisional Q that is later retracted must not become part of the call
+	extractor.HandleOutput(cw.DecodedOutput{Character: 'Q', Provisional: true, Timestamp: start})
+	extractor.HandleOutput(cw.DecodedOutput{Character: 'Q', Retracted: true, Timestamp: start})
+	cwtest.FeedText(extractor.HandleOutput, "K1ABC ", start)
+
+	if len(detections) != 1 || detections[0].Call != "K1ABC" {
+		t.Errorf("detections = %+v, want K1ABC", detections)
+	}
+}
@@ -0,0 +1,168 @@
+// internal/classify/model.go
+// Package classify provides a learned element classifier for the CW decoder:
+// logistic regression over normalised mark and space lengths and their neighbours.
+package classify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+// Model constants
+const (
+	// ModelVersion is the model file format version
+	ModelVersion = 1
+	// NumFeatures is the length of a feature vector, including the bias term
+	NumFeatures = 5
+	// MinRatio keeps the logarithm of a normalised length finite
+	MinRatio = 0.05
+)
+
+// ErrInvalidModel indicates a model file that cannot be used
+var ErrInvalidModel = errors.New("invalid classifier model")
+
+// Model is a trained classifier. Mark holds the weights of a logistic regression
+// for "is a dah"; Space holds one row of softmax weights per cw.SpaceClass.
+type Model struct {
+	Version int         `json:"version"`
+	Mark    []float64   `json:"mark"`
+	Space   [][]float64 `json:"space"`
+}
+
+// ClassifyMark reports whether the mark is more likely a dah than a dit
+func (m *Model) ClassifyMark(f cw.MarkFeatures) bool {
+	return m.MarkProbability(f) > 0.5
+}
+
+// MarkProbability returns the probability that the mark is a dah
+func (m *Model) MarkProbability(f cw.MarkFeatures) float64 {
+	return sigmoid(dot(m.Mark, markVector(f)))
+}
+
+// ClassifySpace returns the most likely space class
+func (m *Model) ClassifySpace(f cw.SpaceFeatures) cw.SpaceClass {
+	x := spaceVector(f)
+	best := cw.SpaceIntraChar
+	bestScore := math.Inf(-1)
+	for class, weights := range m.Space {
+		if score := dot(weights, x); score > bestScore {
+			best, bestScore = cw.SpaceClass(class), score
+		}
+	}
+	return best
+}
+
+// Validate checks that the model has the expected shape
+func (m *Model) Validate() error {
+	if m.Version != ModelVersion {
+		return fmt.Errorf("%w: version %d, want %d", ErrInvalidModel, m.Version, ModelVersion)
+	}
+	if len(m.Mark) != NumFeatures {
+		return fmt.Errorf("%w: %d mark weights, want %d", ErrInvalidModel, len(m.Mark), NumFeatures)
+	}
+	if len(m.Space) != cw.NumSpaceClasses {
+		return fmt.Errorf("%w: %d space classes, want %d", ErrInvalidModel, len(m.Space), cw.NumSpaceClasses)
+	}
+	for i, weights := range m.Space {
+		if len(weights) != NumFeatures {
+			return fmt.Errorf("%w: %d weights for space class %d, want %d", ErrInvalidModel, len(weights), i, NumFeatures)
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the model as indented JSON
+func (m *Model) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return fmt.Errorf("encode model: %w", err)
+	}
+	return nil
+}
+
+// WriteFile writes the model to path
+func (m *Model) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create model: %w", err)
+	}
+	if err := m.WriteJSON(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close model: %w", err)
+	}
+	return nil
+}
+
+// Load reads and validates a model
+func Load(r io.Reader) (*Model, error) {
+	var m Model
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("decode model: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// LoadFile reads and validates a model file
+func LoadFile(path string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open model: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	return Load(f)
+}
+
+// markVector returns the feature vector of a mark: bias, log length, and the
+// log lengths of the previous mark and space when the mark continues a character
+func markVector(f cw.MarkFeatures) []float64 {
+	x := []float64{1, logRatio(f.Duration), 0, 0, 0}
+	if f.PrevMark > 0 {
+		x[2] = 1
+		x[3] = logRatio(f.PrevMark)
+		x[4] = logRatio(f.PrevGap)
+	}
+	return x
+}
+
+// spaceVector returns the feature vector of a space: bias, log length, log length of
+// the mark before it, and the log length of the space before that mark if there was one
+func spaceVector(f cw.SpaceFeatures) []float64 {
+	x := []float64{1, logRatio(f.Duration), logRatio(f.PrevMark), 0, 0}
+	if f.PrevGap > 0 {
+		x[3] = 1
+		x[4] = logRatio(f.PrevGap)
+	}
+	return x
+}
+
+// logRatio returns the natural logarithm of a normalised length
+func logRatio(ratio float64) float64 {
+	return math.Log(max(ratio, MinRatio))
+}
+
+// dot returns the dot product of two vectors of equal length
+func dot(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// sigmoid is the logistic function
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
@@ -0,0 +1,96 @@
+package classify
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+// boundaryModel returns a hand-set model equivalent to the default ratio boundaries
+func boundaryModel() *Model {
+	ln := func(x float64) float64 { return logRatio(x) }
+	return &Model{
+		Version: ModelVersion,
+		// Dah when ln(duration) > ln(2)
+		Mark: []float64{-ln(2) * 10, 10, 0, 0, 0},
+		// Scores cross at ln(2) (intra/inter) and ln(5) (inter/word)
+		Space: [][]float64{
+			{0, 0, 0, 0, 0},
+			{-ln(2) * 10, 10, 0, 0, 0},
+			{-ln(5)*20 - ln(2)*10, 30, 0, 0, 0},
+		},
+	}
+}
+
+func TestModel_Classify(t *testing.T) {
+	m := boundaryModel()
+
+	if m.ClassifyMark(cw.MarkFeatures{Duration: 1.2}) {
+		t.Error("ClassifyMark(1.2) = dah, want dit")
+	}
+	if !m.ClassifyMark(cw.MarkFeatures{Duration: 2.8, PrevMark: 1, PrevGap: 1}) {
+		t.Error("ClassifyMark(2.8) = dit, want dah")
+	}
+
+	spaces := []struct {
+		duration float64
+		want     cw.SpaceClass
+	}{
+		{1, cw.SpaceIntraChar},
+		{3, cw.SpaceInterChar},
+		{7, cw.SpaceWord},
+	}
+	for _, tt := range spaces {
+		if got := m.ClassifySpace(cw.SpaceFeatures{Duration: tt.duration, PrevMark: 1}); got != tt.want {
+			t.Errorf("ClassifySpace(%v) = %v, want %v", tt.duration, got, tt.want)
+		}
+	}
+}
+
+func TestModel_FileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.json")
+	if err := boundaryModel().WriteFile(path); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	m, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if !m.ClassifyMark(cw.MarkFeatures{Duration: 3}) || m.ClassifySpace(cw.SpaceFeatures{Duration: 7, PrevMark: 3}) != cw.SpaceWord {
+		t.Error("loaded model classifies differently")
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	var buf bytes.Buffer
+	wrongShape := boundaryModel()
+	wrongShape.Space = wrongShape.Space[:2]
+	if err := wrongShape.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	tests := map[string]string{
+		"wrong version": `{"version": 2, "mark": [0,0,0,0,0], "space": [[0,0,0,0,0],[0,0,0,0,0],[0,0,0,0,0]]}`,
+		"short mark":    `{"version": 1, "mark": [0,0], "space": [[0,0,0,0,0],[0,0,0,0,0],[0,0,0,0,0]]}`,
+		"wrong shape":   buf.String(),
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(strings.NewReader(input)); !errors.Is(err, ErrInvalidModel) {
+				t.Errorf("Load() error = %v, want ErrInvalidModel", err)
+			}
+		})
+	}
+
+	if _, err := Load(strings.NewReader("not json")); err == nil {
+		t.Error("Load() should fail for malformed JSON")
+	}
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadFile() should fail for a missing file")
+	}
+}
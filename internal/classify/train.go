@@ -0,0 +1,191 @@
+// internal/classify/train.go
+package classify
+
+import (
+	"errors"
+	"math"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+// Training defaults
+const (
+	// DefaultEpochs is the number of full passes of gradient descent
+	DefaultEpochs = 2000
+	// DefaultLearningRate is the gradient descent step size
+	DefaultLearningRate = 0.5
+	// DefaultL2 is the weight decay that keeps the model close to simple boundaries
+	DefaultL2 = 0.001
+)
+
+// ErrNoExamples is returned when there is nothing to train a classifier on
+var ErrNoExamples = errors.New("no training examples")
+
+// MarkExample is a mark labelled with its true class
+type MarkExample struct {
+	Features cw.MarkFeatures `json:"features"`
+	IsDah    bool            `json:"is_dah"`
+}
+
+// SpaceExample is a space labelled with its true class
+type SpaceExample struct {
+	Features cw.SpaceFeatures `json:"features"`
+	Class    cw.SpaceClass    `json:"class"`
+}
+
+// Dataset holds labelled marks and spaces
+type Dataset struct {
+	Marks  []MarkExample  `json:"marks"`
+	Spaces []SpaceExample `json:"spaces"`
+}
+
+// Add appends another dataset's examples
+func (d *Dataset) Add(other Dataset) {
+	d.Marks = append(d.Marks, other.Marks...)
+	d.Spaces = append(d.Spaces, other.Spaces...)
+}
+
+// TrainOptions controls training (zero values use the defaults)
+type TrainOptions struct {
+	Epochs       int
+	LearningRate float64
+	L2           float64
+}
+
+// Train fits a model to the dataset by batch gradient descent on the cross-entropy
+// loss. Both marks and spaces are required.
+func Train(data Dataset, options TrainOptions) (*Model, error) {
+	if len(data.Marks) == 0 || len(data.Spaces) == 0 {
+		return nil, ErrNoExamples
+	}
+	if options.Epochs <= 0 {
+		options.Epochs = DefaultEpochs
+	}
+	if options.LearningRate <= 0 {
+		options.LearningRate = DefaultLearningRate
+	}
+	if options.L2 <= 0 {
+		options.L2 = DefaultL2
+	}
+
+	return &Model{
+		Version: ModelVersion,
+		Mark:    trainMarks(data.Marks, options),
+		Space:   trainSpaces(data.Spaces, options),
+	}, nil
+}
+
+// trainMarks fits the binary logistic regression for dahs
+func trainMarks(examples []MarkExample, options TrainOptions) []float64 {
+	xs := make([][]float64, len(examples))
+	ys := make([]float64, len(examples))
+	for i, ex := range examples {
+		xs[i] = markVector(ex.Features)
+		if ex.IsDah {
+			ys[i] = 1
+		}
+	}
+
+	weights := make([]float64, NumFeatures)
+	grad := make([]float64, NumFeatures)
+	n := float64(len(examples))
+	for range options.Epochs {
+		clear(grad)
+		for i, x := range xs {
+			residual := sigmoid(dot(weights, x)) - ys[i]
+			for j, v := range x {
+				grad[j] += residual * v
+			}
+		}
+		for j := range weights {
+			weights[j] -= options.LearningRate * (grad[j]/n + options.L2*weights[j])
+		}
+	}
+	return weights
+}
+
+// trainSpaces fits the softmax regression over space classes
+func trainSpaces(examples []SpaceExample, options TrainOptions) [][]float64 {
+	xs := make([][]float64, len(examples))
+	for i, ex := range examples {
+		xs[i] = spaceVector(ex.Features)
+	}
+
+	weights := make([][]float64, cw.NumSpaceClasses)
+	grad := make([][]float64, cw.NumSpaceClasses)
+	for k := range weights {
+		weights[k] = make([]float64, NumFeatures)
+		grad[k] = make([]float64, NumFeatures)
+	}
+	probs := make([]float64, cw.NumSpaceClasses)
+	n := float64(len(examples))
+	for range options.Epochs {
+		for k := range grad {
+			clear(grad[k])
+		}
+		for i, x := range xs {
+			softmax(weights, x, probs)
+			for k, p := range probs {
+				residual := p
+				if cw.SpaceClass(k) == examples[i].Class {
+					residual--
+				}
+				for j, v := range x {
+					grad[k][j] += residual * v
+				}
+			}
+		}
+		for k := range weights {
+			for j := range weights[k] {
+				weights[k][j] -= options.LearningRate * (grad[k][j]/n + options.L2*weights[k][j])
+			}
+		}
+	}
+	return weights
+}
+
+// softmax writes the class probabilities for x into probs
+func softmax(weights [][]float64, x, probs []float64) {
+	maxScore := math.Inf(-1)
+	for k, w := range weights {
+		probs[k] = dot(w, x)
+		maxScore = max(maxScore, probs[k])
+	}
+	var sum float64
+	for k := range probs {
+		probs[k] = math.Exp(probs[k] - maxScore)
+		sum += probs[k]
+	}
+	for k := range probs {
+		probs[k] /= sum
+	}
+}
+
+// Accuracy returns the fraction of marks and of spaces a classifier labels correctly
+// (NaN when there are none)
+func Accuracy(c cw.Classifier, data Dataset) (marks, spaces float64) {
+	var correct int
+	for _, ex := range data.Marks {
+		if c.ClassifyMark(ex.Features) == ex.IsDah {
+			correct++
+		}
+	}
+	marks = ratio(correct, len(data.Marks))
+
+	correct = 0
+	for _, ex := range data.Spaces {
+		if c.ClassifySpace(ex.Features) == ex.Class {
+			correct++
+		}
+	}
+	spaces = ratio(correct, len(data.Spaces))
+	return marks, spaces
+}
+
+// ratio returns n/total, or NaN when total is zero
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return math.NaN()
+	}
+	return float64(n) / float64(total)
+}
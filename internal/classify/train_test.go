@@ -0,0 +1,84 @@
+package classify
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+// heavyFistDataset returns examples from a sender whose dahs are only twice as long as
+// dits and whose character gaps are short, which the default boundaries misread
+func heavyFistDataset(n int) Dataset {
+	rng := rand.New(rand.NewPCG(1, 2))
+	jitter := func(x float64) float64 { return x * (1 + 0.15*(rng.Float64()*2-1)) }
+
+	var data Dataset
+	for range n {
+		isDah := rng.IntN(2) == 0
+		length := 1.0
+		if isDah {
+			length = 1.9
+		}
+		data.Marks = append(data.Marks, MarkExample{
+			Features: cw.MarkFeatures{Duration: jitter(length)},
+			IsDah:    isDah,
+		})
+
+		class := cw.SpaceClass(rng.IntN(cw.NumSpaceClasses))
+		length = []float64{1, 2.4, 4.5}[class]
+		data.Spaces = append(data.Spaces, SpaceExample{
+			Features: cw.SpaceFeatures{Duration: jitter(length), PrevMark: jitter(1)},
+			Class:    class,
+		})
+	}
+	return data
+}
+
+func TestTrain_LearnsSenderBoundaries(t *testing.T) {
+	data := heavyFistDataset(400)
+	rules := cw.RatioClassifier{DitDahBoundary: 2, InterCharBoundary: 2, CharWordBoundary: 5}
+	ruleMarks, ruleSpaces := Accuracy(rules, data)
+
+	model, err := Train(data, TrainOptions{})
+	if err != nil {
+		t.Fatalf("Train() error = %v", err)
+	}
+	if err := model.Validate(); err != nil {
+		t.Fatalf("trained model is invalid: %v", err)
+	}
+
+	marks, spaces := Accuracy(model, data)
+	if marks < 0.98 || spaces < 0.98 {
+		t.Errorf("trained accuracy = %.3f marks, %.3f spaces, want >= 0.98", marks, spaces)
+	}
+	if marks <= ruleMarks || spaces <= ruleSpaces {
+		t.Errorf("trained accuracy %.3f/%.3f should beat the ratio rules %.3f/%.3f", marks, spaces, ruleMarks, ruleSpaces)
+	}
+}
+
+func TestTrain_NoExamples(t *testing.T) {
+	data := heavyFistDataset(10)
+	data.Spaces = nil
+	if _, err := Train(data, TrainOptions{}); !errors.Is(err, ErrNoExamples) {
+		t.Errorf("Train() error = %v, want ErrNoExamples", err)
+	}
+}
+
+func TestAccuracy_Empty(t *testing.T) {
+	marks, spaces := Accuracy(boundaryModel(), Dataset{})
+	if !math.IsNaN(marks) || !math.IsNaN(spaces) {
+		t.Errorf("Accuracy(empty) = %v, %v, want NaN", marks, spaces)
+	}
+}
+
+func TestDataset_Add(t *testing.T) {
+	var data Dataset
+	data.Add(heavyFistDataset(3))
+	data.Add(heavyFistDataset(2))
+	if len(data.Marks) != 5 || len(data.Spaces) != 5 {
+		t.Errorf("Add() gave %d marks and %d spaces, want 5 and 5", len(data.Marks), len(data.Spaces))
+	}
+}
@@ -7,7 +7,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
 
+	"github.com/ColonelBlimp/cwdecoder/internal/contest"
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
 	"github.com/spf13/viper"
 )
 
@@ -41,7 +47,9 @@ const (
 	MaxAGCAttack     = 1.0
 	MinWPM           = 5
 	MaxWPM           = 60
-	NyquistDivisor   = 2.0 // Nyquist frequency = sample_rate / 2
+	MinNetworkJitter = 0    // ms
+	MaxNetworkJitter = 2000 // ms
+	NyquistDivisor   = 2.0  // Nyquist frequency = sample_rate / 2
 
 	// CW Decoder validation constants
 	MinAdaptiveSmoothing = 0.0
@@ -52,8 +60,60 @@ const (
 	MaxInterCharBoundary = 4.0  // Must be < char-word boundary
 	MinCharWordBoundary  = 3.0  // Must be > inter-char boundary
 	MaxCharWordBoundary  = 10.0 // Reasonable upper limit
+
+	// Contest validation constants
+	MinContestFrequency = 0      // 0 = unknown, written as-is to the Cabrillo log
+	MaxContestFrequency = 450000 // 70 cm upper edge in kHz
+
+	// Callsign correction validation constants
+	MinSCPMaxDistance = 0 // 0 uses the decoder default
+	MaxSCPMaxDistance = 6 // Beyond this, almost any call matches
+
+	// QRQ (high-speed) constants
+	MaxQRQWPM      = 250 // HST and meteor scatter speeds
+	QRQDitFraction = 0.5 // The shortest detectable tone must fit in this fraction of a dit
+	QRQOverlapPct  = 75  // Short hops keep the timing resolution well inside a dit
+	QRQHysteresis  = 2   // Lowest debounce that still rejects single-block noise
+
+	// Ensemble validation constants
+	MaxEnsembleMembers = 8 // Each member runs its own detector and decoder on every buffer
 )
 
+// ErrUnknownEnsembleKey is returned when an ensemble member overrides a setting
+// that is not part of tone detection or decoding.
+var ErrUnknownEnsembleKey = errors.New("not a detection or decoding setting")
+
+// ensembleKeys are the settings an ensemble member may override. Audio input is
+// shared by every member, so only detector and decoder parameters can differ.
+var ensembleKeys = map[string]bool{
+	"tone_frequency":           true,
+	"block_size":               true,
+	"overlap_pct":              true,
+	"threshold":                true,
+	"hysteresis":               true,
+	"agc_enabled":              true,
+	"agc_decay":                true,
+	"agc_attack":               true,
+	"agc_warmup_blocks":        true,
+	"wpm":                      true,
+	"adaptive_timing":          true,
+	"adaptive_smoothing":       true,
+	"dit_dah_boundary":         true,
+	"inter_char_boundary":      true,
+	"char_word_boundary":       true,
+	"farnsworth_wpm":           true,
+	"farnsworth_auto":          true,
+	"key_mode":                 true,
+	"alphabet":                 true,
+	"morse_code":               true,
+	"qrq":                      true,
+	"classifier_model":         true,
+	"adaptive_pattern_enabled": true,
+	"adaptive_min_confidence":  true,
+	"adaptive_adjustment_rate": true,
+	"adaptive_min_matches":     true,
+}
+
 // Settings holds all application configuration
 type Settings struct {
 	// Audio device settings
@@ -64,6 +124,11 @@ type Settings struct {
 	Format      string  `mapstructure:"format"`
 	BufferSize  int     `mapstructure:"buffer_size"`
 
+	// Network audio (SDR programs streaming raw PCM in format at sample_rate)
+	AudioSource     string `mapstructure:"audio_source"`
+	NetworkAddress  string `mapstructure:"network_address"`
+	NetworkJitterMs int    `mapstructure:"network_jitter_ms"`
+
 	// Tone detection
 	ToneFrequency float64 `mapstructure:"tone_frequency"`
 	BlockSize     int     `mapstructure:"block_size"`
@@ -85,6 +150,15 @@ type Settings struct {
 	InterCharBoundary float64 `mapstructure:"inter_char_boundary"`
 	CharWordBoundary  float64 `mapstructure:"char_word_boundary"`
 	FarnsworthWPM     int     `mapstructure:"farnsworth_wpm"`
+	FarnsworthAuto    bool    `mapstructure:"farnsworth_auto"`
+	KeyMode           string  `mapstructure:"key_mode"`
+	SpeculativeOutput bool    `mapstructure:"speculative_output"`
+	QRQ               bool    `mapstructure:"qrq"`
+	Alphabet          string  `mapstructure:"alphabet"`
+	MorseCode         string  `mapstructure:"morse_code"`
+
+	// Learned element classification
+	ClassifierModel string `mapstructure:"classifier_model"`
 
 	// Adaptive Pattern Matching
 	AdaptivePatternEnabled bool    `mapstructure:"adaptive_pattern_enabled"`
@@ -92,8 +166,40 @@ type Settings struct {
 	AdaptiveAdjustmentRate float64 `mapstructure:"adaptive_adjustment_rate"`
 	AdaptiveMinMatches     int     `mapstructure:"adaptive_min_matches"`
 
+	// Callsign correction
+	SCPFile        string `mapstructure:"scp_file"`
+	SCPMaxDistance int    `mapstructure:"scp_max_distance"`
+
+	// Callsign extraction
+	CallsignEvents bool   `mapstructure:"callsign_events"`
+	CTYFile        string `mapstructure:"cty_file"`
+
+	// QSO logging
+	QSOLog string `mapstructure:"qso_log"`
+
+	// Fist analysis
+	FistReport string `mapstructure:"fist_report"`
+
+	// Tone event recording
+	EventLog string `mapstructure:"event_log"`
+
+	// Sending practice
+	PracticeText   string `mapstructure:"practice_text"`
+	PracticeReport string `mapstructure:"practice_report"`
+
+	// Contest logging
+	Contest          string `mapstructure:"contest"`
+	ContestCall      string `mapstructure:"contest_call"`
+	ContestExchange  string `mapstructure:"contest_exchange"`
+	ContestFrequency int    `mapstructure:"contest_frequency"`
+	ContestLog       string `mapstructure:"contest_log"`
+
+	// Ensemble decoding: extra parameter sets, each overriding detector and decoder settings
+	Ensemble []map[string]any `mapstructure:"ensemble"`
+
 	// Output
-	Debug bool `mapstructure:"debug"`
+	ElementOutput string `mapstructure:"element_output"`
+	Debug         bool   `mapstructure:"debug"`
 }
 
 // Init initializes Viper with defaults and config file.
@@ -106,6 +212,9 @@ func Init() error {
 	viper.SetDefault("channels", 1)
 	viper.SetDefault("format", "S16_LE")
 	viper.SetDefault("buffer_size", 1024)
+	viper.SetDefault("audio_source", "device")
+	viper.SetDefault("network_address", "127.0.0.1:7355") // GQRX's UDP audio default
+	viper.SetDefault("network_jitter_ms", 100)
 	viper.SetDefault("tone_frequency", 600)
 	viper.SetDefault("block_size", 512)
 	viper.SetDefault("overlap_pct", 50)
@@ -122,10 +231,33 @@ func Init() error {
 	viper.SetDefault("inter_char_boundary", 2.0) // Midpoint of intra-char (1) and inter-char (3) ITU spacing
 	viper.SetDefault("char_word_boundary", 5.0)
 	viper.SetDefault("farnsworth_wpm", 0)
+	viper.SetDefault("farnsworth_auto", true)
+	viper.SetDefault("key_mode", "electronic")
+	viper.SetDefault("speculative_output", false)
+	viper.SetDefault("qrq", false)
+	viper.SetDefault("alphabet", "latin")
+	viper.SetDefault("morse_code", "international")
+	viper.SetDefault("classifier_model", "")
 	viper.SetDefault("adaptive_pattern_enabled", true)
 	viper.SetDefault("adaptive_min_confidence", 0.7)
 	viper.SetDefault("adaptive_adjustment_rate", 0.1)
 	viper.SetDefault("adaptive_min_matches", 3)
+	viper.SetDefault("scp_file", "")
+	viper.SetDefault("scp_max_distance", 2)
+	viper.SetDefault("callsign_events", false)
+	viper.SetDefault("cty_file", "")
+	viper.SetDefault("qso_log", "")
+	viper.SetDefault("fist_report", "")
+	viper.SetDefault("event_log", "")
+	viper.SetDefault("practice_text", "")
+	viper.SetDefault("practice_report", "text")
+	viper.SetDefault("contest", "")
+	viper.SetDefault("contest_call", "")
+	viper.SetDefault("contest_exchange", "")
+	viper.SetDefault("contest_frequency", 0)
+	viper.SetDefault("contest_log", "")
+	viper.SetDefault("ensemble", []map[string]any{})
+	viper.SetDefault("element_output", "")
 	viper.SetDefault("debug", false)
 
 	// Support both config.yaml and .config.yaml
@@ -189,12 +321,32 @@ func Get() (*Settings, error) {
 	if err := viper.Unmarshal(&s); err != nil {
 		return nil, fmt.Errorf("unmarshal config: %w", err)
 	}
+	if s.QRQ {
+		s.FitQRQ()
+	}
 	if err := s.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 	return &s, nil
 }
 
+// WriteFile writes the current configuration, with overrides applied, as a YAML
+// config file at path. The global configuration is left unchanged.
+func WriteFile(path string, overrides map[string]any) error {
+	out := viper.New()
+	if err := out.MergeConfigMap(viper.AllSettings()); err != nil {
+		return fmt.Errorf("copy config: %w", err)
+	}
+	for key, value := range overrides {
+		out.Set(key, value)
+	}
+	out.SetConfigType(ConfigType)
+	if err := out.WriteConfigAs(path); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	return nil
+}
+
 // Validate checks that all settings are within acceptable ranges
 func (s *Settings) Validate() error {
 	var errs []error
@@ -214,6 +366,22 @@ func (s *Settings) Validate() error {
 		errs = append(errs, fmt.Errorf("buffer_size should be a power of 2, got %d", s.BufferSize))
 	}
 
+	// Network audio
+	validAudioSources := map[string]bool{
+		"device":     true,
+		"udp":        true,
+		"tcp":        true,
+		"tcp-listen": true,
+	}
+	if !validAudioSources[s.AudioSource] {
+		errs = append(errs, fmt.Errorf("audio_source must be one of device, udp, tcp, tcp-listen, got %q", s.AudioSource))
+	} else if s.AudioSource != "device" && s.NetworkAddress == "" {
+		errs = append(errs, fmt.Errorf("network_address is required for audio_source %q", s.AudioSource))
+	}
+	if s.NetworkJitterMs < MinNetworkJitter || s.NetworkJitterMs > MaxNetworkJitter {
+		errs = append(errs, fmt.Errorf("network_jitter_ms must be between %d and %d, got %d", MinNetworkJitter, MaxNetworkJitter, s.NetworkJitterMs))
+	}
+
 	// Tone detection
 	if s.ToneFrequency < MinToneFrequency || s.ToneFrequency > MaxToneFrequency {
 		errs = append(errs, fmt.Errorf("tone_frequency must be between %d and %d Hz, got %v", MinToneFrequency, MaxToneFrequency, s.ToneFrequency))
@@ -243,8 +411,12 @@ func (s *Settings) Validate() error {
 	}
 
 	// Timing
-	if s.WPM < MinWPM || s.WPM > MaxWPM {
-		errs = append(errs, fmt.Errorf("wpm must be between %d and %d, got %d", MinWPM, MaxWPM, s.WPM))
+	maxWPM := MaxWPM
+	if s.QRQ {
+		maxWPM = MaxQRQWPM
+	}
+	if s.WPM < MinWPM || s.WPM > maxWPM {
+		errs = append(errs, fmt.Errorf("wpm must be between %d and %d, got %d", MinWPM, maxWPM, s.WPM))
 	}
 	if s.AdaptiveSmoothing < MinAdaptiveSmoothing || s.AdaptiveSmoothing > MaxAdaptiveSmoothing {
 		errs = append(errs, fmt.Errorf("adaptive_smoothing must be between %.1f and %.1f, got %v", MinAdaptiveSmoothing, MaxAdaptiveSmoothing, s.AdaptiveSmoothing))
@@ -262,6 +434,52 @@ func (s *Settings) Validate() error {
 		errs = append(errs, fmt.Errorf("farnsworth_wpm must be between 0 and wpm (%d), got %d", s.WPM, s.FarnsworthWPM))
 	}
 
+	// Callsign correction
+	if s.SCPMaxDistance < MinSCPMaxDistance || s.SCPMaxDistance > MaxSCPMaxDistance {
+		errs = append(errs, fmt.Errorf("scp_max_distance must be between %d and %d, got %d", MinSCPMaxDistance, MaxSCPMaxDistance, s.SCPMaxDistance))
+	}
+
+	// Key mode and character table, as the decoder accepts them
+	if !cw.KeyMode(s.KeyMode).Valid() {
+		errs = append(errs, fmt.Errorf("key_mode: %w, got %q", cw.ErrInvalidKeyMode, s.KeyMode))
+	}
+	if !cw.Alphabet(s.Alphabet).Valid() {
+		errs = append(errs, fmt.Errorf("alphabet: %w, got %q", cw.ErrInvalidAlphabet, s.Alphabet))
+	}
+	if !cw.Code(s.MorseCode).Valid() {
+		errs = append(errs, fmt.Errorf("morse_code: %w, got %q", cw.ErrInvalidCode, s.MorseCode))
+	}
+
+	// Fist analysis report format
+	validFistReports := map[string]bool{
+		"":     true,
+		"text": true,
+		"json": true,
+	}
+	if !validFistReports[s.FistReport] {
+		errs = append(errs, fmt.Errorf("fist_report must be empty, text or json, got %q", s.FistReport))
+	}
+
+	// Element stream output
+	validElementOutputs := map[string]bool{
+		"":     true,
+		"both": true,
+		"only": true,
+	}
+	if !validElementOutputs[s.ElementOutput] {
+		errs = append(errs, fmt.Errorf("element_output must be empty, both or only, got %q", s.ElementOutput))
+	}
+
+	// Sending practice report format
+	if s.PracticeText != "" && s.PracticeReport != "text" && s.PracticeReport != "json" {
+		errs = append(errs, fmt.Errorf("practice_report must be text or json, got %q", s.PracticeReport))
+	}
+
+	// Contest logging
+	if s.Contest != "" {
+		errs = append(errs, s.validateContest()...)
+	}
+
 	// Validate audio format
 	validFormats := map[string]bool{
 		"S16_LE": true,
@@ -282,8 +500,124 @@ func (s *Settings) Validate() error {
 		errs = append(errs, fmt.Errorf("tone_frequency (%v Hz) must be less than Nyquist frequency (%v Hz)", s.ToneFrequency, s.SampleRate/NyquistDivisor))
 	}
 
+	// Ensemble members are only checked once the settings they inherit are valid
+	if len(s.Ensemble) > MaxEnsembleMembers {
+		errs = append(errs, fmt.Errorf("ensemble must have at most %d members, got %d", MaxEnsembleMembers, len(s.Ensemble)))
+	} else if len(errs) == 0 && len(s.Ensemble) > 0 {
+		if _, err := s.EnsembleSettings(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	if len(errs) > 0 {
 		return errors.Join(errs...)
 	}
 	return nil
 }
+
+// FitQRQ picks the overlap, hysteresis and the largest block, no larger than the configured
+// one, with which the shortest tone the detector can confirm fits in QRQDitFraction of a dit.
+func (s *Settings) FitQRQ() {
+	s.OverlapPct = QRQOverlapPct
+	s.Hysteresis = QRQHysteresis
+	if s.WPM <= 0 || s.SampleRate <= 0 {
+		return
+	}
+
+	target := time.Duration(QRQDitFraction * float64(cw.DitDuration(s.WPM)))
+	block := min(s.BlockSize, MaxBlockSize)
+	for block > MinBlockSize && dsp.MinToneDuration(s.SampleRate, block, s.OverlapPct, s.Hysteresis) > target {
+		block /= 2
+	}
+	s.BlockSize = max(block, MinBlockSize)
+}
+
+// Warnings returns problems with valid settings that will still prevent decoding
+func (s *Settings) Warnings() []string {
+	var warnings []string
+	if s.WPM <= 0 || s.SampleRate <= 0 {
+		return nil
+	}
+
+	dit := cw.DitDuration(s.WPM)
+	shortest := dsp.MinToneDuration(s.SampleRate, s.BlockSize, s.OverlapPct, s.Hysteresis)
+	if dit < shortest {
+		advice := "enable qrq or lower block_size and hysteresis"
+		if s.QRQ {
+			advice = "raise sample_rate"
+		}
+		warnings = append(warnings, fmt.Sprintf(
+			"a dit at %d WPM lasts %v, shorter than the %v the detector needs (sample_rate %.0f, block_size %d, overlap_pct %d, hysteresis %d); %s",
+			s.WPM, dit.Round(time.Microsecond*100), shortest.Round(time.Microsecond*100),
+			s.SampleRate, s.BlockSize, s.OverlapPct, s.Hysteresis, advice))
+	}
+	return warnings
+}
+
+// EnsembleSettings returns the settings for each ensemble member: these settings
+// with the member's overrides applied. Members have no ensemble of their own.
+func (s *Settings) EnsembleSettings() ([]Settings, error) {
+	base := s.toMap()
+	members := make([]Settings, 0, len(s.Ensemble))
+	for i, overrides := range s.Ensemble {
+		v := viper.New()
+		if err := v.MergeConfigMap(base); err != nil {
+			return nil, fmt.Errorf("ensemble[%d]: %w", i, err)
+		}
+		for key, value := range overrides {
+			if !ensembleKeys[strings.ToLower(key)] {
+				return nil, fmt.Errorf("ensemble[%d]: %s: %w", i, key, ErrUnknownEnsembleKey)
+			}
+			v.Set(key, value)
+		}
+
+		var member Settings
+		if err := v.Unmarshal(&member); err != nil {
+			return nil, fmt.Errorf("ensemble[%d]: %w", i, err)
+		}
+		member.Ensemble = nil
+		if member.QRQ {
+			member.FitQRQ()
+		}
+		if err := member.Validate(); err != nil {
+			return nil, fmt.Errorf("ensemble[%d]: %w", i, err)
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// toMap returns the settings keyed by their config file names, without the ensemble
+func (s *Settings) toMap() map[string]any {
+	value := reflect.ValueOf(*s)
+	fields := value.Type()
+	m := make(map[string]any, fields.NumField())
+	for i := range fields.NumField() {
+		key := fields.Field(i).Tag.Get("mapstructure")
+		if key == "" || key == "ensemble" {
+			continue
+		}
+		m[key] = value.Field(i).Interface()
+	}
+	return m
+}
+
+// validateContest checks the contest settings against the contest's exchange grammar
+func (s *Settings) validateContest() []error {
+	var errs []error
+
+	c, err := contest.Lookup(s.Contest)
+	if err != nil {
+		return []error{fmt.Errorf("contest must be one of %s: %w", strings.Join(contest.Names(), ", "), err)}
+	}
+	if s.ContestCall == "" {
+		errs = append(errs, errors.New("contest_call is required when contest is set"))
+	}
+	if _, err := c.ParseSent(s.ContestExchange); err != nil {
+		errs = append(errs, fmt.Errorf("contest_exchange: %w", err))
+	}
+	if s.ContestFrequency < MinContestFrequency || s.ContestFrequency > MaxContestFrequency {
+		errs = append(errs, fmt.Errorf("contest_frequency must be between %d and %d kHz, got %d", MinContestFrequency, MaxContestFrequency, s.ContestFrequency))
+	}
+	return errs
+}
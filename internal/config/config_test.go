@@ -1,8 +1,10 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/viper"
@@ -54,7 +56,19 @@ func TestInit_WithDefaults(t *testing.T) {
 		{"inter_char_boundary", 2.0},
 		{"char_word_boundary", 5.0},
 		{"farnsworth_wpm", 0},
+		{"farnsworth_auto", true},
+		{"key_mode", "electronic"},
+		{"speculative_output", false},
+		{"qrq", false},
+		{"alphabet", "latin"},
+		{"morse_code", "international"},
+		{"classifier_model", ""},
 		{"buffer_size", 1024},
+		{"audio_source", "device"},
+		{"network_address", "127.0.0.1:7355"},
+		{"network_jitter_ms", 100},
+		{"event_log", ""},
+		{"element_output", ""},
 		{"debug", false},
 	}
 
@@ -295,6 +309,87 @@ func TestEnsureConfigExists_DoesNotOverwrite(t *testing.T) {
 	}
 }
 
+func TestWriteFile_AppliesOverrides(t *testing.T) {
+	resetViper()
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	configDir := filepath.Join(tmpDir, ".config", AppName)
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("wpm: 25"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	path := filepath.Join(tmpDir, "tuned.yaml")
+	if err := WriteFile(path, map[string]any{"threshold": 0.25, "hysteresis": 3}); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if viper.GetFloat64("threshold") != 0.4 {
+		t.Errorf("WriteFile() changed the global threshold to %v", viper.GetFloat64("threshold"))
+	}
+
+	written := viper.New()
+	written.SetConfigFile(path)
+	if err := written.ReadInConfig(); err != nil {
+		t.Fatalf("ReadInConfig() error = %v", err)
+	}
+	if got := written.GetFloat64("threshold"); got != 0.25 {
+		t.Errorf("threshold = %v, want 0.25", got)
+	}
+	if got := written.GetInt("hysteresis"); got != 3 {
+		t.Errorf("hysteresis = %v, want 3", got)
+	}
+	// Settings from the original file and defaults are carried over
+	if got := written.GetInt("wpm"); got != 25 {
+		t.Errorf("wpm = %v, want 25", got)
+	}
+	if got := written.GetInt("block_size"); got != 512 {
+		t.Errorf("block_size = %v, want 512", got)
+	}
+}
+
+func TestGet_Ensemble(t *testing.T) {
+	resetViper()
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	configDir := filepath.Join(tmpDir, ".config", AppName)
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	customConfig := `ensemble:
+  - {threshold: 0.25, hysteresis: 2}
+  - wpm: 30
+    block_size: 256
+`
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(customConfig), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	settings, err := Get()
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	members, err := settings.EnsembleSettings()
+	if err != nil {
+		t.Fatalf("EnsembleSettings() error = %v", err)
+	}
+	if len(members) != 2 {
+		t.Fatalf("EnsembleSettings() returned %d members, want 2", len(members))
+	}
+	if members[1].WPM != 30 || members[1].BlockSize != 256 {
+		t.Errorf("member 1 WPM/block_size = %d/%d, want 30/256", members[1].WPM, members[1].BlockSize)
+	}
+}
+
 func TestConstants(t *testing.T) {
 	if AppName != "cwdecoder" {
 		t.Errorf("AppName = %q, want %q", AppName, "cwdecoder")
@@ -573,6 +668,8 @@ func TestSettings_Validate_ValidSettings(t *testing.T) {
 		Channels:               1,
 		Format:                 "S16_LE",
 		BufferSize:             1024,
+		AudioSource:            "device",
+		NetworkJitterMs:        100,
 		ToneFrequency:          600,
 		BlockSize:              512,
 		OverlapPct:             50,
@@ -589,6 +686,9 @@ func TestSettings_Validate_ValidSettings(t *testing.T) {
 		InterCharBoundary:      2.0,
 		CharWordBoundary:       5.0,
 		FarnsworthWPM:          0,
+		KeyMode:                "electronic",
+		Alphabet:               "latin",
+		MorseCode:              "international",
 		AdaptivePatternEnabled: true,
 		AdaptiveMinConfidence:  0.7,
 		AdaptiveAdjustmentRate: 0.1,
@@ -885,6 +985,382 @@ func TestSettings_Validate_WPM(t *testing.T) {
 	}
 }
 
+func TestSettings_Validate_SCPMaxDistance(t *testing.T) {
+	tests := []struct {
+		name     string
+		distance int
+		wantErr  bool
+	}{
+		{"negative", -1, true},
+		{"default", 0, false},
+		{"typical", 2, false},
+		{"maximum", 6, false},
+		{"too large", 7, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := validSettings()
+			s.SCPMaxDistance = tt.distance
+			err := s.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSettings_Validate_Alphabet(t *testing.T) {
+	for _, alphabet := range []string{"latin", "extended", "cyrillic", "greek", "hebrew", "arabic", "wabun"} {
+		s := validSettings()
+		s.Alphabet = alphabet
+		if err := s.Validate(); err != nil {
+			t.Errorf("Validate() with alphabet %q error = %v", alphabet, err)
+		}
+	}
+	for _, alphabet := range []string{"", "russian", "Latin"} {
+		s := validSettings()
+		s.Alphabet = alphabet
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate() with alphabet %q should fail", alphabet)
+		}
+	}
+}
+
+func TestSettings_Validate_QRQ(t *testing.T) {
+	s := validSettings()
+	s.WPM = 120
+	if err := s.Validate(); err == nil {
+		t.Error("Validate() should reject 120 WPM without qrq")
+	}
+	s.QRQ = true
+	if err := s.Validate(); err != nil {
+		t.Errorf("Validate() with qrq at 120 WPM error = %v", err)
+	}
+	s.WPM = MaxQRQWPM + 1
+	if err := s.Validate(); err == nil {
+		t.Errorf("Validate() should reject %d WPM with qrq", s.WPM)
+	}
+}
+
+func TestSettings_FitQRQ(t *testing.T) {
+	tests := []struct {
+		name       string
+		sampleRate float64
+		wpm        int
+		wantBlock  int
+	}{
+		// A 4.8 ms dit leaves 2.4 ms (115 samples) for the shortest tone: 3/4 of a 128 block
+		{"250 WPM at 48 kHz", 48000, 250, 128},
+		{"100 WPM at 48 kHz", 48000, 100, 256},
+		// Blocks never grow beyond the configured size
+		{"20 WPM at 48 kHz", 48000, 20, 512},
+		// The smallest block is used even if it does not fit
+		{"250 WPM at 8 kHz", 8000, 250, MinBlockSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := validSettings()
+			s.QRQ = true
+			s.SampleRate = tt.sampleRate
+			s.WPM = tt.wpm
+			s.FitQRQ()
+
+			if s.BlockSize != tt.wantBlock || s.OverlapPct != QRQOverlapPct || s.Hysteresis != QRQHysteresis {
+				t.Errorf("FitQRQ() block_size %d overlap_pct %d hysteresis %d, want %d %d %d",
+					s.BlockSize, s.OverlapPct, s.Hysteresis, tt.wantBlock, QRQOverlapPct, QRQHysteresis)
+			}
+			if err := s.Validate(); err != nil {
+				t.Errorf("fitted settings invalid: %v", err)
+			}
+		})
+	}
+}
+
+func TestSettings_Warnings(t *testing.T) {
+	s := validSettings()
+	s.SampleRate = 48000
+	s.BlockSize = 512
+	s.OverlapPct = 50
+	s.Hysteresis = 5
+
+	s.WPM = 20
+	if warnings := s.Warnings(); len(warnings) != 0 {
+		t.Errorf("Warnings() at 20 WPM = %v, want none", warnings)
+	}
+
+	// A 26.7 ms detection window is longer than a 24 ms dit
+	s.WPM = 50
+	warnings := s.Warnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "enable qrq") {
+		t.Errorf("Warnings() at 50 WPM = %v, want one suggesting qrq", warnings)
+	}
+
+	s.QRQ = true
+	s.FitQRQ()
+	if warnings := s.Warnings(); len(warnings) != 0 {
+		t.Errorf("Warnings() after FitQRQ = %v, want none", warnings)
+	}
+}
+
+func TestGet_QRQFitsDetection(t *testing.T) {
+	resetViper()
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	configDir := filepath.Join(tmpDir, ".config", AppName)
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte("qrq: true\nwpm: 150\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	settings, err := Get()
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if settings.WPM != 150 || settings.BlockSize >= 512 || settings.Hysteresis != QRQHysteresis {
+		t.Errorf("Get() wpm %d block_size %d hysteresis %d, want 150 WPM with a fitted detector",
+			settings.WPM, settings.BlockSize, settings.Hysteresis)
+	}
+}
+
+func TestSettings_Validate_MorseCode(t *testing.T) {
+	for _, code := range []string{"international", "american"} {
+		s := validSettings()
+		s.MorseCode = code
+		if err := s.Validate(); err != nil {
+			t.Errorf("Validate() with morse_code %q error = %v", code, err)
+		}
+	}
+	for _, code := range []string{"", "railroad", "American"} {
+		s := validSettings()
+		s.MorseCode = code
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate() with morse_code %q should fail", code)
+		}
+	}
+}
+
+func TestSettings_Validate_KeyMode(t *testing.T) {
+	for _, mode := range []string{"electronic", "straight", "bug"} {
+		s := validSettings()
+		s.KeyMode = mode
+		if err := s.Validate(); err != nil {
+			t.Errorf("Validate() with key_mode %q error = %v", mode, err)
+		}
+	}
+	for _, mode := range []string{"", "iambic", "Straight"} {
+		s := validSettings()
+		s.KeyMode = mode
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate() with key_mode %q should fail", mode)
+		}
+	}
+}
+
+func TestSettings_Validate_FistReport(t *testing.T) {
+	for _, format := range []string{"", "text", "json"} {
+		s := validSettings()
+		s.FistReport = format
+		if err := s.Validate(); err != nil {
+			t.Errorf("Validate() with fist_report %q error = %v", format, err)
+		}
+	}
+
+	s := validSettings()
+	s.FistReport = "xml"
+	if err := s.Validate(); err == nil {
+		t.Error("Validate() with fist_report \"xml\" should fail")
+	}
+}
+
+func TestSettings_Validate_ElementOutput(t *testing.T) {
+	for _, mode := range []string{"", "both", "only"} {
+		s := validSettings()
+		s.ElementOutput = mode
+		if err := s.Validate(); err != nil {
+			t.Errorf("Validate() with element_output %q error = %v", mode, err)
+		}
+	}
+
+	s := validSettings()
+	s.ElementOutput = "all"
+	if err := s.Validate(); err == nil {
+		t.Error("Validate() with element_output \"all\" should fail")
+	}
+}
+
+func TestSettings_Validate_AudioSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		address string
+		jitter  int
+		wantErr bool
+	}{
+		{"device", "device", "", 100, false},
+		{"udp", "udp", "127.0.0.1:7355", 100, false},
+		{"tcp", "tcp", "sdr.local:7355", 0, false},
+		{"tcp-listen", "tcp-listen", ":7355", 2000, false},
+		{"unknown source", "pulse", "", 100, true},
+		{"network without address", "udp", "", 100, true},
+		{"negative jitter", "udp", ":7355", -1, true},
+		{"jitter too long", "udp", ":7355", 2001, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := validSettings()
+			s.AudioSource = tt.source
+			s.NetworkAddress = tt.address
+			s.NetworkJitterMs = tt.jitter
+			err := s.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSettings_Validate_PracticeReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		report  string
+		wantErr bool
+	}{
+		{"disabled ignores format", "", "", false},
+		{"text", "CQ CQ", "text", false},
+		{"json", "CQ CQ", "json", false},
+		{"invalid", "CQ CQ", "pdf", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := validSettings()
+			s.PracticeText = tt.text
+			s.PracticeReport = tt.report
+			err := s.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSettings_Validate_Contest(t *testing.T) {
+	tests := []struct {
+		name      string
+		contest   string
+		call      string
+		exchange  string
+		frequency int
+		wantErr   bool
+	}{
+		{"disabled", "", "", "", 0, false},
+		{"cq ww", "CQ-WW-CW", "K1ABC", "599 5", 14025, false},
+		{"sweepstakes", "ARRL-SS-CW", "K1ABC", "1 A 99 CT", 7030, false},
+		{"unknown contest", "FIELD-DAY", "K1ABC", "599 5", 0, true},
+		{"missing call", "CQ-WW-CW", "", "599 5", 0, true},
+		{"short exchange", "CQ-WW-CW", "K1ABC", "599", 0, true},
+		{"invalid zone", "CQ-WW-CW", "K1ABC", "599 41", 0, true},
+		{"negative frequency", "CQ-WW-CW", "K1ABC", "599 5", -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := validSettings()
+			s.Contest = tt.contest
+			s.ContestCall = tt.call
+			s.ContestExchange = tt.exchange
+			s.ContestFrequency = tt.frequency
+			err := s.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSettings_Validate_Ensemble(t *testing.T) {
+	tests := []struct {
+		name     string
+		ensemble []map[string]any
+		wantErr  bool
+	}{
+		{"none", nil, false},
+		{"detector overrides", []map[string]any{{"threshold": 0.25, "hysteresis": 2}}, false},
+		{"decoder overrides", []map[string]any{{"wpm": 30, "key_mode": "bug"}}, false},
+		{"out of range", []map[string]any{{"threshold": 1.5}}, true},
+		{"audio setting", []map[string]any{{"sample_rate": 8000}}, true},
+		{"unknown key", []map[string]any{{"volume": 11}}, true},
+		{"too many members", make([]map[string]any, MaxEnsembleMembers+1), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := validSettings()
+			s.Ensemble = tt.ensemble
+			err := s.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSettings_EnsembleSettings(t *testing.T) {
+	s := validSettings()
+	s.Ensemble = []map[string]any{
+		{"threshold": 0.25, "hysteresis": "2"},
+		{"WPM": 30},
+	}
+
+	members, err := s.EnsembleSettings()
+	if err != nil {
+		t.Fatalf("EnsembleSettings() error = %v", err)
+	}
+	if len(members) != 2 {
+		t.Fatalf("EnsembleSettings() returned %d members, want 2", len(members))
+	}
+
+	if members[0].Threshold != 0.25 || members[0].Hysteresis != 2 {
+		t.Errorf("member 0 threshold/hysteresis = %v/%d, want 0.25/2", members[0].Threshold, members[0].Hysteresis)
+	}
+	if members[0].WPM != s.WPM {
+		t.Errorf("member 0 WPM = %d, want inherited %d", members[0].WPM, s.WPM)
+	}
+	if members[1].WPM != 30 || members[1].Threshold != s.Threshold {
+		t.Errorf("member 1 WPM/threshold = %d/%v, want 30/%v", members[1].WPM, members[1].Threshold, s.Threshold)
+	}
+	for i, m := range members {
+		if m.SampleRate != s.SampleRate || m.Format != s.Format {
+			t.Errorf("member %d did not inherit the audio settings", i)
+		}
+		if m.Ensemble != nil {
+			t.Errorf("member %d has its own ensemble", i)
+		}
+	}
+	if s.Threshold != 0.4 {
+		t.Errorf("EnsembleSettings() changed the base threshold to %v", s.Threshold)
+	}
+}
+
+func TestSettings_EnsembleSettings_UnknownKey(t *testing.T) {
+	s := validSettings()
+	s.Ensemble = []map[string]any{{"buffer_size": 256}}
+
+	_, err := s.EnsembleSettings()
+	if !errors.Is(err, ErrUnknownEnsembleKey) {
+		t.Errorf("EnsembleSettings() error = %v, want ErrUnknownEnsembleKey", err)
+	}
+}
+
 func TestSettings_Validate_Format(t *testing.T) {
 	validFormats := []string{"S16_LE", "S16_BE", "S24_LE", "S24_BE", "S32_LE", "S32_BE", "F32_LE", "F32_BE"}
 	invalidFormats := []string{"", "invalid", "S8", "U16_LE", "FLOAT"}
@@ -1002,6 +1478,8 @@ func validSettings() *Settings {
 		Channels:               1,
 		Format:                 "S16_LE",
 		BufferSize:             1024,
+		AudioSource:            "device",
+		NetworkJitterMs:        100,
 		ToneFrequency:          600,
 		BlockSize:              512,
 		OverlapPct:             50,
@@ -1018,10 +1496,14 @@ func validSettings() *Settings {
 		InterCharBoundary:      2.0,
 		CharWordBoundary:       5.0,
 		FarnsworthWPM:          0,
+		KeyMode:                "electronic",
+		Alphabet:               "latin",
+		MorseCode:              "international",
 		AdaptivePatternEnabled: true,
 		AdaptiveMinConfidence:  0.7,
 		AdaptiveAdjustmentRate: 0.1,
 		AdaptiveMinMatches:     3,
+		SCPMaxDistance:         2,
 		Debug:                  false,
 	}
 }
@@ -0,0 +1,71 @@
+// internal/contest/cabrillo.go
+package contest
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Cabrillo format constants
+const (
+	// cabrilloVersion is the Cabrillo specification version written
+	cabrilloVersion = "3.0"
+	// cabrilloDateLayout is the QSO line date format
+	cabrilloDateLayout = "2006-01-02"
+	// cabrilloTimeLayout is the QSO line time format (UTC, HHMM)
+	cabrilloTimeLayout = "1504"
+	// cabrilloCallWidth pads callsign columns so exchanges line up
+	cabrilloCallWidth = 13
+)
+
+// Log is a contest log ready for Cabrillo export
+type Log struct {
+	// Contest is the contest the log is for
+	Contest *Contest
+	// Callsign is the operator's callsign
+	Callsign string
+	// Frequency is the operating frequency in kHz
+	Frequency int
+	// Sent is the operator's own exchange, as returned by Contest.ParseSent
+	Sent []string
+	// QSOs are the decoded exchanges
+	QSOs []QSO
+}
+
+// WriteCabrillo writes the log as a Cabrillo 3.0 file
+func WriteCabrillo(w io.Writer, log Log) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "START-OF-LOG: %s\n", cabrilloVersion)
+	fmt.Fprintf(&b, "CONTEST: %s\n", log.Contest.Name)
+	fmt.Fprintf(&b, "CALLSIGN: %s\n", strings.ToUpper(log.Callsign))
+	b.WriteString("CREATED-BY: cwdecoder\n")
+	for _, qso := range log.QSOs {
+		b.WriteString(FormatQSOLine(log, qso))
+		b.WriteString("\n")
+	}
+	b.WriteString("END-OF-LOG:\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("write cabrillo log: %w", err)
+	}
+	return nil
+}
+
+// FormatQSOLine formats one exchange as a Cabrillo QSO line:
+// "QSO: freq mo date time mycall sent... call rcvd..."
+func FormatQSOLine(log Log, qso QSO) string {
+	t := qso.Time.UTC()
+	fields := []string{
+		"QSO:",
+		fmt.Sprintf("%5d", log.Frequency),
+		"CW",
+		t.Format(cabrilloDateLayout),
+		t.Format(cabrilloTimeLayout),
+		fmt.Sprintf("%-*s", cabrilloCallWidth, strings.ToUpper(log.Callsign)),
+	}
+	fields = append(fields, log.Sent...)
+	fields = append(fields, fmt.Sprintf("%-*s", cabrilloCallWidth, qso.Call))
+	fields = append(fields, qso.Exchange...)
+	return strings.TrimRight(strings.Join(fields, " "), " ")
+}
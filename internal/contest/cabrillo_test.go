@@ -0,0 +1,54 @@
+package contest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testLog(t *testing.T) Log {
+	t.Helper()
+	c, err := Lookup(CQWW)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Log{
+		Contest:   c,
+		Callsign:  "k1abc",
+		Frequency: 14025,
+		Sent:      []string{"599", "5"},
+		QSOs: []QSO{
+			{Time: time.Date(2024, 11, 30, 0, 1, 30, 0, time.UTC), Call: "DL1ABC", Exchange: []string{"599", "14"}},
+		},
+	}
+}
+
+func TestFormatQSOLine(t *testing.T) {
+	log := testLog(t)
+	got := FormatQSOLine(log, log.QSOs[0])
+	want := "QSO: 14025 CW 2024-11-30 0001 K1ABC         599 5 DL1ABC        599 14"
+	if got != want {
+		t.Errorf("FormatQSOLine =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestWriteCabrillo(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCabrillo(&buf, testLog(t)); err != nil {
+		t.Fatalf("WriteCabrillo error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[0] != "START-OF-LOG: 3.0" {
+		t.Errorf("first line = %q, want START-OF-LOG: 3.0", lines[0])
+	}
+	if lines[len(lines)-1] != "END-OF-LOG:" {
+		t.Errorf("last line = %q, want END-OF-LOG:", lines[len(lines)-1])
+	}
+	for _, want := range []string{"CONTEST: CQ-WW-CW", "CALLSIGN: K1ABC", "QSO: 14025 CW"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("log missing %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,267 @@
+// internal/contest/contest.go
+// Package contest decodes contest exchanges from the CW character stream and
+// writes them as Cabrillo logs.
+package contest
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/callsign"
+)
+
+// Cabrillo contest names for the supported contests
+const (
+	// CQWW is the CQ World Wide DX Contest: RST and CQ zone
+	CQWW = "CQ-WW-CW"
+	// ARRLDX is the ARRL International DX Contest: RST and state/province (W/VE) or power (DX)
+	ARRLDX = "ARRL-DX-CW"
+	// ARRLSS is ARRL November Sweepstakes: serial, precedence, call, check and section
+	ARRLSS = "ARRL-SS-CW"
+	// CWT is the CWops mini-test: name and member number (or location for non-members)
+	CWT = "CWOPS-CWT"
+)
+
+// ErrUnknownContest indicates a contest name with no exchange grammar
+var ErrUnknownContest = errors.New("unknown contest")
+
+// FieldKind identifies one element of a contest exchange
+type FieldKind int
+
+const (
+	// FieldCall is the sending station's callsign (only when part of the exchange)
+	FieldCall FieldKind = iota
+	// FieldRST is a signal report, usually sent as 5NN
+	FieldRST
+	// FieldCQZone is a CQ zone number (1-40)
+	FieldCQZone
+	// FieldSerial is a QSO serial number
+	FieldSerial
+	// FieldPrecedence is a Sweepstakes entry class (Q, A, B, U, M or S)
+	FieldPrecedence
+	// FieldCheck is the two-digit year of first licence
+	FieldCheck
+	// FieldSection is an ARRL/RAC section
+	FieldSection
+	// FieldName is the operator's name
+	FieldName
+	// FieldPowerOrLocation is a US state or Canadian province, or transmit power for DX
+	FieldPowerOrLocation
+	// FieldMemberOrLocation is a membership number, or state/province/DXCC prefix for non-members
+	FieldMemberOrLocation
+)
+
+// Exchange field limits
+const (
+	// MaxCQZone is the highest CQ zone
+	MaxCQZone = 40
+	// MaxNameLength is the longest operator name accepted
+	MaxNameLength = 10
+)
+
+// String returns the field name used in messages
+func (f FieldKind) String() string {
+	switch f {
+	case FieldCall:
+		return "call"
+	case FieldRST:
+		return "rst"
+	case FieldCQZone:
+		return "zone"
+	case FieldSerial:
+		return "serial"
+	case FieldPrecedence:
+		return "precedence"
+	case FieldCheck:
+		return "check"
+	case FieldSection:
+		return "section"
+	case FieldName:
+		return "name"
+	case FieldPowerOrLocation:
+		return "power/location"
+	case FieldMemberOrLocation:
+		return "member/location"
+	}
+	return "unknown"
+}
+
+// Contest describes the exchange grammar of one contest
+type Contest struct {
+	// Name is the Cabrillo CONTEST name
+	Name string
+	// Fields are the exchange elements in the order they are sent
+	Fields []FieldKind
+}
+
+// contests lists the supported exchange grammars
+var contests = []Contest{
+	{Name: CQWW, Fields: []FieldKind{FieldRST, FieldCQZone}},
+	{Name: ARRLDX, Fields: []FieldKind{FieldRST, FieldPowerOrLocation}},
+	{Name: ARRLSS, Fields: []FieldKind{FieldSerial, FieldPrecedence, FieldCall, FieldCheck, FieldSection}},
+	{Name: CWT, Fields: []FieldKind{FieldName, FieldMemberOrLocation}},
+}
+
+// Lookup returns the contest with the given Cabrillo name (case-insensitive)
+func Lookup(name string) (*Contest, error) {
+	for i := range contests {
+		if strings.EqualFold(contests[i].Name, name) {
+			return &contests[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownContest, name)
+}
+
+// Names returns the Cabrillo names of all supported contests
+func Names() []string {
+	names := make([]string, len(contests))
+	for i := range contests {
+		names[i] = contests[i].Name
+	}
+	return names
+}
+
+// ExchangeLen returns the number of exchange fields excluding the callsign
+func (c *Contest) ExchangeLen() int {
+	n := 0
+	for _, field := range c.Fields {
+		if field != FieldCall {
+			n++
+		}
+	}
+	return n
+}
+
+// hasCall reports whether the callsign is sent as part of the exchange
+func (c *Contest) hasCall() bool {
+	return c.ExchangeLen() != len(c.Fields)
+}
+
+// ParseSent validates the operator's own exchange (e.g. "599 5") against the grammar
+// and returns its normalized fields for the Cabrillo sent columns.
+func (c *Contest) ParseSent(exchange string) ([]string, error) {
+	tokens := strings.Fields(strings.ToUpper(exchange))
+	if len(tokens) != c.ExchangeLen() {
+		return nil, fmt.Errorf("%s exchange needs %d fields, got %d", c.Name, c.ExchangeLen(), len(tokens))
+	}
+
+	sent := make([]string, 0, len(tokens))
+	i := 0
+	for _, field := range c.Fields {
+		if field == FieldCall {
+			continue
+		}
+		value, ok := matchField(field, tokens[i])
+		if !ok {
+			return nil, fmt.Errorf("%s exchange: %q is not a valid %s", c.Name, tokens[i], field)
+		}
+		sent = append(sent, value)
+		i++
+	}
+	return sent, nil
+}
+
+var (
+	// rstShape matches an expanded signal report
+	rstShape = regexp.MustCompile(`^[1-5][1-9][1-9]$`)
+	// nameShape matches an operator name
+	nameShape = regexp.MustCompile(`^[A-Z]{1,10}$`)
+	// dxPrefixShape matches a DXCC prefix sent as a CWT location
+	dxPrefixShape = regexp.MustCompile(`^[A-Z0-9]{1,4}$`)
+)
+
+// precedences are the Sweepstakes entry classes
+const precedences = "QABUMS"
+
+// sections are the ARRL and RAC contest sections
+var sections = toSet(`CT EMA ME NH RI VT WMA ENY NLI NNJ NNY SNJ WNY DE EPA MDC WPA
+AL GA KY NC NFL PR SC SFL TN VA VI WCF AR LA MS NM NTX OK STX WTX
+EB LAX ORG PAC SB SCV SDG SF SJV SV AK AZ EWA ID MT NV OR UT WWA WY
+MI OH WV IL IN WI CO IA KS MN MO ND NE SD
+AB BC GH MB NB NL NS ONE ONN ONS PE QC SK TER`)
+
+// locations are US states, the District of Columbia and Canadian provinces and territories
+var locations = toSet(`AL AK AZ AR CA CO CT DE DC FL GA HI ID IL IN IA KS KY LA ME MD MA MI MN
+MS MO MT NE NV NH NJ NM NY NC ND OH OK OR PA RI SC SD TN TX UT VT VA WA WV WI WY
+AB BC MB NB NL NS NT NU ON PE QC SK YT`)
+
+// toSet splits a whitespace-separated list into a lookup set
+func toSet(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, item := range strings.Fields(list) {
+		set[item] = true
+	}
+	return set
+}
+
+// matchField checks a token against one exchange field and returns its normalized value
+func matchField(field FieldKind, token string) (string, bool) {
+	switch field {
+	case FieldCall:
+		if parsed, err := callsign.Parse(token); err == nil {
+			return parsed.Call, true
+		}
+	case FieldRST:
+		if rst, ok := ExpandCutNumbers(token); ok && rstShape.MatchString(rst) {
+			return rst, true
+		}
+	case FieldCQZone:
+		if zone, ok := parseNumber(token); ok && zone >= 1 && zone <= MaxCQZone {
+			return strconv.Itoa(zone), true
+		}
+	case FieldSerial:
+		if serial, ok := parseNumber(token); ok && serial >= 1 {
+			return strconv.Itoa(serial), true
+		}
+	case FieldPrecedence:
+		if len(token) == 1 && strings.Contains(precedences, token) {
+			return token, true
+		}
+	case FieldCheck:
+		if check, ok := ExpandCutNumbers(token); ok && len(check) == 2 {
+			return check, true
+		}
+	case FieldSection:
+		if sections[token] {
+			return token, true
+		}
+	case FieldName:
+		if nameShape.MatchString(token) && len(token) <= MaxNameLength {
+			return token, true
+		}
+	case FieldPowerOrLocation:
+		if locations[token] || token == "KW" {
+			return token, true
+		}
+		if power, ok := parseNumber(token); ok && power >= 1 {
+			return strconv.Itoa(power), true
+		}
+	case FieldMemberOrLocation:
+		// Only tokens with a real digit are member numbers; all-letter tokens are locations
+		if strings.ContainsAny(token, "0123456789") {
+			if member, ok := parseNumber(token); ok {
+				return strconv.Itoa(member), true
+			}
+		}
+		if locations[token] || dxPrefixShape.MatchString(token) {
+			return token, true
+		}
+	}
+	return "", false
+}
+
+// parseNumber expands cut numbers and parses the result ("TT5" -> 5)
+func parseNumber(token string) (int, bool) {
+	digits, ok := ExpandCutNumbers(token)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
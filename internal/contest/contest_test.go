@@ -0,0 +1,93 @@
+package contest
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	for _, name := range Names() {
+		c, err := Lookup(name)
+		if err != nil {
+			t.Errorf("Lookup(%q) error = %v", name, err)
+			continue
+		}
+		if c.Name != name {
+			t.Errorf("Lookup(%q).Name = %q", name, c.Name)
+		}
+	}
+
+	if _, err := Lookup("cq-ww-cw"); err != nil {
+		t.Errorf("Lookup should be case-insensitive: %v", err)
+	}
+	if _, err := Lookup("FIELD-DAY"); !errors.Is(err, ErrUnknownContest) {
+		t.Errorf("Lookup(FIELD-DAY) error = %v, want ErrUnknownContest", err)
+	}
+}
+
+func TestMatchField(t *testing.T) {
+	tests := []struct {
+		field FieldKind
+		token string
+		want  string
+		ok    bool
+	}{
+		{FieldRST, "5NN", "599", true},
+		{FieldRST, "579", "579", true},
+		{FieldRST, "14", "", false},
+		{FieldCQZone, "T5", "5", true},
+		{FieldCQZone, "14", "14", true},
+		{FieldCQZone, "41", "", false},
+		{FieldSerial, "TT1", "1", true},
+		{FieldSerial, "123", "123", true},
+		{FieldPrecedence, "A", "A", true},
+		{FieldPrecedence, "X", "", false},
+		{FieldCheck, "99", "99", true},
+		{FieldCheck, "NN", "99", true},
+		{FieldCheck, "123", "", false},
+		{FieldSection, "CT", "CT", true},
+		{FieldSection, "ONE", "ONE", true},
+		{FieldSection, "XX", "", false},
+		{FieldName, "BOB", "BOB", true},
+		{FieldName, "599", "", false},
+		{FieldPowerOrLocation, "MA", "MA", true},
+		{FieldPowerOrLocation, "KW", "KW", true},
+		{FieldPowerOrLocation, "ATT", "100", true},
+		{FieldMemberOrLocation, "1234", "1234", true},
+		{FieldMemberOrLocation, "1TT", "100", true},
+		{FieldMemberOrLocation, "ON", "ON", true},
+		{FieldMemberOrLocation, "DL", "DL", true},
+		{FieldCall, "W1AW", "W1AW", true},
+		{FieldCall, "599", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := matchField(tt.field, tt.token)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("matchField(%s, %q) = %q, %v; want %q, %v", tt.field, tt.token, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestContest_ParseSent(t *testing.T) {
+	ss, err := Lookup(ARRLSS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent, err := ss.ParseSent("1 a 99 ct")
+	if err != nil {
+		t.Fatalf("ParseSent error = %v", err)
+	}
+	if want := []string{"1", "A", "99", "CT"}; !slices.Equal(sent, want) {
+		t.Errorf("ParseSent = %v, want %v", sent, want)
+	}
+
+	if _, err := ss.ParseSent("1 A 99"); err == nil {
+		t.Error("ParseSent should reject a short exchange")
+	}
+	if _, err := ss.ParseSent("1 X 99 CT"); err == nil {
+		t.Error("ParseSent should reject an invalid precedence")
+	}
+}
@@ -0,0 +1,41 @@
+// internal/contest/cut.go
+package contest
+
+import "strings"
+
+// cutNumbers maps contest cut-number letters to the digits they abbreviate.
+// T and O are both sent for zero; A, U, V, E, G, D and N are shortened forms
+// of 1, 2, 3, 5, 7, 8 and 9.
+var cutNumbers = map[rune]rune{
+	'T': '0',
+	'O': '0',
+	'A': '1',
+	'U': '2',
+	'V': '3',
+	'E': '5',
+	'G': '7',
+	'D': '8',
+	'N': '9',
+}
+
+// ExpandCutNumbers converts a token of digits and cut-number letters to digits
+// ("5NN" -> "599", "ATT" -> "100"). It returns false if the token contains any
+// other character.
+func ExpandCutNumbers(token string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+
+	var b strings.Builder
+	for _, char := range strings.ToUpper(token) {
+		switch {
+		case char >= '0' && char <= '9':
+			b.WriteRune(char)
+		case cutNumbers[char] != 0:
+			b.WriteRune(cutNumbers[char])
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
@@ -0,0 +1,28 @@
+package contest
+
+import "testing"
+
+func TestExpandCutNumbers(t *testing.T) {
+	tests := []struct {
+		token string
+		want  string
+		ok    bool
+	}{
+		{"5NN", "599", true},
+		{"599", "599", true},
+		{"ATT", "100", true},
+		{"T5", "05", true},
+		{"1O", "10", true},
+		{"UVEGD", "23578", true},
+		{"5nn", "599", true},
+		{"K1ABC", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ExpandCutNumbers(tt.token)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ExpandCutNumbers(%q) = %q, %v; want %q, %v", tt.token, got, ok, tt.want, tt.ok)
+		}
+	}
+}
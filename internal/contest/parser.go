@@ -0,0 +1,191 @@
+// internal/contest/parser.go
+package contest
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/callsign"
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+// fillerWords are skipped between exchange fields ("TU 5NN 14", "NR 123 A")
+var fillerWords = map[string]bool{
+	"TU": true, "R": true, "RR": true, "NR": true, "QSL": true, "TEST": true,
+	"CQ": true, "DE": true, "K": true, "BK": true, "EE": true, "QRZ": true,
+	"AGN": true, "?": true, "CWT": true, "SS": true, "73": true,
+}
+
+// QSO is one decoded contest exchange
+type QSO struct {
+	// Time is when the first character of the exchange was decoded
+	Time time.Time
+	// Call is the station that sent the exchange
+	Call string
+	// Exchange holds the normalized exchange fields in grammar order, excluding the callsign
+	Exchange []string
+}
+
+// QSOCallback is called for each complete exchange.
+// Must be non-blocking and fast.
+type QSOCallback func(qso QSO)
+
+// Parser matches the decoded word stream against a contest exchange grammar.
+//
+// When the grammar has no callsign field, an exchange is credited to the most
+// recent callsign heard other than myCall: the run station's CQ when calling
+// it, or the caller's own call when running.
+type Parser struct {
+	contest *Contest
+	myCall  string
+
+	mu        sync.Mutex
+	word      strings.Builder
+	wordStart time.Time
+
+	// Exchange being matched
+	field      int
+	values     []string
+	call       string
+	fieldStart time.Time
+
+	lastCall string // most recent other station heard
+	logged   string // station whose exchange was last emitted, to suppress repeats
+
+	callback QSOCallback
+}
+
+// NewParser creates an exchange parser for contest. myCall is the operator's
+// own callsign, which is never credited with a received exchange.
+func NewParser(contest *Contest, myCall string) *Parser {
+	return &Parser{
+		contest: contest,
+		myCall:  strings.ToUpper(myCall),
+	}
+}
+
+// SetCallback sets the callback for decoded exchanges
+func (p *Parser) SetCallback(cb QSOCallback) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.callback = cb
+}
+
+// HandleOutput consumes one decoded output from the cw decoder.
+// Signature matches cw.DecodedCallback so it can be chained directly.
+func (p *Parser) HandleOutput(output cw.DecodedOutput) {
+	// Exchanges are parsed from final characters only
+	if !output.IsFinal() {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if output.IsWordSpace {
+		p.endWord()
+		return
+	}
+	if output.Character == 0 {
+		return
+	}
+	if p.word.Len() == 0 {
+		p.wordStart = output.Timestamp
+	}
+	p.word.WriteRune(output.Character)
+}
+
+// Flush processes any word still being built (e.g. at end of a session)
+func (p *Parser) Flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.endWord()
+}
+
+// endWord feeds the completed word to the exchange matcher
+func (p *Parser) endWord() {
+	token := strings.ToUpper(p.word.String())
+	p.word.Reset()
+	if token == "" || fillerWords[token] {
+		return
+	}
+
+	if p.matchNext(token) {
+		return
+	}
+
+	// The token does not continue the exchange; see if it starts a new one
+	p.reset()
+	if p.matchNext(token) {
+		return
+	}
+
+	// Outside an exchange, callsigns tell us who is transmitting
+	if parsed, err := callsign.Parse(token); err == nil && parsed.Call != p.myCall {
+		p.lastCall = parsed.Call
+	}
+}
+
+// matchNext tries token against the next expected field
+func (p *Parser) matchNext(token string) bool {
+	kind := p.contest.Fields[p.field]
+	value, ok := matchField(kind, token)
+	if !ok {
+		return false
+	}
+	// A bare callsign only starts an exchange when the call is its first field
+	if kind != FieldCall && p.field == 0 && isCallsign(token) {
+		return false
+	}
+
+	if p.field == 0 {
+		p.fieldStart = p.wordStart
+	}
+	if kind == FieldCall {
+		p.call = value
+	} else {
+		p.values = append(p.values, value)
+	}
+	p.field++
+
+	if p.field == len(p.contest.Fields) {
+		p.emit()
+		p.reset()
+	}
+	return true
+}
+
+// emit reports the matched exchange, once per station
+func (p *Parser) emit() {
+	call := p.call
+	if !p.contest.hasCall() {
+		call = p.lastCall
+	}
+	if call == "" || call == p.myCall || call == p.logged {
+		return
+	}
+	p.logged = call
+	p.lastCall = call
+
+	if p.callback != nil {
+		p.callback(QSO{
+			Time:     p.fieldStart,
+			Call:     call,
+			Exchange: p.values,
+		})
+	}
+}
+
+// reset discards any partially matched exchange
+func (p *Parser) reset() {
+	p.field = 0
+	p.values = nil
+	p.call = ""
+}
+
+// isCallsign reports whether a token parses as a callsign
+func isCallsign(token string) bool {
+	_, err := callsign.Parse(token)
+	return err == nil
+}
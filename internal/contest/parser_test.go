@@ -0,0 +1,123 @@
+package contest
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw/cwtest"
+)
+
+// parse runs text through a parser for the named contest and returns the exchanges
+func parse(t *testing.T, name, myCall, text string) []QSO {
+	t.Helper()
+	c, err := Lookup(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var qsos []QSO
+	parser := NewParser(c, myCall)
+	parser.SetCallback(func(qso QSO) {
+		qsos = append(qsos, qso)
+	})
+	cwtest.FeedText(parser.HandleOutput, text, time.Date(2024, 11, 30, 0, 1, 0, 0, time.UTC))
+	parser.Flush()
+	return qsos
+}
+
+func TestParser_Exchanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		contest  string
+		text     string
+		wantCall string
+		wantExch []string
+	}{
+		{
+			name:     "cqww search and pounce",
+			contest:  CQWW,
+			text:     "CQ TEST DL1ABC DL1ABC K1ABC 5NN 14",
+			wantCall: "DL1ABC",
+			wantExch: []string{"599", "14"},
+		},
+		{
+			name:     "cqww running with cut zero",
+			contest:  CQWW,
+			text:     "W1AW W1AW TU 5NN T5",
+			wantCall: "W1AW",
+			wantExch: []string{"599", "5"},
+		},
+		{
+			name:     "arrl dx power",
+			contest:  ARRLDX,
+			text:     "CQ TEST G4ABC K1ABC 5NN KW",
+			wantCall: "G4ABC",
+			wantExch: []string{"599", "KW"},
+		},
+		{
+			name:     "sweepstakes",
+			contest:  ARRLSS,
+			text:     "NR 123 A W1AW 68 CT",
+			wantCall: "W1AW",
+			wantExch: []string{"123", "A", "68", "CT"},
+		},
+		{
+			name:     "cwt member",
+			contest:  CWT,
+			text:     "CQ CWT N1MM N1MM K1ABC BOB 1TT",
+			wantCall: "N1MM",
+			wantExch: []string{"BOB", "100"},
+		},
+		{
+			name:     "cwt non-member",
+			contest:  CWT,
+			text:     "W1AW TU JOHN MA",
+			wantCall: "W1AW",
+			wantExch: []string{"JOHN", "MA"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qsos := parse(t, tt.contest, "K1ABC", tt.text)
+			if len(qsos) != 1 {
+				t.Fatalf("received %d exchanges, want 1: %+v", len(qsos), qsos)
+			}
+			if qsos[0].Call != tt.wantCall {
+				t.Errorf("Call = %q, want %q", qsos[0].Call, tt.wantCall)
+			}
+			if !slices.Equal(qsos[0].Exchange, tt.wantExch) {
+				t.Errorf("Exchange = %v, want %v", qsos[0].Exchange, tt.wantExch)
+			}
+		})
+	}
+}
+
+func TestParser_RepeatedExchangeLoggedOnce(t *testing.T) {
+	qsos := parse(t, CQWW, "K1ABC", "DL1ABC 5NN 14 5NN 14 F5XYZ 5NN 14")
+	if len(qsos) != 2 {
+		t.Fatalf("received %d exchanges, want 2: %+v", len(qsos), qsos)
+	}
+	if qsos[0].Call != "DL1ABC" || qsos[1].Call != "F5XYZ" {
+		t.Errorf("calls = %s, %s; want DL1ABC, F5XYZ", qsos[0].Call, qsos[1].Call)
+	}
+}
+
+func TestParser_NoStationHeard(t *testing.T) {
+	if qsos := parse(t, CQWW, "K1ABC", "5NN 14"); len(qsos) != 0 {
+		t.Errorf("exchange without a station should be dropped, got %+v", qsos)
+	}
+}
+
+func TestParser_ExchangeTimestamp(t *testing.T) {
+	qsos := parse(t, CQWW, "", "W1AW 5NN 5")
+	if len(qsos) != 1 {
+		t.Fatalf("received %d exchanges, want 1", len(qsos))
+	}
+	// The exchange starts at character index 5
+	want := time.Date(2024, 11, 30, 0, 1, 0, 500*int(time.Millisecond), time.UTC)
+	if !qsos[0].Time.Equal(want) {
+		t.Errorf("Time = %v, want %v", qsos[0].Time, want)
+	}
+}
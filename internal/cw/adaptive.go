@@ -54,6 +54,8 @@ var CommonPatterns = []MorsePattern{
 	{Text: "73", Elements: []bool{true, true, false, false, false, false, false, false, true, true}, Breaks: []int{4}, Priority: 9},
 	// 5NN = ..... -. -. (5=dit dit dit dit dit, N=dah dit, N=dah dit)
 	{Text: "5NN", Elements: []bool{false, false, false, false, false, true, false, true, false}, Breaks: []int{4, 6}, Priority: 9},
+	// TEST = - . ... - (T=dah, E=dit, S=dit dit dit, T=dah) - contest CQ
+	{Text: "TEST", Elements: []bool{true, false, false, false, false, true}, Breaks: []int{0, 1, 4}, Priority: 8},
 	// 599 = ..... ----. ----.  (5=....., 9=----.)
 	{Text: "599", Elements: []bool{false, false, false, false, false, true, true, true, true, false, true, true, true, true, false}, Breaks: []int{4, 9}, Priority: 8},
 
@@ -119,6 +121,10 @@ type AdaptiveConfig struct {
 	AdjustmentRate float64
 	// MinMatchesForAdjust is how many matches before adjusting (from config: adaptive_min_matches)
 	MinMatchesForAdjust int
+	// SCP is the super-check-partial callsign database (nil disables callsign correction)
+	SCP *SCPDatabase
+	// SCPMaxDistance is the maximum element edit distance for a callsign candidate (from config: scp_max_distance)
+	SCPMaxDistance int
 }
 
 // CorrectedOutput represents pattern-corrected decoded output
@@ -133,6 +139,8 @@ type CorrectedOutput struct {
 	Confidence float64
 	// TimingAdjusted is true if timing was adjusted
 	TimingAdjusted bool
+	// Candidates are the nearest known callsigns (callsign corrections only)
+	Candidates []SCPMatch
 }
 
 // CorrectedCallback is called when pattern correction occurs
@@ -149,6 +157,9 @@ func NewAdaptiveDecoder(decoder *Decoder, config AdaptiveConfig) *AdaptiveDecode
 	if config.MinMatchesForAdjust <= 0 {
 		config.MinMatchesForAdjust = MinMatchesForAdjustment
 	}
+	if config.SCPMaxDistance <= 0 {
+		config.SCPMaxDistance = DefaultSCPMaxDistance
+	}
 
 	return &AdaptiveDecoder{
 		decoder:        decoder,
@@ -190,15 +201,15 @@ func (a *AdaptiveDecoder) RecordElement(isDah bool, duration, gapAfter time.Dura
 	if isWordEnd || isCharEnd {
 		a.checkPatterns()
 	}
-}
 
-// checkPatterns looks for known patterns in the element buffer
-func (a *AdaptiveDecoder) checkPatterns() {
-	if len(a.elementBuffer) < 2 {
-		return
+	// Callsigns are only complete once the word has ended
+	if isWordEnd && a.config.SCP != nil {
+		a.checkCallsign()
 	}
+}
 
-	// Find the start of the current "word" (after last word boundary)
+// currentWord returns the elements since the last word boundary
+func (a *AdaptiveDecoder) currentWord() []Element {
 	startIdx := 0
 	for i := len(a.elementBuffer) - 2; i >= 0; i-- {
 		if a.elementBuffer[i].IsWordEnd {
@@ -206,9 +217,17 @@ func (a *AdaptiveDecoder) checkPatterns() {
 			break
 		}
 	}
+	return a.elementBuffer[startIdx:]
+}
+
+// checkPatterns looks for known patterns in the element buffer
+func (a *AdaptiveDecoder) checkPatterns() {
+	if len(a.elementBuffer) < 2 {
+		return
+	}
 
 	// Extract elements for current word/phrase
-	elements := a.elementBuffer[startIdx:]
+	elements := a.currentWord()
 	if len(elements) < 2 {
 		return
 	}
@@ -220,6 +239,58 @@ func (a *AdaptiveDecoder) checkPatterns() {
 	}
 }
 
+// checkCallsign looks up a callsign-shaped word in the super-check-partial database
+// and offers the nearest known call as a correction.
+func (a *AdaptiveDecoder) checkCallsign() {
+	elements := a.currentWord()
+	original := a.decodeElements(elements)
+	if !IsCallsignShaped(original) {
+		return
+	}
+
+	candidates := a.config.SCP.Nearest(ElementString(elements), a.config.SCPMaxDistance)
+	if len(candidates) == 0 || candidates[0].Confidence < a.config.MinConfidence {
+		return
+	}
+	best := candidates[0]
+
+	pattern, ok := PatternFromText(best.Call)
+	if !ok {
+		return
+	}
+
+	output := CorrectedOutput{
+		Original:   original,
+		Corrected:  best.Call,
+		Pattern:    &pattern,
+		Confidence: best.Confidence,
+		Candidates: candidates,
+	}
+
+	// Gaps can only inform the boundary when every dit and dah lines up with the call
+	if elementsMatch(pattern.Elements, elements) {
+		suggested := a.calculateSuggestedBoundary(&pattern, elements)
+		output.TimingAdjusted = a.recordMatch(best.Call, suggested)
+	}
+
+	if a.correctedCallback != nil {
+		a.correctedCallback(output)
+	}
+}
+
+// elementsMatch reports whether the observed dit/dah sequence equals the expected one
+func elementsMatch(expected []bool, elements []Element) bool {
+	if len(expected) != len(elements) {
+		return false
+	}
+	for i, isDah := range expected {
+		if elements[i].IsDah != isDah {
+			return false
+		}
+	}
+	return true
+}
+
 // findBestMatch finds the best matching pattern for the given elements
 func (a *AdaptiveDecoder) findBestMatch(elements []Element) *PatternMatch {
 	var bestMatch *PatternMatch
@@ -305,10 +376,10 @@ func (a *AdaptiveDecoder) calculateSuggestedBoundary(pattern *MorsePattern, elem
 
 	// Collect intra-character gaps and inter-character gaps
 	var intraGaps, interGaps []float64
-	ditDuration := a.decoder.ditDurationMs
+	ditDuration := a.decoder.spacingDit()
 
 	for i := 0; i < len(pattern.Elements)-1 && i < len(elements)-1; i++ {
-		gapMs := float64(elements[i].GapAfter.Milliseconds())
+		gapMs := ToMs(elements[i].GapAfter)
 		gapRatio := gapMs / ditDuration
 
 		isBreak := false
@@ -355,10 +426,6 @@ func (a *AdaptiveDecoder) calculateSuggestedBoundary(pattern *MorsePattern, elem
 
 // handlePatternMatch processes a successful pattern match
 func (a *AdaptiveDecoder) handlePatternMatch(match *PatternMatch, elements []Element) {
-	// Increment match counter
-	a.patternMatches[match.Pattern.Text]++
-	matchCount := a.patternMatches[match.Pattern.Text]
-
 	// Build original decoded string from elements
 	original := a.decodeElements(elements[:len(match.Pattern.Elements)])
 
@@ -371,18 +438,7 @@ func (a *AdaptiveDecoder) handlePatternMatch(match *PatternMatch, elements []Ele
 		TimingAdjusted: false,
 	}
 
-	// Adjust timing if we have enough matches and a valid suggestion
-	if matchCount >= a.config.MinMatchesForAdjust && match.SuggestedInterCharBoundary > 0 {
-		currentBoundary := a.decoder.config.InterCharBoundary
-		newBoundary := currentBoundary*(1-a.config.AdjustmentRate) +
-			match.SuggestedInterCharBoundary*a.config.AdjustmentRate
-
-		// Only adjust if it's a meaningful change
-		if abs(newBoundary-currentBoundary) > 0.05 {
-			a.decoder.config.InterCharBoundary = newBoundary
-			output.TimingAdjusted = true
-		}
-	}
+	output.TimingAdjusted = a.recordMatch(match.Pattern.Text, match.SuggestedInterCharBoundary)
 
 	// Call the corrected callback
 	if a.correctedCallback != nil {
@@ -390,6 +446,30 @@ func (a *AdaptiveDecoder) handlePatternMatch(match *PatternMatch, elements []Ele
 	}
 }
 
+// recordMatch counts a match for text and, once enough matches have been seen,
+// moves the decoder's inter-character boundary toward the suggested value.
+// Returns true if the timing was adjusted.
+func (a *AdaptiveDecoder) recordMatch(text string, suggestedBoundary float64) bool {
+	a.patternMatches[text]++
+	matchCount := a.patternMatches[text]
+
+	// Adjust timing if we have enough matches and a valid suggestion
+	if matchCount < a.config.MinMatchesForAdjust || suggestedBoundary <= 0 {
+		return false
+	}
+
+	currentBoundary := a.decoder.config.InterCharBoundary
+	newBoundary := currentBoundary*(1-a.config.AdjustmentRate) +
+		suggestedBoundary*a.config.AdjustmentRate
+
+	// Only adjust if it's a meaningful change
+	if abs(newBoundary-currentBoundary) <= 0.05 {
+		return false
+	}
+	a.decoder.config.InterCharBoundary = newBoundary
+	return true
+}
+
 // decodeElements converts elements to decoded text using current decoder state
 func (a *AdaptiveDecoder) decodeElements(elements []Element) string {
 	var result strings.Builder
@@ -0,0 +1,363 @@
+// internal/cw/alphabet.go
+package cw
+
+import "errors"
+
+// Alphabet selects the character table the decoder reads the Morse tree with
+type Alphabet string
+
+const (
+	// AlphabetLatin is the ITU table of MorseTree (default)
+	AlphabetLatin Alphabet = "latin"
+	// AlphabetExtended adds the German, Scandinavian and Spanish letters Ä, Ö, Ü, Å and Ñ to the ITU table
+	AlphabetExtended Alphabet = "extended"
+	// AlphabetCyrillic is the Russian alphabet with ITU figures and punctuation
+	AlphabetCyrillic Alphabet = "cyrillic"
+	// AlphabetGreek is the Greek alphabet with ITU figures and punctuation
+	AlphabetGreek Alphabet = "greek"
+	// AlphabetHebrew is the Hebrew alphabet with ITU figures and punctuation
+	AlphabetHebrew Alphabet = "hebrew"
+	// AlphabetArabic is the Arabic alphabet with ITU figures and punctuation
+	AlphabetArabic Alphabet = "arabic"
+	// AlphabetWabun starts in the ITU table and switches to Japanese kana on DO and back on SN
+	AlphabetWabun Alphabet = "wabun"
+)
+
+// Wabun switching prosigns. They change the table and are not emitted.
+const (
+	// WabunStartCode is DO, which switches from the ITU table to kana
+	WabunStartCode = "-..---"
+	// WabunEndCode is SN, which switches from kana back to the ITU table
+	WabunEndCode = "...-."
+)
+
+// ErrInvalidAlphabet indicates an unknown alphabet
+var ErrInvalidAlphabet = errors.New("alphabet must be latin, extended, cyrillic, greek, hebrew, arabic or wabun")
+
+// extendedLetters are the accented Latin letters with codes of their own
+var extendedLetters = map[string]rune{
+	".-.-":  'Ä',
+	"---.":  'Ö',
+	"..--":  'Ü',
+	".--.-": 'Å',
+	"--.--": 'Ñ',
+}
+
+// cyrillicLetters is the Russian Morse alphabet
+var cyrillicLetters = map[string]rune{
+	".-":    'А',
+	"-...":  'Б',
+	".--":   'В',
+	"--.":   'Г',
+	"-..":   'Д',
+	".":     'Е',
+	"...-":  'Ж',
+	"--..":  'З',
+	"..":    'И',
+	".---":  'Й',
+	"-.-":   'К',
+	".-..":  'Л',
+	"--":    'М',
+	"-.":    'Н',
+	"---":   'О',
+	".--.":  'П',
+	".-.":   'Р',
+	"...":   'С',
+	"-":     'Т',
+	"..-":   'У',
+	"..-.":  'Ф',
+	"....":  'Х',
+	"-.-.":  'Ц',
+	"---.":  'Ч',
+	"----":  'Ш',
+	"--.-":  'Щ',
+	"--.--": 'Ъ',
+	"-.--":  'Ы',
+	"-..-":  'Ь',
+	"..-..": 'Э',
+	"..--":  'Ю',
+	".-.-":  'Я',
+}
+
+// greekLetters is the Greek Morse alphabet
+var greekLetters = map[string]rune{
+	".-":   'Α',
+	"-...": 'Β',
+	"--.":  'Γ',
+	"-..":  'Δ',
+	".":    'Ε',
+	"--..": 'Ζ',
+	"....": 'Η',
+	"-.-.": 'Θ',
+	"..":   'Ι',
+	"-.-":  'Κ',
+	".-..": 'Λ',
+	"--":   'Μ',
+	"-.":   'Ν',
+	"-..-": 'Ξ',
+	"---":  'Ο',
+	".--.": 'Π',
+	".-.":  'Ρ',
+	"...":  'Σ',
+	"-":    'Τ',
+	"-.--": 'Υ',
+	"..-.": 'Φ',
+	"----": 'Χ',
+	"--.-": 'Ψ',
+	".--":  'Ω',
+}
+
+// hebrewLetters is the Hebrew Morse alphabet
+var hebrewLetters = map[string]rune{
+	".-":   'א',
+	"-...": 'ב',
+	"--.":  'ג',
+	"-..":  'ד',
+	"---":  'ה',
+	".":    'ו',
+	"--..": 'ז',
+	"....": 'ח',
+	"..-":  'ט',
+	"..":   'י',
+	"-.-":  'כ',
+	".-..": 'ל',
+	"--":   'מ',
+	"-.":   'נ',
+	"-.-.": 'ס',
+	".---": 'ע',
+	".--.": 'פ',
+	".--":  'צ',
+	"--.-": 'ק',
+	".-.":  'ר',
+	"...":  'ש',
+	"-":    'ת',
+}
+
+// arabicLetters is the Arabic Morse alphabet
+var arabicLetters = map[string]rune{
+	".-":    'ا',
+	"-...":  'ب',
+	"-":     'ت',
+	"-.-.":  'ث',
+	".---":  'ج',
+	"....":  'ح',
+	"---":   'خ',
+	"-..":   'د',
+	"--..":  'ذ',
+	".-.":   'ر',
+	"---.":  'ز',
+	"...":   'س',
+	"----":  'ش',
+	"-..-":  'ص',
+	"...-":  'ض',
+	"..-":   'ط',
+	"-.--":  'ظ',
+	".-.-":  'ع',
+	"--.":   'غ',
+	"..-.":  'ف',
+	"--.-":  'ق',
+	"-.-":   'ك',
+	".-..":  'ل',
+	"--":    'م',
+	"-.":    'ن',
+	"..-..": 'ه',
+	".--":   'و',
+	"..":    'ي',
+	".":     'ء',
+}
+
+// kanaLetters is the Wabun code for katakana and Japanese punctuation.
+// Several kana reuse the codes of ITU punctuation and prosigns, so the
+// kana table keeps only the ITU figures.
+var kanaLetters = map[string]rune{
+	"--.--":  'ア',
+	".-":     'イ',
+	"..-":    'ウ',
+	"-.---":  'エ',
+	".-...":  'オ',
+	".-..":   'カ',
+	"-.-..":  'キ',
+	"...-":   'ク',
+	"-.--":   'ケ',
+	"----":   'コ',
+	"-.-.-":  'サ',
+	"--.-.":  'シ',
+	"---.-":  'ス',
+	".---.":  'セ',
+	"---.":   'ソ',
+	"-.":     'タ',
+	"..-.":   'チ',
+	".--.":   'ツ',
+	".-.--":  'テ',
+	"..-..":  'ト',
+	".-.":    'ナ',
+	"-.-.":   'ニ',
+	"....":   'ヌ',
+	"--.-":   'ネ',
+	"..--":   'ノ',
+	"-...":   'ハ',
+	"--..-":  'ヒ',
+	"--..":   'フ',
+	".":      'ヘ',
+	"-..":    'ホ',
+	"-..-":   'マ',
+	"..-.-":  'ミ',
+	"-":      'ム',
+	"-...-":  'メ',
+	"-..-.":  'モ',
+	".--":    'ヤ',
+	"-..--":  'ユ',
+	"--":     'ヨ',
+	"...":    'ラ',
+	"--.":    'リ',
+	"-.--.":  'ル',
+	"---":    'レ',
+	".-.-":   'ロ',
+	"-.-":    'ワ',
+	".-..-":  'ヰ',
+	".--..":  'ヱ',
+	".---":   'ヲ',
+	".-.-.":  'ン',
+	"..":     '゛',
+	"..--.":  '゜',
+	".--.-":  'ー',
+	".-.-.-": '、',
+	".-.-..": '。',
+	"-.--.-": '（',
+	".-..-.": '）',
+}
+
+// charTable is a character table for the Morse tree, with the characters no longer
+// code extends marked as leaves for speculative emission
+type charTable struct {
+	chars  [len(MorseTree)]rune
+	leaves [len(MorseTree)]bool
+}
+
+var (
+	latinTable    = newCharTable(MorseTree, nil)
+	extendedTable = newCharTable(withLetters(MorseTree, extendedLetters), nil)
+	cyrillicTable = newCharTable(withLetters(figuresAndPunctuation(), cyrillicLetters), nil)
+	greekTable    = newCharTable(withLetters(figuresAndPunctuation(), greekLetters), nil)
+	hebrewTable   = newCharTable(withLetters(figuresAndPunctuation(), hebrewLetters), nil)
+	arabicTable   = newCharTable(withLetters(figuresAndPunctuation(), arabicLetters), nil)
+
+	wabunStartIndex, _ = codeIndex(WabunStartCode)
+	wabunEndIndex, _   = codeIndex(WabunEndCode)
+	wabunSwitches      = []string{WabunStartCode, WabunEndCode}
+	wabunLatinTable    = newCharTable(MorseTree, wabunSwitches)
+	kanaTable          = newCharTable(withLetters(figures(), kanaLetters), wabunSwitches)
+)
+
+// Valid reports whether a names a supported alphabet
+func (a Alphabet) Valid() bool {
+	return a != "" && a.table() != nil
+}
+
+// table returns the character table the alphabet starts in, or nil if it is unknown
+func (a Alphabet) table() *charTable {
+	switch a {
+	case "", AlphabetLatin:
+		return latinTable
+	case AlphabetExtended:
+		return extendedTable
+	case AlphabetCyrillic:
+		return cyrillicTable
+	case AlphabetGreek:
+		return greekTable
+	case AlphabetHebrew:
+		return hebrewTable
+	case AlphabetArabic:
+		return arabicTable
+	case AlphabetWabun:
+		return wabunLatinTable
+	}
+	return nil
+}
+
+// DecodeCode returns the alphabet's character for a code written as dots and dashes
+// (such as "-.-."), as the decoder would emit it. Wabun codes are read as kana.
+func (a Alphabet) DecodeCode(code string) (rune, bool) {
+	table := a.table()
+	if a == AlphabetWabun {
+		table = kanaTable
+	}
+	index, ok := codeIndex(code)
+	if table == nil || !ok || table.chars[index] == 0 {
+		return 0, false
+	}
+	return table.chars[index], true
+}
+
+// newCharTable builds a table and its leaves. Reserved codes are control codes
+// that are not characters but still extend the codes they start with.
+func newCharTable(chars [len(MorseTree)]rune, reserved []string) *charTable {
+	t := &charTable{chars: chars}
+	var extended [len(MorseTree)]bool
+	for _, code := range reserved {
+		if index, ok := codeIndex(code); ok {
+			extended[index/2] = true
+		}
+	}
+	for i := len(chars) - 1; i > 1; i-- {
+		t.leaves[i] = chars[i] != 0 && !extended[i]
+		if chars[i] != 0 || extended[i] {
+			extended[i/2] = true
+		}
+	}
+	return t
+}
+
+// figures returns the ITU digits of MorseTree
+func figures() [len(MorseTree)]rune {
+	var chars [len(MorseTree)]rune
+	for i, char := range MorseTree {
+		if char >= '0' && char <= '9' {
+			chars[i] = char
+		}
+	}
+	return chars
+}
+
+// figuresAndPunctuation returns MorseTree without its letters
+func figuresAndPunctuation() [len(MorseTree)]rune {
+	chars := MorseTree
+	for i, char := range chars {
+		if char >= 'A' && char <= 'Z' {
+			chars[i] = 0
+		}
+	}
+	return chars
+}
+
+// withLetters returns chars with the letters added at their codes
+func withLetters(chars [len(MorseTree)]rune, letters map[string]rune) [len(MorseTree)]rune {
+	for code, letter := range letters {
+		if index, ok := codeIndex(code); ok {
+			chars[index] = letter
+		}
+	}
+	return chars
+}
+
+// codeIndex returns the MorseTree index of a code written as dots and dashes
+func codeIndex(code string) (int, bool) {
+	if code == "" {
+		return 0, false
+	}
+	index := 1
+	for _, c := range code {
+		switch c {
+		case '.':
+			index *= 2
+		case '-':
+			index = index*2 + 1
+		default:
+			return 0, false
+		}
+		if index >= len(MorseTree) {
+			return 0, false
+		}
+	}
+	return index, true
+}
@@ -0,0 +1,169 @@
+package cw
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+)
+
+// sendCodes sends codes written as dots and dashes at 20 WPM, with "" as a word space,
+// and ends the transmission so the last character is emitted
+func sendCodes(t *testing.T, alphabet Alphabet, codes ...string) string {
+	t.Helper()
+	cfg := validConfig()
+	cfg.InitialWPM = 20
+	cfg.AdaptiveTiming = false
+	cfg.Alphabet = alphabet
+	decoder, err := NewDecoder(cfg)
+	if err != nil {
+		t.Fatalf("NewDecoder() error = %v", err)
+	}
+	defer decoder.Stop()
+
+	var decoded strings.Builder
+	decoder.SetCallback(func(output DecodedOutput) {
+		decoded.WriteRune(output.Character)
+	})
+
+	now := time.Now()
+	gap := time.Duration(0)
+	for _, code := range codes {
+		if code == "" {
+			gap = 420 * time.Millisecond
+			continue
+		}
+		for _, element := range code {
+			if gap > 0 {
+				now = now.Add(gap)
+				decoder.HandleToneEvent(dsp.ToneEvent{ToneOn: true, Duration: gap, Timestamp: now})
+			}
+			tone := 60 * time.Millisecond
+			if element == '-' {
+				tone = 180 * time.Millisecond
+			}
+			now = now.Add(tone)
+			decoder.HandleToneEvent(dsp.ToneEvent{ToneOn: false, Duration: tone, Timestamp: now})
+			gap = 60 * time.Millisecond
+		}
+		if gap != 420*time.Millisecond {
+			gap = 180 * time.Millisecond
+		}
+	}
+	decoder.Flush()
+	return decoded.String()
+}
+
+func TestAlphabet_TablesHoldEveryLetter(t *testing.T) {
+	tables := []struct {
+		alphabet Alphabet
+		table    *charTable
+		letters  map[string]rune
+	}{
+		{AlphabetExtended, extendedTable, extendedLetters},
+		{AlphabetCyrillic, cyrillicTable, cyrillicLetters},
+		{AlphabetGreek, greekTable, greekLetters},
+		{AlphabetHebrew, hebrewTable, hebrewLetters},
+		{AlphabetArabic, arabicTable, arabicLetters},
+		{AlphabetWabun, kanaTable, kanaLetters},
+	}
+	for _, tt := range tables {
+		t.Run(string(tt.alphabet), func(t *testing.T) {
+			for code, letter := range tt.letters {
+				if got, ok := tt.alphabet.DecodeCode(code); !ok || got != letter {
+					t.Errorf("DecodeCode(%q) = %c, %v, want %c", code, got, ok, letter)
+				}
+			}
+			// Figures are shared by every table
+			if got, ok := tt.alphabet.DecodeCode("....."); !ok || got != '5' {
+				t.Errorf("DecodeCode(.....) = %c, %v, want 5", got, ok)
+			}
+		})
+	}
+}
+
+func TestAlphabet_ScriptTablesDropLatinLetters(t *testing.T) {
+	for _, table := range []*charTable{cyrillicTable, greekTable, hebrewTable, arabicTable, kanaTable} {
+		for _, char := range table.chars {
+			if char >= 'A' && char <= 'Z' {
+				t.Errorf("script table contains Latin letter %c", char)
+			}
+		}
+	}
+	// Punctuation survives where no letter takes its code
+	if got, ok := AlphabetCyrillic.DecodeCode("..--.."); !ok || got != '?' {
+		t.Errorf("cyrillic DecodeCode(..--..) = %c, %v, want ?", got, ok)
+	}
+}
+
+func TestAlphabet_WabunSwitchesAreNotCharacters(t *testing.T) {
+	for _, code := range []string{WabunStartCode, WabunEndCode} {
+		index, _ := codeIndex(code)
+		if wabunLatinTable.chars[index] != 0 || kanaTable.chars[index] != 0 {
+			t.Errorf("switch code %s is also a character", code)
+		}
+	}
+	// SN extends V, so V is never a leaf in the Wabun tables
+	index, _ := codeIndex("...-")
+	if wabunLatinTable.leaves[index] {
+		t.Error("V is a leaf although SN extends it")
+	}
+}
+
+func TestAlphabet_Valid(t *testing.T) {
+	for _, alphabet := range []Alphabet{AlphabetLatin, AlphabetExtended, AlphabetCyrillic, AlphabetGreek, AlphabetHebrew, AlphabetArabic, AlphabetWabun} {
+		if !alphabet.Valid() {
+			t.Errorf("alphabet %q should be valid", alphabet)
+		}
+	}
+	if Alphabet("").Valid() {
+		t.Error("empty alphabet should not name a valid alphabet")
+	}
+
+	cfg := validConfig()
+	cfg.Alphabet = "klingon"
+	if _, err := NewDecoder(cfg); !errors.Is(err, ErrInvalidAlphabet) {
+		t.Errorf("NewDecoder() error = %v, want ErrInvalidAlphabet", err)
+	}
+}
+
+func TestDecoder_Alphabets(t *testing.T) {
+	tests := []struct {
+		alphabet Alphabet
+		codes    []string
+		want     string
+	}{
+		{AlphabetLatin, []string{"---.", "..--"}, ""},
+		{AlphabetExtended, []string{"---.", "..--", "", "--.--", ".-"}, "ÖÜ ÑA "},
+		{AlphabetCyrillic, []string{"--", ".-.", "", ".-.-", "..---"}, "МР Я2 "},
+		{AlphabetGreek, []string{"--.-", ".--"}, "ΨΩ "},
+		{AlphabetHebrew, []string{"...", ".-..", "---", "--"}, "שלהמ "},
+		{AlphabetArabic, []string{"...", ".-..", ".-", "--"}, "سلام "},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.alphabet), func(t *testing.T) {
+			got := sendCodes(t, tt.alphabet, tt.codes...)
+			// The Latin table has no character for the accented codes, but still ends the word
+			if tt.alphabet == AlphabetLatin {
+				got = strings.TrimSpace(got)
+			}
+			if got != tt.want {
+				t.Errorf("decoded %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecoder_Wabun(t *testing.T) {
+	// "DE JA1XYZ", then DO, then "ヨロシク" (yoroshiku), then SN and "73"
+	codes := []string{
+		"-..", ".", "", ".---", ".-", ".----", "-..-", "-.--", "--..", "",
+		WabunStartCode, "", "--", ".-.-", "--.-.", "...-", "",
+		WabunEndCode, "", "--...", "...--",
+	}
+	if got, want := sendCodes(t, AlphabetWabun, codes...), "DE JA1XYZ  ヨロシク  73 "; got != want {
+		t.Errorf("decoded %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,214 @@
+// internal/cw/american.go
+package cw
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+)
+
+// Code selects the Morse code the decoder reads
+type Code string
+
+const (
+	// CodeInternational is ITU Morse, read with the Morse tree and the configured alphabet (default)
+	CodeInternational Code = "international"
+	// CodeAmerican is American (railroad) Morse, with spaced letters and long dashes
+	CodeAmerican Code = "american"
+)
+
+// ErrInvalidCode indicates an unknown Morse code
+var ErrInvalidCode = errors.New("code must be international or american")
+
+// AmericanElement is an element of American Morse as it appears in AmericanTable codes
+type AmericanElement byte
+
+const (
+	// AmericanDot is a one unit mark
+	AmericanDot AmericanElement = '.'
+	// AmericanDash is a two unit mark
+	AmericanDash AmericanElement = '-'
+	// AmericanLongDash is the four unit mark of L
+	AmericanLongDash AmericanElement = '_'
+	// AmericanLongLongDash is the five unit mark of 0
+	AmericanLongLongDash AmericanElement = '='
+	// AmericanSpace is the two unit space inside the spaced letters C, O, R, Y, Z and &
+	AmericanSpace AmericanElement = ' '
+)
+
+// American Morse timing, in units of one dot
+const (
+	// AmericanDashBoundary separates dots (1 unit) from dashes (2 units)
+	AmericanDashBoundary = 1.5
+	// AmericanLongDashBoundary separates dashes (2 units) from the long dash of L (4 units)
+	AmericanLongDashBoundary = 3.0
+	// AmericanLongLongDashBoundary separates the long dash (4 units) from the long-long dash of 0 (5 units)
+	AmericanLongLongDashBoundary = 4.5
+	// AmericanSpaceBoundary separates element spaces (1 unit) from the internal space of spaced letters (2 units)
+	AmericanSpaceBoundary = 1.5
+	// AmericanCharBoundary separates internal spaces (2 units) from character spaces (3 units)
+	AmericanCharBoundary = 2.5
+	// AmericanWordBoundary separates character spaces (3 units) from word spaces (6 units)
+	AmericanWordBoundary = 4.5
+	// AmericanDashUnits is the length of a dash, used to learn the unit from dashes
+	AmericanDashUnits = 2.0
+	// MaxAmericanCodeLength is the longest code (elements and internal spaces) kept for one character
+	MaxAmericanCodeLength = 8
+)
+
+// AmericanTable maps American Morse codes to characters
+var AmericanTable = map[string]rune{
+	".-":     'A',
+	"-...":   'B',
+	".. .":   'C',
+	"-..":    'D',
+	".":      'E',
+	".-.":    'F',
+	"--.":    'G',
+	"....":   'H',
+	"..":     'I',
+	"-.-.":   'J',
+	"-.-":    'K',
+	"_":      'L',
+	"--":     'M',
+	"-.":     'N',
+	". .":    'O',
+	".....":  'P',
+	"..-.":   'Q',
+	". ..":   'R',
+	"...":    'S',
+	"-":      'T',
+	"..-":    'U',
+	"...-":   'V',
+	".--":    'W',
+	".-..":   'X',
+	".. ..":  'Y',
+	"... .":  'Z',
+	". ...":  '&',
+	".--.":   '1',
+	"..-..":  '2',
+	"...-.":  '3',
+	"....-":  '4',
+	"---":    '5',
+	"......": '6',
+	"--..":   '7',
+	"-....":  '8',
+	"-..-":   '9',
+	"=":      '0',
+	"..--..": '.',
+	".-.-":   ',',
+	"-..-.":  '?',
+	"---.":   '!',
+}
+
+// Valid reports whether c names a supported code
+func (c Code) Valid() bool {
+	switch c {
+	case CodeInternational, CodeAmerican:
+		return true
+	}
+	return false
+}
+
+// DecodeCode returns the character the decoder emits for a code in alphabet a.
+// American Morse codes are written as in AmericanTable, others as dots and dashes.
+func (c Code) DecodeCode(a Alphabet, code string) (rune, bool) {
+	if c == CodeAmerican {
+		char, ok := AmericanTable[code]
+		return char, ok
+	}
+	return a.DecodeCode(code)
+}
+
+// classifyAmericanMark returns the element class of a mark length in units
+func classifyAmericanMark(units float64) AmericanElement {
+	switch {
+	case units > AmericanLongLongDashBoundary:
+		return AmericanLongLongDash
+	case units > AmericanLongDashBoundary:
+		return AmericanLongDash
+	case units > AmericanDashBoundary:
+		return AmericanDash
+	}
+	return AmericanDot
+}
+
+// handleAmericanToneEnd classifies a mark as an American Morse element and adds it to the character.
+func (d *Decoder) handleAmericanToneEnd(event dsp.ToneEvent) {
+	durationMs := ToMs(event.Duration)
+	element := classifyAmericanMark(durationMs / d.ditDurationMs)
+
+	// Track element for listeners; any dash counts as a dah
+	d.lastElementDuration = event.Duration
+	d.lastElementIsDah = element != AmericanDot
+	d.lastElementTime = event.Timestamp
+	d.lastToneOff = event.Timestamp
+
+	d.startFlushTimer()
+
+	// Learn the unit from dots and dashes; long dashes vary too much between operators
+	if d.config.AdaptiveTiming {
+		switch element {
+		case AmericanDot:
+			d.adaptAmericanUnit(durationMs)
+		case AmericanDash:
+			d.adaptAmericanUnit(durationMs / AmericanDashUnits)
+		}
+	}
+
+	d.inChar = true
+	d.americanCode = append(d.americanCode, byte(element))
+	if len(d.americanCode) > MaxAmericanCodeLength {
+		// Invalid sequence - reset
+		d.americanCode = d.americanCode[:0]
+		d.inChar = false
+	}
+}
+
+// handleAmericanSilenceEnd classifies the space that just ended, keeping the internal
+// space of spaced letters as part of the character.
+func (d *Decoder) handleAmericanSilenceEnd(event dsp.ToneEvent) {
+	d.flushOnSilence(event)
+
+	if !d.inChar {
+		return
+	}
+
+	durationMs := ToMs(event.Duration)
+	units := durationMs / d.ditDurationMs
+	isWordSpace := units > AmericanWordBoundary
+	isCharSpace := !isWordSpace && units > AmericanCharBoundary
+
+	switch {
+	case isCharSpace || isWordSpace:
+	case units > AmericanSpaceBoundary:
+		d.americanCode = append(d.americanCode, byte(AmericanSpace))
+	case d.config.AdaptiveTiming:
+		d.adaptAmericanUnit(durationMs)
+	}
+
+	if d.elementCallbackPtr != nil && d.lastElementTime != (time.Time{}) {
+		(*d.elementCallbackPtr)(
+			d.lastElementIsDah,
+			d.lastElementDuration,
+			event.Duration,
+			isCharSpace || isWordSpace,
+			isWordSpace,
+		)
+	}
+
+	if isCharSpace || isWordSpace {
+		d.emitCharacter(event.Timestamp)
+		if isWordSpace {
+			d.emitWordSpace(event.Timestamp)
+		}
+	}
+}
+
+// adaptAmericanUnit moves the unit estimate towards a measured unit length.
+func (d *Decoder) adaptAmericanUnit(unitMs float64) {
+	smoothing := d.config.AdaptiveSmoothing
+	d.ditDurationMs = (1-smoothing)*d.ditDurationMs + smoothing*unitMs
+	d.spacingDitMs = d.ditDurationMs
+}
@@ -0,0 +1,168 @@
+package cw
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+)
+
+// americanUnits are the element and space lengths of American Morse in units
+var americanUnits = map[AmericanElement]float64{
+	AmericanDot:          1,
+	AmericanDash:         2,
+	AmericanLongDash:     4,
+	AmericanLongLongDash: 5,
+}
+
+// decodeAmerican sends text as American Morse with a unit of unitMs (the sender's
+// timing scaled by stretch) and returns what the decoder emits
+func decodeAmerican(t *testing.T, text string, unitMs, stretch float64, adaptive bool) string {
+	t.Helper()
+	codes := make(map[rune]string, len(AmericanTable))
+	for code, char := range AmericanTable {
+		codes[char] = code
+	}
+
+	cfg := validConfig()
+	cfg.InitialWPM = int(MillisecondsPerMinute/(unitMs*DitsPerWord) + 0.5)
+	cfg.AdaptiveTiming = adaptive
+	cfg.Code = CodeAmerican
+	decoder, err := NewDecoder(cfg)
+	if err != nil {
+		t.Fatalf("NewDecoder() error = %v", err)
+	}
+	defer decoder.Stop()
+
+	var decoded strings.Builder
+	decoder.SetCallback(func(output DecodedOutput) {
+		decoded.WriteRune(output.Character)
+	})
+
+	unit := func(units float64) time.Duration {
+		return time.Duration(units * unitMs * stretch * float64(time.Millisecond))
+	}
+	now := time.Now()
+	var gap time.Duration
+	for _, char := range text {
+		if char == ' ' {
+			gap = unit(6)
+			continue
+		}
+		code, ok := codes[char]
+		if !ok {
+			t.Fatalf("no American Morse code for %q", char)
+		}
+		for _, element := range []byte(code) {
+			if AmericanElement(element) == AmericanSpace {
+				gap = unit(2)
+				continue
+			}
+			if gap > 0 {
+				now = now.Add(gap)
+				decoder.HandleToneEvent(dsp.ToneEvent{ToneOn: true, Duration: gap, Timestamp: now})
+			}
+			tone := unit(americanUnits[AmericanElement(element)])
+			now = now.Add(tone)
+			decoder.HandleToneEvent(dsp.ToneEvent{ToneOn: false, Duration: tone, Timestamp: now})
+			gap = unit(1)
+		}
+		if gap != unit(6) {
+			gap = unit(3)
+		}
+	}
+	decoder.Flush()
+	return decoded.String()
+}
+
+func TestClassifyAmericanMark(t *testing.T) {
+	tests := []struct {
+		units float64
+		want  AmericanElement
+	}{
+		{1, AmericanDot},
+		{2, AmericanDash},
+		{4, AmericanLongDash},
+		{5.5, AmericanLongLongDash},
+	}
+	for _, tt := range tests {
+		if got := classifyAmericanMark(tt.units); got != tt.want {
+			t.Errorf("classifyAmericanMark(%v) = %q, want %q", tt.units, got, tt.want)
+		}
+	}
+}
+
+func TestAmericanTable_SpacedLettersAndLongDashes(t *testing.T) {
+	want := map[string]rune{
+		".. .":  'C',
+		". .":   'O',
+		". ..":  'R',
+		".. ..": 'Y',
+		"... .": 'Z',
+		". ...": '&',
+		"_":     'L',
+		"=":     '0',
+	}
+	for code, char := range want {
+		if got := AmericanTable[code]; got != char {
+			t.Errorf("AmericanTable[%q] = %c, want %c", code, got, char)
+		}
+	}
+}
+
+func TestCode_DecodeCode(t *testing.T) {
+	tests := []struct {
+		code     Code
+		alphabet Alphabet
+		elements string
+		want     rune
+	}{
+		{CodeInternational, AlphabetLatin, ".-.", 'R'},
+		{CodeInternational, AlphabetCyrillic, ".--", 'В'},
+		{CodeAmerican, AlphabetLatin, ". ..", 'R'},
+		{CodeAmerican, AlphabetLatin, "_", 'L'},
+	}
+	for _, tt := range tests {
+		if got, ok := tt.code.DecodeCode(tt.alphabet, tt.elements); !ok || got != tt.want {
+			t.Errorf("%s DecodeCode(%s, %q) = %c, %v; want %c", tt.code, tt.alphabet, tt.elements, got, ok, tt.want)
+		}
+	}
+	if _, ok := CodeAmerican.DecodeCode(AlphabetLatin, ".-.-.-.-"); ok {
+		t.Error("DecodeCode() should reject an unknown American code")
+	}
+}
+
+func TestDecoder_American(t *testing.T) {
+	const text = "CORY LAZY 1910 OZARK"
+	if got := decodeAmerican(t, text, 60, 1, false); got != text+" " {
+		t.Errorf("decoded %q, want %q", got, text+" ")
+	}
+}
+
+func TestDecoder_AmericanAdaptsSpeed(t *testing.T) {
+	// Sent 25% slower than the decoder expects
+	const text = "EEEE EEEE ROLL CALL 20 ZOO"
+	got := decodeAmerican(t, text, 60, 1.25, true)
+	if !strings.HasSuffix(got, "ROLL CALL 20 ZOO ") {
+		t.Errorf("decoded %q, want to settle on %q", got, "ROLL CALL 20 ZOO")
+	}
+}
+
+func TestNewDecoder_InvalidCode(t *testing.T) {
+	cfg := validConfig()
+	cfg.Code = "continental"
+	if _, err := NewDecoder(cfg); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("NewDecoder() error = %v, want ErrInvalidCode", err)
+	}
+}
+
+func TestCode_Valid(t *testing.T) {
+	if !CodeInternational.Valid() || !CodeAmerican.Valid() {
+		t.Error("international and american codes should be valid")
+	}
+	if Code("").Valid() || Code("continental").Valid() {
+		t.Error("empty and unknown codes should not be valid")
+	}
+}
@@ -0,0 +1,124 @@
+// internal/cw/classifier.go
+package cw
+
+// SpaceClass is the kind of silence between two marks
+type SpaceClass int
+
+const (
+	// SpaceIntraChar separates the elements of a character (ITU: 1 dit)
+	SpaceIntraChar SpaceClass = iota
+	// SpaceInterChar separates characters (ITU: 3 dits)
+	SpaceInterChar
+	// SpaceWord separates words (ITU: 7 dits)
+	SpaceWord
+
+	// NumSpaceClasses is the number of space classes
+	NumSpaceClasses = 3
+)
+
+// String returns the space class name
+func (c SpaceClass) String() string {
+	switch c {
+	case SpaceIntraChar:
+		return "intra-char"
+	case SpaceInterChar:
+		return "inter-char"
+	case SpaceWord:
+		return "word"
+	}
+	return "unknown"
+}
+
+// MarkFeatures describes a mark to be classified. Lengths are in units of the
+// decoder's current dit estimate; context is 0 at the start of a character.
+type MarkFeatures struct {
+	// Duration is the mark length
+	Duration float64 `json:"duration"`
+	// PrevMark is the previous mark of the same character
+	PrevMark float64 `json:"prev_mark"`
+	// PrevGap is the space between the previous mark and this one
+	PrevGap float64 `json:"prev_gap"`
+}
+
+// SpaceFeatures describes a space to be classified. Lengths are in units of the
+// decoder's spacing dit estimate, except marks, which use the character dit estimate.
+type SpaceFeatures struct {
+	// Duration is the space length
+	Duration float64 `json:"duration"`
+	// PrevMark is the mark before the space
+	PrevMark float64 `json:"prev_mark"`
+	// PrevGap is the space before that mark, 0 if it started the character
+	PrevGap float64 `json:"prev_gap"`
+}
+
+// Classifier decides whether marks are dits or dahs and how spaces divide them,
+// in place of the decoder's fixed ratio boundaries. Called with the decoder locked.
+type Classifier interface {
+	// ClassifyMark reports whether a mark is a dah
+	ClassifyMark(f MarkFeatures) bool
+	// ClassifySpace returns the kind of space
+	ClassifySpace(f SpaceFeatures) SpaceClass
+}
+
+// RatioClassifier applies fixed boundaries to the normalised lengths, as the
+// decoder does in electronic key mode without a classifier
+type RatioClassifier struct {
+	DitDahBoundary    float64
+	InterCharBoundary float64
+	CharWordBoundary  float64
+}
+
+// ClassifyMark reports whether the mark is longer than DitDahBoundary dits
+func (c RatioClassifier) ClassifyMark(f MarkFeatures) bool {
+	return f.Duration > c.DitDahBoundary
+}
+
+// ClassifySpace compares the space with the inter-character and word boundaries
+func (c RatioClassifier) ClassifySpace(f SpaceFeatures) SpaceClass {
+	switch {
+	case f.Duration > c.CharWordBoundary:
+		return SpaceWord
+	case f.Duration > c.InterCharBoundary:
+		return SpaceInterChar
+	}
+	return SpaceIntraChar
+}
+
+// classifyMark reports whether a mark is a dah, using the classifier if one is configured
+func (d *Decoder) classifyMark(durationMs float64) bool {
+	if d.config.Classifier == nil {
+		return durationMs > d.dahThreshold()
+	}
+
+	f := MarkFeatures{Duration: durationMs / d.ditDurationMs}
+	if d.inChar {
+		f.PrevMark = ToMs(d.lastElementDuration) / d.ditDurationMs
+		f.PrevGap = d.lastGapMs / d.ditDurationMs
+	}
+	return d.config.Classifier.ClassifyMark(f)
+}
+
+// classifySpace returns the kind of space that just ended, using the classifier if one is configured
+func (d *Decoder) classifySpace(durationMs float64) SpaceClass {
+	// Use Farnsworth timing for spacing if configured or detected
+	spacingDitMs := d.spacingDit()
+
+	if d.config.Classifier == nil {
+		// Character boundary: silence > InterCharBoundary (configurable, default 2.0) * dit duration
+		// Word boundary: silence > CharWordBoundary (configurable, default 5.0) * dit duration
+		switch {
+		case durationMs > spacingDitMs*d.config.CharWordBoundary:
+			return SpaceWord
+		case durationMs > spacingDitMs*d.config.InterCharBoundary:
+			return SpaceInterChar
+		}
+		return SpaceIntraChar
+	}
+
+	f := SpaceFeatures{
+		Duration: durationMs / spacingDitMs,
+		PrevMark: ToMs(d.lastElementDuration) / d.ditDurationMs,
+		PrevGap:  d.lastGapMs / spacingDitMs,
+	}
+	return d.config.Classifier.ClassifySpace(f)
+}
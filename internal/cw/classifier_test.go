@@ -0,0 +1,133 @@
+package cw
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+)
+
+// recordingClassifier applies ratio rules and records the features it was given
+type recordingClassifier struct {
+	RatioClassifier
+	marks  []MarkFeatures
+	spaces []SpaceFeatures
+}
+
+func (c *recordingClassifier) ClassifyMark(f MarkFeatures) bool {
+	c.marks = append(c.marks, f)
+	return c.RatioClassifier.ClassifyMark(f)
+}
+
+func (c *recordingClassifier) ClassifySpace(f SpaceFeatures) SpaceClass {
+	c.spaces = append(c.spaces, f)
+	return c.RatioClassifier.ClassifySpace(f)
+}
+
+// dahClassifier calls every mark a dah
+type dahClassifier struct {
+	RatioClassifier
+}
+
+func (dahClassifier) ClassifyMark(MarkFeatures) bool {
+	return true
+}
+
+// decodeWith decodes text sent at 20 WPM with the given classifier
+func decodeWith(t *testing.T, classifier Classifier, text string) string {
+	t.Helper()
+	cfg := validConfig()
+	cfg.InitialWPM = 20
+	cfg.AdaptiveTiming = false
+	cfg.Classifier = classifier
+	decoder, err := NewDecoder(cfg)
+	if err != nil {
+		t.Fatalf("NewDecoder() error = %v", err)
+	}
+	defer decoder.Stop()
+
+	var mu sync.Mutex
+	var decoded []rune
+	decoder.SetCallback(func(output DecodedOutput) {
+		mu.Lock()
+		defer mu.Unlock()
+		decoded = append(decoded, output.Character)
+	})
+
+	end := sendText(decoder, text, 60, 180, 420, time.Now())
+	decoder.HandleToneEvent(dsp.ToneEvent{ToneOn: true, Duration: 420 * time.Millisecond, Timestamp: end.Add(420 * time.Millisecond)})
+
+	mu.Lock()
+	defer mu.Unlock()
+	return string(decoded)
+}
+
+func TestRatioClassifier(t *testing.T) {
+	c := RatioClassifier{DitDahBoundary: 2, InterCharBoundary: 2, CharWordBoundary: 5}
+
+	if c.ClassifyMark(MarkFeatures{Duration: 1.1}) {
+		t.Error("ClassifyMark(1.1) = dah, want dit")
+	}
+	if !c.ClassifyMark(MarkFeatures{Duration: 2.9}) {
+		t.Error("ClassifyMark(2.9) = dit, want dah")
+	}
+
+	spaces := []struct {
+		duration float64
+		want     SpaceClass
+	}{
+		{0.9, SpaceIntraChar},
+		{3.2, SpaceInterChar},
+		{7.5, SpaceWord},
+	}
+	for _, tt := range spaces {
+		if got := c.ClassifySpace(SpaceFeatures{Duration: tt.duration}); got != tt.want {
+			t.Errorf("ClassifySpace(%v) = %v, want %v", tt.duration, got, tt.want)
+		}
+	}
+}
+
+func TestDecoder_Classifier(t *testing.T) {
+	rules := RatioClassifier{DitDahBoundary: 2, InterCharBoundary: 2, CharWordBoundary: 5}
+	classifier := &recordingClassifier{RatioClassifier: rules}
+
+	if got := decodeWith(t, classifier, "AN TO"); got != "AN TO " {
+		t.Errorf("decoded %q, want %q", got, "AN TO ")
+	}
+
+	// A N T O: 2 + 2 + 1 + 3 marks, each followed by a space
+	if len(classifier.marks) != 8 || len(classifier.spaces) != 8 {
+		t.Fatalf("classified %d marks and %d spaces, want 8 and 8", len(classifier.marks), len(classifier.spaces))
+	}
+
+	near := func(got, want float64) bool { return math.Abs(got-want) < 0.01 }
+	// The dah of A follows its dit after a one dit space
+	if f := classifier.marks[1]; !near(f.Duration, 3) || !near(f.PrevMark, 1) || !near(f.PrevGap, 1) {
+		t.Errorf("A dah features = %+v, want {3 1 1}", f)
+	}
+	// N starts a new character, so it has no context
+	if f := classifier.marks[2]; f.PrevMark != 0 || f.PrevGap != 0 {
+		t.Errorf("N first mark features = %+v, want no context", f)
+	}
+	// The space after A follows its dah, which followed a one dit space
+	if f := classifier.spaces[1]; !near(f.Duration, 3) || !near(f.PrevMark, 3) || !near(f.PrevGap, 1) {
+		t.Errorf("space after A features = %+v, want {3 3 1}", f)
+	}
+	// The space after N ends the first word
+	if f := classifier.spaces[3]; !near(f.Duration, 7) {
+		t.Errorf("word space features = %+v, want duration 7", f)
+	}
+	if f := classifier.spaces[4]; f.PrevGap != 0 {
+		t.Errorf("space after T features = %+v, want no previous gap", f)
+	}
+}
+
+func TestDecoder_ClassifierReplacesBoundaries(t *testing.T) {
+	rules := RatioClassifier{DitDahBoundary: 2, InterCharBoundary: 2, CharWordBoundary: 5}
+
+	if got := decodeWith(t, dahClassifier{RatioClassifier: rules}, "EE"); got != "TT " {
+		t.Errorf("decoded %q, want %q", got, "TT ")
+	}
+}
@@ -0,0 +1,26 @@
+// Package cwtest provides helpers for testing consumers of decoded CW text.
+package cwtest
+
+import (
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+// CharacterInterval is the time between the characters FeedText sends
+const CharacterInterval = 100 * time.Millisecond
+
+// FeedText sends text to handle as decoded characters and word spaces, one every
+// CharacterInterval from start, and returns the timestamp after the last one.
+func FeedText(handle cw.DecodedCallback, text string, start time.Time) time.Time {
+	timestamp := start
+	for _, char := range text {
+		if char == ' ' {
+			handle(cw.DecodedOutput{Character: ' ', IsWordSpace: true, Timestamp: timestamp})
+		} else {
+			handle(cw.DecodedOutput{Character: char, Timestamp: timestamp})
+		}
+		timestamp = timestamp.Add(CharacterInterval)
+	}
+	return timestamp
+}
@@ -0,0 +1,32 @@
+package cwtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+func TestFeedText(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var outputs []cw.DecodedOutput
+	next := FeedText(func(output cw.DecodedOutput) {
+		outputs = append(outputs, output)
+	}, "CQ K", start)
+
+	if len(outputs) != 4 {
+		t.Fatalf("FeedText() sent %d outputs, want 4", len(outputs))
+	}
+	if outputs[1].Character != 'Q' || outputs[1].IsWordSpace {
+		t.Errorf("output 1 = %+v, want the character Q", outputs[1])
+	}
+	if !outputs[2].IsWordSpace {
+		t.Errorf("output 2 = %+v, want a word space", outputs[2])
+	}
+	if outputs[3].Timestamp != start.Add(3*CharacterInterval) {
+		t.Errorf("output 3 at %v, want %v", outputs[3].Timestamp, start.Add(3*CharacterInterval))
+	}
+	if next != start.Add(4*CharacterInterval) {
+		t.Errorf("FeedText() = %v, want %v", next, start.Add(4*CharacterInterval))
+	}
+}
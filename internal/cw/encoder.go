@@ -0,0 +1,169 @@
+// internal/cw/encoder.go
+package cw
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	// ErrUnencodable indicates a character with no Morse code
+	ErrUnencodable = errors.New("character has no Morse code")
+	// ErrInvalidProsign indicates a malformed <..> prosign in the text
+	ErrInvalidProsign = errors.New("invalid prosign")
+)
+
+// Keying is one interval of a keyed transmission: a mark (tone on) or a space
+type Keying struct {
+	// ToneOn is true for a mark, false for a space
+	ToneOn bool
+	// Duration is the length of the interval
+	Duration time.Duration
+}
+
+// EncoderConfig holds configuration for the Morse encoder
+type EncoderConfig struct {
+	// WPM is the character speed
+	WPM int
+	// FarnsworthWPM is the spacing speed (0 = same as WPM).
+	// Character and word gaps are timed at this speed's dit, matching the decoder's farnsworth_wpm.
+	FarnsworthWPM int
+}
+
+// Symbol is one unit of text to send: a character, a run-together prosign or a word space
+type Symbol struct {
+	// Text is the character, or the prosign in angle brackets ("<SK>"); empty for a word space
+	Text string
+	// Elements is the element sequence (false=dit, true=dah); nil for a word space
+	Elements []bool
+}
+
+// IsWordSpace reports whether the symbol is a word space
+func (s Symbol) IsWordSpace() bool {
+	return s.Elements == nil
+}
+
+// Encoder turns text into element timings
+type Encoder struct {
+	config    EncoderConfig
+	dit       time.Duration
+	spaceUnit time.Duration
+}
+
+// NewEncoder creates a Morse encoder
+func NewEncoder(cfg EncoderConfig) (*Encoder, error) {
+	if cfg.WPM <= 0 {
+		return nil, ErrInvalidWPM
+	}
+	if cfg.FarnsworthWPM < 0 || cfg.FarnsworthWPM > cfg.WPM {
+		return nil, ErrInvalidFarnsworthWPM
+	}
+
+	dit := DitDuration(cfg.WPM)
+	spaceUnit := dit
+	if cfg.FarnsworthWPM > 0 {
+		spaceUnit = DitDuration(cfg.FarnsworthWPM)
+	}
+	return &Encoder{config: cfg, dit: dit, spaceUnit: spaceUnit}, nil
+}
+
+// DitDuration returns the dit length at the character speed
+func (e *Encoder) DitDuration() time.Duration {
+	return e.dit
+}
+
+// SpaceUnit returns the unit used for character and word gaps (the Farnsworth dit when set)
+func (e *Encoder) SpaceUnit() time.Duration {
+	return e.spaceUnit
+}
+
+// Encode converts text to keying intervals, starting and ending with a mark.
+// Prosigns are written as in ParseText.
+func (e *Encoder) Encode(text string) ([]Keying, error) {
+	symbols, err := ParseText(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var keying []Keying
+	wordBreak := false
+	for _, symbol := range symbols {
+		if symbol.IsWordSpace() {
+			wordBreak = true
+			continue
+		}
+		if len(keying) > 0 {
+			gap := e.spaceUnit * InterCharSpaceRatio
+			if wordBreak {
+				gap = e.spaceUnit * WordSpaceRatio
+			}
+			keying = append(keying, Keying{ToneOn: false, Duration: gap})
+		}
+		wordBreak = false
+
+		for i, isDah := range symbol.Elements {
+			if i > 0 {
+				keying = append(keying, Keying{ToneOn: false, Duration: e.dit * IntraCharSpaceRatio})
+			}
+			mark := e.dit
+			if isDah {
+				mark = e.dit * DahDitRatio
+			}
+			keying = append(keying, Keying{ToneOn: true, Duration: mark})
+		}
+	}
+	return keying, nil
+}
+
+// ParseText splits text into symbols. Letters are case-insensitive and runs of
+// whitespace become a single word space, with none at the start or end.
+// Prosigns are written in angle brackets and sent without character gaps,
+// e.g. "<SK>" or "<BT>"; prosign runes such as '+' for AR are also accepted.
+func ParseText(text string) ([]Symbol, error) {
+	var symbols []Symbol
+	wordBreak := false
+	runes := []rune(strings.ToUpper(text))
+	for i := 0; i < len(runes); i++ {
+		char := runes[i]
+		if unicode.IsSpace(char) {
+			wordBreak = len(symbols) > 0
+			continue
+		}
+		if wordBreak {
+			symbols = append(symbols, Symbol{})
+			wordBreak = false
+		}
+
+		if char != '<' {
+			code, ok := EncodeCharacter(char)
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", ErrUnencodable, char)
+			}
+			symbols = append(symbols, Symbol{Text: string(char), Elements: code})
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && runes[end] != '>' {
+			end++
+		}
+		if end >= len(runes) || end == i+1 {
+			return nil, fmt.Errorf("%w: unterminated at position %d", ErrInvalidProsign, i)
+		}
+		letters := string(runes[i+1 : end])
+		var elements []bool
+		for _, letter := range letters {
+			code, ok := EncodeCharacter(letter)
+			if !ok {
+				return nil, fmt.Errorf("%w: %q in <%s>", ErrUnencodable, letter, letters)
+			}
+			elements = append(elements, code...)
+		}
+		symbols = append(symbols, Symbol{Text: "<" + letters + ">", Elements: elements})
+		i = end
+	}
+	return symbols, nil
+}
@@ -0,0 +1,154 @@
+package cw
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// keyingUnits sums keying durations in dits
+func keyingUnits(keying []Keying, dit time.Duration) float64 {
+	var total time.Duration
+	for _, k := range keying {
+		total += k.Duration
+	}
+	return float64(total) / float64(dit)
+}
+
+func TestNewEncoder_InvalidConfig(t *testing.T) {
+	if _, err := NewEncoder(EncoderConfig{WPM: 0}); !errors.Is(err, ErrInvalidWPM) {
+		t.Errorf("WPM 0 error = %v, want ErrInvalidWPM", err)
+	}
+	if _, err := NewEncoder(EncoderConfig{WPM: 10, FarnsworthWPM: 20}); !errors.Is(err, ErrInvalidFarnsworthWPM) {
+		t.Errorf("Farnsworth above WPM error = %v, want ErrInvalidFarnsworthWPM", err)
+	}
+}
+
+func TestEncoder_PARIS(t *testing.T) {
+	encoder, err := NewEncoder(EncoderConfig{WPM: 20})
+	if err != nil {
+		t.Fatalf("NewEncoder() error = %v", err)
+	}
+	if got := encoder.DitDuration(); got != 60*time.Millisecond {
+		t.Errorf("DitDuration() = %v, want 60ms", got)
+	}
+
+	keying, err := encoder.Encode("paris")
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	// PARIS is 50 units including the trailing 7-unit word space, which is not emitted
+	if got := keyingUnits(keying, encoder.DitDuration()); got != 43 {
+		t.Errorf("PARIS length = %v units, want 43", got)
+	}
+	if !keying[0].ToneOn || !keying[len(keying)-1].ToneOn {
+		t.Error("keying should start and end with a mark")
+	}
+}
+
+func TestEncoder_Gaps(t *testing.T) {
+	encoder, _ := NewEncoder(EncoderConfig{WPM: 20})
+	dit := encoder.DitDuration()
+
+	keying, err := encoder.Encode("E  E\tT")
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	want := []Keying{
+		{ToneOn: true, Duration: dit},
+		{ToneOn: false, Duration: 7 * dit},
+		{ToneOn: true, Duration: dit},
+		{ToneOn: false, Duration: 7 * dit},
+		{ToneOn: true, Duration: 3 * dit},
+	}
+	if len(keying) != len(want) {
+		t.Fatalf("Encode() = %+v, want %+v", keying, want)
+	}
+	for i := range want {
+		if keying[i] != want[i] {
+			t.Errorf("keying[%d] = %+v, want %+v", i, keying[i], want[i])
+		}
+	}
+}
+
+func TestEncoder_Prosigns(t *testing.T) {
+	encoder, _ := NewEncoder(EncoderConfig{WPM: 20})
+	dit := encoder.DitDuration()
+
+	tests := []struct {
+		text  string
+		units float64
+	}{
+		{"<SK>", 15},   // ...-.- run together
+		{"<AR>", 13},   // .-.-.
+		{"+", 13},      // prosign rune for AR
+		{"<BT> K", 29}, // -...- then word space then -.-
+	}
+	for _, tt := range tests {
+		keying, err := encoder.Encode(tt.text)
+		if err != nil {
+			t.Errorf("Encode(%q) error = %v", tt.text, err)
+			continue
+		}
+		if got := keyingUnits(keying, dit); got != tt.units {
+			t.Errorf("Encode(%q) = %v units, want %v", tt.text, got, tt.units)
+		}
+	}
+}
+
+func TestEncoder_Farnsworth(t *testing.T) {
+	encoder, _ := NewEncoder(EncoderConfig{WPM: 20, FarnsworthWPM: 10})
+	dit := encoder.DitDuration()
+
+	keying, err := encoder.Encode("E E")
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if keying[0].Duration != dit {
+		t.Errorf("mark = %v, want %v at character speed", keying[0].Duration, dit)
+	}
+	// Word space is 7 units at the 10 WPM spacing speed
+	if want := 7 * 120 * time.Millisecond; keying[1].Duration != want {
+		t.Errorf("word space = %v, want %v", keying[1].Duration, want)
+	}
+
+	keying, _ = encoder.Encode("EE")
+	if want := 3 * 120 * time.Millisecond; keying[1].Duration != want {
+		t.Errorf("character space = %v, want %v", keying[1].Duration, want)
+	}
+}
+
+func TestEncoder_Errors(t *testing.T) {
+	encoder, _ := NewEncoder(EncoderConfig{WPM: 20})
+
+	if _, err := encoder.Encode("CQ #"); !errors.Is(err, ErrUnencodable) {
+		t.Errorf("Encode(#) error = %v, want ErrUnencodable", err)
+	}
+	for _, text := range []string{"<SK", "<>", "CQ <S#>"} {
+		if _, err := encoder.Encode(text); err == nil {
+			t.Errorf("Encode(%q) should fail", text)
+		}
+	}
+	if _, err := encoder.Encode("<SK"); !errors.Is(err, ErrInvalidProsign) {
+		t.Errorf("Encode(<SK) error = %v, want ErrInvalidProsign", err)
+	}
+}
+
+func TestParseText(t *testing.T) {
+	symbols, err := ParseText("  cq <sk>\n")
+	if err != nil {
+		t.Fatalf("ParseText() error = %v", err)
+	}
+	want := []string{"C", "Q", "", "<SK>"}
+	if len(symbols) != len(want) {
+		t.Fatalf("ParseText() = %+v, want texts %v", symbols, want)
+	}
+	for i, text := range want {
+		if symbols[i].Text != text || symbols[i].IsWordSpace() != (text == "") {
+			t.Errorf("symbols[%d] = %+v, want %q", i, symbols[i], text)
+		}
+	}
+	if len(symbols[3].Elements) != 6 {
+		t.Errorf("<SK> has %d elements, want 6", len(symbols[3].Elements))
+	}
+}
@@ -0,0 +1,138 @@
+// internal/cw/fist.go
+package cw
+
+import "errors"
+
+// KeyMode describes how the sender's key times its elements
+type KeyMode string
+
+const (
+	// KeyModeElectronic assumes keyer-timed elements at ITU ratios (default)
+	KeyModeElectronic KeyMode = "electronic"
+	// KeyModeStraight learns the sender's dit, dah and space lengths independently
+	KeyModeStraight KeyMode = "straight"
+	// KeyModeBug treats dits and their spaces as machine-timed and dahs as hand-timed
+	KeyModeBug KeyMode = "bug"
+)
+
+// Fist estimation constants
+const (
+	// MinFistDahDitRatio is the lowest dah:dit ratio the fist estimate may learn
+	MinFistDahDitRatio = 1.5
+	// MaxFistDahDitRatio is the highest dah:dit ratio the fist estimate may learn
+	MaxFistDahDitRatio = 6.0
+	// BugDitTolerance is how much longer than the learned dit a bug mark may be and still be a dit.
+	// Bug dits come from a vibrating arm, so they vary far less than the hand-sent dahs.
+	BugDitTolerance = 1.5
+	// BugDahSmoothingFactor speeds up dah adaptation in bug mode, since dahs vary from one to the next
+	BugDahSmoothingFactor = 2.0
+)
+
+// ErrInvalidKeyMode indicates an unknown key mode
+var ErrInvalidKeyMode = errors.New("key mode must be electronic, straight or bug")
+
+// FistEstimate is the learned timing of the sender's keying
+type FistEstimate struct {
+	// DitMs is the average dit mark length in milliseconds
+	DitMs float64
+	// DahMs is the average dah mark length in milliseconds
+	DahMs float64
+	// SpaceMs is the average intra-character space in milliseconds
+	SpaceMs float64
+	// DahDitRatio is the sender's dah:dit length ratio (ITU: 3.0)
+	DahDitRatio float64
+	// Weighting is the sender's mark:space ratio (ITU: 1.0; heavy fists are above 1)
+	Weighting float64
+}
+
+// Valid reports whether m names a supported key mode
+func (m KeyMode) Valid() bool {
+	switch m {
+	case KeyModeElectronic, KeyModeStraight, KeyModeBug:
+		return true
+	}
+	return false
+}
+
+// isFistMode reports whether element lengths are learned rather than assumed
+func (d *Decoder) isFistMode() bool {
+	return d.config.KeyMode == KeyModeStraight || d.config.KeyMode == KeyModeBug
+}
+
+// resetFist seeds the fist estimate with ITU timing at the current dit duration
+func (d *Decoder) resetFist() {
+	d.fistDitMs = d.ditDurationMs
+	d.fistDahMs = d.ditDurationMs * DahDitRatio
+	d.fistSpaceMs = d.ditDurationMs * IntraCharSpaceRatio
+}
+
+// dahThreshold returns the mark length above which an element is a dah
+func (d *Decoder) dahThreshold() float64 {
+	switch d.config.KeyMode {
+	case KeyModeStraight:
+		return (d.fistDitMs + d.fistDahMs) / 2
+	case KeyModeBug:
+		return min(d.fistDitMs*BugDitTolerance, (d.fistDitMs+d.fistDahMs)/2)
+	}
+	return d.ditDurationMs * d.config.DitDahBoundary
+}
+
+// adaptFistMark updates the learned dit or dah length from a classified mark
+func (d *Decoder) adaptFistMark(durationMs float64, isDah bool) {
+	smoothing := d.config.AdaptiveSmoothing
+	if isDah {
+		if d.config.KeyMode == KeyModeBug {
+			smoothing = min(smoothing*BugDahSmoothingFactor, 1)
+		}
+		d.fistDahMs = (1-smoothing)*d.fistDahMs + smoothing*durationMs
+	} else {
+		d.fistDitMs = (1-smoothing)*d.fistDitMs + smoothing*durationMs
+	}
+
+	// Keep the learned ratio plausible so one misclassified element cannot collapse the clusters
+	d.fistDahMs = max(d.fistDahMs, d.fistDitMs*MinFistDahDitRatio)
+	d.fistDahMs = min(d.fistDahMs, d.fistDitMs*MaxFistDahDitRatio)
+	d.updateFistUnit()
+}
+
+// adaptFistSpace updates the learned intra-character space.
+// In bug mode only spaces after dits are machine-timed, so spaces after dahs are ignored.
+func (d *Decoder) adaptFistSpace(gapMs float64) {
+	if d.config.KeyMode == KeyModeBug && d.lastElementIsDah {
+		return
+	}
+	smoothing := d.config.AdaptiveSmoothing
+	d.fistSpaceMs = (1-smoothing)*d.fistSpaceMs + smoothing*gapMs
+	d.updateFistUnit()
+}
+
+// updateFistUnit derives the timing unit from the learned dit and space.
+// A dit plus its space spans two units whatever the weighting, so their mean
+// is the unit that character and word gaps are measured against.
+func (d *Decoder) updateFistUnit() {
+	d.ditDurationMs = (d.fistDitMs + d.fistSpaceMs) / 2
+}
+
+// Fist returns the learned timing of the sender's keying (thread-safe).
+// In electronic mode the estimate reflects ITU ratios at the current speed.
+func (d *Decoder) Fist() FistEstimate {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.isFistMode() {
+		return FistEstimate{
+			DitMs:       d.ditDurationMs,
+			DahMs:       d.ditDurationMs * DahDitRatio,
+			SpaceMs:     d.ditDurationMs * IntraCharSpaceRatio,
+			DahDitRatio: DahDitRatio,
+			Weighting:   1,
+		}
+	}
+	return FistEstimate{
+		DitMs:       d.fistDitMs,
+		DahMs:       d.fistDahMs,
+		SpaceMs:     d.fistSpaceMs,
+		DahDitRatio: d.fistDahMs / d.fistDitMs,
+		Weighting:   d.fistDitMs / d.fistSpaceMs,
+	}
+}
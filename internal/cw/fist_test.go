@@ -0,0 +1,150 @@
+package cw
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+)
+
+// sendKeyed feeds text to the decoder with explicit mark and space lengths.
+// dahMs is called for each dah so tests can vary hand-sent dahs.
+func sendKeyed(d *Decoder, text string, ditMs int, dahMs func() int, spaceMs, charGapMs, wordGapMs int) {
+	now := time.Now()
+	gap := 0
+	for _, char := range text + " " {
+		if char == ' ' {
+			gap = wordGapMs
+			continue
+		}
+		elements, _ := EncodeCharacter(char)
+		for _, isDah := range elements {
+			if gap > 0 {
+				now = now.Add(time.Duration(gap) * time.Millisecond)
+				d.HandleToneEvent(dsp.ToneEvent{ToneOn: true, Duration: time.Duration(gap) * time.Millisecond, Timestamp: now})
+			}
+			markMs := ditMs
+			if isDah {
+				markMs = dahMs()
+			}
+			now = now.Add(time.Duration(markMs) * time.Millisecond)
+			d.HandleToneEvent(dsp.ToneEvent{ToneOn: false, Duration: time.Duration(markMs) * time.Millisecond, Timestamp: now})
+			gap = spaceMs
+		}
+		if gap != wordGapMs {
+			gap = charGapMs
+		}
+	}
+	// Trailing word gap terminates the last character
+	d.HandleToneEvent(dsp.ToneEvent{ToneOn: true, Duration: time.Duration(wordGapMs) * time.Millisecond, Timestamp: now})
+}
+
+// collectText returns a callback and a function reading the decoded text
+func collectText() (DecodedCallback, func() string) {
+	var mu sync.Mutex
+	var b strings.Builder
+	return func(output DecodedOutput) {
+			mu.Lock()
+			defer mu.Unlock()
+			b.WriteRune(output.Character)
+		}, func() string {
+			mu.Lock()
+			defer mu.Unlock()
+			return b.String()
+		}
+}
+
+func TestNewDecoder_InvalidKeyMode(t *testing.T) {
+	cfg := validConfig()
+	cfg.KeyMode = "cootie"
+
+	if _, err := NewDecoder(cfg); err != ErrInvalidKeyMode {
+		t.Errorf("NewDecoder() error = %v, want %v", err, ErrInvalidKeyMode)
+	}
+}
+
+func TestKeyMode_Valid(t *testing.T) {
+	for _, mode := range []KeyMode{KeyModeElectronic, KeyModeStraight, KeyModeBug} {
+		if !mode.Valid() {
+			t.Errorf("key mode %q should be valid", mode)
+		}
+	}
+	for _, mode := range []KeyMode{"", "cootie", "Bug"} {
+		if mode.Valid() {
+			t.Errorf("key mode %q should not be valid", mode)
+		}
+	}
+}
+
+func TestDecoder_StraightKey_LearnsFist(t *testing.T) {
+	cfg := validConfig()
+	cfg.InitialWPM = 20
+	cfg.KeyMode = KeyModeStraight
+
+	decoder, err := NewDecoder(cfg)
+	if err != nil {
+		t.Fatalf("NewDecoder() error = %v", err)
+	}
+	defer decoder.Stop()
+	callback, text := collectText()
+	decoder.SetCallback(callback)
+
+	// Heavy fist: long 4.3:1 dahs and 1.55:1 mark:space weighting
+	sendKeyed(decoder, strings.Repeat("PARIS ", 8), 70, func() int { return 300 }, 45, 200, 500)
+
+	fist := decoder.Fist()
+	if math.Abs(fist.DahDitRatio-300.0/70) > 0.3 {
+		t.Errorf("DahDitRatio = %.2f, want ~%.2f", fist.DahDitRatio, 300.0/70)
+	}
+	if math.Abs(fist.Weighting-70.0/45) > 0.2 {
+		t.Errorf("Weighting = %.2f, want ~%.2f", fist.Weighting, 70.0/45)
+	}
+	if got := text(); !strings.HasSuffix(got, "PARIS PARIS ") {
+		t.Errorf("decoded %q, want it to end with %q", got, "PARIS PARIS ")
+	}
+}
+
+func TestDecoder_BugMode_VariableDahs(t *testing.T) {
+	cfg := validConfig()
+	cfg.InitialWPM = 24
+	cfg.KeyMode = KeyModeBug
+
+	decoder, err := NewDecoder(cfg)
+	if err != nil {
+		t.Fatalf("NewDecoder() error = %v", err)
+	}
+	defer decoder.Stop()
+	callback, text := collectText()
+	decoder.SetCallback(callback)
+
+	// Machine dits at 50ms; hand-sent dahs swing between 2.5x and 5x
+	dahs := []int{125, 250, 175, 225, 150}
+	next := 0
+	dah := func() int {
+		next++
+		return dahs[next%len(dahs)]
+	}
+	sendKeyed(decoder, "CQ CQ DE K1ABC K1ABC K", 50, dah, 50, 160, 400)
+
+	if got, want := text(), "CQ CQ DE K1ABC K1ABC K "; got != want {
+		t.Errorf("decoded %q, want %q", got, want)
+	}
+	if fist := decoder.Fist(); math.Abs(fist.DitMs-50) > 2 {
+		t.Errorf("DitMs = %.1f, want ~50 (dits are machine-timed)", fist.DitMs)
+	}
+}
+
+func TestDecoder_Fist_Electronic(t *testing.T) {
+	decoder, err := NewDecoder(validConfig())
+	if err != nil {
+		t.Fatalf("NewDecoder() error = %v", err)
+	}
+
+	fist := decoder.Fist()
+	if fist.DahDitRatio != DahDitRatio || fist.Weighting != 1 {
+		t.Errorf("electronic Fist() = %+v, want ITU ratios", fist)
+	}
+}
@@ -4,6 +4,7 @@ package cw
 
 import (
 	"errors"
+	"slices"
 	"sync"
 	"time"
 
@@ -43,6 +44,21 @@ const (
 	AdaptiveWeightOld = 0.9
 )
 
+// Farnsworth detection constants
+const (
+	// FarnsworthDetectRatio is the spacing-unit to dit ratio at which gaps switch to Farnsworth classification
+	FarnsworthDetectRatio = 1.25
+	// FarnsworthReleaseRatio is the ratio below which automatic Farnsworth classification switches off (hysteresis)
+	FarnsworthReleaseRatio = 1.1
+	// SpacingHistorySize is the number of recent inter-character/word gaps used to estimate spacing speed
+	SpacingHistorySize = 16
+	// MinSpacingSamples is the number of gaps needed before spacing speed is estimated
+	MinSpacingSamples = 4
+	// SpacingPercentile selects the inter-character gap from the gap history.
+	// Character gaps outnumber word gaps in running text, so the lower quartile is a character gap.
+	SpacingPercentile = 0.25
+)
+
 // Compile-time assertions to ensure ITU reference constants are defined correctly
 // These constants are used as reference values for config defaults
 var (
@@ -70,71 +86,203 @@ var (
 // Left branch = dit, Right branch = dah.
 // Index 0 is root (unused), 1 is after first element, etc.
 // Tree structure: parent at i, left child at 2i, right child at 2i+1
-var MorseTree = [64]rune{
-	0,   // 0: root (unused)
-	0,   // 1: start
-	'E', // 2: .
-	'T', // 3: -
-	'I', // 4: ..
-	'A', // 5: .-
-	'N', // 6: -.
-	'M', // 7: --
-	'S', // 8: ...
-	'U', // 9: ..-
-	'R', // 10: .-.
-	'W', // 11: .--
-	'D', // 12: -..
-	'K', // 13: -.-
-	'G', // 14: --.
-	'O', // 15: ---
-	'H', // 16: ....
-	'V', // 17: ...-
-	'F', // 18: ..-.
-	0,   // 19: ..-- (Ü with accent, not standard)
-	'L', // 20: .-..
-	0,   // 21: .-.-  (Ä with accent)
-	'P', // 22: .--.
-	'J', // 23: .---
-	'B', // 24: -...
-	'X', // 25: -..-
-	'C', // 26: -.-.
-	'Y', // 27: -.--
-	'Z', // 28: --..
-	'Q', // 29: --.-
-	0,   // 30: ---.  (Ö with accent)
-	0,   // 31: ----
-	'5', // 32: .....
-	'4', // 33: ....-
-	0,   // 34: ...-.
-	'3', // 35: ...--
-	0,   // 36: ..-..
-	0,   // 37: ..-.-
-	0,   // 38: ..--. (?)
-	'2', // 39: ..---
-	0,   // 40: .-...
-	0,   // 41: .-..-
-	0,   // 42: .-.-.
-	0,   // 43: .-.--
-	0,   // 44: .--..
-	0,   // 45: .--.-
-	0,   // 46: .---.
-	'1', // 47: .----
-	'6', // 48: -....
-	'=', // 49: -...-
-	'/', // 50: -..-.
-	0,   // 51: -..--
-	0,   // 52: -.-..
-	0,   // 53: -.-.-
-	0,   // 54: -.--. (open paren)
-	0,   // 55: -.---
-	'7', // 56: --...
-	0,   // 57: --..-
-	0,   // 58: --.-.
-	0,   // 59: --.--
-	'8', // 60: ---..
-	0,   // 61: ---.-
-	'9', // 62: ----.
-	'0', // 63: -----
+// Six levels deep so that six-element punctuation and prosigns decode.
+var MorseTree = [128]rune{
+	0,    // 0: root (unused)
+	0,    // 1: start
+	'E',  // 2: .
+	'T',  // 3: -
+	'I',  // 4: ..
+	'A',  // 5: .-
+	'N',  // 6: -.
+	'M',  // 7: --
+	'S',  // 8: ...
+	'U',  // 9: ..-
+	'R',  // 10: .-.
+	'W',  // 11: .--
+	'D',  // 12: -..
+	'K',  // 13: -.-
+	'G',  // 14: --.
+	'O',  // 15: ---
+	'H',  // 16: ....
+	'V',  // 17: ...-
+	'F',  // 18: ..-.
+	0,    // 19: ..-- (Ü with accent, not standard)
+	'L',  // 20: .-..
+	0,    // 21: .-.-  (Ä with accent)
+	'P',  // 22: .--.
+	'J',  // 23: .---
+	'B',  // 24: -...
+	'X',  // 25: -..-
+	'C',  // 26: -.-.
+	'Y',  // 27: -.--
+	'Z',  // 28: --..
+	'Q',  // 29: --.-
+	0,    // 30: ---.  (Ö with accent)
+	0,    // 31: ----
+	'5',  // 32: .....
+	'4',  // 33: ....-
+	0,    // 34: ...-.
+	'3',  // 35: ...--
+	0,    // 36: ..-..
+	0,    // 37: ..-.-
+	0,    // 38: ..--. (?)
+	'2',  // 39: ..---
+	'&',  // 40: .-... AS (wait)
+	0,    // 41: .-..-
+	'+',  // 42: .-.-. AR (end of message)
+	0,    // 43: .-.--
+	0,    // 44: .--..
+	0,    // 45: .--.-
+	0,    // 46: .---.
+	'1',  // 47: .----
+	'6',  // 48: -....
+	'=',  // 49: -...-
+	'/',  // 50: -..-.
+	0,    // 51: -..--
+	0,    // 52: -.-..
+	0,    // 53: -.-.-
+	'(',  // 54: -.--. KN (go ahead, named station only)
+	0,    // 55: -.---
+	'7',  // 56: --...
+	0,    // 57: --..-
+	0,    // 58: --.-.
+	0,    // 59: --.--
+	'8',  // 60: ---..
+	0,    // 61: ---.-
+	'9',  // 62: ----.
+	'0',  // 63: -----
+	0,    // 64: ......
+	0,    // 65: .....-
+	0,    // 66: ....-.
+	0,    // 67: ....--
+	0,    // 68: ...-..
+	'%',  // 69: ...-.- SK (end of contact)
+	0,    // 70: ...--.
+	0,    // 71: ...---
+	0,    // 72: ..-...
+	0,    // 73: ..-..-
+	0,    // 74: ..-.-.
+	0,    // 75: ..-.--
+	'?',  // 76: ..--..
+	'_',  // 77: ..--.-
+	0,    // 78: ..---.
+	0,    // 79: ..----
+	0,    // 80: .-....
+	0,    // 81: .-...-
+	'"',  // 82: .-..-.
+	0,    // 83: .-..--
+	0,    // 84: .-.-..
+	'.',  // 85: .-.-.-
+	0,    // 86: .-.--.
+	0,    // 87: .-.---
+	0,    // 88: .--...
+	0,    // 89: .--..-
+	'@',  // 90: .--.-.
+	0,    // 91: .--.--
+	0,    // 92: .---..
+	0,    // 93: .---.-
+	'\'', // 94: .----.
+	0,    // 95: .-----
+	0,    // 96: -.....
+	'-',  // 97: -....-
+	0,    // 98: -...-.
+	0,    // 99: -...--
+	0,    // 100: -..-..
+	0,    // 101: -..-.-
+	0,    // 102: -..--.
+	0,    // 103: -..---
+	0,    // 104: -.-...
+	0,    // 105: -.-..-
+	';',  // 106: -.-.-.
+	'!',  // 107: -.-.--
+	0,    // 108: -.--..
+	')',  // 109: -.--.-
+	0,    // 110: -.---.
+	0,    // 111: -.----
+	0,    // 112: --....
+	0,    // 113: --...-
+	0,    // 114: --..-.
+	',',  // 115: --..--
+	0,    // 116: --.-..
+	0,    // 117: --.-.-
+	0,    // 118: --.--.
+	0,    // 119: --.---
+	':',  // 120: ---...
+	0,    // 121: ---..-
+	0,    // 122: ---.-.
+	0,    // 123: ---.--
+	0,    // 124: ----..
+	0,    // 125: ----.-
+	0,    // 126: -----.
+	0,    // 127: ------
+}
+
+// Prosign characters as they appear in DecodedOutput.
+// AR, AS and KN share their codes with ITU punctuation; SK has no punctuation
+// equivalent, so it uses '%' as in fldigi's default prosign table.
+const (
+	ProsignAR = '+' // .-.-.  end of message
+	ProsignAS = '&' // .-...  wait
+	ProsignBT = '=' // -...-  break between sections
+	ProsignKN = '(' // -.--.  go ahead, named station only
+	ProsignSK = '%' // ...-.- end of contact
+)
+
+// morseIndex maps each character in MorseTree back to its tree index.
+// Built once at package init for text-to-Morse lookups.
+var morseIndex = buildMorseIndex()
+
+func buildMorseIndex() map[rune]int {
+	index := make(map[rune]int, len(MorseTree))
+	for i, char := range MorseTree {
+		if char != 0 {
+			index[char] = i
+		}
+	}
+	return index
+}
+
+// EncodeCharacter returns the element sequence for a character (false=dit, true=dah).
+// Lowercase letters are accepted. Returns false if the character has no Morse code.
+func EncodeCharacter(char rune) ([]bool, bool) {
+	if char >= 'a' && char <= 'z' {
+		char -= 'a' - 'A'
+	}
+	index, ok := morseIndex[char]
+	if !ok {
+		return nil, false
+	}
+
+	// Walk from the leaf back to the root: even index = dit, odd index = dah
+	var reversed []bool
+	for ; index > 1; index /= 2 {
+		reversed = append(reversed, index%2 == 1)
+	}
+	elements := make([]bool, len(reversed))
+	for i, isDah := range reversed {
+		elements[len(reversed)-1-i] = isDah
+	}
+	return elements, true
+}
+
+// DecodeElements returns the character for an element sequence (false=dit, true=dah),
+// as the decoder would emit it. Returns false if the sequence has no character.
+func DecodeElements(elements []bool) (rune, bool) {
+	index := 1
+	for _, isDah := range elements {
+		index *= 2
+		if isDah {
+			index++
+		}
+		if index >= len(MorseTree) {
+			return 0, false
+		}
+	}
+	if MorseTree[index] == 0 || index == 1 {
+		return 0, false
+	}
+	return MorseTree[index], true
 }
 
 // DecoderConfig holds configuration for the CW decoder.
@@ -160,6 +308,22 @@ type DecoderConfig struct {
 	// FarnsworthWPM is the effective WPM for spacing (0 = same as character WPM) (from config: farnsworth_wpm)
 	// When set lower than InitialWPM, character spacing is stretched for easier copy
 	FarnsworthWPM int
+	// AutoFarnsworth switches gap classification to the measured spacing speed when it
+	// diverges from the character speed (from config: farnsworth_auto)
+	AutoFarnsworth bool
+	// KeyMode selects how element lengths are classified and learned (from config: key_mode)
+	// Straight and bug modes learn the sender's dah:dit ratio and weighting instead of assuming ITU timing
+	KeyMode KeyMode
+	// Classifier replaces the ratio boundaries for marks and spaces (nil = use the boundaries above)
+	Classifier Classifier
+	// Alphabet selects the character table (from config: alphabet; "" = latin)
+	Alphabet Alphabet
+	// Code selects ITU or American Morse (from config: morse_code; "" = international).
+	// American Morse has its own elements and table, so Alphabet, Classifier and Speculative do not apply.
+	Code Code
+	// Speculative emits a provisional character as soon as the elements reach a character
+	// no longer code extends, before the following space is seen (from config: speculative_output)
+	Speculative bool
 }
 
 // DecodedCallback is called when a character or word boundary is decoded.
@@ -174,14 +338,41 @@ type DecodedOutput struct {
 	IsWordSpace bool
 	// Timestamp is when this was decoded
 	Timestamp time.Time
-	// CurrentWPM is the estimated WPM at time of decode
+	// CurrentWPM is the estimated character speed (from marks and intra-character gaps) at time of decode
 	CurrentWPM int
+	// DitDuration is the estimated dit length at the character speed at time of decode
+	DitDuration time.Duration
+	// SpacingWPM is the estimated spacing speed (from inter-character and word gaps) at time of decode
+	SpacingWPM int
+	// Farnsworth is true if gaps were classified at the spacing speed rather than the character speed
+	Farnsworth bool
+	// Provisional is true for a speculative character that is not yet final. It is followed
+	// by either the final character or a retraction.
+	Provisional bool
+	// Retracted is true when the provisional Character is withdrawn because more elements arrived
+	Retracted bool
+}
+
+// IsFinal reports whether the output is a final character or word space rather than
+// a speculative one or its retraction.
+func (o DecodedOutput) IsFinal() bool {
+	return !o.Provisional && !o.Retracted
 }
 
 // ElementCallback is called when an element (dit/dah) is decoded.
 // Used by AdaptiveDecoder for pattern matching.
 type ElementCallback func(isDah bool, duration, gapAfter time.Duration, isCharEnd, isWordEnd bool)
 
+// ChainElementCallbacks returns an ElementCallback that calls each callback in order,
+// so several listeners (adaptive decoder, fist analysis) can share the decoder's single slot.
+func ChainElementCallbacks(callbacks ...ElementCallback) ElementCallback {
+	return func(isDah bool, duration, gapAfter time.Duration, isCharEnd, isWordEnd bool) {
+		for _, cb := range callbacks {
+			cb(isDah, duration, gapAfter, isCharEnd, isWordEnd)
+		}
+	}
+}
+
 // FlushTimeout constants
 const (
 	// DefaultFlushTimeoutMs is the default timeout to flush pending characters (in milliseconds)
@@ -198,19 +389,37 @@ type Decoder struct {
 	ditDurationMs float64 // Current estimate of dit duration in milliseconds
 	mu            sync.Mutex
 
+	// Spacing state (tracked separately from character speed for Farnsworth)
+	spacingDitMs float64   // Current estimate of the spacing unit in milliseconds
+	spacingGaps  []float64 // Recent gaps longer than an intra-character space (ring buffer)
+	spacingNext  int       // Next write position in spacingGaps
+	farnsworth   bool      // Whether gaps are classified at spacing speed
+
+	// Fist state (straight key and bug modes)
+	fistDitMs   float64 // Learned dit mark length in milliseconds
+	fistDahMs   float64 // Learned dah mark length in milliseconds
+	fistSpaceMs float64 // Learned intra-character space in milliseconds
+
 	// Current character being built
-	treeIndex int  // Position in MorseTree (1 = start)
-	inChar    bool // Whether we're currently building a character
+	treeIndex   int        // Position in MorseTree (1 = start)
+	inChar      bool       // Whether we're currently building a character
+	provisional rune       // Provisional character awaiting its final output or retraction (0 = none)
+	table       *charTable // Character table in use (Wabun switches it)
+
+	// American Morse character being built (elements and internal spaces)
+	americanCode []byte
 
 	// Last element tracking for adaptive decoder
 	lastElementDuration time.Duration
 	lastElementIsDah    bool
 	lastElementTime     time.Time
+	lastGapMs           float64 // Space before the last element, 0 if it started the character
 
 	// Flush timeout for pending characters
 	flushTimer   *time.Timer
 	flushTimeout time.Duration
 	lastToneOff  time.Time // When the last tone ended
+	eventClock   bool      // Flush from event timestamps instead of a wall-clock timer
 
 	// Callback for decoded output
 	callbackPtr *DecodedCallback
@@ -235,6 +444,16 @@ func NewDecoder(cfg DecoderConfig) (*Decoder, error) {
 	if cfg.CharWordBoundary <= 0 {
 		return nil, ErrInvalidCharWordBoundary
 	}
+	// Empty key mode, alphabet and code select the defaults
+	if cfg.KeyMode != "" && !cfg.KeyMode.Valid() {
+		return nil, ErrInvalidKeyMode
+	}
+	if cfg.Alphabet != "" && !cfg.Alphabet.Valid() {
+		return nil, ErrInvalidAlphabet
+	}
+	if cfg.Code != "" && !cfg.Code.Valid() {
+		return nil, ErrInvalidCode
+	}
 	// Default InterCharBoundary to 2.0 if not set (midpoint of intra-char=1 and inter-char=3)
 	if cfg.InterCharBoundary <= 0 {
 		cfg.InterCharBoundary = DahDitThreshold // 2.0
@@ -244,7 +463,7 @@ func NewDecoder(cfg DecoderConfig) (*Decoder, error) {
 	// WPM = (dits per minute) / DitsPerWord
 	// dits per minute = 60000 / dit_duration_ms
 	// dit_duration_ms = 60000 / (WPM * DitsPerWord)
-	ditDurationMs := MillisecondsPerMinute / (float64(cfg.InitialWPM) * DitsPerWord)
+	ditDurationMs := ToMs(DitDuration(cfg.InitialWPM))
 
 	// Calculate flush timeout based on word space timing
 	// Use CharWordBoundary * dit duration as minimum, with a reasonable default
@@ -253,13 +472,29 @@ func NewDecoder(cfg DecoderConfig) (*Decoder, error) {
 		flushTimeoutMs = MinFlushTimeoutMs
 	}
 
-	return &Decoder{
+	d := &Decoder{
 		config:        cfg,
 		ditDurationMs: ditDurationMs,
 		treeIndex:     1, // Start at root
 		inChar:        false,
 		flushTimeout:  time.Duration(flushTimeoutMs) * time.Millisecond,
-	}, nil
+		table:         cfg.Alphabet.table(),
+	}
+	d.resetSpacing()
+	d.resetFist()
+	return d, nil
+}
+
+// resetSpacing seeds the spacing estimate from the configured Farnsworth speed (or character speed).
+func (d *Decoder) resetSpacing() {
+	d.spacingDitMs = d.ditDurationMs
+	d.farnsworth = false
+	if d.config.FarnsworthWPM > 0 && d.config.FarnsworthWPM < d.config.InitialWPM {
+		d.spacingDitMs = ToMs(DitDuration(d.config.FarnsworthWPM))
+		d.farnsworth = true
+	}
+	d.spacingGaps = d.spacingGaps[:0]
+	d.spacingNext = 0
 }
 
 // SetCallback sets the callback for decoded output.
@@ -295,12 +530,47 @@ func (d *Decoder) Stop() {
 	}
 }
 
+// UseEventClock stops the decoder arming its wall-clock flush timer. A pending
+// character is flushed instead when the silence a tone event ends is longer than the
+// flush timeout, or by Flush. Used for offline input timed by its samples, so results
+// do not depend on how fast the input is processed.
+func (d *Decoder) UseEventClock() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.eventClock = true
+	if d.flushTimer != nil {
+		d.flushTimer.Stop()
+		d.flushTimer = nil
+	}
+}
+
+// Flush emits any pending character and a word space immediately, as if the
+// flush timeout had expired. Used at the end of offline input, where no timer should be awaited.
+func (d *Decoder) Flush() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.flushTimer != nil {
+		d.flushTimer.Stop()
+		d.flushTimer = nil
+	}
+	d.flushPendingCharacter(d.flushTimeout)
+}
+
 // HandleToneEvent processes a tone event from the detector.
 // This is the main entry point, typically called from detector's callback.
 func (d *Decoder) HandleToneEvent(event dsp.ToneEvent) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	if d.config.Code == CodeAmerican {
+		if event.ToneOn {
+			d.handleAmericanSilenceEnd(event)
+		} else {
+			d.handleAmericanToneEnd(event)
+		}
+		return
+	}
+
 	if event.ToneOn {
 		// Tone just started - check if previous silence was long enough for char/word boundary
 		d.handleSilenceEnd(event)
@@ -310,12 +580,26 @@ func (d *Decoder) HandleToneEvent(event dsp.ToneEvent) {
 	}
 }
 
+// ToMs returns a duration in fractional milliseconds, keeping the sub-millisecond
+// precision that dits need at high speed.
+func ToMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// DitDuration returns the dit length at wpm using the PARIS standard word, or 0 if wpm is not positive
+func DitDuration(wpm int) time.Duration {
+	if wpm <= 0 {
+		return 0
+	}
+	return time.Duration(MillisecondsPerMinute / (float64(wpm) * DitsPerWord) * float64(time.Millisecond))
+}
+
 // handleToneEnd classifies the tone duration as dit or dah and updates the tree position.
 func (d *Decoder) handleToneEnd(event dsp.ToneEvent) {
-	durationMs := float64(event.Duration.Milliseconds())
+	durationMs := ToMs(event.Duration)
 
 	// Classify as dit or dah based on duration
-	isDah := durationMs > (d.ditDurationMs * d.config.DitDahBoundary)
+	isDah := d.classifyMark(durationMs)
 
 	// Track element for adaptive decoder
 	d.lastElementDuration = event.Duration
@@ -350,6 +634,39 @@ func (d *Decoder) handleToneEnd(event dsp.ToneEvent) {
 		// Invalid sequence - reset
 		d.treeIndex = 1
 		d.inChar = false
+		d.lastGapMs = 0
+	}
+
+	if d.config.Speculative {
+		d.speculate(event.Timestamp)
+	}
+}
+
+// speculate emits the current character provisionally once no more elements can extend it,
+// and retracts an earlier provisional character the new element has invalidated.
+func (d *Decoder) speculate(timestamp time.Time) {
+	if d.inChar && d.table.leaves[d.treeIndex] {
+		d.provisional = d.table.chars[d.treeIndex]
+		d.emitProvisional(timestamp, false)
+	} else if d.provisional != 0 {
+		d.emitProvisional(timestamp, true)
+		d.provisional = 0
+	}
+}
+
+// emitProvisional outputs the provisional character, or its retraction.
+func (d *Decoder) emitProvisional(timestamp time.Time, retracted bool) {
+	if d.callbackPtr != nil {
+		(*d.callbackPtr)(DecodedOutput{
+			Character:   d.provisional,
+			Timestamp:   timestamp,
+			CurrentWPM:  d.currentWPM(),
+			DitDuration: d.ditDuration(),
+			SpacingWPM:  d.spacingWPM(),
+			Farnsworth:  d.farnsworth,
+			Provisional: !retracted,
+			Retracted:   retracted,
+		})
 	}
 }
 
@@ -360,54 +677,86 @@ func (d *Decoder) startFlushTimer() {
 	if d.flushTimer != nil {
 		d.flushTimer.Stop()
 	}
+	if d.eventClock {
+		return
+	}
 
 	// Start new timer
-	d.flushTimer = time.AfterFunc(d.flushTimeout, func() {
+	timeout := d.currentFlushTimeout()
+	d.flushTimer = time.AfterFunc(timeout, func() {
 		d.mu.Lock()
 		defer d.mu.Unlock()
-		d.flushPendingCharacter()
+		d.flushPendingCharacter(timeout)
 	})
 }
 
+// currentFlushTimeout returns the silence after which a pending character is flushed.
+// Farnsworth spacing can stretch character gaps past the default timeout.
+func (d *Decoder) currentFlushTimeout() time.Duration {
+	if !d.farnsworth {
+		return d.flushTimeout
+	}
+	spacingTimeout := time.Duration(d.spacingDitMs*d.config.CharWordBoundary*2) * time.Millisecond
+	return max(d.flushTimeout, spacingTimeout)
+}
+
+// flushOnSilence stops the flush timer now a tone has started. On the event clock it
+// flushes the pending character if the silence was long enough for the timer to have fired.
+func (d *Decoder) flushOnSilence(event dsp.ToneEvent) {
+	if d.flushTimer != nil {
+		d.flushTimer.Stop()
+		d.flushTimer = nil
+	}
+	if timeout := d.currentFlushTimeout(); d.eventClock && event.Duration >= timeout {
+		d.flushPendingCharacter(timeout)
+	}
+}
+
 // flushPendingCharacter emits any character currently being built.
-// Called when flush timer fires (silence timeout).
-func (d *Decoder) flushPendingCharacter() {
+// Called when flush timer fires (silence timeout) with the silence that triggered it.
+func (d *Decoder) flushPendingCharacter(silence time.Duration) {
 	if !d.inChar {
 		return
 	}
 
+	// The last element of a transmission is followed by the timeout rather than a tone
+	if d.elementCallbackPtr != nil && d.lastElementTime != (time.Time{}) {
+		(*d.elementCallbackPtr)(d.lastElementIsDah, d.lastElementDuration, silence, true, true)
+	}
+
+	// Timestamp from the tone clock so offline input (sample-timed events) stays consistent
+	timestamp := d.lastToneOff.Add(silence)
+
 	// Emit the pending character
-	d.emitCharacter(time.Now())
+	d.emitCharacter(timestamp)
 
 	// Also emit word space since we've had a long silence
-	d.emitWordSpace(time.Now())
+	d.emitWordSpace(timestamp)
 }
 
 // handleSilenceEnd checks if the silence duration indicates a character or word boundary.
 func (d *Decoder) handleSilenceEnd(event dsp.ToneEvent) {
 	// Cancel flush timer since we received a new tone
-	if d.flushTimer != nil {
-		d.flushTimer.Stop()
-		d.flushTimer = nil
-	}
+	d.flushOnSilence(event)
 
 	if !d.inChar {
 		return // No character being built
 	}
 
-	durationMs := float64(event.Duration.Milliseconds())
+	durationMs := ToMs(event.Duration)
 
-	// Use Farnsworth timing for spacing if configured
-	spacingDitMs := d.ditDurationMs
-	if d.config.FarnsworthWPM > 0 && d.config.FarnsworthWPM < d.config.InitialWPM {
-		spacingDitMs = MillisecondsPerMinute / (float64(d.config.FarnsworthWPM) * DitsPerWord)
+	// Determine if this is a character boundary or word boundary
+	class := d.classifySpace(durationMs)
+	isWordSpace := class == SpaceWord
+	isCharSpace := class == SpaceInterChar
+	if class == SpaceIntraChar {
+		d.lastGapMs = durationMs
 	}
 
-	// Determine if this is a character boundary or word boundary
-	// Character boundary: silence > InterCharBoundary (configurable, default 2.0) * dit duration
-	// Word boundary: silence > CharWordBoundary (configurable, default 5.0) * dit duration
-	isWordSpace := durationMs > (spacingDitMs * d.config.CharWordBoundary)
-	isCharSpace := durationMs > (spacingDitMs * d.config.InterCharBoundary)
+	// Update character and spacing speed estimates if adaptive timing is enabled
+	if d.config.AdaptiveTiming {
+		d.adaptSpacing(durationMs, isCharSpace || isWordSpace)
+	}
 
 	// Record element for adaptive decoder (before emitting character)
 	if d.elementCallbackPtr != nil && d.lastElementTime != (time.Time{}) {
@@ -433,21 +782,60 @@ func (d *Decoder) handleSilenceEnd(event dsp.ToneEvent) {
 
 // emitCharacter outputs the current character being built.
 func (d *Decoder) emitCharacter(timestamp time.Time) {
-	if d.treeIndex > 0 && d.treeIndex < len(MorseTree) {
-		char := MorseTree[d.treeIndex]
-		if char != 0 && d.callbackPtr != nil {
-			(*d.callbackPtr)(DecodedOutput{
-				Character:   char,
-				IsWordSpace: false,
-				Timestamp:   timestamp,
-				CurrentWPM:  d.currentWPM(),
-			})
-		}
+	if d.switchTable() {
+		d.treeIndex = 1
+		d.inChar = false
+		d.lastGapMs = 0
+		d.provisional = 0
+		return
+	}
+
+	if char := d.pendingCharacter(); char != 0 && d.callbackPtr != nil {
+		(*d.callbackPtr)(DecodedOutput{
+			Character:   char,
+			IsWordSpace: false,
+			Timestamp:   timestamp,
+			CurrentWPM:  d.currentWPM(),
+			DitDuration: d.ditDuration(),
+			SpacingWPM:  d.spacingWPM(),
+			Farnsworth:  d.farnsworth,
+		})
 	}
 
-	// Reset for next character
+	// Reset for next character (a provisional character is always this one, now final)
 	d.treeIndex = 1
 	d.inChar = false
+	d.lastGapMs = 0
+	d.provisional = 0
+	d.americanCode = d.americanCode[:0]
+}
+
+// pendingCharacter returns the character built so far, or 0 if its elements code none.
+func (d *Decoder) pendingCharacter() rune {
+	if d.config.Code == CodeAmerican {
+		return AmericanTable[string(d.americanCode)]
+	}
+	if d.treeIndex > 0 && d.treeIndex < len(MorseTree) {
+		return d.table.chars[d.treeIndex]
+	}
+	return 0
+}
+
+// switchTable handles the Wabun DO and SN prosigns, which change the character
+// table instead of being emitted. Reports whether the current character was one.
+func (d *Decoder) switchTable() bool {
+	if d.config.Alphabet != AlphabetWabun {
+		return false
+	}
+	switch d.treeIndex {
+	case wabunStartIndex:
+		d.table = kanaTable
+	case wabunEndIndex:
+		d.table = wabunLatinTable
+	default:
+		return false
+	}
+	return true
 }
 
 // emitWordSpace outputs a word space marker.
@@ -458,12 +846,21 @@ func (d *Decoder) emitWordSpace(timestamp time.Time) {
 			IsWordSpace: true,
 			Timestamp:   timestamp,
 			CurrentWPM:  d.currentWPM(),
+			DitDuration: d.ditDuration(),
+			SpacingWPM:  d.spacingWPM(),
+			Farnsworth:  d.farnsworth,
 		})
 	}
 }
 
 // adaptTiming updates the dit duration estimate using exponential moving average.
 func (d *Decoder) adaptTiming(durationMs float64, isDah bool) {
+	// Straight key and bug senders do not keep ITU ratios; learn their lengths instead
+	if d.isFistMode() {
+		d.adaptFistMark(durationMs, isDah)
+		return
+	}
+
 	// Convert dah to equivalent dit duration
 	estimatedDit := durationMs
 	if isDah {
@@ -479,6 +876,85 @@ func (d *Decoder) adaptTiming(durationMs float64, isDah bool) {
 	d.ditDurationMs = (1-smoothing)*d.ditDurationMs + smoothing*estimatedDit
 }
 
+// adaptSpacing updates the character speed from intra-character gaps and the
+// spacing speed from inter-character and word gaps, then decides whether gaps
+// should be classified at Farnsworth (spacing) speed.
+func (d *Decoder) adaptSpacing(gapMs float64, isBoundary bool) {
+	// Intra-character gaps are sent at character speed (1 dit)
+	if gapMs <= d.ditDurationMs*d.config.InterCharBoundary {
+		if !isBoundary {
+			if d.isFistMode() {
+				d.adaptFistSpace(gapMs)
+			} else {
+				d.adaptTiming(gapMs, false)
+			}
+		}
+		return
+	}
+
+	if len(d.spacingGaps) < SpacingHistorySize {
+		d.spacingGaps = append(d.spacingGaps, gapMs)
+	} else {
+		d.spacingGaps[d.spacingNext] = gapMs
+	}
+	d.spacingNext = (d.spacingNext + 1) % SpacingHistorySize
+	if len(d.spacingGaps) < MinSpacingSamples {
+		return
+	}
+	// Without auto detection a configured Farnsworth speed fixes the spacing unit
+	configured := d.config.FarnsworthWPM > 0 && d.config.FarnsworthWPM < d.config.InitialWPM
+	if configured && !d.config.AutoFarnsworth {
+		return
+	}
+
+	sorted := slices.Clone(d.spacingGaps)
+	slices.Sort(sorted)
+	charGapMs := sorted[int(float64(len(sorted)-1)*SpacingPercentile)]
+	d.spacingDitMs = charGapMs / InterCharSpaceRatio
+
+	if !d.config.AutoFarnsworth {
+		return
+	}
+	ratio := d.spacingDitMs / d.ditDurationMs
+	switch {
+	case !d.farnsworth && ratio >= FarnsworthDetectRatio:
+		d.farnsworth = true
+	case d.farnsworth && !configured && ratio < FarnsworthReleaseRatio:
+		d.farnsworth = false
+	}
+}
+
+// spacingDit returns the dit duration used to classify gaps.
+func (d *Decoder) spacingDit() float64 {
+	if d.farnsworth {
+		return d.spacingDitMs
+	}
+	return d.ditDurationMs
+}
+
+// spacingWPM returns the estimated spacing speed based on the spacing unit.
+func (d *Decoder) spacingWPM() int {
+	if d.spacingDitMs <= 0 {
+		return d.currentWPM()
+	}
+	wpm := MillisecondsPerMinute / (d.spacingDitMs * DitsPerWord)
+	return int(wpm + 0.5) // Round to nearest
+}
+
+// SpacingWPM returns the estimated spacing speed (thread-safe).
+func (d *Decoder) SpacingWPM() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.spacingWPM()
+}
+
+// IsFarnsworth reports whether gaps are currently classified at spacing speed (thread-safe).
+func (d *Decoder) IsFarnsworth() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.farnsworth
+}
+
 // currentWPM returns the current estimated WPM based on dit duration.
 func (d *Decoder) currentWPM() int {
 	if d.ditDurationMs <= 0 {
@@ -488,6 +964,11 @@ func (d *Decoder) currentWPM() int {
 	return int(wpm + 0.5) // Round to nearest
 }
 
+// ditDuration returns the estimated dit length at the character speed.
+func (d *Decoder) ditDuration() time.Duration {
+	return time.Duration(d.ditDurationMs * float64(time.Millisecond))
+}
+
 // CurrentWPM returns the current estimated WPM (thread-safe).
 func (d *Decoder) CurrentWPM() int {
 	d.mu.Lock()
@@ -506,7 +987,13 @@ func (d *Decoder) Reset() {
 		d.flushTimer = nil
 	}
 
-	d.ditDurationMs = MillisecondsPerMinute / (float64(d.config.InitialWPM) * DitsPerWord)
+	d.ditDurationMs = ToMs(DitDuration(d.config.InitialWPM))
+	d.resetSpacing()
+	d.resetFist()
 	d.treeIndex = 1
 	d.inChar = false
+	d.lastGapMs = 0
+	d.provisional = 0
+	d.table = d.config.Alphabet.table()
+	d.americanCode = d.americanCode[:0]
 }
@@ -1,6 +1,8 @@
 package cw
 
 import (
+	"slices"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -21,6 +23,23 @@ func validConfig() DecoderConfig {
 	}
 }
 
+func TestDitDuration(t *testing.T) {
+	tests := []struct {
+		wpm  int
+		want time.Duration
+	}{
+		{20, 60 * time.Millisecond},
+		{12, 100 * time.Millisecond},
+		{0, 0},
+		{-5, 0},
+	}
+	for _, tt := range tests {
+		if got := DitDuration(tt.wpm); got != tt.want {
+			t.Errorf("DitDuration(%d) = %v, want %v", tt.wpm, got, tt.want)
+		}
+	}
+}
+
 func TestNewDecoder_ValidConfig(t *testing.T) {
 	cfg := validConfig()
 	decoder, err := NewDecoder(cfg)
@@ -215,6 +234,47 @@ func TestDecoder_FlushTimer(t *testing.T) {
 	}
 }
 
+func TestDecoder_UseEventClock(t *testing.T) {
+	cfg := validConfig()
+	cfg.InitialWPM = 15 // 80ms dit, 800ms flush timeout
+
+	decoder, err := NewDecoder(cfg)
+	if err != nil {
+		t.Fatalf("NewDecoder() error = %v", err)
+	}
+	decoder.UseEventClock()
+
+	var mu sync.Mutex
+	var text []rune
+	decoder.SetCallback(func(output DecodedOutput) {
+		mu.Lock()
+		text = append(text, output.Character)
+		mu.Unlock()
+	})
+
+	start := time.Unix(0, 0)
+	decoder.HandleToneEvent(dsp.ToneEvent{ToneOn: false, Duration: 80 * time.Millisecond, Timestamp: start})
+	// However long the input takes to arrive, no timer flushes the E
+	time.Sleep(decoder.flushTimeout + 100*time.Millisecond)
+	mu.Lock()
+	if len(text) != 0 {
+		t.Errorf("decoded %q before the next event, want nothing", string(text))
+	}
+	mu.Unlock()
+
+	// A silence as long as the timeout flushes it when the next tone starts
+	next := start.Add(decoder.flushTimeout + 80*time.Millisecond)
+	decoder.HandleToneEvent(dsp.ToneEvent{ToneOn: true, Duration: decoder.flushTimeout, Timestamp: next})
+	decoder.HandleToneEvent(dsp.ToneEvent{ToneOn: false, Duration: 240 * time.Millisecond, Timestamp: next.Add(240 * time.Millisecond)})
+	decoder.Flush()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if string(text) != "E T " {
+		t.Errorf("decoded %q, want %q", string(text), "E T ")
+	}
+}
+
 func TestDecoder_Stop(t *testing.T) {
 	cfg := validConfig()
 	decoder, err := NewDecoder(cfg)
@@ -509,18 +569,23 @@ func TestMorseTree_KnownCharacters(t *testing.T) {
 		index int
 		char  rune
 	}{
-		{2, 'E'},  // .
-		{3, 'T'},  // -
-		{4, 'I'},  // ..
-		{5, 'A'},  // .-
-		{6, 'N'},  // -.
-		{7, 'M'},  // --
-		{8, 'S'},  // ...
-		{15, 'O'}, // ---
-		{16, 'H'}, // ....
-		{32, '5'}, // .....
-		{47, '1'}, // .----
-		{63, '0'}, // -----
+		{2, 'E'},        // .
+		{3, 'T'},        // -
+		{4, 'I'},        // ..
+		{5, 'A'},        // .-
+		{6, 'N'},        // -.
+		{7, 'M'},        // --
+		{8, 'S'},        // ...
+		{15, 'O'},       // ---
+		{16, 'H'},       // ....
+		{32, '5'},       // .....
+		{47, '1'},       // .----
+		{63, '0'},       // -----
+		{42, ProsignAR}, // .-.-.
+		{69, ProsignSK}, // ...-.-
+		{85, '.'},       // .-.-.-
+		{115, ','},      // --..--
+		{127, 0},        // ------
 	}
 
 	for _, tt := range tests {
@@ -530,6 +595,94 @@ func TestMorseTree_KnownCharacters(t *testing.T) {
 	}
 }
 
+func TestDecoder_SixElementCharacters(t *testing.T) {
+	tests := []struct {
+		code string
+		want rune
+	}{
+		{".-.-.", ProsignAR},
+		{".-...", ProsignAS},
+		{"-.--.", ProsignKN},
+		{"...-.-", ProsignSK},
+		{"..--..", '?'},
+		{"--..--", ','},
+	}
+
+	for _, tt := range tests {
+		cfg := validConfig()
+		cfg.InitialWPM = 15
+		cfg.AdaptiveTiming = false
+		decoder, err := NewDecoder(cfg)
+		if err != nil {
+			t.Fatalf("NewDecoder() error = %v", err)
+		}
+		var received []DecodedOutput
+		decoder.SetCallback(func(output DecodedOutput) {
+			received = append(received, output)
+		})
+
+		// Elements at 15 WPM (80ms dit) with one dit between them, then a character space
+		now := time.Now()
+		for i, element := range tt.code {
+			duration := 80 * time.Millisecond
+			if element == '-' {
+				duration = 240 * time.Millisecond
+			}
+			if i > 0 {
+				decoder.HandleToneEvent(dsp.ToneEvent{ToneOn: true, Duration: 80 * time.Millisecond, Timestamp: now})
+			}
+			decoder.HandleToneEvent(dsp.ToneEvent{ToneOn: false, Duration: duration, Timestamp: now})
+		}
+		decoder.HandleToneEvent(dsp.ToneEvent{ToneOn: true, Duration: 300 * time.Millisecond, Timestamp: now})
+
+		if len(received) == 0 || received[0].Character != tt.want {
+			t.Errorf("decoded %s = %v, want %c", tt.code, received, tt.want)
+		}
+	}
+}
+
+func TestEncodeCharacter(t *testing.T) {
+	tests := []struct {
+		char rune
+		want string
+	}{
+		{'E', "."},
+		{'T', "-"},
+		{'C', "-.-."},
+		{'q', "--.-"},
+		{'1', ".----"},
+		{'/', "-..-."},
+		{'.', ".-.-.-"},
+		{'?', "..--.."},
+		{ProsignAR, ".-.-."},
+		{ProsignKN, "-.--."},
+		{ProsignSK, "...-.-"},
+	}
+
+	for _, tt := range tests {
+		elements, ok := EncodeCharacter(tt.char)
+		if !ok {
+			t.Errorf("EncodeCharacter(%c) returned false", tt.char)
+			continue
+		}
+		got := ""
+		for _, isDah := range elements {
+			if isDah {
+				got += "-"
+			} else {
+				got += "."
+			}
+		}
+		if got != tt.want {
+			t.Errorf("EncodeCharacter(%c) = %s, want %s", tt.char, got, tt.want)
+		}
+	}
+
+	if _, ok := EncodeCharacter('#'); ok {
+		t.Error("EncodeCharacter(#) should return false")
+	}
+}
+
 func TestDecoder_TreeOverflow(t *testing.T) {
 	cfg := validConfig()
 	cfg.AdaptiveTiming = false
@@ -607,6 +760,143 @@ func TestDecoder_FarnsworthTiming(t *testing.T) {
 	}
 }
 
+// sendText feeds text to the decoder as tone events with the given dit, character gap and word gap
+func sendText(d *Decoder, text string, ditMs, charGapMs, wordGapMs int, start time.Time) time.Time {
+	now := start
+	gap := 0
+	for _, char := range text {
+		if char == ' ' {
+			gap = wordGapMs
+			continue
+		}
+		elements, _ := EncodeCharacter(char)
+		for _, isDah := range elements {
+			toneMs := ditMs
+			if isDah {
+				toneMs = ditMs * DahDitRatio
+			}
+			if gap > 0 {
+				now = now.Add(time.Duration(gap) * time.Millisecond)
+				d.HandleToneEvent(dsp.ToneEvent{ToneOn: true, Duration: time.Duration(gap) * time.Millisecond, Timestamp: now})
+			}
+			now = now.Add(time.Duration(toneMs) * time.Millisecond)
+			d.HandleToneEvent(dsp.ToneEvent{ToneOn: false, Duration: time.Duration(toneMs) * time.Millisecond, Timestamp: now})
+			gap = ditMs
+		}
+		if gap != wordGapMs {
+			gap = charGapMs
+		}
+	}
+	return now
+}
+
+func TestDecoder_AutoFarnsworth(t *testing.T) {
+	cfg := validConfig()
+	cfg.InitialWPM = 20
+	cfg.AutoFarnsworth = true
+
+	decoder, err := NewDecoder(cfg)
+	if err != nil {
+		t.Fatalf("NewDecoder() error = %v", err)
+	}
+	defer decoder.Stop()
+
+	var mu sync.Mutex
+	var text []rune
+	var last DecodedOutput
+	decoder.SetCallback(func(output DecodedOutput) {
+		mu.Lock()
+		defer mu.Unlock()
+		text = append(text, output.Character)
+		last = output
+	})
+
+	// 20 WPM characters (60ms dit) with 10 WPM spacing (120ms unit):
+	// character gaps of 360ms exceed the 300ms word threshold at character speed
+	end := sendText(decoder, "PARIS PARIS PARIS", 60, 360, 840, time.Now())
+	// Word gap to terminate the last character
+	decoder.HandleToneEvent(dsp.ToneEvent{ToneOn: true, Duration: 840 * time.Millisecond, Timestamp: end.Add(840 * time.Millisecond)})
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !decoder.IsFarnsworth() {
+		t.Fatal("decoder should switch to Farnsworth classification")
+	}
+	if last.CurrentWPM != 20 || last.SpacingWPM != 10 || !last.Farnsworth {
+		t.Errorf("output = %d/%d WPM farnsworth=%v, want 20/10 WPM farnsworth=true",
+			last.CurrentWPM, last.SpacingWPM, last.Farnsworth)
+	}
+	if (last.DitDuration - 60*time.Millisecond).Abs() > time.Millisecond {
+		t.Errorf("output DitDuration = %v, want the 60ms character dit", last.DitDuration)
+	}
+	// Once detected, later words decode with character gaps intact
+	if got := string(text); !strings.HasSuffix(got, "PARIS PARIS ") {
+		t.Errorf("decoded %q, want it to end with %q", got, "PARIS PARIS ")
+	}
+}
+
+func TestDecoder_AutoFarnsworth_StandardSpacing(t *testing.T) {
+	cfg := validConfig()
+	cfg.InitialWPM = 20
+	cfg.AutoFarnsworth = true
+
+	decoder, err := NewDecoder(cfg)
+	if err != nil {
+		t.Fatalf("NewDecoder() error = %v", err)
+	}
+	defer decoder.Stop()
+
+	sendText(decoder, "PARIS PARIS PARIS", 60, 180, 420, time.Now())
+
+	if decoder.IsFarnsworth() {
+		t.Error("standard ITU spacing should not switch to Farnsworth classification")
+	}
+	if got := decoder.SpacingWPM(); got != 20 {
+		t.Errorf("SpacingWPM() = %d, want 20", got)
+	}
+}
+
+func TestDecoder_ConfiguredFarnsworth_KeepsSpacing(t *testing.T) {
+	cfg := validConfig()
+	cfg.InitialWPM = 20
+	cfg.FarnsworthWPM = 10
+	cfg.AutoFarnsworth = false
+
+	decoder, err := NewDecoder(cfg)
+	if err != nil {
+		t.Fatalf("NewDecoder() error = %v", err)
+	}
+	defer decoder.Stop()
+
+	// Spacing sent at 12 WPM (100ms unit) does not override the configured 10 WPM
+	sendText(decoder, "PARIS PARIS PARIS", 60, 300, 700, time.Now())
+
+	if got := decoder.SpacingWPM(); got != 10 {
+		t.Errorf("SpacingWPM() = %d, want the configured 10", got)
+	}
+	if !decoder.IsFarnsworth() {
+		t.Error("decoder should keep classifying gaps at the configured Farnsworth speed")
+	}
+}
+
+func TestChainElementCallbacks(t *testing.T) {
+	var calls []string
+	chained := ChainElementCallbacks(
+		func(isDah bool, _, _ time.Duration, _, _ bool) {
+			calls = append(calls, "first")
+		},
+		func(isDah bool, _, _ time.Duration, _, _ bool) {
+			calls = append(calls, "second")
+		},
+	)
+
+	chained(true, 0, 0, false, false)
+	if len(calls) != 2 || calls[0] != "first" || calls[1] != "second" {
+		t.Errorf("calls = %v, want [first second]", calls)
+	}
+}
+
 func TestConstants(t *testing.T) {
 	// Verify ITU standard constants
 	if DahDitRatio != 3.0 {
@@ -669,3 +959,123 @@ func TestDecoder_ConcurrentAccess(t *testing.T) {
 	wg.Wait()
 	// If we get here without race detector errors, test passes
 }
+
+func TestMorseLeaves(t *testing.T) {
+	tests := []struct {
+		char rune
+		leaf bool
+	}{
+		{'Q', true},
+		{'5', true},
+		{'E', false},
+		{'Y', false}, // extended by KN
+		{'H', false}, // extended by 5 and 4
+	}
+	for _, tt := range tests {
+		if got := latinTable.leaves[morseIndex[tt.char]]; got != tt.leaf {
+			t.Errorf("latin leaf %c = %v, want %v", tt.char, got, tt.leaf)
+		}
+	}
+}
+
+// collectOutputs returns a decoder with the given speculative setting that records every output
+func collectOutputs(t *testing.T, speculative bool) (*Decoder, func() []DecodedOutput) {
+	t.Helper()
+	cfg := validConfig()
+	cfg.InitialWPM = 20
+	cfg.AdaptiveTiming = false
+	cfg.Speculative = speculative
+	decoder, err := NewDecoder(cfg)
+	if err != nil {
+		t.Fatalf("NewDecoder() error = %v", err)
+	}
+	t.Cleanup(decoder.Stop)
+
+	var mu sync.Mutex
+	var outputs []DecodedOutput
+	decoder.SetCallback(func(output DecodedOutput) {
+		mu.Lock()
+		defer mu.Unlock()
+		outputs = append(outputs, output)
+	})
+	return decoder, func() []DecodedOutput {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(outputs)
+	}
+}
+
+func TestDecoder_SpeculativeConfirmed(t *testing.T) {
+	decoder, outputs := collectOutputs(t, true)
+
+	start := time.Now()
+	end := sendText(decoder, "QE", 60, 180, 420, start)
+
+	// Q is provisional as soon as its last dah ends, before the character space is seen
+	got := outputs()
+	if len(got) != 2 {
+		t.Fatalf("got %d outputs before the end, want 2: %+v", len(got), got)
+	}
+	qEnd := start.Add(780 * time.Millisecond) // --.- : 3 dahs, 1 dit and 3 one-dit spaces
+	if got[0].Character != 'Q' || !got[0].Provisional || !got[0].Timestamp.Equal(qEnd) {
+		t.Errorf("first output = %+v, want provisional Q at the end of its last element", got[0])
+	}
+	if got[1].Character != 'Q' || !got[1].IsFinal() {
+		t.Errorf("second output = %+v, want final Q", got[1])
+	}
+
+	decoder.HandleToneEvent(dsp.ToneEvent{ToneOn: true, Duration: 420 * time.Millisecond, Timestamp: end.Add(420 * time.Millisecond)})
+	got = outputs()
+	// E is extended by many characters, so it is only ever final
+	if len(got) != 4 || got[2].Character != 'E' || !got[2].IsFinal() || !got[3].IsWordSpace {
+		t.Errorf("outputs = %+v, want final E and a word space", got)
+	}
+}
+
+func TestDecoder_SpeculativeRetracted(t *testing.T) {
+	decoder, outputs := collectOutputs(t, true)
+
+	// ..-. (F) and then another dit, which no character codes
+	now := time.Now()
+	for i, isDah := range []bool{false, false, true, false, false} {
+		if i > 0 {
+			now = now.Add(60 * time.Millisecond)
+			decoder.HandleToneEvent(dsp.ToneEvent{ToneOn: true, Duration: 60 * time.Millisecond, Timestamp: now})
+		}
+		tone := 60 * time.Millisecond
+		if isDah {
+			tone = 180 * time.Millisecond
+		}
+		now = now.Add(tone)
+		decoder.HandleToneEvent(dsp.ToneEvent{ToneOn: false, Duration: tone, Timestamp: now})
+	}
+	decoder.Flush()
+
+	got := outputs()
+	if len(got) != 3 {
+		t.Fatalf("got %d outputs, want 3: %+v", len(got), got)
+	}
+	if got[0].Character != 'F' || !got[0].Provisional {
+		t.Errorf("first output = %+v, want provisional F", got[0])
+	}
+	if got[1].Character != 'F' || !got[1].Retracted || got[1].IsFinal() {
+		t.Errorf("second output = %+v, want F retracted", got[1])
+	}
+	if !got[2].IsWordSpace {
+		t.Errorf("third output = %+v, want word space", got[2])
+	}
+}
+
+func TestDecoder_SpeculativeDisabled(t *testing.T) {
+	decoder, outputs := collectOutputs(t, false)
+
+	sendText(decoder, "QE", 60, 180, 420, time.Now())
+	for _, output := range outputs() {
+		if !output.IsFinal() {
+			t.Errorf("output %+v is not final with speculation disabled", output)
+		}
+	}
+	if got := outputs(); len(got) != 1 || got[0].Character != 'Q' {
+		t.Errorf("outputs = %+v, want only the final Q", got)
+	}
+}
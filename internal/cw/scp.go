@@ -0,0 +1,249 @@
+// internal/cw/scp.go
+package cw
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Super-check-partial matching constants
+const (
+	// DefaultSCPMaxDistance is the default maximum element edit distance for a callsign candidate
+	DefaultSCPMaxDistance = 2
+	// MaxSCPCandidates is the maximum number of candidates returned by Nearest
+	MaxSCPCandidates = 5
+	// SCPAmbiguityPenalty scales confidence down when several calls share the best distance
+	SCPAmbiguityPenalty = 0.5
+	// scpCharBreak separates characters in an element string
+	scpCharBreak = ' '
+)
+
+// ErrEmptySCP indicates the super-check-partial file contained no callsigns
+var ErrEmptySCP = errors.New("super-check-partial file contains no callsigns")
+
+// baseCallsignPattern is a home callsign: prefix (1-3 chars ending in a letter,
+// or letter+digit), a call-area digit, then a 1-4 letter suffix.
+const baseCallsignPattern = `([A-Z]{1,2}|[0-9][A-Z]|[A-Z][0-9])[0-9][A-Z]{1,4}`
+
+var (
+	// baseCallsignShape matches a home callsign without portable designators
+	baseCallsignShape = regexp.MustCompile(`^` + baseCallsignPattern + `$`)
+	// callsignShape matches tokens that look like an amateur callsign, including
+	// portable prefixes and suffixes (e.g. K1ABC, DL/K1ABC/P, VP2E/W1AW).
+	callsignShape = regexp.MustCompile(`^([A-Z0-9]{1,4}/)?` + baseCallsignPattern + `(/[A-Z0-9]{1,4})?$`)
+)
+
+// SCPDatabase holds the known callsigns from a super-check-partial (MASTER.SCP) file,
+// bucketed by element count so that nearest-match searches only visit plausible calls.
+type SCPDatabase struct {
+	calls    map[string]bool
+	byLength map[int][]scpEntry
+}
+
+// scpEntry is a callsign with its precomputed element string
+type scpEntry struct {
+	call     string
+	elements string
+}
+
+// SCPMatch is a candidate callsign for an observed element sequence
+type SCPMatch struct {
+	// Call is the candidate callsign from the database
+	Call string
+	// Distance is the element edit distance between the observation and the candidate
+	Distance int
+	// Confidence is the match confidence (0.0-1.0)
+	Confidence float64
+}
+
+// LoadSCPFile reads a super-check-partial file from disk.
+func LoadSCPFile(path string) (*SCPDatabase, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open scp file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return LoadSCP(f)
+}
+
+// LoadSCP parses a super-check-partial file: one callsign per line,
+// with lines starting with '#' treated as comments.
+func LoadSCP(r io.Reader) (*SCPDatabase, error) {
+	db := &SCPDatabase{
+		calls:    make(map[string]bool),
+		byLength: make(map[int][]scpEntry),
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		call := strings.ToUpper(strings.TrimSpace(scanner.Text()))
+		if call == "" || strings.HasPrefix(call, "#") || db.calls[call] {
+			continue
+		}
+		elements, count, ok := textToElementString(call)
+		if !ok {
+			continue // Contains characters with no Morse representation
+		}
+		db.calls[call] = true
+		db.byLength[count] = append(db.byLength[count], scpEntry{call: call, elements: elements})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read scp file: %w", err)
+	}
+	if len(db.calls) == 0 {
+		return nil, ErrEmptySCP
+	}
+
+	return db, nil
+}
+
+// Len returns the number of callsigns in the database
+func (db *SCPDatabase) Len() int {
+	return len(db.calls)
+}
+
+// Contains reports whether the callsign is in the database
+func (db *SCPDatabase) Contains(call string) bool {
+	return db.calls[strings.ToUpper(call)]
+}
+
+// Nearest returns the closest known callsigns to an observed element string
+// (as produced by ElementString), ordered by distance then callsign.
+// Only candidates within maxDistance edits are returned.
+func (db *SCPDatabase) Nearest(observed string, maxDistance int) []SCPMatch {
+	count := strings.Count(observed, ".") + strings.Count(observed, "-")
+
+	var matches []SCPMatch
+	// Each edit changes the element count by at most one, so only nearby buckets can match
+	for length := count - maxDistance; length <= count+maxDistance; length++ {
+		for _, entry := range db.byLength[length] {
+			distance := elementDistance(observed, entry.elements)
+			if distance <= maxDistance {
+				matches = append(matches, SCPMatch{Call: entry.call, Distance: distance})
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].Call < matches[j].Call
+	})
+	if len(matches) > MaxSCPCandidates {
+		matches = matches[:MaxSCPCandidates]
+	}
+
+	// Confidence falls with distance and when the best distance is shared
+	tied := 0
+	for _, m := range matches {
+		if m.Distance == matches[0].Distance {
+			tied++
+		}
+	}
+	symbols := float64(len(observed))
+	for i := range matches {
+		confidence := 1.0 - float64(matches[i].Distance)/max(symbols, 1)
+		if tied > 1 && matches[i].Distance == matches[0].Distance {
+			confidence *= SCPAmbiguityPenalty
+		}
+		matches[i].Confidence = max(confidence, 0)
+	}
+
+	return matches
+}
+
+// IsCallsignShaped reports whether a decoded token looks like an amateur callsign
+func IsCallsignShaped(token string) bool {
+	return callsignShape.MatchString(strings.ToUpper(token))
+}
+
+// IsBaseCallsign reports whether a token is shaped like a home callsign, without
+// portable prefixes or suffixes
+func IsBaseCallsign(token string) bool {
+	return baseCallsignShape.MatchString(strings.ToUpper(token))
+}
+
+// ElementString renders elements as dits, dahs and character breaks (e.g. "-.- .----").
+// Breaks come from each element's IsCharEnd flag, so mis-spaced characters
+// show up as extra or missing breaks rather than different letters.
+func ElementString(elements []Element) string {
+	var sb strings.Builder
+	for i, elem := range elements {
+		if elem.IsDah {
+			sb.WriteByte('-')
+		} else {
+			sb.WriteByte('.')
+		}
+		if elem.IsCharEnd && i < len(elements)-1 {
+			sb.WriteByte(scpCharBreak)
+		}
+	}
+	return sb.String()
+}
+
+// PatternFromText builds a MorsePattern for text with character breaks in place.
+// Returns false if any character has no Morse representation.
+func PatternFromText(text string) (MorsePattern, bool) {
+	pattern := MorsePattern{Text: text}
+	for _, char := range text {
+		elements, ok := EncodeCharacter(char)
+		if !ok {
+			return MorsePattern{}, false
+		}
+		if len(pattern.Elements) > 0 {
+			pattern.Breaks = append(pattern.Breaks, len(pattern.Elements)-1)
+		}
+		pattern.Elements = append(pattern.Elements, elements...)
+	}
+	return pattern, len(pattern.Elements) > 0
+}
+
+// textToElementString converts text to an element string and element count
+func textToElementString(text string) (string, int, bool) {
+	pattern, ok := PatternFromText(text)
+	if !ok {
+		return "", 0, false
+	}
+
+	elements := make([]Element, len(pattern.Elements))
+	for i, isDah := range pattern.Elements {
+		elements[i].IsDah = isDah
+	}
+	for _, breakIdx := range pattern.Breaks {
+		elements[breakIdx].IsCharEnd = true
+	}
+	return ElementString(elements), len(elements), true
+}
+
+// elementDistance is the Levenshtein distance between two element strings.
+// Dit/dah substitutions, dropped or extra elements and misplaced character
+// breaks each cost one edit.
+func elementDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,325 @@
+package cw
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSCP = `# Test super check partial
+K1ABC
+K1ABD
+W1AW
+DL1XYZ
+k1abc
+`
+
+func TestLoadSCP(t *testing.T) {
+	db, err := LoadSCP(strings.NewReader(testSCP))
+	if err != nil {
+		t.Fatalf("LoadSCP() error = %v", err)
+	}
+
+	// Duplicate (case-insensitive) and comment lines are skipped
+	if db.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", db.Len())
+	}
+	if !db.Contains("k1abc") {
+		t.Error("Contains(k1abc) = false, want true")
+	}
+	if db.Contains("N0CALL") {
+		t.Error("Contains(N0CALL) = true, want false")
+	}
+}
+
+func TestLoadSCP_Empty(t *testing.T) {
+	_, err := LoadSCP(strings.NewReader("# only a comment\n\n"))
+	if !errors.Is(err, ErrEmptySCP) {
+		t.Errorf("LoadSCP() error = %v, want %v", err, ErrEmptySCP)
+	}
+}
+
+func TestLoadSCPFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "MASTER.SCP")
+	if err := os.WriteFile(path, []byte(testSCP), 0644); err != nil {
+		t.Fatalf("failed to write scp file: %v", err)
+	}
+
+	db, err := LoadSCPFile(path)
+	if err != nil {
+		t.Fatalf("LoadSCPFile() error = %v", err)
+	}
+	if db.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", db.Len())
+	}
+
+	if _, err := LoadSCPFile(filepath.Join(t.TempDir(), "missing.scp")); err == nil {
+		t.Error("LoadSCPFile() with missing file should return error")
+	}
+}
+
+func TestIsBaseCallsign(t *testing.T) {
+	for token, want := range map[string]bool{
+		"K1ABC":      true,
+		"9A1AA":      true,
+		"vp2e":       true,
+		"DL/K1ABC/P": false,
+		"K1ABC/4":    false,
+		"DL":         false,
+		"599":        false,
+	} {
+		if got := IsBaseCallsign(token); got != want {
+			t.Errorf("IsBaseCallsign(%q) = %v, want %v", token, got, want)
+		}
+	}
+}
+
+func TestIsCallsignShaped(t *testing.T) {
+	tests := []struct {
+		token string
+		want  bool
+	}{
+		{"K1ABC", true},
+		{"W1AW", true},
+		{"DL1XYZ", true},
+		{"9A1AA", true},
+		{"VP2E", true},
+		{"DL/K1ABC/P", true},
+		{"k1abc", true},
+		{"CQ", false},
+		{"599", false},
+		{"TEST", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			if got := IsCallsignShaped(tt.token); got != tt.want {
+				t.Errorf("IsCallsignShaped(%q) = %v, want %v", tt.token, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElementString(t *testing.T) {
+	// K = -.- , 1 = .----
+	elements := []Element{
+		{IsDah: true}, {IsDah: false}, {IsDah: true, IsCharEnd: true},
+		{IsDah: false}, {IsDah: true}, {IsDah: true}, {IsDah: true}, {IsDah: true, IsCharEnd: true, IsWordEnd: true},
+	}
+
+	got := ElementString(elements)
+	if got != "-.- .----" {
+		t.Errorf("ElementString() = %q, want %q", got, "-.- .----")
+	}
+}
+
+func TestPatternFromText(t *testing.T) {
+	pattern, ok := PatternFromText("CQ")
+	if !ok {
+		t.Fatal("PatternFromText(CQ) returned false")
+	}
+
+	// Must agree with the hand-written CommonPatterns entry
+	expected := CommonPatterns[0]
+	if len(pattern.Elements) != len(expected.Elements) {
+		t.Fatalf("elements length = %d, want %d", len(pattern.Elements), len(expected.Elements))
+	}
+	for i := range expected.Elements {
+		if pattern.Elements[i] != expected.Elements[i] {
+			t.Errorf("element[%d] = %v, want %v", i, pattern.Elements[i], expected.Elements[i])
+		}
+	}
+	if len(pattern.Breaks) != 1 || pattern.Breaks[0] != 3 {
+		t.Errorf("breaks = %v, want [3]", pattern.Breaks)
+	}
+
+	if _, ok := PatternFromText("K1#"); ok {
+		t.Error("PatternFromText() with unencodable character should return false")
+	}
+}
+
+func TestElementDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want int
+	}{
+		{"identical", "-.- .----", "-.- .----", 0},
+		{"dit sent as dah", "-.- .----", "--- .----", 1},
+		{"missing break", "-.-.----", "-.- .----", 1},
+		{"extra element", "-.- .-----", "-.- .----", 1},
+		{"empty", "", "-.-", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := elementDistance(tt.a, tt.b); got != tt.want {
+				t.Errorf("elementDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSCPDatabase_Nearest(t *testing.T) {
+	db, err := LoadSCP(strings.NewReader("K1ABC\nW1AW\nDL1XYZ\n"))
+	if err != nil {
+		t.Fatalf("LoadSCP() error = %v", err)
+	}
+
+	// K1ABC with the break between A and B run together: "-.- .---- .--... -.-."
+	observed, _, _ := textToElementString("K1ABC")
+	observed = strings.Replace(observed, ".- -...", ".--...", 1)
+
+	matches := db.Nearest(observed, 2)
+	if len(matches) == 0 {
+		t.Fatal("Nearest() returned no matches")
+	}
+	if matches[0].Call != "K1ABC" {
+		t.Errorf("best match = %s, want K1ABC", matches[0].Call)
+	}
+	if matches[0].Distance != 1 {
+		t.Errorf("best distance = %d, want 1", matches[0].Distance)
+	}
+	if matches[0].Confidence <= 0.9 {
+		t.Errorf("best confidence = %.2f, want > 0.9", matches[0].Confidence)
+	}
+}
+
+func TestSCPDatabase_Nearest_Ambiguous(t *testing.T) {
+	db, err := LoadSCP(strings.NewReader("K1ABC\nK1ABD\n"))
+	if err != nil {
+		t.Fatalf("LoadSCP() error = %v", err)
+	}
+
+	// Final character is -.- (K), one edit from both C (-.-.) and D (-..)
+	observed, _, _ := textToElementString("K1ABK")
+
+	matches := db.Nearest(observed, 2)
+	if len(matches) < 2 {
+		t.Fatalf("Nearest() returned %d matches, want 2", len(matches))
+	}
+	if matches[0].Distance != matches[1].Distance {
+		t.Fatalf("expected a tie, got distances %d and %d", matches[0].Distance, matches[1].Distance)
+	}
+	if matches[0].Confidence >= 0.5 {
+		t.Errorf("tied confidence = %.2f, want < 0.5", matches[0].Confidence)
+	}
+}
+
+func TestSCPDatabase_Nearest_NoMatch(t *testing.T) {
+	db, err := LoadSCP(strings.NewReader("K1ABC\n"))
+	if err != nil {
+		t.Fatalf("LoadSCP() error = %v", err)
+	}
+
+	observed, _, _ := textToElementString("JA1XYZ")
+	if matches := db.Nearest(observed, 2); len(matches) != 0 {
+		t.Errorf("Nearest() = %v, want no matches", matches)
+	}
+}
+
+// recordText feeds text into the adaptive decoder as ideally timed elements,
+// optionally running characters together at the given character index.
+func recordText(a *AdaptiveDecoder, text string, ditMs int, runTogetherAfter int) {
+	dit := time.Duration(ditMs) * time.Millisecond
+	chars := []rune(text)
+	for c, char := range chars {
+		elements, _ := EncodeCharacter(char)
+		for i, isDah := range elements {
+			duration := dit
+			if isDah {
+				duration = 3 * dit
+			}
+			lastInChar := i == len(elements)-1
+			lastInWord := lastInChar && c == len(chars)-1
+			isCharEnd := lastInChar && c != runTogetherAfter
+			gap := dit
+			switch {
+			case lastInWord:
+				gap = 7 * dit
+			case lastInChar && c == runTogetherAfter:
+				gap = 2 * dit
+			case lastInChar:
+				gap = 3 * dit
+			}
+			a.RecordElement(isDah, duration, gap, isCharEnd || lastInWord, lastInWord)
+		}
+	}
+}
+
+func TestAdaptiveDecoder_CallsignCorrection(t *testing.T) {
+	decoder, err := NewDecoder(validConfig())
+	if err != nil {
+		t.Fatalf("NewDecoder() error = %v", err)
+	}
+
+	db, err := LoadSCP(strings.NewReader("K1ABC\nW1AW\n"))
+	if err != nil {
+		t.Fatalf("LoadSCP() error = %v", err)
+	}
+
+	adaptive := NewAdaptiveDecoder(decoder, AdaptiveConfig{
+		Enabled:             true,
+		MinConfidence:       0.7,
+		MinMatchesForAdjust: 1,
+		AdjustmentRate:      0.5,
+		SCP:                 db,
+	})
+
+	var outputs []CorrectedOutput
+	adaptive.SetCorrectedCallback(func(output CorrectedOutput) {
+		if output.Candidates != nil {
+			outputs = append(outputs, output)
+		}
+	})
+
+	// A and B run together overflow the tree, leaving the callsign-shaped "K1C"
+	recordText(adaptive, "K1ABC", 80, 2)
+
+	if len(outputs) != 1 {
+		t.Fatalf("received %d callsign corrections, want 1", len(outputs))
+	}
+	if outputs[0].Corrected != "K1ABC" {
+		t.Errorf("Corrected = %q, want K1ABC", outputs[0].Corrected)
+	}
+	if outputs[0].Original == "K1ABC" {
+		t.Error("Original should be the mis-spaced decode, not the correction")
+	}
+	if counts := adaptive.GetPatternMatchCounts(); counts["K1ABC"] != 1 {
+		t.Errorf("pattern match count for K1ABC = %d, want 1", counts["K1ABC"])
+	}
+	if !outputs[0].TimingAdjusted {
+		t.Error("callsign match should feed the timing adjustment")
+	}
+}
+
+func TestAdaptiveDecoder_CallsignCorrection_NotCallsign(t *testing.T) {
+	decoder, err := NewDecoder(validConfig())
+	if err != nil {
+		t.Fatalf("NewDecoder() error = %v", err)
+	}
+
+	db, err := LoadSCP(strings.NewReader("K1ABC\n"))
+	if err != nil {
+		t.Fatalf("LoadSCP() error = %v", err)
+	}
+
+	adaptive := NewAdaptiveDecoder(decoder, AdaptiveConfig{Enabled: true, SCP: db})
+
+	called := false
+	adaptive.SetCorrectedCallback(func(output CorrectedOutput) {
+		if output.Candidates != nil {
+			called = true
+		}
+	})
+
+	recordText(adaptive, "TEST", 80, -1)
+
+	if called {
+		t.Error("non-callsign word should not trigger callsign correction")
+	}
+}
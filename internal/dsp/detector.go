@@ -100,6 +100,10 @@ type Detector struct {
 	// Timing for duration calculation
 	lastTransition time.Time
 
+	// Sample clock for offline input (zero clockStart = wall clock)
+	clockStart  time.Time
+	bufferStart int64 // absolute index of overlapBuffer[0] in the input stream
+
 	// Callback for tone events (atomic for thread safety)
 	callbackPtr atomic.Pointer[ToneCallback]
 }
@@ -146,6 +150,14 @@ func NewDetector(cfg DetectorConfig, goertzel *Goertzel) (*Detector, error) {
 	}, nil
 }
 
+// MinToneDuration returns the shortest tone or gap a detector with this block timing can
+// confirm: it must fill at least half a block to register, then hold for hysteresis-1 more hops.
+func MinToneDuration(sampleRate float64, blockSize, overlapPct, hysteresis int) time.Duration {
+	hopSize := blockSize - (blockSize*overlapPct)/100
+	samples := blockSize/2 + max(hysteresis-1, 0)*hopSize
+	return time.Duration(float64(samples) / sampleRate * float64(time.Second))
+}
+
 // SetCallback sets the callback for tone events.
 // The callback is invoked from the processing goroutine - it must be fast and non-blocking.
 func (d *Detector) SetCallback(cb ToneCallback) {
@@ -180,7 +192,9 @@ func (d *Detector) Process(samples []float32) {
 		if d.hopSize > 0 && d.hopSize < len(d.overlapBuffer) {
 			copy(d.overlapBuffer, d.overlapBuffer[d.hopSize:])
 			d.overlapBuffer = d.overlapBuffer[:len(d.overlapBuffer)-d.hopSize]
+			d.bufferStart += int64(d.hopSize)
 		} else {
+			d.bufferStart += int64(len(d.overlapBuffer))
 			d.overlapBuffer = d.overlapBuffer[:0]
 		}
 	}
@@ -294,7 +308,7 @@ func (d *Detector) applyAGC(magnitude float64) float64 {
 // The pendingStartTime captures when the pending state began, ensuring
 // accurate duration measurement (not when hysteresis confirmed the change).
 func (d *Detector) updateHysteresis(tonePresent bool, magnitude float64) {
-	now := time.Now()
+	now := d.now()
 
 	if tonePresent == d.toneState {
 		// State matches, reset hysteresis counter
@@ -344,6 +358,23 @@ func (d *Detector) updateHysteresis(tonePresent bool, magnitude float64) {
 	}
 }
 
+// UseSampleClock timestamps events by sample position from start instead of the wall clock.
+// Used for recorded audio, which is processed much faster than real time.
+func (d *Detector) UseSampleClock(start time.Time) {
+	d.clockStart = start
+}
+
+// now returns the time of the block being processed: the wall clock for live audio,
+// or the time of the block's last sample when a sample clock is in use
+func (d *Detector) now() time.Time {
+	if d.clockStart.IsZero() {
+		return time.Now()
+	}
+	sampleRate := d.goertzel.Config().SampleRate
+	position := float64(d.bufferStart+int64(d.blockSize)) / sampleRate
+	return d.clockStart.Add(time.Duration(position * float64(time.Second)))
+}
+
 // emitEvent calls the registered callback if set
 func (d *Detector) emitEvent(event ToneEvent) {
 	cbPtr := d.callbackPtr.Load()
@@ -372,6 +403,7 @@ func (d *Detector) Reset() {
 	d.hysteresisCount = 0
 	d.pendingStartTime = time.Time{}
 	d.lastTransition = time.Time{}
+	d.bufferStart = 0
 }
 
 // Config returns the current configuration
@@ -822,6 +822,48 @@ func TestDetector_OffFrequency_NoDetection(t *testing.T) {
 }
 
 // Benchmark for performance testing
+func TestDetector_UseSampleClock(t *testing.T) {
+	g := createTestGoertzel(t)
+	cfg := createTestDetectorConfig()
+	cfg.Hysteresis = 2
+	cfg.AGCEnabled = false
+
+	d, err := NewDetector(cfg, g)
+	if err != nil {
+		t.Fatalf("NewDetector failed: %v", err)
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d.UseSampleClock(start)
+
+	var events []ToneEvent
+	d.SetCallback(func(event ToneEvent) {
+		events = append(events, event)
+	})
+
+	// 0.5 s silence, 0.3 s tone, 0.5 s silence at 48 kHz, processed instantly
+	var samples []float32
+	samples = append(samples, generateSilence(24000)...)
+	samples = append(samples, generateSineWave(detectorTestToneFrequency, detectorTestSampleRate, 14400, 1.0)...)
+	samples = append(samples, generateSilence(24000)...)
+	for len(samples) > 0 {
+		n := min(1000, len(samples))
+		d.Process(samples[:n])
+		samples = samples[n:]
+	}
+
+	if len(events) != 2 || !events[0].ToneOn || events[1].ToneOn {
+		t.Fatalf("events = %+v, want tone on then off", events)
+	}
+	// Timestamps follow the audio, within a block hop (256 samples = 5.3 ms) plus the block length
+	onAt := events[0].Timestamp.Sub(start)
+	if onAt < 500*time.Millisecond || onAt > 520*time.Millisecond {
+		t.Errorf("tone on at %v, want about 500ms", onAt)
+	}
+	if duration := events[1].Duration; duration < 290*time.Millisecond || duration > 310*time.Millisecond {
+		t.Errorf("tone duration = %v, want about 300ms", duration)
+	}
+}
+
 func BenchmarkDetector_Process(b *testing.B) {
 	cfg := GoertzelConfig{
 		TargetFrequency: detectorTestToneFrequency,
@@ -882,3 +924,56 @@ func BenchmarkDetector_ProcessWithCallback(b *testing.B) {
 		d.Process(samples)
 	}
 }
+
+func TestMinToneDuration(t *testing.T) {
+	// Half of a 512 block, then 4 hops of 256
+	got := MinToneDuration(detectorTestSampleRate, detectorTestBlockSize, detectorTestOverlapPct, detectorTestHysteresis)
+	samples := 256.0 + 4*256
+	if want := time.Duration(samples / detectorTestSampleRate * float64(time.Second)); got != want {
+		t.Errorf("MinToneDuration() = %v, want %v", got, want)
+	}
+
+	// A tone comfortably longer than the minimum is detected; one far shorter is not
+	const blockSize, overlapPct, hysteresis = 128, 75, 2
+	shortest := MinToneDuration(detectorTestSampleRate, blockSize, overlapPct, hysteresis)
+	toneSamples := func(scale float64) int {
+		return int(scale * shortest.Seconds() * detectorTestSampleRate)
+	}
+	for _, tt := range []struct {
+		scale float64
+		want  bool
+	}{
+		{2, true},
+		{0.5, false},
+	} {
+		g, err := NewGoertzel(GoertzelConfig{
+			TargetFrequency: detectorTestToneFrequency,
+			SampleRate:      detectorTestSampleRate,
+			BlockSize:       blockSize,
+		})
+		if err != nil {
+			t.Fatalf("NewGoertzel() error = %v", err)
+		}
+		detector, err := NewDetector(DetectorConfig{
+			Threshold:  detectorTestThreshold,
+			Hysteresis: hysteresis,
+			OverlapPct: overlapPct,
+		}, g)
+		if err != nil {
+			t.Fatalf("NewDetector() error = %v", err)
+		}
+		detected := false
+		detector.SetCallback(func(event ToneEvent) {
+			if event.ToneOn {
+				detected = true
+			}
+		})
+
+		detector.Process(generateSilence(1024))
+		detector.Process(generateSineWave(detectorTestToneFrequency, detectorTestSampleRate, toneSamples(tt.scale), 1))
+		detector.Process(generateSilence(1024))
+		if detected != tt.want {
+			t.Errorf("tone of %.1fx the minimum detected = %v, want %v", tt.scale, detected, tt.want)
+		}
+	}
+}
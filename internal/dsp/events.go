@@ -0,0 +1,108 @@
+// internal/dsp/events.go
+package dsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// eventRecord is the JSON form of a ToneEvent, one per line of an event file
+type eventRecord struct {
+	ToneOn    bool      `json:"tone_on"`
+	Timestamp time.Time `json:"timestamp"`
+	// Duration is in nanoseconds so replayed events are exactly the recorded ones
+	Duration  int64   `json:"duration_ns"`
+	Magnitude float64 `json:"magnitude"`
+}
+
+// EventRecorder writes tone events to a JSON Lines file so a session's decoding
+// can be replayed without its audio.
+type EventRecorder struct {
+	mu  sync.Mutex
+	f   *os.File
+	w   *bufio.Writer
+	enc *json.Encoder
+	err error // first write error, reported by Close
+}
+
+// CreateEventRecorder creates (or truncates) an event file at path
+func CreateEventRecorder(path string) (*EventRecorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create event file: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	return &EventRecorder{f: f, w: w, enc: json.NewEncoder(w)}, nil
+}
+
+// Record appends one event. Signature matches ToneCallback so it can be chained directly.
+// Writes are buffered; an error stops recording and is returned by Close.
+func (r *EventRecorder) Record(event ToneEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	r.err = r.enc.Encode(eventRecord{
+		ToneOn:    event.ToneOn,
+		Timestamp: event.Timestamp,
+		Duration:  int64(event.Duration),
+		Magnitude: event.Magnitude,
+	})
+}
+
+// Close flushes and closes the file, returning the first error met while recording
+func (r *EventRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = r.w.Flush()
+	}
+	if err := r.f.Close(); err != nil && r.err == nil {
+		r.err = err
+	}
+	if r.err != nil {
+		return fmt.Errorf("write event file: %w", r.err)
+	}
+	return nil
+}
+
+// ReadEvents reads tone events written by an EventRecorder
+func ReadEvents(r io.Reader) ([]ToneEvent, error) {
+	var events []ToneEvent
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record eventRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("parse event on line %d: %w", line, err)
+		}
+		events = append(events, ToneEvent{
+			ToneOn:    record.ToneOn,
+			Timestamp: record.Timestamp,
+			Duration:  time.Duration(record.Duration),
+			Magnitude: record.Magnitude,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read events: %w", err)
+	}
+	return events, nil
+}
+
+// ReadEventsFile reads tone events from a file written by an EventRecorder
+func ReadEventsFile(path string) ([]ToneEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open event file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	return ReadEvents(f)
+}
@@ -0,0 +1,78 @@
+package dsp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventRecorder_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	recorder, err := CreateEventRecorder(path)
+	if err != nil {
+		t.Fatalf("CreateEventRecorder() error = %v", err)
+	}
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 123456789, time.UTC)
+	want := []ToneEvent{
+		{ToneOn: true, Timestamp: start, Magnitude: 0.8},
+		{ToneOn: false, Timestamp: start.Add(61 * time.Millisecond), Duration: 61*time.Millisecond + 250*time.Microsecond, Magnitude: 0.1},
+		{ToneOn: true, Timestamp: start.Add(120 * time.Millisecond), Duration: 59 * time.Millisecond, Magnitude: 0.75},
+	}
+	for _, event := range want {
+		recorder.Record(event)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	got, err := ReadEventsFile(path)
+	if err != nil {
+		t.Fatalf("ReadEventsFile() error = %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("read %d events, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].ToneOn != want[i].ToneOn || !got[i].Timestamp.Equal(want[i].Timestamp) ||
+			got[i].Duration != want[i].Duration || got[i].Magnitude != want[i].Magnitude {
+			t.Errorf("event %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestReadEvents_SkipsBlankLines(t *testing.T) {
+	input := `{"tone_on":true,"timestamp":"2024-01-01T12:00:00Z","duration_ns":0,"magnitude":0.5}
+
+{"tone_on":false,"timestamp":"2024-01-01T12:00:00.06Z","duration_ns":60000000,"magnitude":0.1}
+`
+	events, err := ReadEvents(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadEvents() error = %v", err)
+	}
+	if len(events) != 2 || events[1].Duration != 60*time.Millisecond {
+		t.Errorf("events = %+v, want two with the second lasting 60ms", events)
+	}
+}
+
+func TestReadEvents_Malformed(t *testing.T) {
+	input := "{\"tone_on\":true}\nnot json\n"
+	_, err := ReadEvents(strings.NewReader(input))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("ReadEvents() error = %v, want a parse error on line 2", err)
+	}
+}
+
+func TestEventRecorder_CreateError(t *testing.T) {
+	if _, err := CreateEventRecorder(filepath.Join(t.TempDir(), "missing", "events.jsonl")); err == nil {
+		t.Error("CreateEventRecorder() should fail in a missing directory")
+	}
+}
+
+func TestReadEventsFile_Missing(t *testing.T) {
+	if _, err := ReadEventsFile(filepath.Join(os.TempDir(), "does-not-exist.jsonl")); err == nil {
+		t.Error("ReadEventsFile() should fail for a missing file")
+	}
+}
@@ -0,0 +1,38 @@
+// internal/dsp/keyline.go
+package dsp
+
+import "time"
+
+// Tone event magnitudes for a keying line, which is either fully on or off
+const (
+	// KeyDownMagnitude is the magnitude of an event starting a mark
+	KeyDownMagnitude = 1.0
+	// KeyUpMagnitude is the magnitude of an event ending a mark
+	KeyUpMagnitude = 0.0
+)
+
+// KeyLine turns the key going down and up, as captured from a keying line or received
+// over a wire, into the tone events the detector would report. The key is taken to be
+// up before it first goes down.
+type KeyLine struct {
+	last    time.Time
+	started bool
+}
+
+// Down returns the event of the key going down at t, which ends the space since it last came up
+func (k *KeyLine) Down(t time.Time) ToneEvent {
+	event := ToneEvent{ToneOn: true, Timestamp: t, Magnitude: KeyDownMagnitude}
+	if k.started {
+		event.Duration = t.Sub(k.last)
+	}
+	k.last = t
+	k.started = true
+	return event
+}
+
+// Up returns the event of the key coming up at t, which ends the mark since it went down
+func (k *KeyLine) Up(t time.Time) ToneEvent {
+	event := ToneEvent{Timestamp: t, Duration: t.Sub(k.last), Magnitude: KeyUpMagnitude}
+	k.last = t
+	return event
+}
@@ -0,0 +1,25 @@
+package dsp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyLine(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	var k KeyLine
+	events := []ToneEvent{k.Down(at(100)), k.Up(at(160)), k.Down(at(220)), k.Up(at(400))}
+	want := []ToneEvent{
+		{ToneOn: true, Timestamp: at(100), Magnitude: KeyDownMagnitude},
+		{Timestamp: at(160), Duration: 60 * time.Millisecond, Magnitude: KeyUpMagnitude},
+		{ToneOn: true, Timestamp: at(220), Duration: 60 * time.Millisecond, Magnitude: KeyDownMagnitude},
+		{Timestamp: at(400), Duration: 180 * time.Millisecond, Magnitude: KeyUpMagnitude},
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,485 @@
+// internal/ensemble/ensemble.go
+// Package ensemble runs several detector and decoder parameter sets on the same audio
+// and votes their aligned character streams into one consensus output.
+package ensemble
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+	"github.com/ColonelBlimp/cwdecoder/internal/pipeline"
+)
+
+// Ensemble constants
+const (
+	// DefaultMaxDelay is how long a character waits for slow members before it is decided live
+	DefaultMaxDelay = 3 * time.Second
+	// DefaultAlignDits is how close, in dits, two members' characters must end to be the same character
+	DefaultAlignDits = 1.5
+
+	// InitialReliability is each member's vote weight before it has been compared with the consensus
+	InitialReliability = 1.0
+	// ReliabilityRate is how quickly a member's reliability follows its agreement with the consensus
+	ReliabilityRate = 0.1
+	// MinReliability keeps an outvoted member able to win again when conditions change
+	MinReliability = 0.05
+	// AbstainConfidence is the confidence of a member's implied vote that there was no character
+	AbstainConfidence = 0.5
+	// MinConfidence is the lowest timing confidence a decoded character can have
+	MinConfidence = 0.05
+)
+
+// Config holds ensemble configuration
+type Config struct {
+	// MaxDelay is how long a character waits on the wall clock for every member's vote (0 = DefaultMaxDelay)
+	MaxDelay time.Duration
+	// AlignDits is the alignment tolerance between members in dits (0 = DefaultAlignDits)
+	AlignDits float64
+}
+
+// MemberStats summarises how a member has voted
+type MemberStats struct {
+	Name        string  `json:"name"`
+	Reliability float64 `json:"reliability"`
+	Votes       int     `json:"votes"`
+	Agreed      int     `json:"agreed"`
+}
+
+// Member is one detector and decoder parameter set in the ensemble.
+// Its decoder's element and output callbacks must feed RecordElement and HandleOutput.
+type Member struct {
+	ensemble *Ensemble
+	index    int
+	name     string
+	process  func(samples []float32)
+	flush    func()
+
+	// Guarded by ensemble.mu
+	elements    []element
+	charDone    bool
+	lastGap     time.Duration
+	watermark   time.Time
+	lastSlot    *slot
+	reliability float64
+	votes       int
+	agreed      int
+}
+
+// element is one mark of the character a member is decoding
+type element struct {
+	isDah    bool
+	duration time.Duration
+	gapAfter time.Duration
+}
+
+// vote is a member's decoded character for a slot
+type vote struct {
+	output     cw.DecodedOutput
+	confidence float64
+	wordSpace  bool
+}
+
+// slot collects the members' votes for one character position
+type slot struct {
+	anchor    time.Time
+	tolerance time.Duration
+	votes     map[int]*vote
+}
+
+// Ensemble aligns the character streams of several members and emits the weighted consensus
+type Ensemble struct {
+	config      Config
+	members     []*Member
+	sampleClock bool
+
+	mu           sync.Mutex
+	slots        []*slot
+	decidedUntil time.Time
+	callback     cw.DecodedCallback
+}
+
+// New creates an empty ensemble
+func New(cfg Config) *Ensemble {
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = DefaultMaxDelay
+	}
+	if cfg.AlignDits <= 0 {
+		cfg.AlignDits = DefaultAlignDits
+	}
+	return &Ensemble{config: cfg}
+}
+
+// MemberName returns the display name of the i-th member built from settings:
+// the configured settings first, then each ensemble entry.
+func MemberName(i int) string {
+	if i == 0 {
+		return "config"
+	}
+	return fmt.Sprintf("ensemble[%d]", i-1)
+}
+
+// AddMember adds a member. process receives every buffer passed to Process and
+// flush is called by Flush; either may be nil. Members must be added before Process.
+func (e *Ensemble) AddMember(name string, process func(samples []float32), flush func()) *Member {
+	m := &Member{
+		ensemble:    e,
+		index:       len(e.members),
+		name:        name,
+		process:     process,
+		flush:       flush,
+		reliability: InitialReliability,
+	}
+	e.members = append(e.members, m)
+	return m
+}
+
+// AddPipeline adds a pipeline as a member, wiring its decoder's callbacks to the member
+func (e *Ensemble) AddPipeline(name string, p *pipeline.Pipeline) *Member {
+	m := e.AddMember(name, p.Process, p.Flush)
+	p.OnElement(m.RecordElement)
+	p.OnOutput(m.HandleOutput)
+	return m
+}
+
+// SetCallback sets the callback for consensus characters and word spaces
+func (e *Ensemble) SetCallback(cb cw.DecodedCallback) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.callback = cb
+}
+
+// UseSampleClock is for members timed from the samples rather than the wall clock.
+// Characters are then decided only once every member has moved past them.
+func (e *Ensemble) UseSampleClock() {
+	e.sampleClock = true
+}
+
+// Process runs every member on samples in parallel, then emits the characters all members have voted on
+func (e *Ensemble) Process(samples []float32) {
+	var wg sync.WaitGroup
+	for _, m := range e.members {
+		if m.process == nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.process(samples)
+		}()
+	}
+	wg.Wait()
+
+	var now time.Time
+	if !e.sampleClock {
+		now = time.Now()
+	}
+	e.decideAndEmit(now, false)
+}
+
+// Flush flushes every member and emits all remaining characters
+func (e *Ensemble) Flush() {
+	for _, m := range e.members {
+		if m.flush != nil {
+			m.flush()
+		}
+	}
+
+	e.decideAndEmit(time.Time{}, true)
+}
+
+// Members returns each member's voting statistics
+func (e *Ensemble) Members() []MemberStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	stats := make([]MemberStats, len(e.members))
+	for i, m := range e.members {
+		stats[i] = MemberStats{Name: m.name, Reliability: m.reliability, Votes: m.votes, Agreed: m.agreed}
+	}
+	return stats
+}
+
+// Name returns the member's name
+func (m *Member) Name() string {
+	return m.name
+}
+
+// RecordElement records an element of the character the member is decoding.
+// Matches the cw.ElementCallback signature.
+func (m *Member) RecordElement(isDah bool, duration, gapAfter time.Duration, isCharEnd, _ bool) {
+	e := m.ensemble
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if m.charDone {
+		m.elements = m.elements[:0]
+		m.charDone = false
+	}
+	m.elements = append(m.elements, element{isDah: isDah, duration: duration, gapAfter: gapAfter})
+	if isCharEnd {
+		m.charDone = true
+		m.lastGap = gapAfter
+	}
+}
+
+// HandleOutput casts the member's vote for a decoded character or word space.
+// Matches the cw.DecodedCallback signature.
+func (m *Member) HandleOutput(output cw.DecodedOutput) {
+	// Only final characters vote
+	if !output.IsFinal() {
+		return
+	}
+
+	e := m.ensemble
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if output.Timestamp.After(m.watermark) {
+		m.watermark = output.Timestamp
+	}
+
+	if output.IsWordSpace {
+		if m.lastSlot != nil {
+			if v := m.lastSlot.votes[m.index]; v != nil {
+				v.wordSpace = true
+			}
+		}
+		return
+	}
+	if output.Character == 0 {
+		return
+	}
+
+	// Characters are aligned on the end of their last mark, which the
+	// decoder reports one gap before the character is emitted
+	var anchor time.Time
+	var confidence float64
+	if m.charDone {
+		anchor = output.Timestamp.Add(-m.lastGap)
+		confidence = timingConfidence(m.elements, output.CurrentWPM)
+	} else {
+		anchor = output.Timestamp
+		confidence = AbstainConfidence
+	}
+	m.elements = m.elements[:0]
+	m.charDone = false
+
+	m.lastSlot = e.addVote(m, anchor, cw.DitDuration(output.CurrentWPM), &vote{output: output, confidence: confidence})
+}
+
+// addVote places a vote in the slot whose anchor is within tolerance, or a new slot.
+// Votes for positions already decided are dropped.
+func (e *Ensemble) addVote(m *Member, anchor time.Time, dit time.Duration, v *vote) *slot {
+	tolerance := time.Duration(float64(dit) * e.config.AlignDits)
+	if !e.decidedUntil.IsZero() && !anchor.After(e.decidedUntil.Add(tolerance)) {
+		return nil
+	}
+	m.votes++
+
+	var best *slot
+	bestDistance := tolerance + 1
+	for _, s := range e.slots {
+		if s.votes[m.index] != nil {
+			continue
+		}
+		distance := s.anchor.Sub(anchor).Abs()
+		if distance <= max(tolerance, s.tolerance) && distance < bestDistance {
+			best, bestDistance = s, distance
+		}
+	}
+	if best == nil {
+		best = &slot{anchor: anchor, votes: make(map[int]*vote)}
+		e.slots = append(e.slots, best)
+		sort.SliceStable(e.slots, func(i, j int) bool { return e.slots[i].anchor.Before(e.slots[j].anchor) })
+	}
+	best.tolerance = max(best.tolerance, tolerance)
+	best.votes[m.index] = v
+	return best
+}
+
+// decideAndEmit decides the complete slots, then sends the consensus to the callback
+// after releasing the lock, so the callback may call back into the ensemble
+func (e *Ensemble) decideAndEmit(now time.Time, force bool) {
+	e.mu.Lock()
+	outputs := e.decide(now, force)
+	callback := e.callback
+	e.mu.Unlock()
+
+	if callback == nil {
+		return
+	}
+	for _, output := range outputs {
+		callback(output)
+	}
+}
+
+// decide resolves slots in order while they are complete: every member has voted or moved
+// past them, or now is more than MaxDelay after them. force decides every slot.
+// Returns the consensus characters and word spaces in order.
+func (e *Ensemble) decide(now time.Time, force bool) []cw.DecodedOutput {
+	var outputs []cw.DecodedOutput
+	for len(e.slots) > 0 {
+		s := e.slots[0]
+		if !force && !e.complete(s, now) {
+			break
+		}
+		e.slots = e.slots[1:]
+		e.decidedUntil = s.anchor
+		outputs = e.resolve(s, outputs)
+	}
+	return outputs
+}
+
+// complete reports whether no more votes are expected for a slot
+func (e *Ensemble) complete(s *slot, now time.Time) bool {
+	if !now.IsZero() && now.Sub(s.anchor) >= e.config.MaxDelay {
+		return true
+	}
+	for _, m := range e.members {
+		if s.votes[m.index] == nil && !m.watermark.After(s.anchor.Add(s.tolerance)) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolve chooses a slot's character by reliability and confidence weighted vote,
+// updates each member's reliability and appends the result to outputs
+func (e *Ensemble) resolve(s *slot, outputs []cw.DecodedOutput) []cw.DecodedOutput {
+	weights := make(map[rune]float64)
+	var abstain, space, noSpace float64
+	for _, m := range e.members {
+		v := s.votes[m.index]
+		if v == nil {
+			abstain += m.reliability * AbstainConfidence
+			continue
+		}
+		w := m.reliability * v.confidence
+		weights[v.output.Character] += w
+		if v.wordSpace {
+			space += w
+		} else {
+			noSpace += w
+		}
+	}
+
+	// Members are visited in order so ties go to the earlier member's character,
+	// and the winning character is reported with its strongest vote's timing
+	var winner *vote
+	var winnerWeight, voteWeight float64
+	for _, m := range e.members {
+		v := s.votes[m.index]
+		if v == nil {
+			continue
+		}
+		w := weights[v.output.Character]
+		own := m.reliability * v.confidence
+		switch {
+		case winner == nil || w > winnerWeight:
+			winner, winnerWeight, voteWeight = v, w, own
+		case v.output.Character == winner.output.Character && own > voteWeight:
+			winner, voteWeight = v, own
+		}
+	}
+	if winner != nil && abstain > winnerWeight {
+		winner = nil
+	}
+
+	for _, m := range e.members {
+		v := s.votes[m.index]
+		agree := (winner == nil && v == nil) || (winner != nil && v != nil && v.output.Character == winner.output.Character)
+		target := 0.0
+		if agree {
+			target = 1.0
+			m.agreed++
+		}
+		m.reliability = max(MinReliability, m.reliability+ReliabilityRate*(target-m.reliability))
+	}
+
+	if winner == nil {
+		return outputs
+	}
+	outputs = append(outputs, winner.output)
+	if space > noSpace {
+		output := winner.output
+		output.Character = ' '
+		output.IsWordSpace = true
+		outputs = append(outputs, output)
+	}
+	return outputs
+}
+
+// timingConfidence scores how well a character's marks and intra-character gaps fit
+// ideal dits and dahs at wpm, from 1 (exact) down to MinConfidence
+func timingConfidence(elements []element, wpm int) float64 {
+	if len(elements) == 0 || wpm <= 0 {
+		return AbstainConfidence
+	}
+	dit := cw.DitDuration(wpm)
+
+	var score float64
+	var n int
+	for i, el := range elements {
+		expected := dit
+		if el.isDah {
+			expected = time.Duration(cw.DahDitRatio * float64(dit))
+		}
+		score += fit(el.duration, expected)
+		n++
+		// The gap after the last mark is a character or word space
+		if i < len(elements)-1 {
+			score += fit(el.gapAfter, dit)
+			n++
+		}
+	}
+	return max(MinConfidence, score/float64(n))
+}
+
+// fit scores a duration against its expected length: 1 when equal, 0 at half or double
+func fit(actual, expected time.Duration) float64 {
+	if actual <= 0 || expected <= 0 {
+		return 0
+	}
+	return max(0, 1-math.Abs(math.Log2(float64(actual)/float64(expected))))
+}
+
+// Decode runs samples recorded at sampleRate through the configured settings and every
+// ensemble member, returning the consensus text. Without an ensemble it is pipeline.Decode.
+func Decode(settings config.Settings, samples []float32, sampleRate float64) (string, error) {
+	settings.SampleRate = sampleRate
+	members, err := settings.EnsembleSettings()
+	if err != nil {
+		return "", err
+	}
+	if len(members) == 0 {
+		return pipeline.Decode(settings, samples, sampleRate)
+	}
+
+	e := New(Config{})
+	e.UseSampleClock()
+	for i, s := range append([]config.Settings{settings}, members...) {
+		p, err := pipeline.New(s)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", MemberName(i), err)
+		}
+		p.UseSampleClock(pipeline.SampleClockStart)
+		e.AddPipeline(MemberName(i), p)
+	}
+
+	var text strings.Builder
+	e.SetCallback(func(output cw.DecodedOutput) {
+		if output.IsWordSpace {
+			text.WriteRune(' ')
+		} else {
+			text.WriteRune(output.Character)
+		}
+	})
+	e.Process(samples)
+	e.Flush()
+	return strings.Join(strings.Fields(text.String()), " "), nil
+}
@@ -0,0 +1,304 @@
+package ensemble
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+	"github.com/ColonelBlimp/cwdecoder/internal/synth"
+)
+
+const testWPM = 20
+
+// epoch is the sample clock start used by the voting tests
+var epoch = time.Unix(0, 0).UTC()
+
+// at returns the time offset seconds after epoch
+func at(seconds float64) time.Time {
+	return epoch.Add(time.Duration(seconds * float64(time.Second)))
+}
+
+// send feeds a member the elements and output of char, whose last mark ends at end.
+// Marks are scaled by stretch to model poor timing.
+func send(t *testing.T, m *Member, char rune, end time.Time, stretch float64, wordSpace bool) {
+	t.Helper()
+	elements, ok := cw.EncodeCharacter(char)
+	if !ok {
+		t.Fatalf("EncodeCharacter(%q) failed", char)
+	}
+
+	dit := cw.DitDuration(testWPM)
+	gap := 3 * dit
+	if wordSpace {
+		gap = 7 * dit
+	}
+	for i, isDah := range elements {
+		duration := dit
+		if isDah {
+			duration = 3 * dit
+		}
+		duration = time.Duration(float64(duration) * stretch)
+		last := i == len(elements)-1
+		gapAfter := dit
+		if last {
+			gapAfter = gap
+		}
+		m.RecordElement(isDah, duration, gapAfter, last, last && wordSpace)
+	}
+
+	emitted := end.Add(gap)
+	m.HandleOutput(cw.DecodedOutput{Character: char, Timestamp: emitted, CurrentWPM: testWPM})
+	if wordSpace {
+		m.HandleOutput(cw.DecodedOutput{Character: ' ', IsWordSpace: true, Timestamp: emitted, CurrentWPM: testWPM})
+	}
+}
+
+// newTestEnsemble returns a sample-clocked ensemble of n members and the text it emits
+func newTestEnsemble(n int) (*Ensemble, []*Member, *strings.Builder) {
+	e := New(Config{})
+	e.UseSampleClock()
+	members := make([]*Member, n)
+	for i := range members {
+		members[i] = e.AddMember(MemberName(i), nil, nil)
+	}
+	text := &strings.Builder{}
+	e.SetCallback(func(output cw.DecodedOutput) {
+		text.WriteRune(output.Character)
+	})
+	return e, members, text
+}
+
+func TestEnsemble_MajorityWins(t *testing.T) {
+	e, m, text := newTestEnsemble(3)
+
+	send(t, m[0], 'A', at(1), 1, false)
+	send(t, m[1], 'A', at(1), 1, false)
+	send(t, m[2], 'N', at(1.02), 1, false)
+	for _, member := range m {
+		send(t, member, 'B', at(2), 1, false)
+	}
+	e.Flush()
+
+	if got := text.String(); got != "AB" {
+		t.Errorf("consensus = %q, want %q", got, "AB")
+	}
+	stats := e.Members()
+	if stats[2].Reliability >= stats[0].Reliability {
+		t.Errorf("outvoted member reliability %v should be below %v", stats[2].Reliability, stats[0].Reliability)
+	}
+	if stats[0].Votes != 2 || stats[0].Agreed != 2 || stats[2].Agreed != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestEnsemble_AbstentionsRejectNoise(t *testing.T) {
+	e, m, text := newTestEnsemble(3)
+
+	// Only the most sensitive member hears a false character, with noise-like timing
+	send(t, m[2], 'E', at(0.5), 1.8, false)
+	for _, member := range m {
+		send(t, member, 'T', at(1), 1, false)
+	}
+	e.Flush()
+
+	if got := text.String(); got != "T" {
+		t.Errorf("consensus = %q, want %q", got, "T")
+	}
+}
+
+func TestEnsemble_ConfidenceBreaksTie(t *testing.T) {
+	e, m, text := newTestEnsemble(2)
+
+	// The first member's marks are badly timed for its speed estimate
+	send(t, m[0], 'I', at(1), 1.8, false)
+	send(t, m[1], 'S', at(1), 1, false)
+	e.Flush()
+
+	if got := text.String(); got != "S" {
+		t.Errorf("consensus = %q, want %q", got, "S")
+	}
+}
+
+func TestEnsemble_WordSpaces(t *testing.T) {
+	e, m, text := newTestEnsemble(3)
+
+	send(t, m[0], 'K', at(1), 1, true)
+	send(t, m[1], 'K', at(1), 1, true)
+	send(t, m[2], 'K', at(1), 1, false)
+	for _, member := range m {
+		send(t, member, 'R', at(2), 1, false)
+	}
+	e.Flush()
+
+	if got := text.String(); got != "K R" {
+		t.Errorf("consensus = %q, want %q", got, "K R")
+	}
+}
+
+func TestEnsemble_WaitsForEveryMember(t *testing.T) {
+	e, m, text := newTestEnsemble(2)
+
+	send(t, m[0], 'E', at(1), 1, false)
+	e.Process(nil)
+	if text.Len() != 0 {
+		t.Fatalf("emitted %q before every member voted", text.String())
+	}
+
+	send(t, m[1], 'E', at(1), 1, false)
+	e.Process(nil)
+	if got := text.String(); got != "E" {
+		t.Errorf("consensus = %q, want %q", got, "E")
+	}
+}
+
+func TestEnsemble_MaxDelay(t *testing.T) {
+	e := New(Config{MaxDelay: 10 * time.Millisecond})
+	m := []*Member{e.AddMember("fast", nil, nil), e.AddMember("silent", nil, nil)}
+	var text strings.Builder
+	e.SetCallback(func(output cw.DecodedOutput) {
+		text.WriteRune(output.Character)
+	})
+
+	// Live members run on the wall clock; the silent member never votes
+	end := time.Now().Add(-time.Second)
+	send(t, m[0], 'T', end, 1, false)
+	e.Process(nil)
+	if got := text.String(); got != "T" {
+		t.Errorf("consensus = %q, want %q", got, "T")
+	}
+
+	// Late votes for a decided position are dropped
+	send(t, m[1], 'T', end, 1, false)
+	e.Flush()
+	if got := text.String(); got != "T" {
+		t.Errorf("late vote changed consensus to %q", got)
+	}
+	if stats := e.Members(); stats[1].Votes != 0 {
+		t.Errorf("silent member votes = %d, want 0", stats[1].Votes)
+	}
+}
+
+func TestEnsemble_CallbackCanReenter(t *testing.T) {
+	e, m, _ := newTestEnsemble(1)
+
+	// A callback that reads member statistics would deadlock if called under the lock
+	var votes []int
+	e.SetCallback(func(cw.DecodedOutput) {
+		votes = append(votes, e.Members()[0].Votes)
+	})
+	send(t, m[0], 'A', at(1), 1, false)
+
+	done := make(chan struct{})
+	go func() {
+		e.Flush()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Flush() deadlocked calling back into the ensemble")
+	}
+	if len(votes) != 1 || votes[0] != 1 {
+		t.Errorf("callback saw votes %v, want [1]", votes)
+	}
+}
+
+func TestTimingConfidence(t *testing.T) {
+	dit := cw.DitDuration(testWPM)
+	exact := []element{{isDah: false, duration: dit, gapAfter: dit}, {isDah: true, duration: 3 * dit, gapAfter: 3 * dit}}
+	if got := timingConfidence(exact, testWPM); got != 1 {
+		t.Errorf("timingConfidence(exact) = %v, want 1", got)
+	}
+
+	sloppy := []element{{isDah: false, duration: dit * 3 / 2, gapAfter: dit / 2}, {isDah: true, duration: 2 * dit, gapAfter: 3 * dit}}
+	got := timingConfidence(sloppy, testWPM)
+	if got <= MinConfidence || got >= 1 {
+		t.Errorf("timingConfidence(sloppy) = %v, want between %v and 1", got, MinConfidence)
+	}
+
+	if got := timingConfidence(nil, testWPM); got != AbstainConfidence {
+		t.Errorf("timingConfidence(nil) = %v, want %v", got, AbstainConfidence)
+	}
+}
+
+// testSettings returns valid decoder settings for 20 WPM audio at 8 kHz
+func testSettings() config.Settings {
+	return config.Settings{
+		SampleRate:             8000,
+		Channels:               1,
+		Format:                 "S16_LE",
+		AudioSource:            "device",
+		BufferSize:             1024,
+		ToneFrequency:          600,
+		BlockSize:              128,
+		OverlapPct:             50,
+		Threshold:              0.4,
+		Hysteresis:             2,
+		AGCEnabled:             true,
+		AGCDecay:               0.9995,
+		AGCAttack:              0.1,
+		AGCWarmupBlocks:        10,
+		WPM:                    20,
+		AdaptiveTiming:         true,
+		AdaptiveSmoothing:      0.1,
+		DitDahBoundary:         2.0,
+		InterCharBoundary:      2.0,
+		CharWordBoundary:       5.0,
+		FarnsworthAuto:         true,
+		KeyMode:                "electronic",
+		Alphabet:               "latin",
+		MorseCode:              "international",
+		AdaptivePatternEnabled: true,
+		AdaptiveMinConfidence:  0.7,
+		AdaptiveAdjustmentRate: 0.1,
+		AdaptiveMinMatches:     3,
+		SCPMaxDistance:         2,
+	}
+}
+
+func TestDecode(t *testing.T) {
+	const text = "CQ CQ DE W1AW K"
+	signal, err := synth.Generate(synth.GeneratorConfig{
+		Synth: synth.Config{SampleRate: 8000, ToneFrequency: 600, Amplitude: synth.DefaultAmplitude, RiseTime: synth.DefaultRiseTime},
+		WPM:   20,
+	}, text)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		ensemble []map[string]any
+	}{
+		{"no ensemble", nil},
+		{"agreeing members", []map[string]any{{"threshold": 0.3, "hysteresis": 3}, {"block_size": 64}}},
+		// Alone, the fixed 40 WPM member splits every dah into dits and decodes nonsense
+		{"outvoted member", []map[string]any{{"threshold": 0.3}, {"wpm": 40, "adaptive_timing": false}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := testSettings()
+			settings.Ensemble = tt.ensemble
+			got, err := Decode(settings, signal.Samples, signal.SampleRate)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if got != text {
+				t.Errorf("Decode() = %q, want %q", got, text)
+			}
+		})
+	}
+}
+
+func TestDecode_InvalidMember(t *testing.T) {
+	settings := testSettings()
+	settings.Ensemble = []map[string]any{{"sample_rate": 48000}}
+
+	_, err := Decode(settings, make([]float32, 8000), 8000)
+	if !errors.Is(err, config.ErrUnknownEnsembleKey) {
+		t.Errorf("Decode() error = %v, want ErrUnknownEnsembleKey", err)
+	}
+}
@@ -0,0 +1,266 @@
+// internal/fist/analyzer.go
+// Package fist analyzes a sender's keying from decoded elements and reports
+// timing statistics for coaching.
+package fist
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+// Analysis constants
+const (
+	// DriftWindow is the span of keying time averaged into one speed drift sample
+	DriftWindow = 30 * time.Second
+	// MaxWordGapUnits excludes pauses between transmissions from word gap statistics.
+	// The decoder reports the silence that ends a transmission as a gap of about 10 units.
+	MaxWordGapUnits = 9.0
+	// StretchedIntraUnits is the intra-character gap (in dit units) above which a character is mis-spaced
+	StretchedIntraUnits = 1.5
+	// CrowdedInterUnits is the inter-character gap (in dit units) below which a character is mis-spaced
+	CrowdedInterUnits = 2.5
+	// MaxMisspacedReported is the number of mis-spaced characters listed in the report
+	MaxMisspacedReported = 5
+)
+
+// element is one recorded mark with the gap that followed it
+type element struct {
+	isDah     bool
+	duration  time.Duration
+	gapAfter  time.Duration
+	isCharEnd bool
+	isWordEnd bool
+	offset    time.Duration // keying time since the first element
+}
+
+// Analyzer collects elements from the decoder for a fist report
+type Analyzer struct {
+	mu       sync.Mutex
+	alphabet cw.Alphabet
+	code     cw.Code
+	elements []element
+	elapsed  time.Duration
+}
+
+// NewAnalyzer creates an empty fist analyzer that reads characters in the
+// alphabet and code the decoder is configured with
+func NewAnalyzer(alphabet cw.Alphabet, code cw.Code) *Analyzer {
+	return &Analyzer{alphabet: alphabet, code: code}
+}
+
+// RecordElement records one element and the gap after it.
+// Signature matches cw.ElementCallback so it can be chained directly.
+func (a *Analyzer) RecordElement(isDah bool, duration, gapAfter time.Duration, isCharEnd, isWordEnd bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.elements = append(a.elements, element{
+		isDah:     isDah,
+		duration:  duration,
+		gapAfter:  gapAfter,
+		isCharEnd: isCharEnd,
+		isWordEnd: isWordEnd,
+		offset:    a.elapsed,
+	})
+	a.elapsed += duration + gapAfter
+}
+
+// Reset discards all recorded elements
+func (a *Analyzer) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.elements = nil
+	a.elapsed = 0
+}
+
+// Report computes the fist report from the elements recorded so far
+func (a *Analyzer) Report() Report {
+	a.mu.Lock()
+	elements := make([]element, len(a.elements))
+	copy(elements, a.elements)
+	a.mu.Unlock()
+
+	report := Report{Elements: len(elements)}
+	if len(elements) == 0 {
+		return report
+	}
+
+	var dits, dahs, intra, inter []float64
+	for _, e := range elements {
+		if e.isDah {
+			dahs = append(dahs, cw.ToMs(e.duration))
+		} else {
+			dits = append(dits, cw.ToMs(e.duration))
+		}
+		switch {
+		case !e.isCharEnd:
+			intra = append(intra, cw.ToMs(e.gapAfter))
+		case !e.isWordEnd:
+			inter = append(inter, cw.ToMs(e.gapAfter))
+		}
+	}
+	report.Dit = newStats(dits)
+	report.Dah = newStats(dahs)
+	report.IntraGap = newStats(intra)
+	report.InterGap = newStats(inter)
+
+	unit := ditUnit(report.Dit, report.Dah)
+	if unit > 0 {
+		report.WPM = cw.MillisecondsPerMinute / (unit * cw.DitsPerWord)
+	}
+
+	// Word gaps longer than a pause between transmissions say nothing about spacing
+	var words []float64
+	for _, e := range elements {
+		if e.isWordEnd && (unit == 0 || cw.ToMs(e.gapAfter) <= unit*MaxWordGapUnits) {
+			words = append(words, cw.ToMs(e.gapAfter))
+		}
+	}
+	report.WordGap = newStats(words)
+
+	if report.Dit.Count > 0 && report.Dah.Count > 0 {
+		report.DahDitRatio = report.Dah.MeanMs / report.Dit.MeanMs
+	}
+	if report.Dit.Count > 0 && report.IntraGap.Count > 0 {
+		report.Weighting = report.Dit.MeanMs / report.IntraGap.MeanMs
+	}
+
+	report.SpeedDrift = speedDrift(elements)
+	report.Misspaced = misspaced(elements, unit, a.alphabet, a.code)
+	return report
+}
+
+// ditUnit estimates the dit length from dits, falling back to dahs at the ITU ratio
+func ditUnit(dit, dah Stats) float64 {
+	if dit.Count > 0 {
+		return dit.MeanMs
+	}
+	if dah.Count > 0 {
+		return dah.MeanMs / cw.DahDitRatio
+	}
+	return 0
+}
+
+// speedDrift averages the sending speed over consecutive DriftWindow spans
+func speedDrift(elements []element) []SpeedSample {
+	var samples []SpeedSample
+	var dits, dahs []float64
+	windowStart := time.Duration(0)
+
+	flush := func() {
+		unit := ditUnit(newStats(dits), newStats(dahs))
+		if unit > 0 {
+			samples = append(samples, SpeedSample{
+				OffsetSeconds: windowStart.Seconds(),
+				WPM:           cw.MillisecondsPerMinute / (unit * cw.DitsPerWord),
+			})
+		}
+		dits, dahs = nil, nil
+	}
+
+	for _, e := range elements {
+		for e.offset >= windowStart+DriftWindow {
+			flush()
+			windowStart += DriftWindow
+		}
+		if e.isDah {
+			dahs = append(dahs, cw.ToMs(e.duration))
+		} else {
+			dits = append(dits, cw.ToMs(e.duration))
+		}
+	}
+	flush()
+	return samples
+}
+
+// misspaced rebuilds characters from the elements and counts those with
+// stretched intra-character gaps or crowded inter-character gaps
+func misspaced(elements []element, unit float64, alphabet cw.Alphabet, code cw.Code) []MisspacedCharacter {
+	if unit <= 0 {
+		return nil
+	}
+
+	totals := make(map[rune]int)
+	faults := make(map[rune]int)
+
+	var elementCode []byte
+	faulty := false
+	for _, e := range elements {
+		if e.isDah {
+			elementCode = append(elementCode, '-')
+		} else {
+			elementCode = append(elementCode, '.')
+		}
+
+		gapUnits := cw.ToMs(e.gapAfter) / unit
+		switch {
+		case !e.isCharEnd && code == cw.CodeAmerican && gapUnits > cw.AmericanSpaceBoundary:
+			// The internal space of an American spaced letter is part of its code
+			elementCode = append(elementCode, byte(cw.AmericanSpace))
+		case !e.isCharEnd && gapUnits > StretchedIntraUnits:
+			faulty = true
+		case e.isCharEnd && !e.isWordEnd && gapUnits < CrowdedInterUnits:
+			faulty = true
+		}
+
+		if !e.isCharEnd {
+			continue
+		}
+		if char, ok := code.DecodeCode(alphabet, string(elementCode)); ok {
+			totals[char]++
+			if faulty {
+				faults[char]++
+			}
+		}
+		elementCode = elementCode[:0]
+		faulty = false
+	}
+
+	result := make([]MisspacedCharacter, 0, len(faults))
+	for char, count := range faults {
+		result = append(result, MisspacedCharacter{
+			Character: string(char),
+			Count:     count,
+			Total:     totals[char],
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Character < result[j].Character
+	})
+	if len(result) > MaxMisspacedReported {
+		result = result[:MaxMisspacedReported]
+	}
+	return result
+}
+
+// newStats computes the count, mean and standard deviation of values
+func newStats(values []float64) Stats {
+	if len(values) == 0 {
+		return Stats{}
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values))
+
+	return Stats{
+		Count:    len(values),
+		MeanMs:   mean,
+		StdDevMs: math.Sqrt(variance),
+	}
+}
@@ -0,0 +1,167 @@
+package fist
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+// timing holds the millisecond lengths used to key test text
+type timing struct {
+	dit, dah, intra, inter, word int
+}
+
+// recordText keys text into the analyzer with the given timing
+func recordText(a *Analyzer, text string, tm timing) {
+	for i, char := range text {
+		if char == ' ' {
+			continue
+		}
+		elements, _ := cw.EncodeCharacter(char)
+		for j, isDah := range elements {
+			duration := tm.dit
+			if isDah {
+				duration = tm.dah
+			}
+			isCharEnd := j == len(elements)-1
+			isWordEnd := isCharEnd && (i == len(text)-1 || text[i+1] == ' ')
+			gap := tm.intra
+			switch {
+			case isWordEnd:
+				gap = tm.word
+			case isCharEnd:
+				gap = tm.inter
+			}
+			a.RecordElement(isDah, ms2d(duration), ms2d(gap), isCharEnd, isWordEnd)
+		}
+	}
+}
+
+func ms2d(ms int) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
+
+func TestAnalyzer_Report_Statistics(t *testing.T) {
+	a := NewAnalyzer(cw.AlphabetLatin, cw.CodeInternational)
+	// 20 WPM with heavy weighting and long dahs
+	recordText(a, "PARIS PARIS", timing{dit: 60, dah: 240, intra: 40, inter: 180, word: 420})
+
+	r := a.Report()
+	if r.Elements != 28 {
+		t.Errorf("Elements = %d, want 28", r.Elements)
+	}
+	if math.Abs(r.WPM-20) > 0.01 {
+		t.Errorf("WPM = %.2f, want 20", r.WPM)
+	}
+	if r.Dit.MeanMs != 60 || r.Dit.StdDevMs != 0 {
+		t.Errorf("Dit = %+v, want mean 60 stddev 0", r.Dit)
+	}
+	if math.Abs(r.DahDitRatio-4) > 0.001 {
+		t.Errorf("DahDitRatio = %.2f, want 4.00", r.DahDitRatio)
+	}
+	if math.Abs(r.Weighting-1.5) > 0.001 {
+		t.Errorf("Weighting = %.2f, want 1.50", r.Weighting)
+	}
+	if r.InterGap.Count != 8 || r.WordGap.Count != 2 {
+		t.Errorf("InterGap.Count = %d, WordGap.Count = %d; want 8, 2", r.InterGap.Count, r.WordGap.Count)
+	}
+	if len(r.Misspaced) != 0 {
+		t.Errorf("Misspaced = %+v, want none", r.Misspaced)
+	}
+}
+
+func TestAnalyzer_Report_ExcludesLongPauses(t *testing.T) {
+	a := NewAnalyzer(cw.AlphabetLatin, cw.CodeInternational)
+	recordText(a, "E E", timing{dit: 60, dah: 180, intra: 60, inter: 180, word: 420})
+	a.RecordElement(false, ms2d(60), 10*time.Second, true, true)
+
+	if r := a.Report(); r.WordGap.Count != 2 || r.WordGap.MeanMs != 420 {
+		t.Errorf("WordGap = %+v, want two 420ms gaps", r.WordGap)
+	}
+}
+
+func TestAnalyzer_Report_Misspaced(t *testing.T) {
+	a := NewAnalyzer(cw.AlphabetLatin, cw.CodeInternational)
+	tm := timing{dit: 60, dah: 180, intra: 60, inter: 180, word: 420}
+	recordText(a, "AN", tm)
+	// R with a stretched second gap, then crowded into the next character
+	a.RecordElement(false, ms2d(60), ms2d(60), false, false)
+	a.RecordElement(true, ms2d(180), ms2d(110), false, false)
+	a.RecordElement(false, ms2d(60), ms2d(130), true, false)
+	recordText(a, "T", tm)
+
+	r := a.Report()
+	if len(r.Misspaced) != 1 {
+		t.Fatalf("Misspaced = %+v, want one entry", r.Misspaced)
+	}
+	if m := r.Misspaced[0]; m.Character != "R" || m.Count != 1 || m.Total != 1 {
+		t.Errorf("Misspaced[0] = %+v, want R 1 of 1", m)
+	}
+}
+
+func TestAnalyzer_Report_MisspacedAlphabet(t *testing.T) {
+	a := NewAnalyzer(cw.AlphabetCyrillic, cw.CodeInternational)
+	// .-- is W in Latin but В in Cyrillic, here with a stretched gap
+	a.RecordElement(false, ms2d(60), ms2d(120), false, false)
+	a.RecordElement(true, ms2d(180), ms2d(60), false, false)
+	a.RecordElement(true, ms2d(180), ms2d(420), true, true)
+
+	r := a.Report()
+	if len(r.Misspaced) != 1 || r.Misspaced[0].Character != "В" {
+		t.Errorf("Misspaced = %+v, want В", r.Misspaced)
+	}
+}
+
+func TestAnalyzer_Report_MisspacedAmerican(t *testing.T) {
+	a := NewAnalyzer(cw.AlphabetLatin, cw.CodeAmerican)
+	// American R is a dot, the two unit internal space, then two dots crowded into the next character
+	a.RecordElement(false, ms2d(60), ms2d(120), false, false)
+	a.RecordElement(false, ms2d(60), ms2d(60), false, false)
+	a.RecordElement(false, ms2d(60), ms2d(90), true, false)
+	a.RecordElement(false, ms2d(60), ms2d(420), true, true)
+
+	r := a.Report()
+	if len(r.Misspaced) != 1 {
+		t.Fatalf("Misspaced = %+v, want one entry", r.Misspaced)
+	}
+	if m := r.Misspaced[0]; m.Character != "R" || m.Count != 1 || m.Total != 1 {
+		t.Errorf("Misspaced[0] = %+v, want R 1 of 1", m)
+	}
+}
+
+func TestAnalyzer_Report_SpeedDrift(t *testing.T) {
+	a := NewAnalyzer(cw.AlphabetLatin, cw.CodeInternational)
+	slow := timing{dit: 80, dah: 240, intra: 80, inter: 240, word: 560}
+	fast := timing{dit: 60, dah: 180, intra: 60, inter: 180, word: 420}
+	for range 10 {
+		recordText(a, "PARIS ", slow) // 4.0s per word at 15 WPM
+	}
+	for range 10 {
+		recordText(a, "PARIS ", fast)
+	}
+
+	r := a.Report()
+	if len(r.SpeedDrift) < 2 {
+		t.Fatalf("SpeedDrift has %d samples, want at least 2", len(r.SpeedDrift))
+	}
+	first := r.SpeedDrift[0]
+	last := r.SpeedDrift[len(r.SpeedDrift)-1]
+	if math.Abs(first.WPM-15) > 0.01 || math.Abs(last.WPM-20) > 0.01 {
+		t.Errorf("drift %.1f -> %.1f WPM, want 15 -> 20", first.WPM, last.WPM)
+	}
+	if last.OffsetSeconds <= first.OffsetSeconds {
+		t.Errorf("drift offsets not increasing: %+v", r.SpeedDrift)
+	}
+}
+
+func TestAnalyzer_Reset(t *testing.T) {
+	a := NewAnalyzer(cw.AlphabetLatin, cw.CodeInternational)
+	recordText(a, "E", timing{dit: 60, dah: 180, intra: 60, inter: 180, word: 420})
+	a.Reset()
+
+	if r := a.Report(); r.Elements != 0 {
+		t.Errorf("Elements after Reset = %d, want 0", r.Elements)
+	}
+}
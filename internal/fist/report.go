@@ -0,0 +1,123 @@
+// internal/fist/report.go
+package fist
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Stats summarizes one kind of element or gap
+type Stats struct {
+	// Count is the number of samples
+	Count int `json:"count"`
+	// MeanMs is the mean length in milliseconds
+	MeanMs float64 `json:"mean_ms"`
+	// StdDevMs is the standard deviation in milliseconds
+	StdDevMs float64 `json:"stddev_ms"`
+}
+
+// SpeedSample is the average sending speed over one drift window
+type SpeedSample struct {
+	// OffsetSeconds is the start of the window in keying time
+	OffsetSeconds float64 `json:"offset_seconds"`
+	// WPM is the average speed over the window
+	WPM float64 `json:"wpm"`
+}
+
+// MisspacedCharacter counts how often a character was sent with poor spacing
+type MisspacedCharacter struct {
+	// Character is the decoded character
+	Character string `json:"character"`
+	// Count is how many times it was mis-spaced
+	Count int `json:"count"`
+	// Total is how many times it was sent
+	Total int `json:"total"`
+}
+
+// Report is a sender's fist analysis
+type Report struct {
+	// Elements is the number of dits and dahs analyzed
+	Elements int `json:"elements"`
+	// WPM is the average sending speed
+	WPM float64 `json:"wpm"`
+	// Dit summarizes dit lengths
+	Dit Stats `json:"dit"`
+	// Dah summarizes dah lengths
+	Dah Stats `json:"dah"`
+	// IntraGap summarizes gaps between elements of a character (ITU: 1 dit)
+	IntraGap Stats `json:"intra_char_gap"`
+	// InterGap summarizes gaps between characters (ITU: 3 dits)
+	InterGap Stats `json:"inter_char_gap"`
+	// WordGap summarizes gaps between words (ITU: 7 dits)
+	WordGap Stats `json:"word_gap"`
+	// DahDitRatio is the actual dah:dit ratio (ITU: 3.0)
+	DahDitRatio float64 `json:"dah_dit_ratio"`
+	// Weighting is the actual mark:space ratio (ITU: 1.0)
+	Weighting float64 `json:"weighting"`
+	// SpeedDrift is the speed over time
+	SpeedDrift []SpeedSample `json:"speed_drift"`
+	// Misspaced lists the characters most often sent with poor spacing
+	Misspaced []MisspacedCharacter `json:"misspaced_characters"`
+}
+
+// WriteJSON writes the report as indented JSON
+func (r Report) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode fist report: %w", err)
+	}
+	data = append(data, '\n')
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("write fist report: %w", err)
+	}
+	return nil
+}
+
+// WriteText writes the report as a human-readable summary
+func (r Report) WriteText(w io.Writer) error {
+	if _, err := io.WriteString(w, r.Text()); err != nil {
+		return fmt.Errorf("write fist report: %w", err)
+	}
+	return nil
+}
+
+// Text formats the report as a human-readable summary
+func (r Report) Text() string {
+	var b strings.Builder
+	b.WriteString("Fist analysis\n")
+	if r.Elements == 0 {
+		b.WriteString("  No elements recorded.\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "  Elements: %d   Speed: %.1f WPM\n", r.Elements, r.WPM)
+	fmt.Fprintf(&b, "  Dah:dit ratio: %.2f (ideal 3.00)   Weighting: %.2f (ideal 1.00)\n", r.DahDitRatio, r.Weighting)
+	b.WriteString("\n  Timing           count    mean ms   stddev ms\n")
+	writeStatsRow(&b, "Dit", r.Dit)
+	writeStatsRow(&b, "Dah", r.Dah)
+	writeStatsRow(&b, "Intra-char gap", r.IntraGap)
+	writeStatsRow(&b, "Inter-char gap", r.InterGap)
+	writeStatsRow(&b, "Word gap", r.WordGap)
+
+	if len(r.SpeedDrift) > 1 {
+		b.WriteString("\n  Speed drift\n")
+		for _, sample := range r.SpeedDrift {
+			fmt.Fprintf(&b, "    %6.0fs  %5.1f WPM\n", sample.OffsetSeconds, sample.WPM)
+		}
+	}
+
+	if len(r.Misspaced) > 0 {
+		b.WriteString("\n  Most often mis-spaced\n")
+		for _, m := range r.Misspaced {
+			fmt.Fprintf(&b, "    %-3s %d of %d\n", m.Character, m.Count, m.Total)
+		}
+	}
+	return b.String()
+}
+
+// writeStatsRow writes one row of the timing table
+func writeStatsRow(b *strings.Builder, name string, s Stats) {
+	fmt.Fprintf(b, "  %-15s %6d %10.1f %11.1f\n", name, s.Count, s.MeanMs, s.StdDevMs)
+}
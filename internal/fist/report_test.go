@@ -0,0 +1,48 @@
+package fist
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+func testReport() Report {
+	a := NewAnalyzer(cw.AlphabetLatin, cw.CodeInternational)
+	recordText(a, "PARIS PARIS", timing{dit: 60, dah: 240, intra: 40, inter: 180, word: 420})
+	return a.Report()
+}
+
+func TestReport_WriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON error = %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	for _, key := range []string{"wpm", "dit", "dah", "intra_char_gap", "inter_char_gap", "word_gap", "dah_dit_ratio", "weighting", "speed_drift"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("JSON report missing %q", key)
+		}
+	}
+}
+
+func TestReport_Text(t *testing.T) {
+	text := testReport().Text()
+	for _, want := range []string{"Speed: 20.0 WPM", "Dah:dit ratio: 4.00", "Weighting: 1.50", "Intra-char gap"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text report missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestReport_Text_Empty(t *testing.T) {
+	if text := NewAnalyzer(cw.AlphabetLatin, cw.CodeInternational).Report().Text(); !strings.Contains(text, "No elements recorded") {
+		t.Errorf("empty report = %q", text)
+	}
+}
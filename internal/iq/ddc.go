@@ -0,0 +1,179 @@
+// internal/iq/ddc.go
+package iq
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// Digital down-conversion constants
+const (
+	// AudioRate is the lowest rate in Hz a channel is decimated to
+	AudioRate = 8000.0
+	// DecimationStages is the number of cascaded moving averages (a CIC filter)
+	// that suppress signals folding onto the channel when decimating
+	DecimationStages = 4
+	// ChannelLevel is the peak amplitude channels are scaled to, as an SDR's audio gain
+	// would be set; filtering a wide recording down to one channel leaves it very quiet
+	ChannelLevel = 0.8
+	// FilterTransitionTaps sets the channel filter length: a Blackman window needs
+	// about this many taps per (rate / transition width)
+	FilterTransitionTaps = 5.5
+)
+
+// downConverter mixes the signal at an offset from the centre of a recording down to
+// the tone frequency of a real audio channel, a block of frames at a time, carrying
+// its oscillator phases and filter state from one block to the next.
+// Frames are mixed to baseband, decimated to AudioRate or just above by a CIC filter,
+// low-pass filtered and mixed back up to the tone. The bandwidth filter rejects the
+// image tone*2 Hz below the signal that a real channel folds over.
+type downConverter struct {
+	decimation int
+	rate       float64
+	mixStep    float64
+	toneStep   float64
+	stages     [DecimationStages]movingAverage
+	taps       []float64
+	half       int
+
+	mixed   int64 // frames mixed to baseband
+	pushed  int64 // decimated samples into the low-pass filter
+	toned   int64 // samples mixed up to the tone
+	history []complex128
+	pos     int
+	out     []complex128
+	audio   []float32
+}
+
+// movingAverage is one stage of the decimating CIC filter: the mean of the last
+// len(history) samples
+type movingAverage struct {
+	history []complex128
+	sum     complex128
+	pos     int
+}
+
+// newDownConverter returns a converter for the signal offset Hz from the centre of a
+// recording at sampleRate, filtered to bandwidth Hz around tone Hz
+func newDownConverter(sampleRate int, offset, tone, bandwidth float64) *downConverter {
+	fs := float64(sampleRate)
+	decimation := max(1, int(fs/AudioRate))
+	rate := fs / float64(decimation)
+	taps := lowPassTaps(bandwidth/2, rate)
+	d := &downConverter{
+		decimation: decimation,
+		rate:       rate,
+		mixStep:    -2 * math.Pi * offset / fs,
+		toneStep:   2 * math.Pi * tone / rate,
+		taps:       taps,
+		half:       len(taps) / 2,
+	}
+	for s := range d.stages {
+		d.stages[s].history = make([]complex128, decimation)
+	}
+	d.reset()
+	return d
+}
+
+// reset returns the converter to the start of the recording
+func (d *downConverter) reset() {
+	for s := range d.stages {
+		clear(d.stages[s].history)
+		d.stages[s].sum, d.stages[s].pos = 0, 0
+	}
+	// The filter history is stored twice over so the taps always see it contiguously
+	d.history = make([]complex128, 2*len(d.taps))
+	d.pos = 0
+	d.mixed, d.pushed, d.toned = 0, 0, 0
+}
+
+// convert mixes, decimates and filters the frames i and q, returning the channel's
+// baseband samples. The filter is centred on each sample, so a sample is returned
+// once the frames half its length later have arrived. The result is reused by the
+// next call.
+func (d *downConverter) convert(i, q []float32) []complex128 {
+	d.out = d.out[:0]
+	scale := complex(1/float64(d.decimation), 0)
+	for n := range i {
+		sample := complex(float64(i[n]), float64(q[n]))
+		sample *= cmplx.Rect(1, math.Mod(d.mixStep*float64(d.mixed), 2*math.Pi))
+		d.mixed++
+		if d.decimation > 1 {
+			// Decimate behind a cascade of moving averages, each nulling the folding bands
+			for s := range d.stages {
+				stage := &d.stages[s]
+				stage.sum += sample - stage.history[stage.pos]
+				stage.history[stage.pos] = sample
+				stage.pos = (stage.pos + 1) % d.decimation
+				sample = stage.sum * scale
+			}
+			if d.mixed%int64(d.decimation) != 0 {
+				continue
+			}
+		}
+		d.push(sample)
+	}
+	return d.out
+}
+
+// flush returns the samples still in the filter at the end of the recording
+func (d *downConverter) flush() []complex128 {
+	d.out = d.out[:0]
+	for range d.half {
+		d.push(0)
+	}
+	return d.out
+}
+
+// push feeds a decimated sample to the low-pass filter, adding the filtered sample
+// half the filter's length before it to the output
+func (d *downConverter) push(sample complex128) {
+	taps := len(d.taps)
+	d.pos = (d.pos + 1) % taps
+	d.history[d.pos], d.history[d.pos+taps] = sample, sample
+	d.pushed++
+	if d.pushed <= int64(d.half) {
+		return
+	}
+	var acc complex128
+	for k, sample := range d.history[d.pos+1 : d.pos+1+taps] {
+		acc += sample * complex(d.taps[k], 0)
+	}
+	d.out = append(d.out, acc)
+}
+
+// tone mixes filtered baseband samples up to the tone frequency of a real audio
+// channel, scaled by gain. The result is reused by the next call.
+func (d *downConverter) tone(filtered []complex128, gain float64) []float32 {
+	d.audio = d.audio[:0]
+	for _, sample := range filtered {
+		phase := math.Mod(d.toneStep*float64(d.toned), 2*math.Pi)
+		d.audio = append(d.audio, float32(gain*real(sample*cmplx.Rect(1, phase))))
+		d.toned++
+	}
+	return d.audio
+}
+
+// lowPassTaps returns a normalised Blackman-windowed sinc cutting off at cutoff Hz
+func lowPassTaps(cutoff, rate float64) []float64 {
+	taps := int(FilterTransitionTaps*rate/cutoff) | 1
+	half := taps / 2
+	h := make([]float64, taps)
+	fc := cutoff / rate
+	var sum float64
+	for k := range h {
+		x := float64(k - half)
+		sinc := 2 * fc
+		if x != 0 {
+			sinc = math.Sin(2*math.Pi*fc*x) / (math.Pi * x)
+		}
+		w := 0.42 - 0.5*math.Cos(2*math.Pi*float64(k)/float64(taps-1)) +
+			0.08*math.Cos(4*math.Pi*float64(k)/float64(taps-1))
+		h[k] = sinc * w
+		sum += h[k]
+	}
+	for k := range h {
+		h[k] /= sum
+	}
+	return h
+}
@@ -0,0 +1,273 @@
+// internal/iq/iq.go
+// Package iq decodes CW from SDR I/Q recordings. A digital down-converter mixes a
+// signal at an offset from the recording's centre frequency down to an audio channel
+// for the usual detector and decoder, for one chosen signal or, skimmer style, for
+// every carrier found in the recorded band.
+package iq
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/pipeline"
+	"github.com/ColonelBlimp/cwdecoder/internal/wav"
+)
+
+// Channel defaults
+const (
+	// DefaultBandwidth is the channel filter bandwidth in Hz
+	DefaultBandwidth = 500.0
+	// DefaultMinSNR is how far in dB a carrier must stand above the noise floor to be skimmed
+	DefaultMinSNR = 10.0
+	// DefaultSpacing is the closest in Hz two skimmed signals may be
+	DefaultSpacing = 100.0
+	// MaxSignals is the most signals skimmed from one recording, strongest first
+	MaxSignals = 50
+	// BlockFrames is how many frames of a recording are read and down-converted at a
+	// time, so memory does not grow with the recording's length
+	BlockFrames = 1 << 14
+)
+
+var (
+	// ErrSampleRate indicates a recording too narrow to hold an audio channel
+	ErrSampleRate = fmt.Errorf("I/Q sample rate must be at least %.0f Hz", AudioRate)
+	// ErrOffset indicates an offset outside the recorded band
+	ErrOffset = errors.New("offset must be within the recorded band")
+	// ErrBandwidth indicates a channel bandwidth that does not fit around the tone
+	ErrBandwidth = errors.New("bandwidth must be positive and fit between the tone frequency and the channel's Nyquist frequency")
+)
+
+// nameFrequency finds the centre frequency SDR#, HDSDR and SDR Console put in
+// recording names, e.g. "SDRSharp_20240101_120000Z_7030000Hz_IQ.wav",
+// "HDSDR_20240101_120000Z_7030kHz_RF.wav" or "01-Jan-2024 120000.000 7.030MHz.wav"
+var nameFrequency = regexp.MustCompile(`(?i)(?:^|[_\s-])(\d+(?:\.\d+)?)\s?(hz|khz|mhz)(?:$|[_\s.-])`)
+
+// nameUnits maps the units in recording names to Hz
+var nameUnits = map[string]float64{
+	"hz":  1,
+	"khz": 1e3,
+	"mhz": 1e6,
+}
+
+// Signal is a CW signal decoded from a recording
+type Signal struct {
+	// Offset is the signal's frequency relative to the centre, in Hz
+	Offset float64
+	// Frequency is the signal's RF frequency in Hz, or 0 if the centre is unknown
+	Frequency float64
+	// SNR is the carrier's average power above the noise floor, in dB
+	SNR float64
+	// Text is the decoded text
+	Text string
+}
+
+// Config holds I/Q decoding configuration
+type Config struct {
+	// Settings configures each channel's detector and decoder; the sample rate is the channel's
+	Settings config.Settings
+	// Bandwidth is the channel filter bandwidth in Hz
+	Bandwidth float64
+	// MinSNR is the carrier level in dB above the noise floor skimmed signals need
+	MinSNR float64
+	// Spacing is the closest in Hz two skimmed signals may be
+	Spacing float64
+}
+
+// DefaultConfig returns I/Q decoding defaults with the given decoder settings
+func DefaultConfig(settings config.Settings) Config {
+	return Config{
+		Settings:  settings,
+		Bandwidth: DefaultBandwidth,
+		MinSNR:    DefaultMinSNR,
+		Spacing:   DefaultSpacing,
+	}
+}
+
+// Decode decodes the signal offset Hz from the centre of rec
+func Decode(rec *wav.IQReader, offset float64, cfg Config) (Signal, error) {
+	if err := cfg.validate(rec); err != nil {
+		return Signal{}, err
+	}
+	if math.Abs(offset) >= float64(rec.SampleRate)/2 {
+		return Signal{}, fmt.Errorf("%w (±%d Hz), got %.1f Hz", ErrOffset, rec.SampleRate/2, offset)
+	}
+
+	spec, err := powerSpectrum(rec)
+	if err != nil {
+		return Signal{}, err
+	}
+	bin := math.Round(offset / spec.binWidth)
+	if bin < 0 {
+		bin += float64(len(spec.power))
+	}
+	snr := 10 * math.Log10(spec.power[int(bin)%len(spec.power)]/median(spec.power))
+	signals, err := decodeChannels(rec, []Signal{{Offset: offset, SNR: snr}}, cfg)
+	if err != nil {
+		return Signal{}, err
+	}
+	return signals[0], nil
+}
+
+// Skim finds the carriers in rec and decodes each, returning those that decoded
+// to text in order of frequency
+func Skim(rec *wav.IQReader, cfg Config) ([]Signal, error) {
+	if err := cfg.validate(rec); err != nil {
+		return nil, err
+	}
+	spec, err := powerSpectrum(rec)
+	if err != nil {
+		return nil, err
+	}
+	candidates := spec.peaks(cfg.MinSNR, cfg.Spacing, float64(rec.SampleRate))
+	candidates = candidates[:min(len(candidates), MaxSignals)]
+
+	decoded, err := decodeChannels(rec, candidates, cfg)
+	if err != nil {
+		return nil, err
+	}
+	var signals []Signal
+	for _, signal := range decoded {
+		if signal.Text != "" {
+			signals = append(signals, signal)
+		}
+	}
+	sort.Slice(signals, func(i, j int) bool { return signals[i].Offset < signals[j].Offset })
+	return signals, nil
+}
+
+// FrequencyFromName returns the centre frequency in Hz written in an SDR recording's
+// file name, for recordings without an auxi chunk
+func FrequencyFromName(path string) (float64, bool) {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	match := nameFrequency.FindStringSubmatch(name)
+	if match == nil {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return value * nameUnits[strings.ToLower(match[2])], true
+}
+
+// decodeChannels mixes each signal's offset down to an audio channel and decodes it,
+// streaming rec through every channel a block at a time. A first pass finds each
+// channel's peak so it can be scaled to ChannelLevel, as an SDR's audio gain would be set.
+func decodeChannels(rec *wav.IQReader, signals []Signal, cfg Config) ([]Signal, error) {
+	converters := make([]*downConverter, len(signals))
+	for c, signal := range signals {
+		converters[c] = newDownConverter(rec.SampleRate, signal.Offset, cfg.Settings.ToneFrequency, cfg.Bandwidth)
+	}
+
+	peaks := make([]float64, len(signals))
+	peak := func(c int, filtered []complex128) {
+		for _, sample := range filtered {
+			peaks[c] = max(peaks[c], cmplx.Abs(sample))
+		}
+	}
+	err := readBlocks(rec, func(i, q []float32) {
+		eachChannel(len(signals), func(c int) { peak(c, converters[c].convert(i, q)) })
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gains := make([]float64, len(signals))
+	pipelines := make([]*pipeline.Pipeline, len(signals))
+	for c, converter := range converters {
+		peak(c, converter.flush())
+		if peaks[c] > 0 {
+			gains[c] = ChannelLevel / peaks[c]
+		}
+		converter.reset()
+
+		// The channel is already scaled to the level the AGC starts at; a warmup would
+		// calibrate it to the noise before the first character instead
+		settings := cfg.Settings
+		settings.SampleRate = converter.rate
+		settings.AGCWarmupBlocks = 0
+		if pipelines[c], err = pipeline.New(settings); err != nil {
+			return nil, err
+		}
+		// Recorded audio runs faster than real time, so time comes from the samples
+		pipelines[c].UseSampleClock(pipeline.SampleClockStart)
+	}
+
+	err = readBlocks(rec, func(i, q []float32) {
+		eachChannel(len(signals), func(c int) {
+			pipelines[c].Process(converters[c].tone(converters[c].convert(i, q), gains[c]))
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := make([]Signal, len(signals))
+	for c, signal := range signals {
+		pipelines[c].Process(converters[c].tone(converters[c].flush(), gains[c]))
+		signal.Text = pipelines[c].Finish()
+		if rec.CentreFrequency != 0 {
+			signal.Frequency = rec.CentreFrequency + signal.Offset
+		}
+		decoded[c] = signal
+	}
+	return decoded, nil
+}
+
+// readBlocks rewinds rec and passes it to fn BlockFrames frames at a time
+func readBlocks(rec *wav.IQReader, fn func(i, q []float32)) error {
+	if err := rec.Rewind(); err != nil {
+		return err
+	}
+	i, q := make([]float32, BlockFrames), make([]float32, BlockFrames)
+	for {
+		n, err := rec.Read(i, q)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fn(i[:n], q[:n])
+	}
+}
+
+// eachChannel calls fn for channels 0 to n-1; channels are independent, so it
+// spreads them over up to GOMAXPROCS goroutines
+func eachChannel(n int, fn func(c int)) {
+	workers := min(runtime.GOMAXPROCS(0), n)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := w; c < n; c += workers {
+				fn(c)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// validate checks the configuration against the recording
+func (cfg Config) validate(rec *wav.IQReader) error {
+	if float64(rec.SampleRate) < AudioRate {
+		return fmt.Errorf("%w, got %d Hz", ErrSampleRate, rec.SampleRate)
+	}
+	tone := cfg.Settings.ToneFrequency
+	if cfg.Bandwidth <= 0 || tone-cfg.Bandwidth/2 <= 0 || tone+cfg.Bandwidth/2 >= AudioRate/2 {
+		return fmt.Errorf("%w, got %.0f Hz around %.0f Hz", ErrBandwidth, cfg.Bandwidth, tone)
+	}
+	return nil
+}
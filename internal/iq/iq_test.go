@@ -0,0 +1,259 @@
+package iq
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+	"github.com/ColonelBlimp/cwdecoder/internal/wav"
+)
+
+// testSettings returns decoder settings suited to 20 WPM test signals
+func testSettings() config.Settings {
+	return config.Settings{
+		SampleRate:        8000,
+		BufferSize:        1024,
+		ToneFrequency:     600,
+		BlockSize:         128,
+		OverlapPct:        50,
+		Threshold:         0.4,
+		Hysteresis:        2,
+		AGCEnabled:        true,
+		AGCDecay:          0.9995,
+		AGCAttack:         0.1,
+		AGCWarmupBlocks:   10,
+		WPM:               20,
+		AdaptiveTiming:    true,
+		AdaptiveSmoothing: 0.1,
+		DitDahBoundary:    2.0,
+		InterCharBoundary: 2.0,
+		CharWordBoundary:  5.0,
+		KeyMode:           "electronic",
+		Alphabet:          "latin",
+		MorseCode:         "international",
+	}
+}
+
+// carrier is a CW signal in a test recording
+type carrier struct {
+	offset float64
+	text   string
+}
+
+// recording synthesizes a 48 kHz I/Q recording of carriers keyed at 20 WPM, with noise
+func recording(t *testing.T, centre float64, carriers ...carrier) *wav.IQReader {
+	t.Helper()
+	const sampleRate = 48000
+	encoder, err := cw.NewEncoder(cw.EncoderConfig{WPM: 20})
+	if err != nil {
+		t.Fatalf("NewEncoder() error = %v", err)
+	}
+
+	lead := sampleRate / 2 // half a second of carrier-off either side
+	signals := make([][]complex128, len(carriers))
+	length := 0
+	for i, c := range carriers {
+		keying, err := encoder.Encode(c.text)
+		if err != nil {
+			t.Fatalf("Encode(%q) error = %v", c.text, err)
+		}
+		n := lead
+		for _, k := range keying {
+			end := n + int(k.Duration.Seconds()*sampleRate)
+			if k.ToneOn {
+				signals[i] = append(signals[i], make([]complex128, n-len(signals[i]))...)
+				for ; n < end; n++ {
+					phase := 2 * math.Pi * c.offset * float64(n) / sampleRate
+					signals[i] = append(signals[i], cmplx.Rect(0.2, phase))
+				}
+			}
+			n = end
+		}
+		length = max(length, n+lead)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	i, q := make([]float32, length), make([]float32, length)
+	for n := range length {
+		sample := complex(0.02*rng.NormFloat64(), 0.02*rng.NormFloat64())
+		for _, signal := range signals {
+			if n < len(signal) {
+				sample += signal[n]
+			}
+		}
+		i[n], q[n] = float32(real(sample)), float32(imag(sample))
+	}
+	rec := iqReader(t, sampleRate, i, q)
+	rec.CentreFrequency = centre
+	return rec
+}
+
+// iqReader reads a two-channel float WAV of i and q at sampleRate from memory
+func iqReader(t *testing.T, sampleRate int, i, q []float32) *wav.IQReader {
+	t.Helper()
+	var data bytes.Buffer
+	for n := range i {
+		_ = binary.Write(&data, binary.LittleEndian, [2]float32{i[n], q[n]})
+	}
+	var stream bytes.Buffer
+	stream.WriteString("RIFF")
+	_ = binary.Write(&stream, binary.LittleEndian, uint32(36+data.Len()))
+	stream.WriteString("WAVEfmt ")
+	_ = binary.Write(&stream, binary.LittleEndian, []uint32{16})
+	_ = binary.Write(&stream, binary.LittleEndian, []uint16{wav.FormatFloat, 2})
+	_ = binary.Write(&stream, binary.LittleEndian, []uint32{uint32(sampleRate), uint32(sampleRate * 8)})
+	_ = binary.Write(&stream, binary.LittleEndian, []uint16{8, 32})
+	stream.WriteString("data")
+	_ = binary.Write(&stream, binary.LittleEndian, uint32(data.Len()))
+	stream.Write(data.Bytes())
+
+	rec, err := wav.NewIQReader(bytes.NewReader(stream.Bytes()))
+	if err != nil {
+		t.Fatalf("NewIQReader() error = %v", err)
+	}
+	return rec
+}
+
+func TestDecode_Offset(t *testing.T) {
+	rec := recording(t, 7030000, carrier{3000, "CQ DE W1AW"}, carrier{-2400, "TEST"})
+
+	signal, err := Decode(rec, 3000, DefaultConfig(testSettings()))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if signal.Text != "CQ DE W1AW" {
+		t.Errorf("Decode() text = %q, want %q", signal.Text, "CQ DE W1AW")
+	}
+	if signal.Frequency != 7033000 || signal.SNR < DefaultMinSNR {
+		t.Errorf("Decode() = %+v, want 7033000 Hz well above the noise", signal)
+	}
+
+	// The other signal is below: a real audio channel's image of it is rejected
+	signal, err = Decode(rec, -2400, DefaultConfig(testSettings()))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if signal.Text != "TEST" || signal.Frequency != 7027600 {
+		t.Errorf("Decode() = %+v, want TEST at 7027600 Hz", signal)
+	}
+}
+
+func TestDecode_RejectsImage(t *testing.T) {
+	// A carrier twice the tone frequency below the channel folds onto the tone in a
+	// real channel unless the bandwidth filter removes it
+	rec := recording(t, 0, carrier{1000, "TEST"}, carrier{1000 - 2*600, "EEEE"})
+	signal, err := Decode(rec, 1000, DefaultConfig(testSettings()))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if signal.Text != "TEST" || signal.Frequency != 0 {
+		t.Errorf("Decode() = %+v, want TEST and no frequency without a centre", signal)
+	}
+}
+
+func TestSkim(t *testing.T) {
+	rec := recording(t, 14000000,
+		carrier{-6000, "TEST"},
+		carrier{1500, "CQ"},
+		carrier{9000, "DE W1AW"},
+	)
+	signals, err := Skim(rec, DefaultConfig(testSettings()))
+	if err != nil {
+		t.Fatalf("Skim() error = %v", err)
+	}
+
+	want := []Signal{
+		{Frequency: 13994000, Text: "TEST"},
+		{Frequency: 14001500, Text: "CQ"},
+		{Frequency: 14009000, Text: "DE W1AW"},
+	}
+	if len(signals) != len(want) {
+		t.Fatalf("Skim() = %+v, want %+v", signals, want)
+	}
+	for i := range want {
+		if signals[i].Text != want[i].Text || math.Abs(signals[i].Frequency-want[i].Frequency) > 5 {
+			t.Errorf("signal %d = %+v, want %q at %.0f Hz", i, signals[i], want[i].Text, want[i].Frequency)
+		}
+	}
+}
+
+func TestDownConverter_Blocks(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	i, q := make([]float32, 20000), make([]float32, 20000)
+	for n := range i {
+		i[n], q[n] = float32(rng.NormFloat64()), float32(rng.NormFloat64())
+	}
+
+	whole := newDownConverter(48000, 3000, 600, 500)
+	want := append(slices.Clone(whole.convert(i, q)), whole.flush()...)
+
+	// Blocks that do not fall on decimation boundaries must carry the filter state over
+	blocked := newDownConverter(48000, 3000, 600, 500)
+	var got []complex128
+	for start := 0; start < len(i); start += 777 {
+		end := min(start+777, len(i))
+		got = append(got, blocked.convert(i[start:end], q[start:end])...)
+	}
+	got = append(got, blocked.flush()...)
+
+	if len(got) != len(i)/6 || len(got) != len(want) {
+		t.Fatalf("converted %d samples in blocks and %d at once, want %d", len(got), len(want), len(i)/6)
+	}
+	for n := range want {
+		if cmplx.Abs(got[n]-want[n]) > 1e-9 {
+			t.Fatalf("sample %d = %v in blocks, want %v", n, got[n], want[n])
+		}
+	}
+}
+
+func TestDecode_InvalidConfig(t *testing.T) {
+	rec := recording(t, 0, carrier{1000, "E"})
+	narrow := iqReader(t, 4000, make([]float32, 100), make([]float32, 100))
+	wide := DefaultConfig(testSettings())
+	wide.Bandwidth = 2000
+
+	tests := []struct {
+		name   string
+		rec    *wav.IQReader
+		offset float64
+		cfg    Config
+		want   error
+	}{
+		{"narrow recording", narrow, 0, DefaultConfig(testSettings()), ErrSampleRate},
+		{"offset outside band", rec, 24000, DefaultConfig(testSettings()), ErrOffset},
+		{"bandwidth wider than tone", rec, 1000, wide, ErrBandwidth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(tt.rec, tt.offset, tt.cfg); !errors.Is(err, tt.want) {
+				t.Errorf("Decode() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFrequencyFromName(t *testing.T) {
+	tests := []struct {
+		path string
+		want float64
+		ok   bool
+	}{
+		{"/rec/SDRSharp_20240101_120000Z_7030000Hz_IQ.wav", 7030000, true},
+		{"HDSDR_20240101_120000Z_7030kHz_RF.wav", 7030000, true},
+		{"01-Jan-2024 120000.000 14.060MHz.wav", 14060000, true},
+		{"capture_20240101.wav", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := FrequencyFromName(tt.path)
+		if ok != tt.ok || math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("FrequencyFromName(%q) = %v, %v, want %v, %v", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// internal/iq/spectrum.go
+package iq
+
+import (
+	"io"
+	"math"
+	"math/bits"
+	"math/cmplx"
+	"slices"
+	"sort"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/wav"
+)
+
+// Spectrum constants
+const (
+	// SpectrumResolution is the widest FFT bin in Hz used to find signals
+	SpectrumResolution = 20.0
+	// EdgeFraction is how far towards the edge of the recorded band, as a fraction
+	// of the sample rate, signals are looked for; SDR filters roll off beyond it
+	EdgeFraction = 0.45
+)
+
+// spectrum is the average power of a recording in FFT bins, DC in bin 0
+type spectrum struct {
+	power    []float64
+	binWidth float64
+}
+
+// powerSpectrum averages the Hann-windowed power spectra of consecutive blocks of
+// rec, with blocks long enough for bins of at most SpectrumResolution Hz. A recording
+// shorter than one block is zero-padded to it.
+func powerSpectrum(rec *wav.IQReader) (spectrum, error) {
+	fs := float64(rec.SampleRate)
+	size := 1 << bits.Len(uint(math.Ceil(fs/SpectrumResolution))-1)
+	power := make([]float64, size)
+
+	window := make([]float64, size)
+	for k := range window {
+		window[k] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(k)/float64(size))
+	}
+	if err := rec.Rewind(); err != nil {
+		return spectrum{}, err
+	}
+	i, q := make([]float32, size), make([]float32, size)
+	block := make([]complex128, size)
+	blocks := 0
+	for {
+		frames, err := readFull(rec, i, q)
+		if err != nil {
+			return spectrum{}, err
+		}
+		if frames < size && (blocks > 0 || frames == 0) {
+			break
+		}
+		clear(block)
+		for k := range frames {
+			block[k] = complex(float64(i[k])*window[k], float64(q[k])*window[k])
+		}
+		fft(block)
+		for k, bin := range block {
+			power[k] += real(bin)*real(bin) + imag(bin)*imag(bin)
+		}
+		blocks++
+		if frames < size {
+			break
+		}
+	}
+	for k := range power {
+		power[k] /= float64(max(1, blocks))
+	}
+	return spectrum{power: power, binWidth: fs / float64(size)}, nil
+}
+
+// readFull reads frames from rec until i and q are full or the recording ends,
+// returning the number read
+func readFull(rec *wav.IQReader, i, q []float32) (int, error) {
+	frames := 0
+	for frames < len(i) {
+		n, err := rec.Read(i[frames:], q[frames:])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		frames += n
+	}
+	return frames, nil
+}
+
+// offset returns the frequency of bin k relative to the centre
+func (s spectrum) offset(k float64) float64 {
+	if k >= float64(len(s.power))/2 {
+		k -= float64(len(s.power))
+	}
+	return k * s.binWidth
+}
+
+// peaks returns carriers standing minSNR dB above the median noise floor, at least
+// spacing Hz apart and from the centre (where SDRs leave a DC spike), strongest first.
+// Offsets are interpolated between bins.
+func (s spectrum) peaks(minSNR, spacing, sampleRate float64) []Signal {
+	floor := median(s.power)
+	if floor <= 0 {
+		return nil
+	}
+	threshold := floor * math.Pow(10, minSNR/10)
+	reach := max(1, int(spacing/2/s.binWidth))
+	n := len(s.power)
+
+	var found []Signal
+	for k, p := range s.power {
+		offset := s.offset(float64(k))
+		if p <= threshold || math.Abs(offset) < spacing || math.Abs(offset) > EdgeFraction*sampleRate {
+			continue
+		}
+		highest := true
+		for d := -reach; d <= reach && highest; d++ {
+			if d != 0 && s.power[(k+d+n)%n] > p {
+				highest = false
+			}
+		}
+		if !highest {
+			continue
+		}
+		// Parabolic interpolation of the peak on a dB scale
+		left := 10 * math.Log10(s.power[(k-1+n)%n])
+		centre := 10 * math.Log10(p)
+		right := 10 * math.Log10(s.power[(k+1)%n])
+		shift := 0.0
+		if denom := left - 2*centre + right; denom < 0 {
+			shift = 0.5 * (left - right) / denom
+		}
+		found = append(found, Signal{
+			Offset: s.offset(float64(k) + shift),
+			SNR:    centre - 10*math.Log10(floor),
+		})
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].SNR > found[j].SNR })
+	var kept []Signal
+	for _, candidate := range found {
+		if !slices.ContainsFunc(kept, func(k Signal) bool { return math.Abs(k.Offset-candidate.Offset) < spacing }) {
+			kept = append(kept, candidate)
+		}
+	}
+	return kept
+}
+
+// median returns the median of values
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	return sorted[len(sorted)/2]
+}
+
+// fft transforms x in place; its length must be a power of two
+func fft(x []complex128) {
+	n := len(x)
+	// Bit-reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j |= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Rect(1, -2*math.Pi/float64(size))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := range size / 2 {
+				a, b := x[start+k], x[start+k+size/2]*w
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// internal/keying/csv.go
+package keying
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCSV indicates a malformed keying CSV file
+var ErrInvalidCSV = errors.New("invalid keying CSV")
+
+// csvLevels maps the level column's accepted values to line levels
+var csvLevels = map[string]bool{
+	"1":     true,
+	"0":     false,
+	"high":  true,
+	"low":   false,
+	"on":    true,
+	"off":   false,
+	"true":  true,
+	"false": false,
+}
+
+// ReadCSV reads transitions from CSV rows of time in seconds and line level
+// (1/0, high/low, on/off or true/false), as exported by logic analysers.
+// A header row is skipped, as are any columns after the level.
+func ReadCSV(r io.Reader) ([]Transition, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var transitions []Transition
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read keying CSV: %w", err)
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("%w: row %d needs time and level", ErrInvalidCSV, row)
+		}
+
+		seconds, timeErr := strconv.ParseFloat(strings.TrimSpace(record[0]), 64)
+		high, ok := csvLevels[strings.ToLower(strings.TrimSpace(record[1]))]
+		if row == 1 && timeErr != nil {
+			continue // header
+		}
+		if timeErr != nil || !ok {
+			return nil, fmt.Errorf("%w: row %d: %q", ErrInvalidCSV, row, strings.Join(record, ","))
+		}
+		transitions = append(transitions, Transition{
+			Time: time.Duration(seconds * float64(time.Second)),
+			High: high,
+		})
+	}
+	return transitions, nil
+}
+
+// ReadCSVFile reads transitions from a keying CSV file
+func ReadCSVFile(path string) ([]Transition, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open keying CSV: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	return ReadCSV(f)
+}
@@ -0,0 +1,58 @@
+package keying
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadCSV(t *testing.T) {
+	input := `Time [s], Channel 0
+# exported by a logic analyser
+0.000000, 1
+0.100000, 0
+0.160000, HIGH, extra
+0.220, off
+`
+	transitions, err := ReadCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadCSV() error = %v", err)
+	}
+	want := []Transition{
+		{Time: 0, High: true},
+		{Time: 100 * time.Millisecond, High: false},
+		{Time: 160 * time.Millisecond, High: true},
+		{Time: 220 * time.Millisecond, High: false},
+	}
+	if len(transitions) != len(want) {
+		t.Fatalf("ReadCSV() = %+v, want %+v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("transition %d = %+v, want %+v", i, transitions[i], want[i])
+		}
+	}
+}
+
+func TestReadCSV_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"bad level":     "0.1,maybe\n",
+		"bad time":      "0.1,1\nsoon,0\n",
+		"missing level": "0.1\n",
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ReadCSV(strings.NewReader(input)); !errors.Is(err, ErrInvalidCSV) {
+				t.Errorf("ReadCSV() error = %v, want %v", err, ErrInvalidCSV)
+			}
+		})
+	}
+}
+
+func TestReadCSVFile_Missing(t *testing.T) {
+	if _, err := ReadCSVFile(filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+		t.Error("ReadCSVFile() should fail for a missing file")
+	}
+}
@@ -0,0 +1,94 @@
+// internal/keying/keying.go
+// Package keying imports keying line captures, such as logic analyser VCD exports and
+// CSV timestamp files, as tone events so keyer timing can be decoded without audio.
+package keying
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+)
+
+// Capture formats
+const (
+	// FormatVCD is a Value Change Dump, as exported by logic analysers
+	FormatVCD = "vcd"
+	// FormatCSV is a file of time,level rows
+	FormatCSV = "csv"
+)
+
+var (
+	// ErrUnknownFormat indicates a capture file whose format is not known
+	ErrUnknownFormat = errors.New("capture format must be vcd or csv")
+	// ErrNoTransitions indicates a capture in which the keying line never changes
+	ErrNoTransitions = errors.New("no keying transitions")
+)
+
+// Transition is a change of level on the keying line
+type Transition struct {
+	// Time is the offset of the change from the start of the capture
+	Time time.Duration
+	// High is the line level after the change
+	High bool
+}
+
+// Events converts transitions to the tone events the audio detector would report,
+// starting at start. The key is down while the line is high, or low if activeLow is set
+// (keyers usually pull the line to ground). Repeated levels are ignored, and the key is
+// taken to be up before the first transition.
+func Events(transitions []Transition, start time.Time, activeLow bool) []dsp.ToneEvent {
+	var events []dsp.ToneEvent
+	var key dsp.KeyLine
+	keyDown := false
+	for _, transition := range transitions {
+		down := transition.High != activeLow
+		if down == keyDown {
+			continue
+		}
+		keyDown = down
+
+		if down {
+			events = append(events, key.Down(start.Add(transition.Time)))
+		} else {
+			events = append(events, key.Up(start.Add(transition.Time)))
+		}
+	}
+	return events
+}
+
+// FormatOf returns the capture format of a file from its extension, or "" if unknown
+func FormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".vcd":
+		return FormatVCD
+	case ".csv":
+		return FormatCSV
+	}
+	return ""
+}
+
+// ReadFile reads the transitions of a capture file in the given format.
+// signal selects the VCD variable by name ("" = the first one-bit variable).
+func ReadFile(path, format, signal string) ([]Transition, error) {
+	var transitions []Transition
+	var err error
+	switch format {
+	case FormatVCD:
+		transitions, err = ReadVCDFile(path, signal)
+	case FormatCSV:
+		transitions, err = ReadCSVFile(path)
+	default:
+		return nil, fmt.Errorf("%w, got %q", ErrUnknownFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(transitions) == 0 {
+		return nil, fmt.Errorf("%s: %w", path, ErrNoTransitions)
+	}
+	return transitions, nil
+}
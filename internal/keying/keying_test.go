@@ -0,0 +1,85 @@
+package keying
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+)
+
+func TestEvents(t *testing.T) {
+	ms := func(n int) time.Duration { return time.Duration(n) * time.Millisecond }
+	transitions := []Transition{
+		{Time: 0, High: false},
+		{Time: ms(100), High: true},
+		{Time: ms(160), High: false},
+		{Time: ms(160), High: false}, // repeated level
+		{Time: ms(220), High: true},
+		{Time: ms(400), High: false},
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	events := Events(transitions, start, false)
+	if len(events) != 4 {
+		t.Fatalf("Events() returned %d events, want 4", len(events))
+	}
+	wantDurations := []time.Duration{0, ms(60), ms(60), ms(180)}
+	for i, event := range events {
+		if event.ToneOn != (i%2 == 0) {
+			t.Errorf("event %d ToneOn = %v, want %v", i, event.ToneOn, i%2 == 0)
+		}
+		if event.Duration != wantDurations[i] {
+			t.Errorf("event %d Duration = %v, want %v", i, event.Duration, wantDurations[i])
+		}
+	}
+	if !events[0].Timestamp.Equal(start.Add(ms(100))) || events[0].Magnitude != dsp.KeyDownMagnitude {
+		t.Errorf("first event = %+v, want key down at 100ms", events[0])
+	}
+
+	// Active low: the line idles high and the key pulls it low
+	events = Events(transitions, start, true)
+	if len(events) != 5 || !events[0].ToneOn || events[0].Timestamp != start || events[1].Duration != ms(100) {
+		t.Errorf("active low events = %+v, want key down from 0 to 100ms first", events)
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	tests := map[string]string{
+		"capture.vcd":  FormatVCD,
+		"CAPTURE.VCD":  FormatVCD,
+		"keying.csv":   FormatCSV,
+		"events.jsonl": "",
+	}
+	for path, want := range tests {
+		if got := FormatOf(path); got != want {
+			t.Errorf("FormatOf(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "keying.csv")
+	if err := os.WriteFile(path, []byte("0.1,1\n0.2,0\n"), 0644); err != nil {
+		t.Fatalf("failed to write capture: %v", err)
+	}
+	transitions, err := ReadFile(path, FormatCSV, "")
+	if err != nil || len(transitions) != 2 {
+		t.Errorf("ReadFile() = %v, %v, want two transitions", transitions, err)
+	}
+
+	if _, err := ReadFile(path, "wav", ""); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("ReadFile() with unknown format error = %v, want %v", err, ErrUnknownFormat)
+	}
+
+	empty := filepath.Join(dir, "empty.csv")
+	if err := os.WriteFile(empty, []byte("time,level\n"), 0644); err != nil {
+		t.Fatalf("failed to write capture: %v", err)
+	}
+	if _, err := ReadFile(empty, FormatCSV, ""); !errors.Is(err, ErrNoTransitions) {
+		t.Errorf("ReadFile() of empty capture error = %v, want %v", err, ErrNoTransitions)
+	}
+}
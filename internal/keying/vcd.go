@@ -0,0 +1,183 @@
+// internal/keying/vcd.go
+package keying
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxVCDToken is the longest token read from a VCD file, in bytes
+const MaxVCDToken = 1024 * 1024
+
+var (
+	// ErrInvalidVCD indicates a malformed Value Change Dump
+	ErrInvalidVCD = errors.New("invalid VCD")
+	// ErrSignalNotFound indicates the requested VCD variable is not declared
+	ErrSignalNotFound = errors.New("signal not found in VCD")
+)
+
+// vcdUnits maps VCD timescale units to nanoseconds
+var vcdUnits = map[string]float64{
+	"s":  1e9,
+	"ms": 1e6,
+	"us": 1e3,
+	"ns": 1,
+	"ps": 1e-3,
+	"fs": 1e-6,
+}
+
+// vcdReader holds the state of a VCD parse
+type vcdReader struct {
+	tokens *bufio.Scanner
+	signal string
+
+	id          string  // identifier code of the selected variable
+	nsPerStep   float64 // length of one time step
+	step        float64 // current time in steps
+	transitions []Transition
+}
+
+// ReadVCD reads the transitions of one variable from a Value Change Dump.
+// signal is the variable's reference name; "" selects the first one-bit variable.
+// Unknown (x) and high-impedance (z) values are ignored.
+func ReadVCD(r io.Reader, signal string) ([]Transition, error) {
+	tokens := bufio.NewScanner(r)
+	tokens.Buffer(nil, MaxVCDToken)
+	tokens.Split(bufio.ScanWords)
+
+	v := &vcdReader{tokens: tokens, signal: signal, nsPerStep: vcdUnits["ns"]}
+	for tokens.Scan() {
+		if err := v.token(tokens.Text()); err != nil {
+			return nil, err
+		}
+	}
+	if err := tokens.Err(); err != nil {
+		return nil, fmt.Errorf("read VCD: %w", err)
+	}
+	if v.id == "" {
+		return nil, fmt.Errorf("%w: %q", ErrSignalNotFound, signal)
+	}
+	return v.transitions, nil
+}
+
+// ReadVCDFile reads the transitions of one variable from a VCD file
+func ReadVCDFile(path, signal string) ([]Transition, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open VCD: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	return ReadVCD(f, signal)
+}
+
+// token handles one whitespace-separated token
+func (v *vcdReader) token(token string) error {
+	switch token[0] {
+	case '$':
+		switch token {
+		case "$timescale":
+			return v.timescale(v.section())
+		case "$var":
+			return v.variable(v.section())
+		case "$dumpvars", "$dumpall", "$dumpon", "$dumpoff", "$end":
+			// These wrap ordinary value changes
+		default:
+			v.section()
+		}
+	case '#':
+		step, err := strconv.ParseFloat(token[1:], 64)
+		if err != nil {
+			return fmt.Errorf("%w: time %q", ErrInvalidVCD, token)
+		}
+		v.step = step
+	case 'b', 'B', 'r', 'R':
+		// Vector and real values are followed by their identifier
+		if !v.tokens.Scan() {
+			return fmt.Errorf("%w: value %q without identifier", ErrInvalidVCD, token)
+		}
+		if v.tokens.Text() == v.id {
+			v.change(vectorLevel(token))
+		}
+	default:
+		if token[1:] == v.id {
+			v.change(scalarLevel(token[0]))
+		}
+	}
+	return nil
+}
+
+// section returns the tokens up to the next $end
+func (v *vcdReader) section() []string {
+	var body []string
+	for v.tokens.Scan() && v.tokens.Text() != "$end" {
+		body = append(body, v.tokens.Text())
+	}
+	return body
+}
+
+// timescale sets the length of one time step from a timescale such as "10 us" or "1ns"
+func (v *vcdReader) timescale(body []string) error {
+	spec := strings.Join(body, "")
+	digits := strings.TrimRight(spec, "munpfs")
+	magnitude, err := strconv.Atoi(digits)
+	unit, ok := vcdUnits[spec[len(digits):]]
+	if err != nil || !ok {
+		return fmt.Errorf("%w: timescale %q", ErrInvalidVCD, spec)
+	}
+	v.nsPerStep = float64(magnitude) * unit
+	return nil
+}
+
+// variable selects a declared variable ("wire 1 ! key") if it is the requested signal
+func (v *vcdReader) variable(body []string) error {
+	if len(body) < 4 {
+		return fmt.Errorf("%w: variable %q", ErrInvalidVCD, strings.Join(body, " "))
+	}
+	size, id, name := body[1], body[2], body[3]
+	if v.id == "" && ((v.signal == "" && size == "1") || name == v.signal) {
+		v.id = id
+	}
+	return nil
+}
+
+// change records the selected variable's level at the current time unless it is unknown
+func (v *vcdReader) change(high, known bool) {
+	if !known || v.id == "" {
+		return
+	}
+	v.transitions = append(v.transitions, Transition{
+		Time: time.Duration(v.step * v.nsPerStep),
+		High: high,
+	})
+}
+
+// scalarLevel returns the level of a scalar value and whether it is known (not x or z)
+func scalarLevel(value byte) (high, known bool) {
+	switch value {
+	case '1':
+		return true, true
+	case '0':
+		return false, true
+	}
+	return false, false
+}
+
+// vectorLevel returns whether a vector ("b0010") or real ("r1.5") value is non-zero,
+// and whether it is known
+func vectorLevel(token string) (high, known bool) {
+	value := token[1:]
+	if strings.ContainsAny(value, "xXzZ") {
+		return false, false
+	}
+	if token[0] == 'r' || token[0] == 'R' {
+		f, err := strconv.ParseFloat(value, 64)
+		return f != 0, err == nil
+	}
+	return strings.Contains(value, "1"), true
+}
@@ -0,0 +1,122 @@
+package keying
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testVCD = `$date today $end
+$version logic analyser export $end
+$timescale 10 us $end
+$scope module top $end
+$var wire 8 # bus [7:0] $end
+$var wire 1 ! key $end
+$var wire 1 " paddle_dah $end
+$upscope $end
+$enddefinitions $end
+#0
+$dumpvars
+1!
+0"
+b00000000 #
+$end
+#10000
+0!
+1"
+#16000
+1!
+#22000
+x!
+#25000
+0!
+b00000101 #
+`
+
+func TestReadVCD_FirstOneBitSignal(t *testing.T) {
+	transitions, err := ReadVCD(strings.NewReader(testVCD), "")
+	if err != nil {
+		t.Fatalf("ReadVCD() error = %v", err)
+	}
+	want := []Transition{
+		{Time: 0, High: true},
+		{Time: 100 * time.Millisecond, High: false},
+		{Time: 160 * time.Millisecond, High: true},
+		{Time: 250 * time.Millisecond, High: false},
+	}
+	if len(transitions) != len(want) {
+		t.Fatalf("ReadVCD() = %+v, want %+v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("transition %d = %+v, want %+v", i, transitions[i], want[i])
+		}
+	}
+}
+
+func TestReadVCD_NamedSignals(t *testing.T) {
+	transitions, err := ReadVCD(strings.NewReader(testVCD), "paddle_dah")
+	if err != nil {
+		t.Fatalf("ReadVCD() error = %v", err)
+	}
+	if len(transitions) != 2 || transitions[0].High || !transitions[1].High {
+		t.Errorf("paddle_dah transitions = %+v, want low then high", transitions)
+	}
+
+	transitions, err = ReadVCD(strings.NewReader(testVCD), "bus")
+	if err != nil {
+		t.Fatalf("ReadVCD() error = %v", err)
+	}
+	if len(transitions) != 2 || transitions[0].High || !transitions[1].High {
+		t.Errorf("bus transitions = %+v, want zero then non-zero", transitions)
+	}
+}
+
+func TestReadVCD_Timescales(t *testing.T) {
+	tests := map[string]time.Duration{
+		"1 ns":   5 * time.Nanosecond,
+		"1ms":    5 * time.Millisecond,
+		"100 ps": 500 * time.Nanosecond / 1000,
+		"1 s":    5 * time.Second,
+	}
+	for scale, want := range tests {
+		input := "$timescale " + scale + " $end $var wire 1 k key $end $enddefinitions $end #5 1k"
+		transitions, err := ReadVCD(strings.NewReader(input), "")
+		if err != nil {
+			t.Fatalf("ReadVCD() with timescale %q error = %v", scale, err)
+		}
+		if len(transitions) != 1 || transitions[0].Time != want {
+			t.Errorf("timescale %q: transitions = %+v, want one at %v", scale, transitions, want)
+		}
+	}
+}
+
+func TestReadVCD_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		signal string
+		want   error
+	}{
+		{"unknown signal", testVCD, "straight_key", ErrSignalNotFound},
+		{"bad timescale", "$timescale 1 parsec $end", "", ErrInvalidVCD},
+		{"bad time", "$var wire 1 k key $end #later 1k", "", ErrInvalidVCD},
+		{"short variable", "$var wire 1 $end", "", ErrInvalidVCD},
+		{"vector without identifier", "$var wire 1 k key $end #0 b1", "", ErrInvalidVCD},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadVCD(strings.NewReader(tt.input), tt.signal); !errors.Is(err, tt.want) {
+				t.Errorf("ReadVCD() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadVCDFile_Missing(t *testing.T) {
+	if _, err := ReadVCDFile(filepath.Join(t.TempDir(), "missing.vcd"), ""); err == nil {
+		t.Error("ReadVCDFile() should fail for a missing file")
+	}
+}
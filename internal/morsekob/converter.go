@@ -0,0 +1,56 @@
+// internal/morsekob/converter.go
+package morsekob
+
+import (
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+)
+
+// Converter turns the code of data packets into the tone events the audio detector
+// would report. Event times run on a clock advanced by the code durations, so
+// timing is exact however the packets were delayed on the network.
+type Converter struct {
+	now      time.Time
+	key      dsp.KeyLine
+	space    time.Duration    // key-up time since the last mark
+	sequence map[string]int32 // last sequence number seen from each station
+}
+
+// NewConverter creates a converter whose clock starts at start
+func NewConverter(start time.Time) *Converter {
+	return &Converter{
+		now:      start,
+		sequence: make(map[string]int32),
+	}
+}
+
+// Events returns the tone events for a packet's code. Packets other than code,
+// and repeats of a station's last packet, give no events. Circuit open and closed
+// markers are skipped; the key is taken to be up before the first mark.
+func (c *Converter) Events(packet Packet) []dsp.ToneEvent {
+	if packet.Command != CommandData || packet.IsID || len(packet.Code) == 0 {
+		return nil
+	}
+	if last, ok := c.sequence[packet.Station]; ok && last == packet.Sequence {
+		return nil
+	}
+	c.sequence[packet.Station] = packet.Sequence
+
+	var events []dsp.ToneEvent
+	for _, code := range packet.Code {
+		duration := time.Duration(code) * time.Millisecond
+		switch {
+		case code < 0:
+			c.space -= duration
+		case code == CircuitClosed || code == CircuitOpen:
+		default:
+			c.now = c.now.Add(c.space)
+			events = append(events, c.key.Down(c.now))
+			c.now = c.now.Add(duration)
+			events = append(events, c.key.Up(c.now))
+			c.space = 0
+		}
+	}
+	return events
+}
@@ -0,0 +1,65 @@
+package morsekob
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+)
+
+func TestConverter_Events(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewConverter(start)
+
+	ms := func(n int) time.Duration { return time.Duration(n) * time.Millisecond }
+	events := c.Events(Packet{Command: CommandData, Station: "A", Sequence: 1,
+		Code: []int32{LongSpace, 60, -60, 180, CircuitClosed}})
+	want := []dsp.ToneEvent{
+		{ToneOn: true, Timestamp: start.Add(ms(32767)), Magnitude: dsp.KeyDownMagnitude},
+		{ToneOn: false, Timestamp: start.Add(ms(32827)), Duration: ms(60)},
+		{ToneOn: true, Timestamp: start.Add(ms(32887)), Duration: ms(60), Magnitude: dsp.KeyDownMagnitude},
+		{ToneOn: false, Timestamp: start.Add(ms(33067)), Duration: ms(180)},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Events() = %+v, want %+v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+
+	// The space carries over to the next packet
+	events = c.Events(Packet{Command: CommandData, Station: "A", Sequence: 2, Code: []int32{-100, -80, 60}})
+	if len(events) != 2 || events[0].Duration != ms(180) || !events[0].Timestamp.Equal(start.Add(ms(33247))) {
+		t.Errorf("next packet events = %+v, want key down after 180ms", events)
+	}
+}
+
+func TestConverter_SkipsRepeatsAndOtherPackets(t *testing.T) {
+	c := NewConverter(time.Now())
+	code := Packet{Command: CommandData, Station: "A", Sequence: 5, Code: []int32{-60, 60}}
+	if events := c.Events(code); len(events) != 2 {
+		t.Fatalf("Events() returned %d events, want 2", len(events))
+	}
+	if events := c.Events(code); len(events) != 0 {
+		t.Errorf("repeated packet gave %d events, want 0", len(events))
+	}
+
+	// Another station may use the same sequence number
+	code.Station = "B"
+	if events := c.Events(code); len(events) != 2 {
+		t.Errorf("other station's packet gave %d events, want 2", len(events))
+	}
+
+	others := []Packet{
+		{Command: CommandConnect, Wire: 1},
+		{Command: CommandData, Station: "C", IsID: true},
+		{Command: CommandData, Station: "D", Code: []int32{CircuitOpen}},
+	}
+	for _, packet := range others {
+		if events := c.Events(packet); len(events) != 0 {
+			t.Errorf("Events(%+v) = %+v, want none", packet, events)
+		}
+	}
+}
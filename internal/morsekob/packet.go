@@ -0,0 +1,146 @@
+// internal/morsekob/packet.go
+// Package morsekob receives landline Morse sent over the MorseKOB wire protocol, which
+// CWCom also speaks, and turns its keying into tone events for the CW decoder.
+package morsekob
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Packet commands
+const (
+	// CommandDisconnect leaves a wire
+	CommandDisconnect int16 = 2
+	// CommandData carries code or a station ID
+	CommandData int16 = 3
+	// CommandConnect joins a wire
+	CommandConnect int16 = 4
+	// CommandAck acknowledges a connect
+	CommandAck int16 = 5
+)
+
+// Packet layout. All fields are little-endian.
+const (
+	// DefaultPort is the UDP port MorseKOB and CWCom servers listen on
+	DefaultPort = 7890
+	// ShortPacketSize is the size of a command and wire number packet
+	ShortPacketSize = 4
+	// DataPacketSize is the size of a code or ID packet
+	DataPacketSize = 496
+	// StationSize is the size of the station ID field
+	StationSize = 128
+	// TextSize is the size of the text (or program version) field
+	TextSize = 128
+	// MaxCode is the number of code durations a packet can carry
+	MaxCode = 51
+
+	stationOffset  = 4
+	sequenceOffset = 136
+	idFlagOffset   = 140
+	codeOffset     = 152
+	countOffset    = codeOffset + 4*MaxCode
+	textOffset     = countOffset + 4
+)
+
+// Special code values
+const (
+	// LongSpace is the space sent before the first mark after a long pause (-32.767s)
+	LongSpace int32 = -0x7fff
+	// CircuitClosed marks the sender latching their key closed
+	CircuitClosed int32 = 1
+	// CircuitOpen marks the sender opening their key
+	CircuitOpen int32 = 2
+)
+
+// ErrInvalidPacket indicates a datagram that is not a MorseKOB packet
+var ErrInvalidPacket = errors.New("invalid MorseKOB packet")
+
+// Packet is one MorseKOB datagram
+type Packet struct {
+	// Command is one of the Command constants
+	Command int16
+	// Wire is the wire number of a connect packet
+	Wire int16
+	// Station is the sending station's ID
+	Station string
+	// Sequence numbers data packets; senders may repeat a packet
+	Sequence int32
+	// IsID is set on the data packets stations send to announce themselves
+	IsID bool
+	// Code holds mark (positive) and space (negative) durations in milliseconds
+	Code []int32
+	// Text is the text of a code packet, or the program version of an ID packet
+	Text string
+}
+
+// ParsePacket decodes a datagram
+func ParsePacket(data []byte) (Packet, error) {
+	if len(data) < ShortPacketSize {
+		return Packet{}, fmt.Errorf("%w: %d bytes", ErrInvalidPacket, len(data))
+	}
+	p := Packet{Command: int16(binary.LittleEndian.Uint16(data))}
+	if p.Command != CommandData {
+		if len(data) == ShortPacketSize {
+			p.Wire = int16(binary.LittleEndian.Uint16(data[2:]))
+		}
+		return p, nil
+	}
+	if len(data) < DataPacketSize {
+		return Packet{}, fmt.Errorf("%w: data packet of %d bytes", ErrInvalidPacket, len(data))
+	}
+
+	p.Station = cString(data[stationOffset : stationOffset+StationSize])
+	p.Sequence = int32(binary.LittleEndian.Uint32(data[sequenceOffset:]))
+	p.IsID = binary.LittleEndian.Uint32(data[idFlagOffset:]) != 0
+	p.Text = cString(data[textOffset : textOffset+TextSize])
+	if p.IsID {
+		return p, nil
+	}
+
+	count := int32(binary.LittleEndian.Uint32(data[countOffset:]))
+	if count < 0 || count > MaxCode {
+		return Packet{}, fmt.Errorf("%w: %d code durations", ErrInvalidPacket, count)
+	}
+	p.Code = make([]int32, count)
+	for i := range p.Code {
+		p.Code[i] = int32(binary.LittleEndian.Uint32(data[codeOffset+4*i:]))
+	}
+	return p, nil
+}
+
+// Bytes encodes the packet. Data packets carry at most MaxCode durations.
+func (p Packet) Bytes() []byte {
+	if p.Command != CommandData {
+		data := make([]byte, ShortPacketSize)
+		binary.LittleEndian.PutUint16(data, uint16(p.Command))
+		binary.LittleEndian.PutUint16(data[2:], uint16(p.Wire))
+		return data
+	}
+
+	data := make([]byte, DataPacketSize)
+	binary.LittleEndian.PutUint16(data, uint16(p.Command))
+	binary.LittleEndian.PutUint16(data[2:], DataPacketSize-ShortPacketSize)
+	copy(data[stationOffset:stationOffset+StationSize-1], p.Station)
+	binary.LittleEndian.PutUint32(data[sequenceOffset:], uint32(p.Sequence))
+	copy(data[textOffset:textOffset+TextSize-1], p.Text)
+	if p.IsID {
+		binary.LittleEndian.PutUint32(data[idFlagOffset:], 1)
+		return data
+	}
+
+	code := p.Code[:min(len(p.Code), MaxCode)]
+	for i, duration := range code {
+		binary.LittleEndian.PutUint32(data[codeOffset+4*i:], uint32(duration))
+	}
+	binary.LittleEndian.PutUint32(data[countOffset:], uint32(len(code)))
+	return data
+}
+
+// cString returns a NUL-terminated string field
+func cString(field []byte) string {
+	s, _, _ := strings.Cut(string(field), "\x00")
+	return s
+}
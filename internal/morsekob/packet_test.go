@@ -0,0 +1,83 @@
+package morsekob
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestPacket_CodeRoundTrip(t *testing.T) {
+	packet := Packet{
+		Command:  CommandData,
+		Station:  "W1AW, Newington CT",
+		Sequence: 42,
+		Code:     []int32{LongSpace, 60, -60, 180, -180, 60},
+		Text:     "EN",
+	}
+	data := packet.Bytes()
+	if len(data) != DataPacketSize {
+		t.Fatalf("Bytes() length = %d, want %d", len(data), DataPacketSize)
+	}
+
+	got, err := ParsePacket(data)
+	if err != nil {
+		t.Fatalf("ParsePacket() error = %v", err)
+	}
+	if got.Command != CommandData || got.Station != packet.Station || got.Sequence != 42 ||
+		got.IsID || got.Text != "EN" || !slices.Equal(got.Code, packet.Code) {
+		t.Errorf("ParsePacket() = %+v, want %+v", got, packet)
+	}
+}
+
+func TestPacket_IDRoundTrip(t *testing.T) {
+	packet := Packet{Command: CommandData, Station: "KOB office", Sequence: 7, IsID: true, Text: "MorseKOB 2.5"}
+	got, err := ParsePacket(packet.Bytes())
+	if err != nil {
+		t.Fatalf("ParsePacket() error = %v", err)
+	}
+	if !got.IsID || got.Station != "KOB office" || got.Text != "MorseKOB 2.5" || len(got.Code) != 0 {
+		t.Errorf("ParsePacket() = %+v, want %+v", got, packet)
+	}
+}
+
+func TestPacket_ShortRoundTrip(t *testing.T) {
+	got, err := ParsePacket(Packet{Command: CommandConnect, Wire: 108}.Bytes())
+	if err != nil {
+		t.Fatalf("ParsePacket() error = %v", err)
+	}
+	if got.Command != CommandConnect || got.Wire != 108 {
+		t.Errorf("ParsePacket() = %+v, want connect to wire 108", got)
+	}
+}
+
+func TestPacket_BytesLimitsCode(t *testing.T) {
+	code := make([]int32, MaxCode+10)
+	for i := range code {
+		code[i] = 60
+	}
+	got, err := ParsePacket(Packet{Command: CommandData, Code: code}.Bytes())
+	if err != nil {
+		t.Fatalf("ParsePacket() error = %v", err)
+	}
+	if len(got.Code) != MaxCode {
+		t.Errorf("code length = %d, want %d", len(got.Code), MaxCode)
+	}
+}
+
+func TestParsePacket_Invalid(t *testing.T) {
+	badCount := Packet{Command: CommandData, Code: []int32{60}}.Bytes()
+	badCount[countOffset] = MaxCode + 1
+
+	tests := map[string][]byte{
+		"too short":      {3, 0},
+		"short data":     {3, 0, 0xec, 1, 'W'},
+		"bad code count": badCount,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParsePacket(data); !errors.Is(err, ErrInvalidPacket) {
+				t.Errorf("ParsePacket() error = %v, want %v", err, ErrInvalidPacket)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+// internal/morsekob/receiver.go
+package morsekob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+)
+
+// Receiver constants
+const (
+	// KeepAliveInterval is how often a connected receiver re-sends its connect and ID
+	// packets; servers drop stations they have not heard from for a while
+	KeepAliveInterval = 10 * time.Second
+	// MaxDatagramSize is the largest datagram read
+	MaxDatagramSize = 1024
+	// DefaultStation is the station ID sent to servers when none is configured
+	DefaultStation = "cwdecoder"
+	// Version is the program version sent in ID packets
+	Version = "cwdecoder"
+	// DefaultIdleTimeout is how long after the last code packet the receiver reports
+	// the wire idle; senders pause far less than this between the packets of an over
+	DefaultIdleTimeout = 2 * time.Second
+)
+
+// ErrInvalidWire indicates a wire number outside 1-32767
+var ErrInvalidWire = errors.New("wire must be between 1 and 32767")
+
+// Config holds receiver configuration
+type Config struct {
+	// Listen is the local UDP address to receive on, e.g. ":7890" (default: any port)
+	Listen string
+	// Server is the MorseKOB or CWCom server to connect to, e.g. "mtc-kob.dyndns.org:7890".
+	// Empty receives whatever is sent to Listen.
+	Server string
+	// Wire is the wire to join on Server
+	Wire int
+	// Station is the ID announced to Server (default: DefaultStation)
+	Station string
+	// IdleTimeout is how long after the last code packet the idle callback is called
+	// (default: DefaultIdleTimeout)
+	IdleTimeout time.Duration
+}
+
+// Receiver receives MorseKOB packets over UDP and reports their keying as tone events
+type Receiver struct {
+	config    Config
+	conn      *net.UDPConn
+	server    *net.UDPAddr
+	converter *Converter
+	sequence  int32
+
+	callbackPtr     atomic.Pointer[dsp.ToneCallback]
+	idleCallbackPtr atomic.Pointer[func()]
+}
+
+// Listen opens the receiver's UDP socket and resolves its server
+func Listen(cfg Config) (*Receiver, error) {
+	if cfg.Station == "" {
+		cfg.Station = DefaultStation
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = DefaultIdleTimeout
+	}
+	r := &Receiver{config: cfg}
+	if cfg.Server != "" {
+		if cfg.Wire < 1 || cfg.Wire > 32767 {
+			return nil, fmt.Errorf("%w, got %d", ErrInvalidWire, cfg.Wire)
+		}
+		server, err := net.ResolveUDPAddr("udp", cfg.Server)
+		if err != nil {
+			return nil, fmt.Errorf("resolve server: %w", err)
+		}
+		r.server = server
+	}
+
+	local, err := net.ResolveUDPAddr("udp", cfg.Listen)
+	if err != nil {
+		return nil, fmt.Errorf("resolve listen address: %w", err)
+	}
+	r.conn, err = net.ListenUDP("udp", local)
+	if err != nil {
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+	return r, nil
+}
+
+// Addr returns the local address packets are received on
+func (r *Receiver) Addr() net.Addr {
+	return r.conn.LocalAddr()
+}
+
+// SetCallback sets the callback for tone events. Set before calling Run.
+func (r *Receiver) SetCallback(cb dsp.ToneCallback) {
+	if cb == nil {
+		r.callbackPtr.Store(nil)
+	} else {
+		r.callbackPtr.Store(&cb)
+	}
+}
+
+// SetIdleCallback sets the callback for when no code has arrived for the idle timeout
+// since the last code packet, e.g. to flush the last character of an over. Called from
+// the goroutine running Run, as tone events are. Set before calling Run.
+func (r *Receiver) SetIdleCallback(cb func()) {
+	if cb == nil {
+		r.idleCallbackPtr.Store(nil)
+	} else {
+		r.idleCallbackPtr.Store(&cb)
+	}
+}
+
+// Run receives packets until ctx is cancelled, then disconnects and closes the socket.
+// Event times start from the wall clock when Run is called. Datagrams that are not
+// MorseKOB packets are ignored.
+func (r *Receiver) Run(ctx context.Context) error {
+	r.converter = NewConverter(time.Now())
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		r.disconnect()
+		_ = r.conn.Close()
+	}()
+
+	if r.server != nil {
+		if err := r.connect(); err != nil {
+			return err
+		}
+		go r.keepAlive(done)
+	}
+
+	buf := make([]byte, MaxDatagramSize)
+	idle := true // no code since the last idle callback
+	for {
+		var deadline time.Time
+		if !idle && r.idleCallbackPtr.Load() != nil {
+			deadline = time.Now().Add(r.config.IdleTimeout)
+		}
+		if err := r.conn.SetReadDeadline(deadline); err != nil && ctx.Err() == nil {
+			return fmt.Errorf("set read deadline: %w", err)
+		}
+		n, _, err := r.conn.ReadFromUDP(buf)
+		switch {
+		case err == nil:
+		case ctx.Err() != nil:
+			return nil
+		case errors.Is(err, os.ErrDeadlineExceeded):
+			idle = true
+			if cb := r.idleCallbackPtr.Load(); cb != nil {
+				(*cb)()
+			}
+			continue
+		default:
+			return fmt.Errorf("receive: %w", err)
+		}
+		packet, err := ParsePacket(buf[:n])
+		if err != nil {
+			continue
+		}
+		events := r.converter.Events(packet)
+		if len(events) > 0 {
+			idle = false
+		}
+		if cb := r.callbackPtr.Load(); cb != nil {
+			for _, event := range events {
+				(*cb)(event)
+			}
+		}
+	}
+}
+
+// keepAlive re-sends the connect and ID packets until done is closed
+func (r *Receiver) keepAlive(done <-chan struct{}) {
+	ticker := time.NewTicker(KeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			_ = r.connect()
+		}
+	}
+}
+
+// connect joins the configured wire and announces the station
+func (r *Receiver) connect() error {
+	if err := r.send(Packet{Command: CommandConnect, Wire: int16(r.config.Wire)}); err != nil {
+		return err
+	}
+	r.sequence++
+	return r.send(Packet{
+		Command:  CommandData,
+		Station:  r.config.Station,
+		Sequence: r.sequence,
+		IsID:     true,
+		Text:     Version,
+	})
+}
+
+// disconnect leaves the configured wire
+func (r *Receiver) disconnect() {
+	if r.server != nil {
+		_ = r.send(Packet{Command: CommandDisconnect})
+	}
+}
+
+// send writes a packet to the server
+func (r *Receiver) send(packet Packet) error {
+	if _, err := r.conn.WriteToUDP(packet.Bytes(), r.server); err != nil {
+		return fmt.Errorf("send to server: %w", err)
+	}
+	return nil
+}
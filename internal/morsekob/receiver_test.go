@@ -0,0 +1,193 @@
+package morsekob
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+)
+
+// cqCode is "CQ" at 20 WPM as a sender would packet it, one character per packet
+var cqCode = [][]int32{
+	{LongSpace, 180, -60, 60, -60, 180, -60, 60},
+	{-180, 180, -60, 180, -60, 60, -60, 180},
+}
+
+// startReceiver runs a receiver on a local port until the test ends
+func startReceiver(t *testing.T, cfg Config, cb dsp.ToneCallback, idle func()) *Receiver {
+	t.Helper()
+	cfg.Listen = "127.0.0.1:0"
+	r, err := Listen(cfg)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	r.SetCallback(cb)
+	r.SetIdleCallback(idle)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- r.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	})
+	return r
+}
+
+func TestReceiver_DecodesUDPPackets(t *testing.T) {
+	decoder, err := cw.NewDecoder(cw.DecoderConfig{
+		InitialWPM:        20,
+		DitDahBoundary:    2.0,
+		InterCharBoundary: 2.0,
+		CharWordBoundary:  5.0,
+	})
+	if err != nil {
+		t.Fatalf("NewDecoder() error = %v", err)
+	}
+	defer decoder.Stop()
+
+	var mu sync.Mutex
+	var text strings.Builder
+	decoded := make(chan struct{}, 4)
+	decoder.SetCallback(func(output cw.DecodedOutput) {
+		if output.Character != 0 && !output.IsWordSpace {
+			mu.Lock()
+			text.WriteRune(output.Character)
+			mu.Unlock()
+			decoded <- struct{}{}
+		}
+	})
+	r := startReceiver(t, Config{}, decoder.HandleToneEvent, nil)
+
+	sender, err := net.Dial("udp", r.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer func() { _ = sender.Close() }()
+	for i, code := range cqCode {
+		packet := Packet{Command: CommandData, Station: "W1AW", Sequence: int32(i + 1), Code: code}
+		// Senders may send each packet twice; the repeat must not double the character
+		for range 2 {
+			if _, err := sender.Write(packet.Bytes()); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+		}
+	}
+	_, _ = sender.Write([]byte("not a packet"))
+
+	for range 2 {
+		select {
+		case <-decoded:
+		case <-time.After(5 * time.Second):
+			mu.Lock()
+			t.Fatalf("decoded %q before timing out, want %q", text.String(), "CQ")
+			mu.Unlock()
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if text.String() != "CQ" {
+		t.Errorf("decoded %q, want %q", text.String(), "CQ")
+	}
+}
+
+func TestReceiver_DelayedPackets(t *testing.T) {
+	decoder, err := cw.NewDecoder(cw.DecoderConfig{
+		InitialWPM:        20,
+		DitDahBoundary:    2.0,
+		InterCharBoundary: 2.0,
+		CharWordBoundary:  5.0,
+	})
+	if err != nil {
+		t.Fatalf("NewDecoder() error = %v", err)
+	}
+	decoder.UseEventClock()
+
+	var mu sync.Mutex
+	var text strings.Builder
+	decoder.SetCallback(func(output cw.DecodedOutput) {
+		mu.Lock()
+		text.WriteRune(output.Character)
+		mu.Unlock()
+	})
+	idle := make(chan struct{}, 1)
+	r := startReceiver(t, Config{IdleTimeout: time.Second}, decoder.HandleToneEvent, func() {
+		decoder.Flush()
+		idle <- struct{}{}
+	})
+
+	sender, err := net.Dial("udp", r.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer func() { _ = sender.Close() }()
+	for i, code := range cqCode {
+		// The second packet arrives after the 600 ms flush timeout of 20 WPM audio
+		if i > 0 {
+			time.Sleep(800 * time.Millisecond)
+		}
+		packet := Packet{Command: CommandData, Station: "W1AW", Sequence: int32(i + 1), Code: code}
+		if _, err := sender.Write(packet.Bytes()); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	select {
+	case <-idle:
+	case <-time.After(5 * time.Second):
+		t.Fatal("idle callback not called")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if text.String() != "CQ " {
+		t.Errorf("decoded %q, want %q", text.String(), "CQ ")
+	}
+}
+
+func TestReceiver_ConnectsToServer(t *testing.T) {
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP() error = %v", err)
+	}
+	defer func() { _ = server.Close() }()
+
+	startReceiver(t, Config{Server: server.LocalAddr().String(), Wire: 108, Station: "Test station"}, nil, nil)
+
+	_ = server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, MaxDatagramSize)
+	var packets []Packet
+	for range 2 {
+		n, _, err := server.ReadFromUDP(buf)
+		if err != nil {
+			t.Fatalf("server read error = %v", err)
+		}
+		packet, err := ParsePacket(buf[:n])
+		if err != nil {
+			t.Fatalf("ParsePacket() error = %v", err)
+		}
+		packets = append(packets, packet)
+	}
+	if packets[0].Command != CommandConnect || packets[0].Wire != 108 {
+		t.Errorf("first packet = %+v, want connect to wire 108", packets[0])
+	}
+	if !packets[1].IsID || packets[1].Station != "Test station" {
+		t.Errorf("second packet = %+v, want ID of Test station", packets[1])
+	}
+}
+
+func TestListen_Errors(t *testing.T) {
+	if _, err := Listen(Config{Server: "127.0.0.1:7890", Wire: 0}); !errors.Is(err, ErrInvalidWire) {
+		t.Errorf("Listen() with wire 0 error = %v, want %v", err, ErrInvalidWire)
+	}
+	if _, err := Listen(Config{Listen: "not an address"}); err == nil {
+		t.Error("Listen() should fail on a bad listen address")
+	}
+}
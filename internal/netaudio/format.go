@@ -0,0 +1,75 @@
+// internal/netaudio/format.go
+package netaudio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrUnknownFormat indicates a sample format that cannot be decoded
+var ErrUnknownFormat = errors.New("format must be one of S16_LE, S16_BE, S24_LE, S24_BE, S32_LE, S32_BE, F32_LE, F32_BE")
+
+// sampleFormat describes how one sample is encoded
+type sampleFormat struct {
+	size      int // bytes per sample
+	bigEndian bool
+	float     bool
+}
+
+// formats maps the ALSA-style format names used in the config to encodings
+var formats = map[string]sampleFormat{
+	"S16_LE": {size: 2},
+	"S16_BE": {size: 2, bigEndian: true},
+	"S24_LE": {size: 3},
+	"S24_BE": {size: 3, bigEndian: true},
+	"S32_LE": {size: 4},
+	"S32_BE": {size: 4, bigEndian: true},
+	"F32_LE": {size: 4, float: true},
+	"F32_BE": {size: 4, bigEndian: true, float: true},
+}
+
+// lookupFormat returns the encoding of a format name
+func lookupFormat(name string) (sampleFormat, error) {
+	f, ok := formats[name]
+	if !ok {
+		return sampleFormat{}, fmt.Errorf("%w, got %q", ErrUnknownFormat, name)
+	}
+	return f, nil
+}
+
+// decode appends the samples in data, which must be a whole number of samples,
+// to dst as float32 normalized to -1.0 to 1.0
+func (f sampleFormat) decode(dst []float32, data []byte) []float32 {
+	for ; len(data) >= f.size; data = data[f.size:] {
+		dst = append(dst, f.sample(data[:f.size]))
+	}
+	return dst
+}
+
+// sample decodes one sample
+func (f sampleFormat) sample(b []byte) float32 {
+	var order binary.ByteOrder = binary.LittleEndian
+	if f.bigEndian {
+		order = binary.BigEndian
+	}
+	switch f.size {
+	case 2:
+		return float32(int16(order.Uint16(b))) / (1 << 15)
+	case 3:
+		// Sign-extend the 24-bit value from the top of an int32
+		var v int32
+		if f.bigEndian {
+			v = int32(uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8)
+		} else {
+			v = int32(uint32(b[2])<<24 | uint32(b[1])<<16 | uint32(b[0])<<8)
+		}
+		return float32(v>>8) / (1 << 23)
+	}
+	bits := order.Uint32(b)
+	if f.float {
+		return math.Float32frombits(bits)
+	}
+	return float32(float64(int32(bits)) / (1 << 31))
+}
@@ -0,0 +1,48 @@
+package netaudio
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestSampleFormat_Decode(t *testing.T) {
+	half := math.Float32bits(0.5)
+	tests := []struct {
+		format string
+		data   []byte
+		want   []float32
+	}{
+		{"S16_LE", []byte{0x00, 0x40, 0x00, 0x80}, []float32{0.5, -1}},
+		{"S16_BE", []byte{0x40, 0x00, 0x80, 0x00}, []float32{0.5, -1}},
+		{"S24_LE", []byte{0x00, 0x00, 0x40, 0x00, 0x00, 0xc0}, []float32{0.5, -0.5}},
+		{"S24_BE", []byte{0x40, 0x00, 0x00, 0xc0, 0x00, 0x00}, []float32{0.5, -0.5}},
+		{"S32_LE", []byte{0x00, 0x00, 0x00, 0x40, 0x00, 0x00, 0x00, 0x80}, []float32{0.5, -1}},
+		{"S32_BE", []byte{0x40, 0x00, 0x00, 0x00, 0x80, 0x00, 0x00, 0x00}, []float32{0.5, -1}},
+		{"F32_LE", []byte{byte(half), byte(half >> 8), byte(half >> 16), byte(half >> 24)}, []float32{0.5}},
+		{"F32_BE", []byte{byte(half >> 24), byte(half >> 16), byte(half >> 8), byte(half)}, []float32{0.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			f, err := lookupFormat(tt.format)
+			if err != nil {
+				t.Fatalf("lookupFormat() error = %v", err)
+			}
+			got := f.decode(nil, tt.data)
+			if len(got) != len(tt.want) {
+				t.Fatalf("decode() = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("sample %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLookupFormat_Unknown(t *testing.T) {
+	if _, err := lookupFormat("U8"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("lookupFormat() error = %v, want %v", err, ErrUnknownFormat)
+	}
+}
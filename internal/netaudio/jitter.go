@@ -0,0 +1,69 @@
+// internal/netaudio/jitter.go
+package netaudio
+
+// JitterLimitFactor is how many jitter delays of audio the buffer holds before
+// discarding the oldest, bounding latency when the sender's clock runs fast
+const JitterLimitFactor = 4
+
+// jitterBuffer queues received samples so that playout can run at a steady rate
+// through bursts and gaps in network delivery. Playout starts once the buffer
+// holds the jitter delay; when it runs dry the missing audio is replaced with
+// silence until the delay has built up again. Not safe for concurrent use.
+type jitterBuffer struct {
+	queue      []float32
+	delay      int // samples to build up before playing
+	limit      int // samples held before the oldest are discarded
+	playing    bool
+	rebuffered bool // playout ran dry and is building up the delay again
+
+	concealed uint64 // silent samples played in place of late or lost audio
+	dropped   uint64 // samples discarded on overflow
+	underruns uint64 // times playout ran dry
+}
+
+// newJitterBuffer creates a buffer that delays playout by delay samples and is read
+// chunk samples at a time. However short the delay, it holds JitterLimitFactor chunks
+// so that playout without a delay still gets every sample.
+func newJitterBuffer(delay, chunk int) *jitterBuffer {
+	return &jitterBuffer{
+		delay: delay,
+		limit: max(delay, chunk) * JitterLimitFactor,
+	}
+}
+
+// write queues received samples, discarding the oldest beyond the limit.
+// A packet larger than the limit is always kept whole.
+func (j *jitterBuffer) write(samples []float32) {
+	j.queue = append(j.queue, samples...)
+	if excess := len(j.queue) - max(j.limit, len(samples)); excess > 0 {
+		j.queue = append(j.queue[:0], j.queue[excess:]...)
+		j.dropped += uint64(excess)
+	}
+}
+
+// read returns the next n samples to play, or nil before playout has started
+func (j *jitterBuffer) read(n int) []float32 {
+	if j.rebuffered && len(j.queue) >= j.delay {
+		j.rebuffered = false
+	}
+	if !j.playing {
+		if len(j.queue) < j.delay || len(j.queue) == 0 {
+			return nil
+		}
+		j.playing = true
+	}
+
+	out := make([]float32, n)
+	if j.rebuffered {
+		j.concealed += uint64(n)
+		return out
+	}
+	have := copy(out, j.queue)
+	j.queue = append(j.queue[:0], j.queue[have:]...)
+	if have < n {
+		j.concealed += uint64(n - have)
+		j.underruns++
+		j.rebuffered = true
+	}
+	return out
+}
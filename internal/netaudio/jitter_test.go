@@ -0,0 +1,76 @@
+package netaudio
+
+import "testing"
+
+func TestJitterBuffer_WaitsForDelay(t *testing.T) {
+	j := newJitterBuffer(4, 2)
+	j.write([]float32{1, 2, 3})
+	if got := j.read(2); got != nil {
+		t.Fatalf("read() before the delay = %v, want nil", got)
+	}
+	j.write([]float32{4})
+	if got := j.read(2); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("read() = %v, want [1 2]", got)
+	}
+	if j.concealed != 0 || j.underruns != 0 {
+		t.Errorf("concealed = %d, underruns = %d, want 0", j.concealed, j.underruns)
+	}
+}
+
+func TestJitterBuffer_ConcealsUnderrun(t *testing.T) {
+	j := newJitterBuffer(2, 2)
+	j.write([]float32{1, 2, 3})
+	if got := j.read(2); got[0] != 1 || got[1] != 2 {
+		t.Fatalf("read() = %v, want [1 2]", got)
+	}
+	// Runs dry: the missing sample is silence
+	if got := j.read(2); got[0] != 3 || got[1] != 0 {
+		t.Errorf("read() = %v, want [3 0]", got)
+	}
+	// Rebuffering plays silence until the delay has built up again
+	j.write([]float32{4})
+	if got := j.read(2); got[0] != 0 || got[1] != 0 {
+		t.Errorf("read() while rebuffering = %v, want silence", got)
+	}
+	j.write([]float32{5})
+	if got := j.read(2); got[0] != 4 || got[1] != 5 {
+		t.Errorf("read() after rebuffering = %v, want [4 5]", got)
+	}
+	if j.underruns != 1 || j.concealed != 3 {
+		t.Errorf("underruns = %d, concealed = %d, want 1 and 3", j.underruns, j.concealed)
+	}
+}
+
+func TestJitterBuffer_DropsOldestOnOverflow(t *testing.T) {
+	j := newJitterBuffer(1, 1)
+	j.write([]float32{1, 2, 3})
+	j.write([]float32{4, 5, 6})
+	if j.dropped != 2 {
+		t.Errorf("dropped = %d, want 2", j.dropped)
+	}
+	if got := j.read(4); got[0] != 3 || got[3] != 6 {
+		t.Errorf("read() = %v, want [3 4 5 6]", got)
+	}
+}
+
+func TestJitterBuffer_NoDelay(t *testing.T) {
+	j := newJitterBuffer(0, 512)
+	for range 3 {
+		j.write(make([]float32, 512))
+		if got := j.read(512); len(got) != 512 {
+			t.Fatalf("read() = %d samples, want 512", len(got))
+		}
+	}
+	if j.dropped != 0 || j.concealed != 0 || j.underruns != 0 {
+		t.Errorf("dropped = %d, concealed = %d, underruns = %d, want 0",
+			j.dropped, j.concealed, j.underruns)
+	}
+}
+
+func TestJitterBuffer_KeepsLargePacket(t *testing.T) {
+	j := newJitterBuffer(0, 2)
+	j.write(make([]float32, 100))
+	if j.dropped != 0 {
+		t.Errorf("dropped = %d, want a packet larger than the limit kept whole", j.dropped)
+	}
+}
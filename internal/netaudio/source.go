@@ -0,0 +1,326 @@
+// internal/netaudio/source.go
+// Package netaudio receives raw PCM audio streamed over the network by SDR programs
+// such as GQRX and SDR++, and plays it out at a steady rate like an audio device.
+package netaudio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stream protocols
+const (
+	// ProtocolUDP receives datagrams sent to the address (GQRX "UDP" output)
+	ProtocolUDP = "udp"
+	// ProtocolTCP connects to a server streaming audio at the address
+	ProtocolTCP = "tcp"
+	// ProtocolTCPListen accepts a connection at the address and reads audio from it
+	ProtocolTCPListen = "tcp-listen"
+)
+
+const (
+	// MaxDatagramSize is the largest UDP datagram read
+	MaxDatagramSize = 65536
+	// ReadBufferSize is how many bytes are read from a TCP stream at a time
+	ReadBufferSize = 8192
+	// ReconnectInterval is how long to wait before reconnecting a dropped TCP stream
+	ReconnectInterval = time.Second
+)
+
+var (
+	ErrUnknownProtocol = errors.New("protocol must be one of udp, tcp, tcp-listen")
+	ErrInvalidConfig   = errors.New("invalid network audio config")
+	ErrAlreadyRunning  = errors.New("network audio already running")
+	ErrNotRunning      = errors.New("network audio not running")
+)
+
+// SampleCallback is called from the playout goroutine with each buffer of samples.
+// WARNING: The samples slice is only valid for the duration of the callback.
+type SampleCallback func(samples []float32)
+
+// Config holds network audio configuration
+type Config struct {
+	Protocol    string        // udp, tcp or tcp-listen
+	Address     string        // host:port to listen on or connect to
+	Format      string        // sample format, e.g. S16_LE
+	SampleRate  float64       // samples per second per channel
+	Channels    int           // interleaved channels
+	BufferSize  int           // frames per callback
+	JitterDelay time.Duration // audio buffered before playout starts
+}
+
+// Stats counts what has been received and how playout coped with the network
+type Stats struct {
+	// Packets is the number of datagrams, or TCP reads, received
+	Packets uint64
+	// Bytes is the number of bytes received
+	Bytes uint64
+	// Samples is the number of samples received
+	Samples uint64
+	// Malformed is the number of bytes discarded from datagrams that did not
+	// hold a whole number of frames
+	Malformed uint64
+	// Concealed is the number of silent samples played in place of late or lost audio
+	Concealed uint64
+	// Dropped is the number of samples discarded because the buffer overflowed
+	Dropped uint64
+	// Underruns is the number of times playout ran out of audio
+	Underruns uint64
+}
+
+// Source receives a network audio stream
+type Source struct {
+	config    Config
+	format    sampleFormat
+	frameSize int // bytes per frame
+
+	mu       sync.Mutex // protects jitter and stats
+	jitter   *jitterBuffer
+	received Stats
+
+	callbackPtr atomic.Pointer[SampleCallback]
+	running     atomic.Bool
+	addr        net.Addr
+	closer      io.Closer
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// New creates a network audio source
+func New(cfg Config) (*Source, error) {
+	switch cfg.Protocol {
+	case ProtocolUDP, ProtocolTCP, ProtocolTCPListen:
+	default:
+		return nil, fmt.Errorf("%w, got %q", ErrUnknownProtocol, cfg.Protocol)
+	}
+	format, err := lookupFormat(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SampleRate <= 0 || cfg.Channels < 1 || cfg.BufferSize < 1 || cfg.JitterDelay < 0 {
+		return nil, fmt.Errorf("%w: sample rate, channels and buffer size must be positive", ErrInvalidConfig)
+	}
+
+	delay := int(cfg.JitterDelay.Seconds()*cfg.SampleRate) * cfg.Channels
+	return &Source{
+		config:    cfg,
+		format:    format,
+		frameSize: format.size * cfg.Channels,
+		jitter:    newJitterBuffer(delay, cfg.BufferSize*cfg.Channels),
+	}, nil
+}
+
+// SetCallback sets the callback for played-out samples. Set before calling Start().
+func (s *Source) SetCallback(cb SampleCallback) {
+	if cb == nil {
+		s.callbackPtr.Store(nil)
+	} else {
+		s.callbackPtr.Store(&cb)
+	}
+}
+
+// Start opens the stream and begins receiving and playing out audio.
+// TCP connections are made in the background and remade if they drop.
+func (s *Source) Start(ctx context.Context) error {
+	if !s.running.CompareAndSwap(false, true) {
+		return ErrAlreadyRunning
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	var receive func(context.Context)
+	switch s.config.Protocol {
+	case ProtocolUDP:
+		addr, err := net.ResolveUDPAddr("udp", s.config.Address)
+		if err == nil {
+			var conn *net.UDPConn
+			if conn, err = net.ListenUDP("udp", addr); err == nil {
+				s.addr, s.closer = conn.LocalAddr(), conn
+				receive = func(context.Context) { s.receiveUDP(conn) }
+			}
+		}
+		if err != nil {
+			s.stopped()
+			return fmt.Errorf("listen: %w", err)
+		}
+	case ProtocolTCP:
+		receive = s.dialTCP
+	case ProtocolTCPListen:
+		listener, err := net.Listen("tcp", s.config.Address)
+		if err != nil {
+			s.stopped()
+			return fmt.Errorf("listen: %w", err)
+		}
+		s.addr, s.closer = listener.Addr(), listener
+		receive = func(ctx context.Context) { s.acceptTCP(ctx, listener) }
+	}
+
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		receive(ctx)
+	}()
+	go func() {
+		defer s.wg.Done()
+		s.playout(ctx)
+	}()
+
+	// Stop on context cancellation
+	go func() {
+		<-ctx.Done()
+		if err := s.Stop(); err != nil && !errors.Is(err, ErrNotRunning) {
+			log.Printf("netaudio: stop on context cancel: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop stops receiving and playout, and waits for them to finish
+func (s *Source) Stop() error {
+	if !s.running.CompareAndSwap(true, false) {
+		return ErrNotRunning
+	}
+	s.cancel()
+	if s.closer != nil {
+		_ = s.closer.Close()
+	}
+	s.wg.Wait()
+	return nil
+}
+
+// stopped undoes a failed Start
+func (s *Source) stopped() {
+	s.cancel()
+	s.running.Store(false)
+}
+
+// IsRunning returns true if the source is active
+func (s *Source) IsRunning() bool {
+	return s.running.Load()
+}
+
+// Addr returns the local address a udp or tcp-listen source receives on, once started
+func (s *Source) Addr() net.Addr {
+	return s.addr
+}
+
+// Stats returns the stream's counters so far
+func (s *Source) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.received
+	stats.Concealed = s.jitter.concealed
+	stats.Dropped = s.jitter.dropped
+	stats.Underruns = s.jitter.underruns
+	return stats
+}
+
+// receiveUDP reads datagrams until the socket is closed
+func (s *Source) receiveUDP(conn *net.UDPConn) {
+	buf := make([]byte, MaxDatagramSize)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		whole := n - n%s.frameSize
+		s.receive(buf[:whole], uint64(n-whole))
+	}
+}
+
+// dialTCP connects to the server and reads its stream, reconnecting until ctx is done
+func (s *Source) dialTCP(ctx context.Context) {
+	var dialer net.Dialer
+	for ctx.Err() == nil {
+		conn, err := dialer.DialContext(ctx, "tcp", s.config.Address)
+		if err == nil {
+			s.readTCP(ctx, conn)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(ReconnectInterval):
+		}
+	}
+}
+
+// acceptTCP reads from one connection at a time until the listener is closed
+func (s *Source) acceptTCP(ctx context.Context, listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		s.readTCP(ctx, conn)
+	}
+}
+
+// readTCP reads a stream until it ends or ctx is done, keeping frames split across reads
+func (s *Source) readTCP(ctx context.Context, conn net.Conn) {
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+	defer func() { _ = conn.Close() }()
+
+	buf := make([]byte, ReadBufferSize)
+	pending := 0 // bytes of a partial frame at the start of buf
+	for {
+		n, err := conn.Read(buf[pending:])
+		if err != nil {
+			return
+		}
+		n += pending
+		whole := n - n%s.frameSize
+		s.receive(buf[:whole], 0)
+		pending = copy(buf, buf[whole:n])
+	}
+}
+
+// receive queues a whole number of frames for playout
+func (s *Source) receive(data []byte, malformed uint64) {
+	samples := s.format.decode(make([]float32, 0, len(data)/s.format.size), data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.received.Packets++
+	s.received.Bytes += uint64(len(data)) + malformed
+	s.received.Samples += uint64(len(samples))
+	s.received.Malformed += malformed
+	s.jitter.write(samples)
+}
+
+// playout delivers buffers to the callback at the sample rate until ctx is done
+func (s *Source) playout(ctx context.Context) {
+	chunk := s.config.BufferSize * s.config.Channels
+	period := time.Duration(float64(s.config.BufferSize) / s.config.SampleRate * float64(time.Second))
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	// Buffers due are counted from the start so a late tick catches up
+	start := time.Now()
+	var played int64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for due := int64(now.Sub(start) / period); played < due; played++ {
+				s.mu.Lock()
+				samples := s.jitter.read(chunk)
+				s.mu.Unlock()
+				if samples == nil {
+					// Not started yet: playout time begins with the first buffer
+					start, played = now, 0
+					break
+				}
+				if cb := s.callbackPtr.Load(); cb != nil {
+					(*cb)(samples)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package netaudio
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector gathers played-out samples
+type collector struct {
+	mu      sync.Mutex
+	samples []float32
+}
+
+func (c *collector) callback(samples []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.samples = append(c.samples, samples...)
+}
+
+// waitFor waits until a non-zero sample equal to want has been played
+func (c *collector) waitFor(t *testing.T, want float32) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		for _, s := range c.samples {
+			if s == want {
+				c.mu.Unlock()
+				return
+			}
+		}
+		c.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("sample %v was not played", want)
+}
+
+// s16 encodes samples as S16_LE
+func s16(samples ...int16) []byte {
+	data := make([]byte, 2*len(samples))
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(data[2*i:], uint16(s))
+	}
+	return data
+}
+
+// startSource starts a source on a local address until the test ends
+func startSource(t *testing.T, protocol, address string) (*Source, *collector) {
+	t.Helper()
+	s, err := New(Config{
+		Protocol:    protocol,
+		Address:     address,
+		Format:      "S16_LE",
+		SampleRate:  8000,
+		Channels:    1,
+		BufferSize:  64,
+		JitterDelay: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	c := &collector{}
+	s.SetCallback(c.callback)
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { _ = s.Stop() })
+	return s, c
+}
+
+func TestSource_UDP(t *testing.T) {
+	s, c := startSource(t, ProtocolUDP, "127.0.0.1:0")
+
+	conn, err := net.Dial("udp", s.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	packet := s16(make([]int16, 100)...)
+	packet = append(packet, s16(16384)...)
+	_, _ = conn.Write(packet)
+	_, _ = conn.Write([]byte{1, 2, 3}) // one whole sample and a stray byte
+
+	c.waitFor(t, 0.5)
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	stats := s.Stats()
+	if stats.Packets != 2 || stats.Bytes != 205 || stats.Samples != 102 || stats.Malformed != 1 {
+		t.Errorf("Stats() = %+v, want 2 packets, 205 bytes, 102 samples, 1 malformed byte", stats)
+	}
+	if err := s.Stop(); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("second Stop() error = %v, want %v", err, ErrNotRunning)
+	}
+}
+
+func TestSource_TCPListen(t *testing.T) {
+	s, c := startSource(t, ProtocolTCPListen, "127.0.0.1:0")
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	data := append(s16(make([]int16, 100)...), s16(-16384)...)
+	// A frame split across writes is reassembled
+	_, _ = conn.Write(data[:101])
+	time.Sleep(10 * time.Millisecond)
+	_, _ = conn.Write(data[101:])
+
+	c.waitFor(t, -0.5)
+	if stats := s.Stats(); stats.Samples != 101 || stats.Malformed != 0 {
+		t.Errorf("Stats() = %+v, want 101 samples and none malformed", stats)
+	}
+}
+
+func TestSource_TCPClient(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_, _ = conn.Write(append(s16(make([]int16, 100)...), s16(8192)...))
+		time.Sleep(time.Second)
+	}()
+
+	_, c := startSource(t, ProtocolTCP, listener.Addr().String())
+	c.waitFor(t, 0.25)
+}
+
+func TestSource_StopsOnContextCancel(t *testing.T) {
+	s, err := New(Config{Protocol: ProtocolUDP, Address: "127.0.0.1:0", Format: "S16_LE",
+		SampleRate: 8000, Channels: 1, BufferSize: 64})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := s.Start(ctx); !errors.Is(err, ErrAlreadyRunning) {
+		t.Errorf("second Start() error = %v, want %v", err, ErrAlreadyRunning)
+	}
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.IsRunning() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if s.IsRunning() {
+		t.Error("source should stop when its context is cancelled")
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	valid := Config{Protocol: ProtocolUDP, Address: ":0", Format: "S16_LE", SampleRate: 48000, Channels: 1, BufferSize: 512}
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   error
+	}{
+		{"protocol", func(c *Config) { c.Protocol = "sctp" }, ErrUnknownProtocol},
+		{"format", func(c *Config) { c.Format = "U8" }, ErrUnknownFormat},
+		{"sample rate", func(c *Config) { c.SampleRate = 0 }, ErrInvalidConfig},
+		{"jitter delay", func(c *Config) { c.JitterDelay = -time.Second }, ErrInvalidConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+			if _, err := New(cfg); !errors.Is(err, tt.want) {
+				t.Errorf("New() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+// internal/pipeline/pipeline.go
+// Package pipeline assembles the tone detector and CW decoder, for live and recorded audio.
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/classify"
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+	"github.com/ColonelBlimp/cwdecoder/internal/fist"
+	"github.com/ColonelBlimp/cwdecoder/internal/wav"
+)
+
+// Pipeline constants
+const (
+	// DefaultChunkSize is how many samples are fed at a time when no buffer_size is set
+	DefaultChunkSize = 512
+)
+
+// SampleClockStart is the time recorded audio is decoded from
+var SampleClockStart = time.Unix(0, 0).UTC()
+
+// Pipeline is a detector and decoder wired together
+type Pipeline struct {
+	settings  config.Settings
+	detector  *dsp.Detector
+	decoder   *cw.Decoder
+	adaptive  *cw.AdaptiveDecoder
+	fist      *fist.Analyzer
+	chunkSize int
+
+	toneCallbacks    []func(dsp.ToneEvent)
+	elementCallbacks []cw.ElementCallback
+	outputCallbacks  []cw.DecodedCallback
+
+	text strings.Builder
+}
+
+// New builds a pipeline from settings. The sample rate in settings must match the audio.
+// The detector runs on the wall clock; call UseSampleClock for recorded audio.
+func New(settings config.Settings) (*Pipeline, error) {
+	if settings.ClassifierModel == "" {
+		return NewWithClassifier(settings, nil)
+	}
+	model, err := classify.LoadFile(settings.ClassifierModel)
+	if err != nil {
+		return nil, fmt.Errorf("load classifier: %w", err)
+	}
+	return NewWithClassifier(settings, model)
+}
+
+// NewWithClassifier builds a pipeline whose decoder classifies elements with classifier
+// instead of the model in settings (nil = ratio boundaries).
+func NewWithClassifier(settings config.Settings, classifier cw.Classifier) (*Pipeline, error) {
+	goertzel, err := dsp.NewGoertzel(dsp.GoertzelConfig{
+		TargetFrequency: settings.ToneFrequency,
+		SampleRate:      settings.SampleRate,
+		BlockSize:       settings.BlockSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("init goertzel: %w", err)
+	}
+
+	detector, err := dsp.NewDetector(dsp.DetectorConfig{
+		Threshold:       settings.Threshold,
+		Hysteresis:      settings.Hysteresis,
+		OverlapPct:      settings.OverlapPct,
+		AGCEnabled:      settings.AGCEnabled,
+		AGCDecay:        settings.AGCDecay,
+		AGCAttack:       settings.AGCAttack,
+		AGCWarmupBlocks: settings.AGCWarmupBlocks,
+	}, goertzel)
+	if err != nil {
+		return nil, fmt.Errorf("init detector: %w", err)
+	}
+
+	decoder, err := cw.NewDecoder(cw.DecoderConfig{
+		InitialWPM:        settings.WPM,
+		AdaptiveTiming:    settings.AdaptiveTiming,
+		AdaptiveSmoothing: settings.AdaptiveSmoothing,
+		DitDahBoundary:    settings.DitDahBoundary,
+		InterCharBoundary: settings.InterCharBoundary,
+		CharWordBoundary:  settings.CharWordBoundary,
+		FarnsworthWPM:     settings.FarnsworthWPM,
+		AutoFarnsworth:    settings.FarnsworthAuto,
+		KeyMode:           cw.KeyMode(settings.KeyMode),
+		Alphabet:          cw.Alphabet(settings.Alphabet),
+		Code:              cw.Code(settings.MorseCode),
+		Speculative:       settings.SpeculativeOutput,
+		Classifier:        classifier,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("init cw decoder: %w", err)
+	}
+
+	p := &Pipeline{
+		settings:  settings,
+		detector:  detector,
+		decoder:   decoder,
+		chunkSize: settings.BufferSize,
+	}
+	if p.chunkSize <= 0 {
+		p.chunkSize = DefaultChunkSize
+	}
+
+	// The adaptive pattern decoder retunes the character boundary and corrects callsigns
+	if settings.AdaptivePatternEnabled {
+		adaptiveConfig := cw.AdaptiveConfig{
+			Enabled:             true,
+			MinConfidence:       settings.AdaptiveMinConfidence,
+			AdjustmentRate:      settings.AdaptiveAdjustmentRate,
+			MinMatchesForAdjust: settings.AdaptiveMinMatches,
+			SCPMaxDistance:      settings.SCPMaxDistance,
+		}
+		if settings.SCPFile != "" {
+			adaptiveConfig.SCP, err = cw.LoadSCPFile(settings.SCPFile)
+			if err != nil {
+				return nil, fmt.Errorf("load scp: %w", err)
+			}
+		}
+		p.adaptive = cw.NewAdaptiveDecoder(decoder, adaptiveConfig)
+		p.elementCallbacks = append(p.elementCallbacks, p.adaptive.RecordElement)
+	}
+	if settings.FistReport != "" {
+		p.fist = fist.NewAnalyzer(cw.Alphabet(settings.Alphabet), cw.Code(settings.MorseCode))
+		p.elementCallbacks = append(p.elementCallbacks, p.fist.RecordElement)
+	}
+
+	decoder.SetElementCallback(func(isDah bool, duration, gapAfter time.Duration, isCharEnd, isWordEnd bool) {
+		for _, cb := range p.elementCallbacks {
+			cb(isDah, duration, gapAfter, isCharEnd, isWordEnd)
+		}
+	})
+	decoder.SetCallback(func(output cw.DecodedOutput) {
+		// Provisional characters and retractions reach listeners but not the text
+		switch {
+		case !output.IsFinal():
+		case output.IsWordSpace:
+			p.text.WriteRune(' ')
+		case output.Character != 0:
+			p.text.WriteRune(output.Character)
+		}
+		for _, cb := range p.outputCallbacks {
+			cb(output)
+		}
+	})
+	detector.SetCallback(func(event dsp.ToneEvent) {
+		for _, cb := range p.toneCallbacks {
+			cb(event)
+		}
+		decoder.HandleToneEvent(event)
+	})
+	return p, nil
+}
+
+// OnToneEvent adds a listener for each tone event the detector passes to the decoder.
+// Must be called before Process.
+func (p *Pipeline) OnToneEvent(cb func(dsp.ToneEvent)) {
+	p.toneCallbacks = append(p.toneCallbacks, cb)
+}
+
+// OnElement adds a listener for each decoded element. Must be called before Process.
+func (p *Pipeline) OnElement(cb cw.ElementCallback) {
+	p.elementCallbacks = append(p.elementCallbacks, cb)
+}
+
+// OnOutput adds a listener for each decoded character and word space. Must be called before Process.
+func (p *Pipeline) OnOutput(cb cw.DecodedCallback) {
+	p.outputCallbacks = append(p.outputCallbacks, cb)
+}
+
+// UseSampleClock times tone events from the samples processed rather than the
+// wall clock, so recorded audio can be decoded faster than real time. The decoder
+// then flushes characters from the event times too, never from a wall-clock timer.
+func (p *Pipeline) UseSampleClock(start time.Time) {
+	p.detector.UseSampleClock(start)
+	p.decoder.UseEventClock()
+}
+
+// Detector returns the pipeline's tone detector
+func (p *Pipeline) Detector() *dsp.Detector {
+	return p.detector
+}
+
+// Decoder returns the pipeline's CW decoder
+func (p *Pipeline) Decoder() *cw.Decoder {
+	return p.decoder
+}
+
+// Adaptive returns the pipeline's adaptive pattern decoder, or nil if it is disabled
+func (p *Pipeline) Adaptive() *cw.AdaptiveDecoder {
+	return p.adaptive
+}
+
+// Fist returns the pipeline's fist analyzer, or nil if no fist_report is configured
+func (p *Pipeline) Fist() *fist.Analyzer {
+	return p.fist
+}
+
+// Process feeds samples through the detector in buffer_size chunks, as audio capture would
+func (p *Pipeline) Process(samples []float32) {
+	for len(samples) > 0 {
+		n := min(p.chunkSize, len(samples))
+		p.detector.Process(samples[:n])
+		samples = samples[n:]
+	}
+}
+
+// Replay feeds recorded tone events straight to the decoder, bypassing the detector.
+// The decoder flushes characters from the event times, as for UseSampleClock.
+func (p *Pipeline) Replay(events []dsp.ToneEvent) {
+	p.decoder.UseEventClock()
+	for _, event := range events {
+		p.decoder.HandleToneEvent(event)
+	}
+}
+
+// Flush emits the last character and stops the decoder's flush timer
+func (p *Pipeline) Flush() {
+	p.decoder.Flush()
+	p.decoder.Stop()
+}
+
+// Finish flushes the last character, stops the decoder and returns the decoded text
+func (p *Pipeline) Finish() string {
+	p.Flush()
+	return strings.Join(strings.Fields(p.text.String()), " ")
+}
+
+// Decode runs samples recorded at sampleRate through a new pipeline and returns the decoded text
+func Decode(settings config.Settings, samples []float32, sampleRate float64) (string, error) {
+	settings.SampleRate = sampleRate
+	p, err := New(settings)
+	if err != nil {
+		return "", err
+	}
+	// Recorded audio runs faster than real time, so time comes from the samples
+	p.UseSampleClock(SampleClockStart)
+	p.Process(samples)
+	return p.Finish(), nil
+}
+
+// DecodeFile decodes a WAV file and returns the decoded text
+func DecodeFile(settings config.Settings, path string) (string, error) {
+	samples, sampleRate, err := wav.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return Decode(settings, samples, float64(sampleRate))
+}
@@ -0,0 +1,307 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+	"github.com/ColonelBlimp/cwdecoder/internal/synth"
+	"github.com/ColonelBlimp/cwdecoder/internal/wav"
+)
+
+// testSettings returns the default decoder settings
+func testSettings() config.Settings {
+	return config.Settings{
+		SampleRate:             8000,
+		BufferSize:             1024,
+		ToneFrequency:          600,
+		BlockSize:              128,
+		OverlapPct:             50,
+		Threshold:              0.4,
+		Hysteresis:             2,
+		AGCEnabled:             true,
+		AGCDecay:               0.9995,
+		AGCAttack:              0.1,
+		AGCWarmupBlocks:        10,
+		WPM:                    20,
+		AdaptiveTiming:         true,
+		AdaptiveSmoothing:      0.1,
+		DitDahBoundary:         2.0,
+		InterCharBoundary:      2.0,
+		CharWordBoundary:       5.0,
+		FarnsworthAuto:         true,
+		KeyMode:                "electronic",
+		Alphabet:               "latin",
+		MorseCode:              "international",
+		AdaptivePatternEnabled: true,
+		AdaptiveMinConfidence:  0.7,
+		AdaptiveAdjustmentRate: 0.1,
+		AdaptiveMinMatches:     3,
+		SCPMaxDistance:         2,
+	}
+}
+
+// generate renders clean text at 20 WPM
+func generate(t *testing.T, text string) *synth.Signal {
+	t.Helper()
+	signal, err := synth.Generate(synth.GeneratorConfig{
+		Synth: synth.Config{SampleRate: 8000, ToneFrequency: 600, Amplitude: synth.DefaultAmplitude, RiseTime: synth.DefaultRiseTime},
+		WPM:   20,
+	}, text)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	return signal
+}
+
+func TestDecode_CleanSignal(t *testing.T) {
+	signal := generate(t, "CQ CQ DE W1AW K")
+
+	text, err := Decode(testSettings(), signal.Samples, signal.SampleRate)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if text != signal.Truth.Text {
+		t.Errorf("Decode() = %q, want %q", text, signal.Truth.Text)
+	}
+}
+
+func TestDecode_SpeculativeOutput(t *testing.T) {
+	signal := generate(t, "CQ 73 DE K1ABC 5NN")
+	settings := testSettings()
+	settings.SpeculativeOutput = true
+
+	text, err := Decode(settings, signal.Samples, signal.SampleRate)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if text != signal.Truth.Text {
+		t.Errorf("Decode() with speculative output = %q, want %q", text, signal.Truth.Text)
+	}
+}
+
+func TestDecode_QRQ(t *testing.T) {
+	// A 5 ms rise time would swallow an 8 ms dit, so keying is sharper at these speeds
+	signal, err := synth.Generate(synth.GeneratorConfig{
+		Synth: synth.Config{SampleRate: 48000, ToneFrequency: 600, Amplitude: synth.DefaultAmplitude, RiseTime: time.Millisecond},
+		WPM:   150,
+	}, "CQ CQ DE W1AW K")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	settings := testSettings()
+	settings.SampleRate = 48000
+	settings.BlockSize = 512
+	settings.WPM = 150
+	settings.QRQ = true
+	settings.FitQRQ()
+
+	text, err := Decode(settings, signal.Samples, signal.SampleRate)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if text != signal.Truth.Text {
+		t.Errorf("Decode() = %q, want %q", text, signal.Truth.Text)
+	}
+}
+
+func TestDecodeFile(t *testing.T) {
+	signal := generate(t, "TEST")
+	path := filepath.Join(t.TempDir(), "test.wav")
+	if err := wav.WriteFile(path, signal.Samples, int(signal.SampleRate)); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	text, err := DecodeFile(testSettings(), path)
+	if err != nil {
+		t.Fatalf("DecodeFile() error = %v", err)
+	}
+	if text != "TEST" {
+		t.Errorf("DecodeFile() = %q, want TEST", text)
+	}
+
+	if _, err := DecodeFile(testSettings(), filepath.Join(t.TempDir(), "missing.wav")); err == nil {
+		t.Error("DecodeFile() should fail for a missing file")
+	}
+}
+
+func TestNew_InvalidSettings(t *testing.T) {
+	settings := testSettings()
+	settings.Threshold = 2
+	if _, err := New(settings); err == nil {
+		t.Error("New() should reject an invalid threshold")
+	}
+}
+
+func TestNew_MissingClassifierModel(t *testing.T) {
+	settings := testSettings()
+	settings.ClassifierModel = filepath.Join(t.TempDir(), "missing.json")
+	if _, err := New(settings); err == nil {
+		t.Error("New() should fail when the classifier model cannot be loaded")
+	}
+}
+
+func TestNew_SCPFile(t *testing.T) {
+	settings := testSettings()
+	settings.SCPFile = filepath.Join(t.TempDir(), "MASTER.SCP")
+	if _, err := New(settings); err == nil {
+		t.Error("New() should fail when the SCP file cannot be loaded")
+	}
+
+	if err := os.WriteFile(settings.SCPFile, []byte("W1AW\nK1ABC\n"), 0644); err != nil {
+		t.Fatalf("failed to write SCP file: %v", err)
+	}
+	p, err := New(settings)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if p.Adaptive() == nil {
+		t.Error("Adaptive() = nil, want the adaptive decoder with the SCP database")
+	}
+
+	settings.AdaptivePatternEnabled = false
+	if p, _ := New(settings); p.Adaptive() != nil {
+		t.Error("Adaptive() should be nil with adaptive_pattern_enabled off")
+	}
+}
+
+func TestPipeline_Fist(t *testing.T) {
+	p, err := New(testSettings())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if p.Fist() != nil {
+		t.Error("Fist() should be nil without fist_report")
+	}
+
+	settings := testSettings()
+	settings.FistReport = "text"
+	p, err = New(settings)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	p.UseSampleClock(SampleClockStart)
+	p.Process(generate(t, "PARIS").Samples)
+	p.Finish()
+	// PARIS is 10 dits and 4 dahs
+	if r := p.Fist().Report(); r.Dit.Count != 10 || r.Dah.Count != 4 {
+		t.Errorf("Report() has %d dits and %d dahs, want 10 and 4", r.Dit.Count, r.Dah.Count)
+	}
+}
+
+func TestPipeline_Listeners(t *testing.T) {
+	signal := generate(t, "TEST")
+
+	p, err := New(testSettings())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	p.UseSampleClock(SampleClockStart)
+
+	var elements, charEnds int
+	p.OnElement(func(_ bool, _, _ time.Duration, isCharEnd, _ bool) {
+		elements++
+		if isCharEnd {
+			charEnds++
+		}
+	})
+	var toneEvents int
+	p.OnToneEvent(func(dsp.ToneEvent) { toneEvents++ })
+	var decoded []rune
+	p.OnOutput(func(output cw.DecodedOutput) {
+		if !output.IsWordSpace {
+			decoded = append(decoded, output.Character)
+		}
+	})
+
+	p.Process(signal.Samples)
+	if text := p.Finish(); text != "TEST" {
+		t.Errorf("Finish() = %q, want %q", text, "TEST")
+	}
+	if string(decoded) != "TEST" {
+		t.Errorf("OnOutput saw %q, want %q", string(decoded), "TEST")
+	}
+	// T E S T is 1 + 1 + 3 + 1 elements
+	if elements != 6 || charEnds != 4 {
+		t.Errorf("OnElement saw %d elements and %d character ends, want 6 and 4", elements, charEnds)
+	}
+	// Each element starts and ends with a tone event
+	if toneEvents != 2*elements {
+		t.Errorf("OnToneEvent saw %d events, want %d", toneEvents, 2*elements)
+	}
+}
+
+func TestPipeline_SlowProcessing(t *testing.T) {
+	signal := generate(t, "CQ TEST")
+
+	p, err := New(testSettings())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	p.UseSampleClock(SampleClockStart)
+	var toneEnds int
+	p.OnToneEvent(func(event dsp.ToneEvent) {
+		if !event.ToneOn {
+			toneEnds++
+		}
+	})
+	stalled := false
+	for samples := signal.Samples; len(samples) > 0; {
+		n := min(100, len(samples))
+		p.Process(samples[:n])
+		samples = samples[n:]
+		// Stall after C's last element for longer than the 600 ms flush timeout, as a
+		// loaded host decoding many channels might; the gap in the samples is still C to Q
+		if toneEnds == 4 && !stalled {
+			time.Sleep(800 * time.Millisecond)
+			stalled = true
+		}
+	}
+	if text := p.Finish(); text != "CQ TEST" {
+		t.Errorf("Finish() after a stall = %q, want %q", text, "CQ TEST")
+	}
+}
+
+func TestPipeline_Replay(t *testing.T) {
+	signal := generate(t, "CQ DE W1AW")
+	settings := testSettings()
+
+	// Record the detector's events while decoding the audio
+	live, err := New(settings)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	live.UseSampleClock(SampleClockStart)
+	var events []dsp.ToneEvent
+	live.OnToneEvent(func(event dsp.ToneEvent) {
+		events = append(events, event)
+	})
+	live.Process(signal.Samples)
+	want := live.Finish()
+
+	replay, err := New(settings)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	replay.Replay(events)
+	if got := replay.Finish(); got != want || got != signal.Truth.Text {
+		t.Errorf("Replay() = %q, want %q as decoded live", got, want)
+	}
+
+	// Different decoder settings see the same events differently
+	settings.CharWordBoundary = 10
+	settings.AdaptivePatternEnabled = false
+	merged, err := New(settings)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	merged.Replay(events)
+	if got := merged.Finish(); got != "CQDEW1AW" {
+		t.Errorf("Replay() with char_word_boundary 10 = %q, want %q", got, "CQDEW1AW")
+	}
+}
@@ -0,0 +1,132 @@
+// internal/practice/align.go
+package practice
+
+import "math"
+
+// gapKind classifies the space after an element
+type gapKind int
+
+const (
+	gapIntra gapKind = iota // between elements of a character
+	gapChar                 // between characters
+	gapWord                 // between words
+)
+
+// Alignment costs. Element errors dominate so that spacing mistakes never
+// pull the alignment off the elements the trainee actually sent.
+const (
+	costElement   = 1.0  // dit sent as dah (or vice versa), missing or extra element
+	costBoundary  = 0.5  // character boundary missing or inserted
+	costWordSpace = 0.25 // character space sent as word space (or vice versa)
+	// alignSlack is how many elements the trainee may run ahead of or behind the
+	// text, beyond the difference in length, and still be aligned
+	alignSlack = 32
+)
+
+// symbol is one element with the gap that follows it
+type symbol struct {
+	isDah bool
+	gap   gapKind
+}
+
+// opKind is one step of an alignment
+type opKind uint8
+
+const (
+	opMatch  opKind = iota // expected and observed element aligned (may still differ)
+	opDelete               // expected element not sent
+	opInsert               // observed element not expected
+)
+
+// step pairs an expected and observed symbol index (-1 when absent)
+type step struct {
+	op       opKind
+	expected int
+	observed int
+}
+
+// gapCost is the cost of aligning two gaps
+func gapCost(expected, observed gapKind) float64 {
+	switch {
+	case expected == observed:
+		return 0
+	case expected == gapIntra || observed == gapIntra:
+		return costBoundary
+	default:
+		return costWordSpace
+	}
+}
+
+// substitutionCost is the cost of aligning two symbols
+func substitutionCost(expected, observed symbol) float64 {
+	cost := gapCost(expected.gap, observed.gap)
+	if expected.isDah != observed.isDah {
+		cost += costElement
+	}
+	return cost
+}
+
+// align finds the lowest-cost alignment of observed symbols to expected symbols
+// (Needleman-Wunsch over elements) and returns it in order. Only alignments that
+// stay within alignSlack elements of the diagonal, beyond the difference in length,
+// are considered, so memory grows with the session length rather than its square.
+func align(expected, observed []symbol) []step {
+	n, m := len(expected), len(observed)
+	band := max(n-m, m-n) + alignSlack
+	width := 2*band + 1
+
+	// Cells of row i hold observed[:j] for j within band of i, at index j-i+band.
+	// Costs are kept for two rows; the last step of each cell's alignment for all.
+	prev := make([]float64, width)
+	cur := make([]float64, width)
+	moves := make([]opKind, (n+1)*width)
+	for i := 0; i <= n; i++ {
+		for k := range cur {
+			cur[k] = math.Inf(1)
+		}
+		for j := max(0, i-band); j <= min(m, i+band); j++ {
+			k := j - i + band
+			if i == 0 && j == 0 {
+				cur[k] = 0
+				continue
+			}
+			// Ties prefer a match, then a deletion
+			best, move := math.Inf(1), opMatch
+			if i > 0 && j > 0 {
+				best = prev[k] + substitutionCost(expected[i-1], observed[j-1])
+			}
+			if i > 0 && k+1 < width && prev[k+1]+costElement < best {
+				best, move = prev[k+1]+costElement, opDelete
+			}
+			if j > 0 && k > 0 && cur[k-1]+costElement < best {
+				best, move = cur[k-1]+costElement, opInsert
+			}
+			cur[k] = best
+			moves[i*width+k] = move
+		}
+		prev, cur = cur, prev
+	}
+
+	// Trace back from the end
+	var steps []step
+	i, j := n, m
+	for i > 0 || j > 0 {
+		switch moves[i*width+j-i+band] {
+		case opMatch:
+			steps = append(steps, step{op: opMatch, expected: i - 1, observed: j - 1})
+			i--
+			j--
+		case opDelete:
+			steps = append(steps, step{op: opDelete, expected: i - 1, observed: -1})
+			i--
+		default:
+			steps = append(steps, step{op: opInsert, expected: -1, observed: j - 1})
+			j--
+		}
+	}
+
+	for left, right := 0, len(steps)-1; left < right; left, right = left+1, right-1 {
+		steps[left], steps[right] = steps[right], steps[left]
+	}
+	return steps
+}
@@ -0,0 +1,67 @@
+package practice
+
+import "testing"
+
+func TestAlign(t *testing.T) {
+	dit := symbol{isDah: false, gap: gapIntra}
+	dah := symbol{isDah: true, gap: gapIntra}
+	end := symbol{isDah: false, gap: gapChar}
+
+	tests := []struct {
+		name     string
+		expected []symbol
+		observed []symbol
+		want     []opKind
+	}{
+		{"identical", []symbol{dit, dah, end}, []symbol{dit, dah, end}, []opKind{opMatch, opMatch, opMatch}},
+		{"substitution", []symbol{dit, dah, end}, []symbol{dit, dit, end}, []opKind{opMatch, opMatch, opMatch}},
+		{"deletion", []symbol{dit, dah, end}, []symbol{dit, end}, []opKind{opMatch, opDelete, opMatch}},
+		{"insertion", []symbol{dit, end}, []symbol{dit, dah, end}, []opKind{opMatch, opInsert, opMatch}},
+		{"nothing sent", []symbol{dit, end}, nil, []opKind{opDelete, opDelete}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps := align(tt.expected, tt.observed)
+			if len(steps) != len(tt.want) {
+				t.Fatalf("align returned %d steps, want %d: %+v", len(steps), len(tt.want), steps)
+			}
+			for i, st := range steps {
+				if st.op != tt.want[i] {
+					t.Errorf("step %d op = %v, want %v", i, st.op, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAlign_LongSession(t *testing.T) {
+	dit := symbol{isDah: false, gap: gapIntra}
+	dah := symbol{isDah: true, gap: gapChar}
+	var expected []symbol
+	for range 5000 {
+		expected = append(expected, dit, dah)
+	}
+	// The dit at 5000 is not sent
+	observed := append(append([]symbol{}, expected[:5000]...), expected[5001:]...)
+
+	steps := align(expected, observed)
+	if len(steps) != len(expected) {
+		t.Fatalf("align returned %d steps, want %d", len(steps), len(expected))
+	}
+	deleted := -1
+	for _, st := range steps {
+		switch st.op {
+		case opDelete:
+			if deleted >= 0 {
+				t.Errorf("deletes expected %d and %d, want one deletion", deleted, st.expected)
+			}
+			deleted = st.expected
+		case opInsert:
+			t.Errorf("inserts observed %d, want no insertions", st.observed)
+		}
+	}
+	if deleted != 5000 {
+		t.Errorf("deleted expected %d, want the missed element 5000", deleted)
+	}
+}
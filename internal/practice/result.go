@@ -0,0 +1,93 @@
+// internal/practice/result.go
+package practice
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// CharacterResult is the score for one character of the practice text
+type CharacterResult struct {
+	// Character is the expected character (" " for a word space)
+	Character string `json:"character"`
+	// Errors lists the mistakes made sending it
+	Errors []ErrorKind `json:"errors,omitempty"`
+	// TimingDeviation is the worst relative deviation of its elements from ideal timing
+	TimingDeviation float64 `json:"timing_deviation"`
+}
+
+// Result is the score for one attempt at the practice text
+type Result struct {
+	// Expected is the practice text
+	Expected string `json:"expected"`
+	// Characters scores each character of the text in order
+	Characters []CharacterResult `json:"characters"`
+	// Accuracy is the percentage of characters sent without errors
+	Accuracy float64 `json:"accuracy"`
+	// TimingScore is 100 minus the mean relative timing deviation as a percentage
+	TimingScore float64 `json:"timing_score"`
+	// WPM is the trainee's average sending speed
+	WPM float64 `json:"wpm"`
+}
+
+// addError records a mistake once per kind
+func (c *CharacterResult) addError(kind ErrorKind) {
+	if !slices.Contains(c.Errors, kind) {
+		c.Errors = append(c.Errors, kind)
+	}
+}
+
+// hasSendingError reports whether the character has any error
+func (c *CharacterResult) hasSendingError() bool {
+	return len(c.Errors) > 0
+}
+
+// WriteJSON writes the result as indented JSON
+func (r Result) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode practice result: %w", err)
+	}
+	data = append(data, '\n')
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("write practice result: %w", err)
+	}
+	return nil
+}
+
+// WriteText writes the result as a human-readable summary
+func (r Result) WriteText(w io.Writer) error {
+	if _, err := io.WriteString(w, r.Text()); err != nil {
+		return fmt.Errorf("write practice result: %w", err)
+	}
+	return nil
+}
+
+// Text formats the result as a human-readable summary
+func (r Result) Text() string {
+	var b strings.Builder
+	b.WriteString("Practice score\n")
+	fmt.Fprintf(&b, "  Text:     %s\n", r.Expected)
+	fmt.Fprintf(&b, "  Accuracy: %.1f%%   Timing: %.1f%%   Speed: %.1f WPM\n", r.Accuracy, r.TimingScore, r.WPM)
+
+	var errs []string
+	for i, c := range r.Characters {
+		if len(c.Errors) == 0 {
+			continue
+		}
+		kinds := make([]string, len(c.Errors))
+		for k, kind := range c.Errors {
+			kinds[k] = strings.ReplaceAll(string(kind), "_", " ")
+		}
+		errs = append(errs, fmt.Sprintf("    %3d  %-2s %s", i+1, c.Character, strings.Join(kinds, ", ")))
+	}
+	if len(errs) > 0 {
+		b.WriteString("\n  Errors\n")
+		b.WriteString(strings.Join(errs, "\n"))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,292 @@
+// internal/practice/scorer.go
+// Package practice scores a trainee's sending against a known practice text.
+package practice
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+// Scoring constants
+const (
+	// TimingTolerance is the relative deviation from ideal timing above which a character has a timing error
+	TimingTolerance = 0.35
+)
+
+var (
+	// ErrEmptyText indicates the practice text has nothing to send
+	ErrEmptyText = errors.New("practice text is empty")
+	// ErrUnencodable indicates the practice text contains a character with no Morse code
+	ErrUnencodable = errors.New("practice text contains a character with no Morse code")
+)
+
+// ErrorKind is one kind of sending mistake
+type ErrorKind string
+
+const (
+	// ErrorDitAsDah is a dit sent as a dah
+	ErrorDitAsDah ErrorKind = "dit_as_dah"
+	// ErrorDahAsDit is a dah sent as a dit
+	ErrorDahAsDit ErrorKind = "dah_as_dit"
+	// ErrorMissingElement is an element that was not sent
+	ErrorMissingElement ErrorKind = "missing_element"
+	// ErrorExtraElement is an element that was sent but not expected
+	ErrorExtraElement ErrorKind = "extra_element"
+	// ErrorRunTogether is a character run into the next without a character space
+	ErrorRunTogether ErrorKind = "run_together"
+	// ErrorSplit is a character broken in two by a long intra-character gap
+	ErrorSplit ErrorKind = "split"
+	// ErrorWordSpace is a word space sent between characters, or missing between words
+	ErrorWordSpace ErrorKind = "word_space"
+	// ErrorTiming is an element or gap well away from its ideal length
+	ErrorTiming ErrorKind = "timing"
+)
+
+// expectedSymbol is a symbol of the practice text with the character it belongs to
+type expectedSymbol struct {
+	symbol
+	char int // index into Result.Characters
+}
+
+// observedSymbol is a sent element with its measured lengths
+type observedSymbol struct {
+	symbol
+	duration time.Duration
+	gapAfter time.Duration
+}
+
+// Scorer collects sent elements and scores them against a practice text
+type Scorer struct {
+	text     []rune
+	expected []expectedSymbol
+
+	mu       sync.Mutex
+	observed []observedSymbol
+}
+
+// NewScorer creates a scorer for the practice text.
+// Text is case-insensitive; runs of spaces are a single word space.
+func NewScorer(text string) (*Scorer, error) {
+	words := strings.Fields(strings.ToUpper(text))
+	if len(words) == 0 {
+		return nil, ErrEmptyText
+	}
+
+	s := &Scorer{}
+	for w, word := range words {
+		for c, char := range word {
+			elements, ok := cw.EncodeCharacter(char)
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", ErrUnencodable, char)
+			}
+			for e, isDah := range elements {
+				gap := gapIntra
+				if e == len(elements)-1 {
+					gap = gapChar
+					if c == len([]rune(word))-1 && w < len(words)-1 {
+						gap = gapWord
+					}
+				}
+				s.expected = append(s.expected, expectedSymbol{
+					symbol: symbol{isDah: isDah, gap: gap},
+					char:   len(s.text),
+				})
+			}
+			s.text = append(s.text, char)
+		}
+		if w < len(words)-1 {
+			s.text = append(s.text, ' ')
+		}
+	}
+	// The end of the text is the end of a word, whatever the decoder saw after it
+	s.expected[len(s.expected)-1].gap = gapWord
+	return s, nil
+}
+
+// RecordElement records one sent element.
+// Signature matches cw.ElementCallback so it can be chained directly.
+func (s *Scorer) RecordElement(isDah bool, duration, gapAfter time.Duration, isCharEnd, isWordEnd bool) {
+	gap := gapIntra
+	switch {
+	case isWordEnd:
+		gap = gapWord
+	case isCharEnd:
+		gap = gapChar
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observed = append(s.observed, observedSymbol{
+		symbol:   symbol{isDah: isDah, gap: gap},
+		duration: duration,
+		gapAfter: gapAfter,
+	})
+}
+
+// Reset discards the recorded elements so the text can be sent again
+func (s *Scorer) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observed = nil
+}
+
+// Score aligns the sent elements with the practice text and scores them
+func (s *Scorer) Score() Result {
+	s.mu.Lock()
+	observed := make([]observedSymbol, len(s.observed))
+	copy(observed, s.observed)
+	s.mu.Unlock()
+
+	result := Result{Expected: string(s.text)}
+	for _, char := range s.text {
+		result.Characters = append(result.Characters, CharacterResult{Character: string(char)})
+	}
+
+	expectedSymbols := make([]symbol, len(s.expected))
+	for i, e := range s.expected {
+		expectedSymbols[i] = e.symbol
+	}
+	observedSymbols := make([]symbol, len(observed))
+	for i, o := range observed {
+		observedSymbols[i] = o.symbol
+	}
+	// The last element sent ends the text, however long the silence after it was
+	if n := len(observedSymbols); n > 0 {
+		observedSymbols[n-1].gap = gapWord
+	}
+
+	unit := timingUnit(observed)
+	var deviations []float64
+	lastChar := 0
+	pendingExtra := false // extra elements sent at the start of the next character
+
+	for _, st := range align(expectedSymbols, observedSymbols) {
+		if st.op != opInsert && pendingExtra {
+			result.Characters[s.expected[st.expected].char].addError(ErrorExtraElement)
+			pendingExtra = false
+		}
+
+		switch st.op {
+		case opDelete:
+			lastChar = s.expected[st.expected].char
+			result.Characters[lastChar].addError(ErrorMissingElement)
+		case opInsert:
+			// Extra elements belong to the character being sent at the time,
+			// or to the next one if they follow a character space
+			if st.observed > 0 && observedSymbols[st.observed-1].gap != gapIntra {
+				pendingExtra = true
+			} else {
+				result.Characters[lastChar].addError(ErrorExtraElement)
+			}
+		case opMatch:
+			exp := s.expected[st.expected]
+			obs := observed[st.observed]
+			lastChar = exp.char
+			cr := &result.Characters[exp.char]
+
+			if exp.isDah != obs.isDah {
+				if exp.isDah {
+					cr.addError(ErrorDahAsDit)
+				} else {
+					cr.addError(ErrorDitAsDah)
+				}
+			}
+			obs.gap = observedSymbols[st.observed].gap
+			switch {
+			case exp.gap == obs.gap:
+			case exp.gap == gapIntra:
+				cr.addError(ErrorSplit)
+			case obs.gap == gapIntra:
+				cr.addError(ErrorRunTogether)
+			default:
+				cr.addError(ErrorWordSpace)
+			}
+
+			// Timing is only meaningful for elements sent as the right kind
+			if unit > 0 && exp.isDah == obs.isDah {
+				deviation := markDeviation(obs, unit)
+				if exp.gap == gapIntra && obs.gap == gapIntra {
+					deviation = max(deviation, relativeDeviation(obs.gapAfter, unit))
+				}
+				deviations = append(deviations, deviation)
+				cr.TimingDeviation = max(cr.TimingDeviation, deviation)
+			}
+		}
+	}
+
+	// Extra elements after the last character belong to it
+	if pendingExtra {
+		result.Characters[lastChar].addError(ErrorExtraElement)
+	}
+
+	correct := 0
+	letters := 0
+	for i := range result.Characters {
+		cr := &result.Characters[i]
+		if cr.Character == " " {
+			continue
+		}
+		letters++
+		if cr.TimingDeviation > TimingTolerance {
+			cr.addError(ErrorTiming)
+		}
+		if !cr.hasSendingError() {
+			correct++
+		}
+	}
+
+	result.Accuracy = 100 * float64(correct) / float64(letters)
+	if len(deviations) > 0 {
+		var sum float64
+		for _, d := range deviations {
+			sum += d
+		}
+		result.TimingScore = math.Max(0, 100*(1-sum/float64(len(deviations))))
+	}
+	if unit > 0 {
+		result.WPM = cw.MillisecondsPerMinute / (unit * cw.DitsPerWord)
+	}
+	return result
+}
+
+// timingUnit estimates the trainee's dit length from the elements sent
+func timingUnit(observed []observedSymbol) float64 {
+	var dits, dahs float64
+	var ditCount, dahCount int
+	for _, o := range observed {
+		if o.isDah {
+			dahs += cw.ToMs(o.duration)
+			dahCount++
+		} else {
+			dits += cw.ToMs(o.duration)
+			ditCount++
+		}
+	}
+	switch {
+	case ditCount > 0:
+		return dits / float64(ditCount)
+	case dahCount > 0:
+		return dahs / float64(dahCount) / cw.DahDitRatio
+	}
+	return 0
+}
+
+// markDeviation is the relative deviation of a mark from its ideal length
+func markDeviation(o observedSymbol, unit float64) float64 {
+	ideal := unit
+	if o.isDah {
+		ideal = unit * cw.DahDitRatio
+	}
+	return relativeDeviation(o.duration, ideal)
+}
+
+// relativeDeviation is |actual - ideal| / ideal
+func relativeDeviation(actual time.Duration, idealMs float64) float64 {
+	return math.Abs(cw.ToMs(actual)-idealMs) / idealMs
+}
@@ -0,0 +1,195 @@
+package practice
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+	"github.com/ColonelBlimp/cwdecoder/internal/dsp"
+)
+
+// sendPattern records a pattern such as ".- -." (space = character gap, "/" = word gap)
+// at 60ms dits with ideal timing
+func sendPattern(s *Scorer, pattern string) {
+	const dit = 60
+	runes := []rune(pattern)
+	for i, r := range runes {
+		if r != '.' && r != '-' {
+			continue
+		}
+		mark := dit
+		if r == '-' {
+			mark = dit * 3
+		}
+		gap, charEnd, wordEnd := dit, false, false
+		if i+1 >= len(runes) || runes[i+1] == '/' {
+			gap, charEnd, wordEnd = dit*7, true, true
+		} else if runes[i+1] == ' ' {
+			gap, charEnd = dit*3, true
+		}
+		s.RecordElement(r == '-', ms2d(mark), ms2d(gap), charEnd, wordEnd)
+	}
+}
+
+func ms2d(ms int) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
+
+// errorsAt returns the errors recorded for the character at index i
+func errorsAt(r Result, i int) []ErrorKind {
+	return r.Characters[i].Errors
+}
+
+func TestNewScorer_Errors(t *testing.T) {
+	if _, err := NewScorer("   "); !errors.Is(err, ErrEmptyText) {
+		t.Errorf("NewScorer(blank) error = %v, want ErrEmptyText", err)
+	}
+	if _, err := NewScorer("CQ #"); !errors.Is(err, ErrUnencodable) {
+		t.Errorf("NewScorer(#) error = %v, want ErrUnencodable", err)
+	}
+}
+
+func TestScorer_Perfect(t *testing.T) {
+	s, err := NewScorer("cq de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendPattern(s, "-.-. --.-/-.. .")
+
+	r := s.Score()
+	if r.Expected != "CQ DE" {
+		t.Errorf("Expected = %q, want CQ DE", r.Expected)
+	}
+	if r.Accuracy != 100 || r.TimingScore != 100 {
+		t.Errorf("Accuracy = %.1f, TimingScore = %.1f; want 100, 100", r.Accuracy, r.TimingScore)
+	}
+	for i, c := range r.Characters {
+		if len(c.Errors) != 0 {
+			t.Errorf("character %d (%s) errors = %v, want none", i, c.Character, c.Errors)
+		}
+	}
+	if r.WPM != 20 {
+		t.Errorf("WPM = %.1f, want 20", r.WPM)
+	}
+}
+
+func TestScorer_DitSentAsDah(t *testing.T) {
+	s, _ := NewScorer("PARIS")
+	// P = .--. sent as .---
+	sendPattern(s, ".--- .- .-. .. ...")
+
+	r := s.Score()
+	if got := errorsAt(r, 0); !slices.Contains(got, ErrorDitAsDah) {
+		t.Errorf("P errors = %v, want dit_as_dah", got)
+	}
+	if r.Accuracy != 80 {
+		t.Errorf("Accuracy = %.1f, want 80", r.Accuracy)
+	}
+}
+
+func TestScorer_RunTogether(t *testing.T) {
+	s, _ := NewScorer("AN")
+	// A and N without a character space: .--.
+	sendPattern(s, ".--.")
+
+	r := s.Score()
+	if got := errorsAt(r, 0); !slices.Equal(got, []ErrorKind{ErrorRunTogether}) {
+		t.Errorf("A errors = %v, want run_together", got)
+	}
+	if got := errorsAt(r, 1); len(got) != 0 {
+		t.Errorf("N errors = %v, want none", got)
+	}
+}
+
+func TestScorer_SplitCharacter(t *testing.T) {
+	s, _ := NewScorer("K")
+	// K = -.- with a character space in the middle
+	sendPattern(s, "-. -")
+
+	if got := errorsAt(s.Score(), 0); !slices.Equal(got, []ErrorKind{ErrorSplit}) {
+		t.Errorf("K errors = %v, want split", got)
+	}
+}
+
+func TestScorer_MissingAndExtraElements(t *testing.T) {
+	s, _ := NewScorer("SOS")
+	// First S has a dit missing; last S has an extra dit
+	sendPattern(s, ".. --- ....")
+
+	r := s.Score()
+	if got := errorsAt(r, 0); !slices.Contains(got, ErrorMissingElement) {
+		t.Errorf("first S errors = %v, want missing_element", got)
+	}
+	if got := errorsAt(r, 2); !slices.Contains(got, ErrorExtraElement) {
+		t.Errorf("last S errors = %v, want extra_element", got)
+	}
+	if got := errorsAt(r, 1); len(got) != 0 {
+		t.Errorf("O errors = %v, want none", got)
+	}
+}
+
+func TestScorer_TimingDeviation(t *testing.T) {
+	s, _ := NewScorer("ET")
+	// T sent as a 100ms dah among 60ms dits (ideal 180ms)
+	s.RecordElement(false, ms2d(60), ms2d(180), true, false)
+	s.RecordElement(true, ms2d(100), ms2d(420), true, true)
+
+	r := s.Score()
+	if got := errorsAt(r, 1); !slices.Equal(got, []ErrorKind{ErrorTiming}) {
+		t.Errorf("T errors = %v, want timing", got)
+	}
+	if r.TimingScore >= 100 || r.TimingScore <= 0 {
+		t.Errorf("TimingScore = %.1f, want between 0 and 100", r.TimingScore)
+	}
+}
+
+func TestScorer_WithDecoder(t *testing.T) {
+	s, _ := NewScorer("TEST")
+	decoder, err := cw.NewDecoder(cw.DecoderConfig{
+		InitialWPM:        20,
+		DitDahBoundary:    2.0,
+		InterCharBoundary: 2.0,
+		CharWordBoundary:  5.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer decoder.Stop()
+	decoder.SetElementCallback(s.RecordElement)
+
+	// Key "TEST" at 20 WPM through the decoder
+	now := time.Now()
+	events := []struct {
+		on bool
+		ms int
+	}{
+		{false, 180}, {true, 180}, // T
+		{false, 60}, {true, 180}, // E
+		{false, 60}, {true, 60}, {false, 60}, {true, 60}, {false, 60}, {true, 180}, // S
+		{false, 180}, {true, 420}, // T, then word space
+	}
+	for _, e := range events {
+		now = now.Add(ms2d(e.ms))
+		decoder.HandleToneEvent(dsp.ToneEvent{ToneOn: e.on, Duration: ms2d(e.ms), Timestamp: now})
+	}
+
+	r := s.Score()
+	if r.Accuracy != 100 {
+		t.Errorf("Accuracy = %.1f, want 100:\n%s", r.Accuracy, r.Text())
+	}
+}
+
+func TestResult_Text(t *testing.T) {
+	s, _ := NewScorer("AN")
+	sendPattern(s, ".--.")
+
+	text := s.Score().Text()
+	for _, want := range []string{"Accuracy: 50.0%", "run together"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text missing %q:\n%s", want, text)
+		}
+	}
+}
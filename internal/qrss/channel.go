@@ -0,0 +1,108 @@
+// internal/qrss/channel.go
+package qrss
+
+import (
+	"math"
+	"math/cmplx"
+	"slices"
+)
+
+// Narrowband channel constants
+const (
+	// BasebandRate is the sample rate in Hz audio is decimated to after mixing to baseband.
+	// Offsets from the tone frequency of up to a quarter of it pass the decimation filter.
+	BasebandRate = 50.0
+	// MaxOffset is the furthest a channel may sit from the tone frequency, in Hz
+	MaxOffset = BasebandRate / 4
+	// FloorPercentile is the level percentile taken as the noise floor
+	FloorPercentile = 0.1
+	// PeakPercentile is the level percentile taken as the keyed signal level
+	PeakPercentile = 0.95
+)
+
+// baseband is audio mixed down from a centre frequency and decimated to BasebandRate
+type baseband struct {
+	samples []complex128
+	rate    float64
+}
+
+// downconvert mixes samples with the centre frequency and averages them in blocks,
+// a boxcar low-pass that decimates to about BasebandRate
+func downconvert(samples []float32, sampleRate, centre float64) baseband {
+	d := newDownconverter(sampleRate, centre)
+	d.write(samples)
+	return d.base
+}
+
+// downconverter is downconvert for audio fed a block at a time; it carries the
+// mixer phase and the partly averaged block from one write to the next
+type downconverter struct {
+	decimation int
+	step       float64
+	n          int // samples mixed
+	sum        complex128
+	base       baseband
+}
+
+// newDownconverter returns a downconverter for audio at sampleRate
+func newDownconverter(sampleRate, centre float64) *downconverter {
+	decimation := max(1, int(sampleRate/BasebandRate))
+	return &downconverter{
+		decimation: decimation,
+		step:       -2 * math.Pi * centre / sampleRate,
+		base:       baseband{rate: sampleRate / float64(decimation)},
+	}
+}
+
+// write mixes and decimates samples, appending to the baseband
+func (d *downconverter) write(samples []float32) {
+	for _, sample := range samples {
+		d.sum += complex(float64(sample), 0) * cmplx.Rect(1, d.step*float64(d.n))
+		d.n++
+		if d.n%d.decimation == 0 {
+			d.base.samples = append(d.base.samples, d.sum/complex(float64(d.decimation), 0))
+			d.sum = 0
+		}
+	}
+}
+
+// levels returns the tone amplitude offset Hz from the centre, integrated coherently
+// over window baseband samples. Level i covers samples i-window+1 to i, so the
+// bandwidth is about the reciprocal of the window length.
+func (b baseband) levels(offset float64, window int) []float64 {
+	out := make([]float64, len(b.samples))
+	step := -2 * math.Pi * offset / b.rate
+	shifted := make([]complex128, len(b.samples))
+	var sum complex128
+	for i, sample := range b.samples {
+		shifted[i] = sample * cmplx.Rect(1, step*float64(i))
+		sum += shifted[i]
+		if i >= window {
+			sum -= shifted[i-window]
+		}
+		// A real sine of amplitude A mixes down to A/2
+		out[i] = 2 * cmplx.Abs(sum) / float64(window)
+	}
+	return out
+}
+
+// percentile returns the p-th fraction of the sorted levels
+func percentile(levels []float64, p float64) float64 {
+	if len(levels) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(levels)
+	slices.Sort(sorted)
+	return sorted[int(p*float64(len(sorted)-1))]
+}
+
+// contrast returns how far the keyed signal level stands above the noise floor
+func contrast(levels []float64) float64 {
+	return percentile(levels, PeakPercentile) - percentile(levels, FloorPercentile)
+}
+
+// threshold returns the level a fraction of the way from the noise floor to the signal level
+func threshold(levels []float64, fraction float64) float64 {
+	floor := percentile(levels, FloorPercentile)
+	return floor + fraction*(percentile(levels, PeakPercentile)-floor)
+}
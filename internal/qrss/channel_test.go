@@ -0,0 +1,94 @@
+package qrss
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// sine returns n samples of a sine at frequency with the given amplitude
+func sine(n int, frequency, sampleRate, amplitude float64) []float32 {
+	samples := make([]float32, n)
+	for i := range samples {
+		samples[i] = float32(amplitude * math.Sin(2*math.Pi*frequency*float64(i)/sampleRate))
+	}
+	return samples
+}
+
+func TestDownconvert(t *testing.T) {
+	base := downconvert(sine(8000, 600, 8000, 0.5), 8000, 600)
+	if base.rate != BasebandRate {
+		t.Errorf("rate = %v, want %v", base.rate, BasebandRate)
+	}
+	if len(base.samples) != 50 {
+		t.Fatalf("len(samples) = %d, want 50", len(base.samples))
+	}
+}
+
+func TestDownconverter_Blocks(t *testing.T) {
+	samples := sine(8000, 600, 8000, 0.5)
+	want := downconvert(samples, 8000, 600)
+
+	d := newDownconverter(8000, 600)
+	for start := 0; start < len(samples); start += 333 {
+		d.write(samples[start:min(start+333, len(samples))])
+	}
+	if len(d.base.samples) != len(want.samples) {
+		t.Fatalf("len(samples) = %d in blocks, want %d", len(d.base.samples), len(want.samples))
+	}
+	for i := range want.samples {
+		if cmplx.Abs(d.base.samples[i]-want.samples[i]) > 1e-9 {
+			t.Errorf("samples[%d] = %v in blocks, want %v", i, d.base.samples[i], want.samples[i])
+		}
+	}
+}
+
+func TestLevels_Amplitude(t *testing.T) {
+	const sampleRate = 1000.0
+	base := downconvert(sine(20000, 400, sampleRate, 0.25), sampleRate, 400)
+	levels := base.levels(0, 50)
+
+	if got := levels[len(levels)-1]; math.Abs(got-0.25) > 0.01 {
+		t.Errorf("level = %.4f, want the tone amplitude 0.25", got)
+	}
+	// The window fills over its first samples
+	if got := levels[24]; math.Abs(got-0.125) > 0.01 {
+		t.Errorf("level half way into the window = %.4f, want 0.125", got)
+	}
+}
+
+func TestLevels_Selectivity(t *testing.T) {
+	const sampleRate = 1000.0
+	// A tone 1 Hz away from a channel integrating over 2 seconds falls in its nulls
+	base := downconvert(sine(20000, 401, sampleRate, 0.25), sampleRate, 400)
+	window := int(2 * base.rate)
+
+	if got := base.levels(0, window)[len(base.samples)-1]; got > 0.01 {
+		t.Errorf("level 1 Hz off = %.4f, want the tone rejected", got)
+	}
+	if got := base.levels(1, window)[len(base.samples)-1]; math.Abs(got-0.25) > 0.01 {
+		t.Errorf("level on the tone = %.4f, want 0.25", got)
+	}
+}
+
+func TestPercentileAndThreshold(t *testing.T) {
+	levels := make([]float64, 101)
+	for i := range levels {
+		levels[100-i] = float64(i)
+	}
+	if got := percentile(levels, 0.1); got != 10 {
+		t.Errorf("percentile(0.1) = %v, want 10", got)
+	}
+	if got := percentile(levels, 0.95); got != 95 {
+		t.Errorf("percentile(peak) = %v, want 95", got)
+	}
+	if got := threshold(levels, 0.5); got != 52.5 {
+		t.Errorf("threshold(0.5) = %v, want 52.5", got)
+	}
+	if got := contrast(levels); got != 85 {
+		t.Errorf("contrast() = %v, want 85", got)
+	}
+	if got := percentile(nil, 0.5); got != 0 {
+		t.Errorf("percentile(nil) = %v, want 0", got)
+	}
+}
@@ -0,0 +1,350 @@
+// internal/qrss/qrss.go
+// Package qrss decodes very slow CW, as sent by QRSS and DFCW beacons, from recordings.
+// Dits last seconds to minutes, so each channel is a sub-Hz narrowband filter: the audio
+// is mixed to baseband, decimated, and integrated coherently over half a dit.
+package qrss
+
+import (
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+	"github.com/ColonelBlimp/cwdecoder/internal/wav"
+)
+
+// Mode selects how dits and dahs are sent
+type Mode string
+
+const (
+	// ModeQRSS sends dits and dahs on one frequency, dahs three times as long (default)
+	ModeQRSS Mode = "qrss"
+	// ModeDFCW sends dits and dahs of equal length, dahs shifted in frequency
+	ModeDFCW Mode = "dfcw"
+)
+
+// Slow CW timing, in units of one dit
+const (
+	// DitDahBoundary separates QRSS dits (1 unit) from dahs (3 units)
+	DitDahBoundary = 2.0
+	// CharSpaceBoundary separates element spaces (1 unit) from character spaces (3 units)
+	CharSpaceBoundary = 2.0
+	// WordSpaceBoundary separates character spaces (3 units) from word spaces (7 units)
+	WordSpaceBoundary = 5.0
+	// MinRunUnits is the shortest mark or space kept; shorter ones are noise and join their neighbour
+	MinRunUnits = 0.5
+	// WindowUnits is the coherent integration time. Half a dit lets a dit hold its full level
+	// for half its length, and gives a bandwidth of two over the dit length (0.67 Hz for QRSS3).
+	WindowUnits = 0.5
+)
+
+// Limits and defaults
+const (
+	// MinDit is the shortest dit decoded; faster CW belongs to the live decoder
+	MinDit = time.Second
+	// MaxDit is the longest dit decoded
+	MaxDit = 10 * time.Minute
+	// DefaultDit is the dit length of QRSS3, the most common beacon speed
+	DefaultDit = 3 * time.Second
+	// DefaultShift is the usual DFCW dah shift in Hz
+	DefaultShift = 5.0
+	// DefaultSearch is how far either side of the tone frequency a beacon is looked for, in Hz
+	DefaultSearch = 5.0
+	// DefaultThreshold is the keying threshold as a fraction from noise floor to signal level
+	DefaultThreshold = 0.5
+	// SearchStepsPerBandwidth is how many search frequencies fit in one channel bandwidth
+	SearchStepsPerBandwidth = 2
+	// ReadBlockSize is how many samples of a recording DecodeFile reads at a time
+	ReadBlockSize = 1 << 16
+)
+
+var (
+	// ErrInvalidSampleRate indicates sample rate must be at least twice BasebandRate
+	ErrInvalidSampleRate = errors.New("sample rate must be at least twice the baseband rate")
+	// ErrInvalidFrequency indicates the tone frequency must be positive and below Nyquist
+	ErrInvalidFrequency = errors.New("tone frequency must be positive and below the Nyquist frequency")
+	// ErrInvalidDit indicates the dit length is outside MinDit to MaxDit
+	ErrInvalidDit = errors.New("dit must be between 1s and 10m")
+	// ErrInvalidMode indicates an unknown mode
+	ErrInvalidMode = errors.New("mode must be qrss or dfcw")
+	// ErrInvalidOffset indicates the search range and DFCW shift reach beyond MaxOffset
+	ErrInvalidOffset = errors.New("search and shift must be non-negative and together no more than 12.5 Hz")
+	// ErrInvalidThreshold indicates the threshold must be between 0 and 1
+	ErrInvalidThreshold = errors.New("threshold must be between 0.0 and 1.0")
+)
+
+// Config holds configuration for slow CW decoding
+type Config struct {
+	// SampleRate is the recording's sample rate in Hz
+	SampleRate float64
+	// Frequency is the tone frequency in Hz; in DFCW mode, the frequency of dits
+	Frequency float64
+	// Dit is the dit length (3s for QRSS3)
+	Dit time.Duration
+	// Mode selects QRSS or DFCW keying ("" means QRSS)
+	Mode Mode
+	// Shift is the DFCW dah frequency above the dit frequency, in Hz
+	Shift float64
+	// Search is how far either side of Frequency to look for the signal, in Hz (0 = exactly Frequency)
+	Search float64
+	// Threshold is the keying threshold as a fraction from noise floor to signal level
+	Threshold float64
+	// Alphabet is the character table codes are read with ("" means latin)
+	Alphabet cw.Alphabet
+}
+
+// Mark is a decoded dit or dah
+type Mark struct {
+	// Start is the offset of the mark from the start of the recording
+	Start time.Duration
+	// Duration is the length of the mark
+	Duration time.Duration
+	// IsDah is true for a dah
+	IsDah bool
+}
+
+// Result is the outcome of decoding a recording
+type Result struct {
+	// Frequency is the frequency the signal was found at, in Hz; in DFCW mode, the dit frequency
+	Frequency float64
+	// Marks are the decoded dits and dahs in order
+	Marks []Mark
+	// Text is the decoded text, words separated by single spaces
+	Text string
+}
+
+// keyState is the key state of one baseband sample
+type keyState int
+
+const (
+	keyUp keyState = iota
+	keyDit
+	keyDah
+)
+
+// run is a stretch of baseband samples in one key state
+type run struct {
+	state  keyState
+	start  int
+	length int
+}
+
+// validate checks the configuration
+func (c Config) validate() error {
+	if c.SampleRate < 2*BasebandRate {
+		return ErrInvalidSampleRate
+	}
+	if c.Frequency <= 0 || c.Frequency >= c.SampleRate/2 {
+		return ErrInvalidFrequency
+	}
+	if c.Dit < MinDit || c.Dit > MaxDit {
+		return ErrInvalidDit
+	}
+	if c.Mode != "" && c.Mode != ModeQRSS && c.Mode != ModeDFCW {
+		return ErrInvalidMode
+	}
+	reach := c.Search
+	if c.Mode == ModeDFCW {
+		reach += c.Shift
+	}
+	if c.Search < 0 || c.Shift < 0 || reach > MaxOffset {
+		return ErrInvalidOffset
+	}
+	if c.Threshold <= 0 || c.Threshold >= 1 {
+		return ErrInvalidThreshold
+	}
+	return nil
+}
+
+// Decode finds the slow CW signal in samples and decodes it
+func Decode(samples []float32, cfg Config) (*Result, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return decodeBaseband(downconvert(samples, cfg.SampleRate, cfg.Frequency), cfg), nil
+}
+
+// DecodeFile decodes the slow CW signal in the WAV file at path, at the file's sample
+// rate. The file is read a block at a time, so recordings of hours need not fit in memory.
+func DecodeFile(path string, cfg Config) (*Result, error) {
+	r, err := wav.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+	cfg.SampleRate = float64(r.SampleRate)
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	d := newDownconverter(cfg.SampleRate, cfg.Frequency)
+	block := make([]float32, ReadBlockSize)
+	for {
+		n, err := r.Read(block)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		d.write(block[:n])
+	}
+	return decodeBaseband(d.base, cfg), nil
+}
+
+// decodeBaseband finds the slow CW signal in audio already mixed to baseband and decodes it
+func decodeBaseband(base baseband, cfg Config) *Result {
+	window := max(1, int(math.Round(WindowUnits*cfg.Dit.Seconds()*base.rate)))
+	ditLength := cfg.Dit.Seconds() * base.rate
+
+	offset, ditLevels, dahLevels := search(base, window, cfg)
+	states := keyStates(ditLevels, dahLevels, cfg.Threshold)
+	runs := mergeRuns(states, int(math.Round(MinRunUnits*ditLength)))
+
+	marks := toMarks(runs, window, ditLength, base.rate, cfg.Mode == ModeDFCW)
+	return &Result{
+		Frequency: cfg.Frequency + offset,
+		Marks:     marks,
+		Text:      text(marks, cfg.Dit, cfg.Alphabet),
+	}
+}
+
+// search tries offsets across the search range and returns the one whose channels
+// show the most contrast between signal and noise, with their levels. The dah levels
+// are nil in QRSS mode.
+func search(base baseband, window int, cfg Config) (float64, []float64, []float64) {
+	step := base.rate / (float64(window) * SearchStepsPerBandwidth)
+	steps := int(cfg.Search / step)
+
+	var bestOffset, bestScore float64
+	var bestDit, bestDah []float64
+	for i := -steps; i <= steps; i++ {
+		offset := float64(i) * step
+		dit := base.levels(offset, window)
+		score := contrast(dit)
+		var dah []float64
+		if cfg.Mode == ModeDFCW {
+			dah = base.levels(offset+cfg.Shift, window)
+			score += contrast(dah)
+		}
+		if bestDit == nil || score > bestScore {
+			bestOffset, bestScore, bestDit, bestDah = offset, score, dit, dah
+		}
+	}
+	return bestOffset, bestDit, bestDah
+}
+
+// keyStates thresholds the channel levels. Where both DFCW channels are keyed,
+// the one further above its threshold wins.
+func keyStates(dit, dah []float64, fraction float64) []keyState {
+	states := make([]keyState, len(dit))
+	ditThreshold := threshold(dit, fraction)
+	var dahThreshold float64
+	if dah != nil {
+		dahThreshold = threshold(dah, fraction)
+	}
+	for i := range dit {
+		ditOn := dit[i] > ditThreshold
+		dahOn := dah != nil && dah[i] > dahThreshold
+		switch {
+		case ditOn && dahOn:
+			if dah[i]/dahThreshold > dit[i]/ditThreshold {
+				states[i] = keyDah
+			} else {
+				states[i] = keyDit
+			}
+		case ditOn:
+			states[i] = keyDit
+		case dahOn:
+			states[i] = keyDah
+		}
+	}
+	return states
+}
+
+// mergeRuns groups states into runs, joining runs shorter than minLength to the run before them
+func mergeRuns(states []keyState, minLength int) []run {
+	var runs []run
+	for i, state := range states {
+		n := len(runs)
+		if n > 0 && runs[n-1].state == state {
+			runs[n-1].length++
+			continue
+		}
+		runs = append(runs, run{state: state, start: i, length: 1})
+	}
+
+	var merged []run
+	for _, r := range runs {
+		n := len(merged)
+		if n > 0 && (r.length < minLength || merged[n-1].state == r.state) {
+			merged[n-1].length += r.length
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// toMarks converts keyed runs to marks. Levels lag the signal by half the integration
+// window, which is taken off the start times. In QRSS mode marks are told apart by length.
+func toMarks(runs []run, window int, ditLength, rate float64, dfcw bool) []Mark {
+	var marks []Mark
+	for _, r := range runs {
+		if r.state == keyUp {
+			continue
+		}
+		isDah := r.state == keyDah
+		if !dfcw {
+			isDah = float64(r.length)/ditLength > DitDahBoundary
+		}
+		start := max(0, float64(r.start)-float64(window)/2)
+		marks = append(marks, Mark{
+			Start:    seconds(start / rate),
+			Duration: seconds(float64(r.length) / rate),
+			IsDah:    isDah,
+		})
+	}
+	return marks
+}
+
+// text reads the marks as Morse, splitting characters and words at the spaces between them
+func text(marks []Mark, dit time.Duration, alphabet cw.Alphabet) string {
+	var out strings.Builder
+	var code strings.Builder
+	emit := func() {
+		if char, ok := alphabet.DecodeCode(code.String()); ok {
+			out.WriteRune(char)
+		}
+		code.Reset()
+	}
+
+	for i, mark := range marks {
+		if i > 0 {
+			previous := marks[i-1]
+			space := float64(mark.Start-previous.Start-previous.Duration) / float64(dit)
+			switch {
+			case space > WordSpaceBoundary:
+				emit()
+				out.WriteRune(' ')
+			case space > CharSpaceBoundary:
+				emit()
+			}
+		}
+		if mark.IsDah {
+			code.WriteByte('-')
+		} else {
+			code.WriteByte('.')
+		}
+	}
+	emit()
+	return strings.Join(strings.Fields(out.String()), " ")
+}
+
+// seconds converts a time in seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,253 @@
+package qrss
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+	"github.com/ColonelBlimp/cwdecoder/internal/wav"
+)
+
+const (
+	testSampleRate = 1000.0
+	testFrequency  = 400.0
+)
+
+// testConfig returns a QRSS3 configuration for the test sample rate
+func testConfig() Config {
+	return Config{
+		SampleRate: testSampleRate,
+		Frequency:  testFrequency,
+		Dit:        DefaultDit,
+		Mode:       ModeQRSS,
+		Shift:      DefaultShift,
+		Search:     DefaultSearch,
+		Threshold:  DefaultThreshold,
+		Alphabet:   cw.AlphabetLatin,
+	}
+}
+
+// beacon renders text as slow CW at frequency with 1/3/7 dit spacing and five dits of
+// silence either side, buried in white noise of the given standard deviation.
+// In DFCW mode every element is one dit long and dahs are sent shift Hz higher.
+func beacon(t *testing.T, text string, frequency float64, cfg Config, noise float64) []float32 {
+	t.Helper()
+	symbols, err := cw.ParseText(text)
+	if err != nil {
+		t.Fatalf("ParseText(%q) error = %v", text, err)
+	}
+
+	ditSamples := int(cfg.Dit.Seconds() * cfg.SampleRate)
+	var samples []float32
+	silence := func(units int) {
+		samples = append(samples, make([]float32, units*ditSamples)...)
+	}
+	tone := func(units int, frequency float64) {
+		for range units * ditSamples {
+			phase := 2 * math.Pi * frequency * float64(len(samples)) / cfg.SampleRate
+			samples = append(samples, float32(0.1*math.Sin(phase)))
+		}
+	}
+
+	silence(5)
+	for i, symbol := range symbols {
+		if symbol.IsWordSpace() {
+			silence(7)
+			continue
+		}
+		if i > 0 && !symbols[i-1].IsWordSpace() {
+			silence(3)
+		}
+		for j, isDah := range symbol.Elements {
+			if j > 0 {
+				silence(1)
+			}
+			switch {
+			case cfg.Mode == ModeDFCW && isDah:
+				tone(1, frequency+cfg.Shift)
+			case cfg.Mode == ModeDFCW:
+				tone(1, frequency)
+			case isDah:
+				tone(3, frequency)
+			default:
+				tone(1, frequency)
+			}
+		}
+	}
+	silence(5)
+
+	rng := rand.New(rand.NewSource(1))
+	for i := range samples {
+		samples[i] += float32(noise * rng.NormFloat64())
+	}
+	return samples
+}
+
+func TestDecode_QRSS(t *testing.T) {
+	cfg := testConfig()
+	// The beacon is off the tone frequency and well below the noise in the audio bandwidth
+	samples := beacon(t, "W1AW K", 401.3, cfg, 0.25)
+
+	result, err := Decode(samples, cfg)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if result.Text != "W1AW K" {
+		t.Errorf("Text = %q, want %q", result.Text, "W1AW K")
+	}
+	if math.Abs(result.Frequency-401.3) > 0.2 {
+		t.Errorf("Frequency = %.2f, want about 401.3", result.Frequency)
+	}
+	// The first mark, the dit of W, starts after five dits of silence
+	if len(result.Marks) == 0 {
+		t.Fatal("no marks decoded")
+	}
+	first := result.Marks[0]
+	if first.IsDah || absDuration(first.Start-15*time.Second) > time.Second ||
+		absDuration(first.Duration-3*time.Second) > time.Second {
+		t.Errorf("first mark = %+v, want a 3s dit at 15s", first)
+	}
+}
+
+func TestDecodeFile(t *testing.T) {
+	cfg := testConfig()
+	samples := beacon(t, "W1AW", 401.3, cfg, 0.25)
+	path := filepath.Join(t.TempDir(), "beacon.wav")
+	if err := wav.WriteFile(path, samples, int(cfg.SampleRate)); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	// The recording is read in blocks that do not fall on decimation boundaries
+	cfg.SampleRate = 0
+	result, err := DecodeFile(path, cfg)
+	if err != nil {
+		t.Fatalf("DecodeFile() error = %v", err)
+	}
+	if len(samples) <= ReadBlockSize {
+		t.Fatalf("recording of %d samples fits in one block", len(samples))
+	}
+	if result.Text != "W1AW" || math.Abs(result.Frequency-401.3) > 0.2 {
+		t.Errorf("DecodeFile() = %q at %.2f Hz, want W1AW at about 401.3 Hz", result.Text, result.Frequency)
+	}
+
+	if _, err := DecodeFile(filepath.Join(t.TempDir(), "missing.wav"), cfg); err == nil {
+		t.Error("DecodeFile() should fail for a missing file")
+	}
+}
+
+func TestDecode_DFCW(t *testing.T) {
+	cfg := testConfig()
+	cfg.Mode = ModeDFCW
+	samples := beacon(t, "G0ABC", 399.5, cfg, 0.25)
+
+	result, err := Decode(samples, cfg)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if result.Text != "G0ABC" {
+		t.Errorf("Text = %q, want %q", result.Text, "G0ABC")
+	}
+	for _, mark := range result.Marks {
+		if absDuration(mark.Duration-cfg.Dit) > time.Second {
+			t.Errorf("mark %+v, want every DFCW element one dit long", mark)
+		}
+	}
+}
+
+func TestDecode_ExactFrequency(t *testing.T) {
+	cfg := testConfig()
+	cfg.Search = 0
+	cfg.Dit = 6 * time.Second
+	samples := beacon(t, "TEST", testFrequency, cfg, 0.2)
+
+	result, err := Decode(samples, cfg)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if result.Text != "TEST" || result.Frequency != testFrequency {
+		t.Errorf("result = %q at %.2f Hz, want TEST at %.0f Hz", result.Text, result.Frequency, testFrequency)
+	}
+}
+
+func TestDecode_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   error
+	}{
+		{"low sample rate", func(c *Config) { c.SampleRate = 80 }, ErrInvalidSampleRate},
+		{"frequency above nyquist", func(c *Config) { c.Frequency = 600 }, ErrInvalidFrequency},
+		{"zero frequency", func(c *Config) { c.Frequency = 0 }, ErrInvalidFrequency},
+		{"fast dit", func(c *Config) { c.Dit = 100 * time.Millisecond }, ErrInvalidDit},
+		{"slow dit", func(c *Config) { c.Dit = time.Hour }, ErrInvalidDit},
+		{"unknown mode", func(c *Config) { c.Mode = "fsk" }, ErrInvalidMode},
+		{"negative search", func(c *Config) { c.Search = -1 }, ErrInvalidOffset},
+		{"wide search", func(c *Config) { c.Search = 20 }, ErrInvalidOffset},
+		{"wide dfcw shift", func(c *Config) { c.Mode = ModeDFCW; c.Shift = 10 }, ErrInvalidOffset},
+		{"zero threshold", func(c *Config) { c.Threshold = 0 }, ErrInvalidThreshold},
+		{"full threshold", func(c *Config) { c.Threshold = 1 }, ErrInvalidThreshold},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			tt.modify(&cfg)
+			if _, err := Decode(nil, cfg); !errors.Is(err, tt.want) {
+				t.Errorf("Decode() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeRuns(t *testing.T) {
+	states := []keyState{
+		keyUp, keyUp, keyUp,
+		keyDit, keyDit, keyUp, keyDit, keyDit, // a one sample dropout inside a mark
+		keyUp, keyUp, keyUp,
+		keyDah, keyDah, keyDah,
+	}
+	got := mergeRuns(states, 2)
+	want := []run{
+		{state: keyUp, start: 0, length: 3},
+		{state: keyDit, start: 3, length: 5},
+		{state: keyUp, start: 8, length: 3},
+		{state: keyDah, start: 11, length: 3},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("mergeRuns() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("run %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	dit := 3 * time.Second
+	at := func(units float64) time.Duration { return time.Duration(units * float64(dit)) }
+	marks := []Mark{
+		// K: dah dit dah
+		{Start: at(0), Duration: at(3), IsDah: true},
+		{Start: at(4), Duration: at(1)},
+		{Start: at(6), Duration: at(3), IsDah: true},
+		// E after a character space
+		{Start: at(12), Duration: at(1)},
+		// T after a word space
+		{Start: at(20), Duration: at(3), IsDah: true},
+	}
+	if got := text(marks, dit, cw.AlphabetLatin); got != "KE T" {
+		t.Errorf("text() = %q, want %q", got, "KE T")
+	}
+}
+
+// absDuration returns the magnitude of d
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
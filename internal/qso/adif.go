@@ -0,0 +1,114 @@
+// internal/qso/adif.go
+package qso
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ADIF export constants
+const (
+	// adifDateLayout is the ADIF date format (YYYYMMDD)
+	adifDateLayout = "20060102"
+	// adifTimeLayout is the ADIF time format (HHMMSS)
+	adifTimeLayout = "150405"
+	// adifProgramID identifies this program in the ADIF header
+	adifProgramID = "cwdecoder"
+)
+
+// WriteADIFHeader writes the ADIF file header. Call once per new file.
+func WriteADIFHeader(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("ADIF export from cwdecoder\n")
+	writeADIFField(&b, "ADIF_VER", "3.1.4")
+	writeADIFField(&b, "PROGRAMID", adifProgramID)
+	b.WriteString("<EOH>\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("write adif header: %w", err)
+	}
+	return nil
+}
+
+// WriteADIFRecord writes one contact as an ADIF record.
+// StationA is logged as the station call and StationB as the worked station.
+func WriteADIFRecord(w io.Writer, record QSO) error {
+	if _, err := io.WriteString(w, FormatADIF(record)); err != nil {
+		return fmt.Errorf("write adif record: %w", err)
+	}
+	return nil
+}
+
+// WriteADIF writes a complete ADIF file containing qsos
+func WriteADIF(w io.Writer, qsos []QSO) error {
+	if err := WriteADIFHeader(w); err != nil {
+		return err
+	}
+	for _, record := range qsos {
+		if err := WriteADIFRecord(w, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FormatADIF formats one contact as an ADIF record terminated by <EOR>
+func FormatADIF(record QSO) string {
+	start := record.Start.UTC()
+	end := record.End.UTC()
+	a := record.StationA
+	b := record.StationB
+
+	var sb strings.Builder
+	writeADIFField(&sb, "CALL", b.Call)
+	writeADIFField(&sb, "STATION_CALLSIGN", a.Call)
+	writeADIFField(&sb, "MODE", "CW")
+	if !record.Start.IsZero() {
+		writeADIFField(&sb, "QSO_DATE", start.Format(adifDateLayout))
+		writeADIFField(&sb, "TIME_ON", start.Format(adifTimeLayout))
+	}
+	if !record.End.IsZero() {
+		writeADIFField(&sb, "QSO_DATE_OFF", end.Format(adifDateLayout))
+		writeADIFField(&sb, "TIME_OFF", end.Format(adifTimeLayout))
+	}
+
+	// Reports: A sent its RST to B, and received B's
+	writeADIFField(&sb, "RST_SENT", a.Exchange.RST)
+	writeADIFField(&sb, "RST_RCVD", b.Exchange.RST)
+
+	// Worked station's details
+	writeADIFField(&sb, "NAME", b.Exchange.Name)
+	writeADIFField(&sb, "QTH", b.Exchange.QTH)
+	writeADIFField(&sb, "GRIDSQUARE", b.Exchange.Grid)
+	writeADIFField(&sb, "RX_PWR", b.Exchange.Power)
+	writeADIFField(&sb, "RIG", b.Exchange.Rig)
+
+	// Logging station's details
+	writeADIFField(&sb, "MY_NAME", a.Exchange.Name)
+	writeADIFField(&sb, "MY_CITY", a.Exchange.QTH)
+	writeADIFField(&sb, "MY_GRIDSQUARE", a.Exchange.Grid)
+	writeADIFField(&sb, "TX_PWR", a.Exchange.Power)
+	writeADIFField(&sb, "MY_RIG", a.Exchange.Rig)
+
+	sb.WriteString("<EOR>\n")
+	return sb.String()
+}
+
+// writeADIFField writes "<NAME:len>value " and skips empty values
+func writeADIFField(b *strings.Builder, name, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(b, "<%s:%d>%s ", name, len(value), value)
+}
+
+// FormatSummary formats a one-line human-readable contact summary
+func FormatSummary(record QSO) string {
+	return fmt.Sprintf("%s %s <-> %s (%s, %d overs)",
+		record.Start.UTC().Format(time.DateTime),
+		record.StationA.Call, record.StationB.Call,
+		record.End.Sub(record.Start).Round(time.Second),
+		len(record.Overs))
+}
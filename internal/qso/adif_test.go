@@ -0,0 +1,92 @@
+package qso
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testQSO() QSO {
+	return QSO{
+		Start: time.Date(2024, 3, 1, 18, 30, 5, 0, time.UTC),
+		End:   time.Date(2024, 3, 1, 18, 42, 10, 0, time.UTC),
+		StationA: Station{
+			Call:     "K1ABC",
+			Exchange: Exchange{RST: "579", Name: "JOHN", QTH: "BOSTON MA", Grid: "FN42", Power: "100"},
+		},
+		StationB: Station{
+			Call:     "W1AW",
+			Exchange: Exchange{RST: "599", Name: "BOB", Rig: "K3"},
+		},
+	}
+}
+
+func TestFormatADIF(t *testing.T) {
+	record := FormatADIF(testQSO())
+
+	wantFields := []string{
+		"<CALL:4>W1AW ",
+		"<STATION_CALLSIGN:5>K1ABC ",
+		"<MODE:2>CW ",
+		"<QSO_DATE:8>20240301 ",
+		"<TIME_ON:6>183005 ",
+		"<TIME_OFF:6>184210 ",
+		"<RST_SENT:3>579 ",
+		"<RST_RCVD:3>599 ",
+		"<NAME:3>BOB ",
+		"<RIG:2>K3 ",
+		"<MY_NAME:4>JOHN ",
+		"<MY_CITY:9>BOSTON MA ",
+		"<MY_GRIDSQUARE:4>FN42 ",
+		"<TX_PWR:3>100 ",
+	}
+	for _, field := range wantFields {
+		if !strings.Contains(record, field) {
+			t.Errorf("record missing %q:\n%s", field, record)
+		}
+	}
+	if strings.Contains(record, "<QTH:") {
+		t.Error("empty fields should be omitted")
+	}
+	if !strings.HasSuffix(record, "<EOR>\n") {
+		t.Errorf("record should end with <EOR>: %q", record)
+	}
+}
+
+func TestFormatADIF_ConvertsToUTC(t *testing.T) {
+	record := testQSO()
+	record.Start = time.Date(2024, 3, 1, 23, 30, 0, 0, time.FixedZone("EST", -5*3600))
+
+	adif := FormatADIF(record)
+	if !strings.Contains(adif, "<QSO_DATE:8>20240302 ") || !strings.Contains(adif, "<TIME_ON:6>043000 ") {
+		t.Errorf("times should be logged in UTC:\n%s", adif)
+	}
+}
+
+func TestWriteADIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteADIF(&buf, []QSO{testQSO(), testQSO()}); err != nil {
+		t.Fatalf("WriteADIF: %v", err)
+	}
+
+	out := buf.String()
+	header, body, found := strings.Cut(out, "<EOH>\n")
+	if !found {
+		t.Fatalf("missing <EOH>:\n%s", out)
+	}
+	if !strings.Contains(header, "<ADIF_VER:5>3.1.4") {
+		t.Errorf("header missing ADIF_VER: %q", header)
+	}
+	if count := strings.Count(body, "<EOR>"); count != 2 {
+		t.Errorf("body has %d records, want 2", count)
+	}
+}
+
+func TestFormatSummary(t *testing.T) {
+	summary := FormatSummary(testQSO())
+	want := "2024-03-01 18:30:05 K1ABC <-> W1AW (12m5s, 0 overs)"
+	if summary != want {
+		t.Errorf("FormatSummary = %q, want %q", summary, want)
+	}
+}
@@ -0,0 +1,194 @@
+// internal/qso/fields.go
+package qso
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Field extraction limits
+const (
+	// MaxMultiWordField is the most tokens taken for free-text fields like QTH and RIG
+	MaxMultiWordField = 3
+)
+
+var (
+	// rstShape matches a signal report, including cut-number nines (5NN)
+	rstShape = regexp.MustCompile(`^[1-5][1-9N][1-9N]$`)
+	// gridShape matches a 4- or 6-character Maidenhead locator
+	gridShape = regexp.MustCompile(`^[A-R]{2}[0-9]{2}([A-X]{2})?$`)
+	// powerShape matches power statements such as 100, 100W, 5WATTS or 1KW
+	powerShape = regexp.MustCompile(`^([0-9]+)(W|WATTS|KW)?$`)
+	// nameShape matches an operator name
+	nameShape = regexp.MustCompile(`^[A-Z]{2,12}$`)
+)
+
+// fillerWords are skipped between a field keyword and its value ("NAME IS JOHN")
+var fillerWords = map[string]bool{
+	"IS":   true,
+	"HR":   true,
+	"HERE": true,
+	"NR":   true, // near
+	"=":    true,
+}
+
+// fieldKeywords end a multi-word field value
+var fieldKeywords = map[string]bool{
+	"NAME": true, "OP": true, "QTH": true, "RST": true, "UR": true, "RIG": true,
+	"PWR": true, "POWER": true, "GRID": true, "LOC": true, "WX": true, "ANT": true,
+	"ES": true, "HW": true, "DE": true, "TNX": true, "FB": true, "BK": true, "K": true,
+	"KN": true, "SK": true, "AR": true, "73": true, "TU": true,
+}
+
+// Exchange holds the fields one station sent during its overs
+type Exchange struct {
+	// RST is the report this station gave the other station
+	RST string
+	// Name is this station's operator name
+	Name string
+	// QTH is this station's location
+	QTH string
+	// Grid is this station's Maidenhead locator
+	Grid string
+	// Power is this station's transmit power in watts
+	Power string
+	// Rig is this station's equipment description
+	Rig string
+}
+
+// merge copies non-empty fields from other, keeping the first value heard
+func (e *Exchange) merge(other Exchange) {
+	if e.RST == "" {
+		e.RST = other.RST
+	}
+	if e.Name == "" {
+		e.Name = other.Name
+	}
+	if e.QTH == "" {
+		e.QTH = other.QTH
+	}
+	if e.Grid == "" {
+		e.Grid = other.Grid
+	}
+	if e.Power == "" {
+		e.Power = other.Power
+	}
+	if e.Rig == "" {
+		e.Rig = other.Rig
+	}
+}
+
+// extractExchange pulls exchange fields out of the tokens of a single over
+func extractExchange(tokens []string) Exchange {
+	var ex Exchange
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		switch token {
+		case "RST", "UR":
+			if value, next := valueAfter(tokens, i); rstShape.MatchString(value) && ex.RST == "" {
+				ex.RST = normalizeRST(value)
+				i = next
+			} else if value == "RST" {
+				// "UR RST 579"
+				if report, next := valueAfter(tokens, next); rstShape.MatchString(report) && ex.RST == "" {
+					ex.RST = normalizeRST(report)
+					i = next
+				}
+			}
+		case "NAME", "OP":
+			if value, next := valueAfter(tokens, i); nameShape.MatchString(value) && ex.Name == "" {
+				ex.Name = value
+				i = next
+			}
+		case "QTH":
+			if value, next := phraseAfter(tokens, i); value != "" && ex.QTH == "" {
+				ex.QTH = value
+				i = next
+			}
+		case "GRID", "LOC":
+			if value, next := valueAfter(tokens, i); gridShape.MatchString(value) && ex.Grid == "" {
+				ex.Grid = value
+				i = next
+			}
+		case "PWR", "POWER":
+			if value, next := powerAfter(tokens, i); value != "" && ex.Power == "" {
+				ex.Power = value
+				i = next
+			}
+		case "RIG":
+			if value, next := phraseAfter(tokens, i); value != "" && ex.Rig == "" {
+				ex.Rig = value
+				i = next
+			}
+		default:
+			// Bare reports and locators are common in short overs ("DE W1AW 599 599 BK")
+			if rstShape.MatchString(token) && ex.RST == "" {
+				ex.RST = normalizeRST(token)
+			} else if gridShape.MatchString(token) && ex.Grid == "" {
+				ex.Grid = token
+			}
+		}
+	}
+
+	return ex
+}
+
+// valueAfter returns the first non-filler token after index i and its index
+func valueAfter(tokens []string, i int) (string, int) {
+	for j := i + 1; j < len(tokens); j++ {
+		if !fillerWords[tokens[j]] {
+			return tokens[j], j
+		}
+	}
+	return "", i
+}
+
+// phraseAfter returns up to MaxMultiWordField tokens after index i, stopping at a keyword
+func phraseAfter(tokens []string, i int) (string, int) {
+	var words []string
+	last := i
+	for j := i + 1; j < len(tokens) && len(words) < MaxMultiWordField; j++ {
+		if len(words) == 0 && fillerWords[tokens[j]] {
+			last = j
+			continue
+		}
+		if fieldKeywords[tokens[j]] || isTerminator(tokens[j]) {
+			break
+		}
+		words = append(words, tokens[j])
+		last = j
+	}
+	return strings.Join(words, " "), last
+}
+
+// powerAfter parses a power statement after index i into watts
+func powerAfter(tokens []string, i int) (string, int) {
+	value, next := valueAfter(tokens, i)
+	match := powerShape.FindStringSubmatch(value)
+	if match == nil {
+		return "", i
+	}
+
+	watts := match[1]
+	unit := match[2]
+	// "100 W" or "5 WATTS" with the unit as a separate token
+	if unit == "" && next+1 < len(tokens) {
+		switch tokens[next+1] {
+		case "W", "WATTS":
+			next++
+		case "KW":
+			unit = "KW"
+			next++
+		}
+	}
+	if unit == "KW" {
+		watts += "000"
+	}
+	return watts, next
+}
+
+// normalizeRST expands cut-number nines in a report (5NN -> 599)
+func normalizeRST(rst string) string {
+	return strings.ReplaceAll(rst, "N", "9")
+}
@@ -0,0 +1,71 @@
+package qso
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractExchange(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want Exchange
+	}{
+		{
+			name: "ragchew fields",
+			text: "UR RST 579 579 NAME JOHN QTH BOSTON MA GRID FN42 PWR 100W BK",
+			want: Exchange{RST: "579", Name: "JOHN", QTH: "BOSTON MA", Grid: "FN42", Power: "100"},
+		},
+		{
+			name: "filler words",
+			text: "NAME HR IS BOB QTH IS NR HARTFORD ES RIG IS K3 ES DIPOLE",
+			want: Exchange{Name: "BOB", QTH: "HARTFORD", Rig: "K3"},
+		},
+		{
+			name: "cut numbers",
+			text: "UR 5NN 5NN TU",
+			want: Exchange{RST: "599"},
+		},
+		{
+			name: "bare report and locator",
+			text: "DE W1AW 449 449 JO62QM BK",
+			want: Exchange{RST: "449", Grid: "JO62QM"},
+		},
+		{
+			name: "kilowatt with separate unit",
+			text: "PWR 1 KW",
+			want: Exchange{Power: "1000"},
+		},
+		{
+			name: "first value wins",
+			text: "NAME JOHN NAME JON",
+			want: Exchange{Name: "JOHN"},
+		},
+		{
+			name: "keyword without value",
+			text: "QTH K",
+			want: Exchange{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractExchange(strings.Fields(tt.text))
+			if got != tt.want {
+				t.Errorf("extractExchange(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExchange_Merge(t *testing.T) {
+	ex := Exchange{Name: "JOHN"}
+	ex.merge(Exchange{Name: "JON", QTH: "BOSTON"})
+
+	if ex.Name != "JOHN" {
+		t.Errorf("Name = %q, want first value JOHN", ex.Name)
+	}
+	if ex.QTH != "BOSTON" {
+		t.Errorf("QTH = %q, want BOSTON", ex.QTH)
+	}
+}
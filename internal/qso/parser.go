@@ -0,0 +1,329 @@
+// internal/qso/parser.go
+// Package qso segments a decoded CW transcript into overs and assembles
+// completed contacts with their exchange fields.
+package qso
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/callsign"
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+// Parser timing constants
+const (
+	// DefaultIdleTimeout is how long a contact may go quiet before it is closed
+	DefaultIdleTimeout = 2 * time.Minute
+)
+
+// Over is one station's transmission, ended by a turn-taking prosign
+type Over struct {
+	// From is the sending station (empty if it never identified)
+	From string
+	// To is the station being addressed ("CQ" for a general call)
+	To string
+	// Text is the decoded text of the over
+	Text string
+	// Start is when the first character of the over was decoded
+	Start time.Time
+	// End is when the last character of the over was decoded
+	End time.Time
+}
+
+// Station is one side of a contact
+type Station struct {
+	// Call is the station's callsign
+	Call string
+	// Exchange is what this station sent
+	Exchange Exchange
+	// SignedOff is true once the station sent SK
+	SignedOff bool
+}
+
+// QSO is a completed contact between two stations
+type QSO struct {
+	// Start is when the contact began (the answered CQ, if one was heard)
+	Start time.Time
+	// End is when the last over of the contact finished
+	End time.Time
+	// StationA is the station that transmitted first (the CQ caller when heard)
+	StationA Station
+	// StationB is the station that answered
+	StationB Station
+	// Overs are the transmissions that make up the contact
+	Overs []Over
+}
+
+// QSOCallback is called when a contact is complete.
+// Must be non-blocking and fast.
+type QSOCallback func(record QSO)
+
+// Parser is a state machine that turns decoded characters into overs and contacts
+type Parser struct {
+	idleTimeout time.Duration
+
+	mu sync.Mutex
+
+	// Current over being built
+	tokens     []string
+	word       strings.Builder
+	overStart  time.Time
+	lastOutput time.Time
+
+	// Contact state
+	current *QSO
+	lastCQ  *Over // most recent CQ, to date a contact that answers it
+
+	callback QSOCallback
+}
+
+// NewParser creates a QSO parser. A contact with no overs for idleTimeout
+// is considered finished; zero uses DefaultIdleTimeout.
+func NewParser(idleTimeout time.Duration) *Parser {
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultIdleTimeout
+	}
+	return &Parser{idleTimeout: idleTimeout}
+}
+
+// SetCallback sets the callback for completed contacts
+func (p *Parser) SetCallback(cb QSOCallback) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.callback = cb
+}
+
+// HandleOutput consumes one decoded output from the cw decoder.
+// Signature matches cw.DecodedCallback so it can be chained directly.
+func (p *Parser) HandleOutput(output cw.DecodedOutput) {
+	// Only final characters build words and contacts
+	if !output.IsFinal() {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// A long silence closes any contact in progress
+	if !p.lastOutput.IsZero() && output.Timestamp.Sub(p.lastOutput) > p.idleTimeout {
+		p.endWord()
+		p.endOver()
+		p.finishContact()
+	}
+	p.lastOutput = output.Timestamp
+
+	if output.IsWordSpace {
+		p.endWord()
+		return
+	}
+	if output.Character == 0 {
+		return
+	}
+	if len(p.tokens) == 0 && p.word.Len() == 0 {
+		p.overStart = output.Timestamp
+	}
+	p.word.WriteRune(output.Character)
+}
+
+// Flush ends any over and contact in progress (e.g. at end of a session)
+func (p *Parser) Flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.endWord()
+	p.endOver()
+	p.finishContact()
+}
+
+// endWord appends the word being built to the current over
+func (p *Parser) endWord() {
+	if p.word.Len() == 0 {
+		return
+	}
+	token := strings.ToUpper(p.word.String())
+	p.word.Reset()
+
+	// Prosigns sent run together arrive as characters; name them as tokens
+	switch token {
+	case string(cw.ProsignSK):
+		token = "SK"
+	case string(cw.ProsignAR):
+		token = "AR"
+	case string(cw.ProsignKN):
+		token = "KN"
+	}
+	p.tokens = append(p.tokens, token)
+
+	if isTerminator(token) {
+		p.endOver()
+	}
+}
+
+// isTerminator reports whether a token hands the turn to the other station
+func isTerminator(token string) bool {
+	switch token {
+	case "K", "KN", "BK", "SK", "AR":
+		return true
+	}
+	return false
+}
+
+// endOver processes the tokens of a finished over
+func (p *Parser) endOver() {
+	if len(p.tokens) == 0 {
+		return
+	}
+	tokens := p.tokens
+	p.tokens = nil
+
+	over := Over{
+		Text:  strings.Join(tokens, " "),
+		Start: p.overStart,
+		End:   p.lastOutput,
+	}
+	over.To, over.From = identify(tokens)
+
+	if over.To == "CQ" {
+		// A new CQ ends whatever contact came before it
+		p.finishContact()
+		p.lastCQ = &over
+		return
+	}
+
+	// Overs without identification (e.g. "R R 73 BK") belong to the contact in progress
+	if over.From == "" && p.current != nil {
+		over.From, over.To = p.inferSender()
+	}
+	if over.From == "" {
+		return
+	}
+	if over.To == "" && p.current != nil {
+		over.To = p.otherStation(over.From)
+	}
+
+	if p.current != nil && !p.isParticipant(over.From, over.To) {
+		p.finishContact()
+	}
+	if p.current == nil {
+		p.startContact(over)
+	}
+
+	p.current.Overs = append(p.current.Overs, over)
+	p.current.End = over.End
+
+	station := p.station(over.From)
+	if station == nil {
+		return
+	}
+	station.Exchange.merge(extractExchange(tokens))
+	for _, token := range tokens {
+		if token == "SK" {
+			station.SignedOff = true
+		}
+	}
+
+	if p.current.StationA.SignedOff && p.current.StationB.SignedOff {
+		p.finishContact()
+	}
+}
+
+// identify finds the addressee and sender around "DE" ("K1ABC DE W1AW", "CQ CQ DE W1AW")
+func identify(tokens []string) (to, from string) {
+	for i, token := range tokens {
+		if token != "DE" {
+			continue
+		}
+		for j := i + 1; j < len(tokens); j++ {
+			if parsed, err := callsign.Parse(tokens[j]); err == nil {
+				from = parsed.Call
+				break
+			}
+		}
+		for j := i - 1; j >= 0; j-- {
+			if tokens[j] == "CQ" {
+				to = "CQ"
+				break
+			}
+			if parsed, err := callsign.Parse(tokens[j]); err == nil {
+				to = parsed.Call
+				break
+			}
+		}
+		return to, from
+	}
+	return "", ""
+}
+
+// startContact begins a new contact from its first identified over
+func (p *Parser) startContact(over Over) {
+	p.current = &QSO{Start: over.Start}
+
+	// Answering a CQ: the CQ caller transmitted first
+	if p.lastCQ != nil && p.lastCQ.From == over.To {
+		p.current.Start = p.lastCQ.Start
+		p.current.StationA.Call = over.To
+		p.current.StationB.Call = over.From
+		p.current.Overs = append(p.current.Overs, *p.lastCQ)
+		p.lastCQ = nil
+		return
+	}
+
+	p.current.StationA.Call = over.From
+	p.current.StationB.Call = over.To
+}
+
+// finishContact emits the contact in progress if both stations were identified
+func (p *Parser) finishContact() {
+	record := p.current
+	p.current = nil
+	if record == nil || record.StationA.Call == "" || record.StationB.Call == "" {
+		return
+	}
+	if p.callback != nil {
+		p.callback(*record)
+	}
+}
+
+// isParticipant reports whether an over belongs to the contact in progress
+func (p *Parser) isParticipant(from, to string) bool {
+	a, b := p.current.StationA.Call, p.current.StationB.Call
+	if b == "" {
+		// Contact started by an over with no addressee; adopt the first reply
+		if from != a && (to == "" || to == a) {
+			p.current.StationB.Call = from
+			return true
+		}
+		return from == a
+	}
+	return (from == a || from == b) && (to == "" || to == a || to == b)
+}
+
+// inferSender assumes an unidentified over came from the station that did not send last
+func (p *Parser) inferSender() (from, to string) {
+	overs := p.current.Overs
+	if len(overs) == 0 {
+		return "", ""
+	}
+	last := overs[len(overs)-1].From
+	return p.otherStation(last), last
+}
+
+// otherStation returns the contact participant that is not call
+func (p *Parser) otherStation(call string) string {
+	if call == p.current.StationA.Call {
+		return p.current.StationB.Call
+	}
+	return p.current.StationA.Call
+}
+
+// station returns the participant record for call
+func (p *Parser) station(call string) *Station {
+	switch call {
+	case p.current.StationA.Call:
+		return &p.current.StationA
+	case p.current.StationB.Call:
+		return &p.current.StationB
+	}
+	return nil
+}
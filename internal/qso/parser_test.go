@@ -0,0 +1,138 @@
+package qso
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw/cwtest"
+)
+
+// collect returns a parser that appends completed contacts to the returned slice
+func collect() (*Parser, *[]QSO) {
+	var records []QSO
+	parser := NewParser(0)
+	parser.SetCallback(func(record QSO) {
+		records = append(records, record)
+	})
+	return parser, &records
+}
+
+func TestParser_Ragchew(t *testing.T) {
+	parser, records := collect()
+
+	start := time.Date(2024, 3, 1, 18, 30, 0, 0, time.UTC)
+	next := cwtest.FeedText(parser.HandleOutput, "CQ CQ DE K1ABC K ", start)
+	next = cwtest.FeedText(parser.HandleOutput, "K1ABC DE W1AW W1AW K ", next)
+	next = cwtest.FeedText(parser.HandleOutput, "W1AW DE K1ABC UR RST 579 579 NAME JOHN QTH BOSTON MA GRID FN42 PWR 100W BK ", next)
+	next = cwtest.FeedText(parser.HandleOutput, "R UR 599 NAME BOB QTH NEWINGTON RIG K3 ES DIPOLE 73 SK ", next)
+	if len(*records) != 0 {
+		t.Fatal("contact should stay open until both stations sign off")
+	}
+	cwtest.FeedText(parser.HandleOutput, "TU 73 % ", next)
+
+	if len(*records) != 1 {
+		t.Fatalf("received %d contacts, want 1", len(*records))
+	}
+	record := (*records)[0]
+
+	if record.StationA.Call != "K1ABC" || record.StationB.Call != "W1AW" {
+		t.Errorf("stations = %s/%s, want K1ABC/W1AW", record.StationA.Call, record.StationB.Call)
+	}
+	if !record.Start.Equal(start) {
+		t.Errorf("Start = %v, want the CQ at %v", record.Start, start)
+	}
+	if !record.End.After(record.Start) {
+		t.Errorf("End = %v, want after Start", record.End)
+	}
+	if len(record.Overs) != 5 {
+		t.Errorf("Overs = %d, want 5", len(record.Overs))
+	}
+
+	wantA := Exchange{RST: "579", Name: "JOHN", QTH: "BOSTON MA", Grid: "FN42", Power: "100"}
+	if record.StationA.Exchange != wantA {
+		t.Errorf("StationA.Exchange = %+v, want %+v", record.StationA.Exchange, wantA)
+	}
+	wantB := Exchange{RST: "599", Name: "BOB", QTH: "NEWINGTON", Rig: "K3"}
+	if record.StationB.Exchange != wantB {
+		t.Errorf("StationB.Exchange = %+v, want %+v", record.StationB.Exchange, wantB)
+	}
+}
+
+func TestParser_NewPairClosesContact(t *testing.T) {
+	parser, records := collect()
+
+	next := cwtest.FeedText(parser.HandleOutput, "W1AW DE K1ABC 599 BK ", time.Now())
+	next = cwtest.FeedText(parser.HandleOutput, "K1ABC DE W1AW 579 BK ", next)
+	cwtest.FeedText(parser.HandleOutput, "N2XYZ DE DL1ABC 559 K ", next)
+
+	if len(*records) != 1 {
+		t.Fatalf("received %d contacts, want 1", len(*records))
+	}
+	record := (*records)[0]
+	if record.StationA.Call != "K1ABC" || record.StationB.Call != "W1AW" {
+		t.Errorf("stations = %s/%s, want K1ABC/W1AW", record.StationA.Call, record.StationB.Call)
+	}
+	if record.StationA.Exchange.RST != "599" || record.StationB.Exchange.RST != "579" {
+		t.Errorf("reports = %s/%s, want 599/579", record.StationA.Exchange.RST, record.StationB.Exchange.RST)
+	}
+
+	parser.Flush()
+	if len(*records) != 2 {
+		t.Fatalf("received %d contacts after Flush, want 2", len(*records))
+	}
+	if (*records)[1].StationA.Call != "DL1ABC" {
+		t.Errorf("second contact StationA = %s, want DL1ABC", (*records)[1].StationA.Call)
+	}
+}
+
+func TestParser_IdleTimeout(t *testing.T) {
+	var records []QSO
+	parser := NewParser(time.Minute)
+	parser.SetCallback(func(record QSO) {
+		records = append(records, record)
+	})
+
+	start := time.Now()
+	cwtest.FeedText(parser.HandleOutput, "W1AW DE K1ABC BK ", start)
+	if len(records) != 0 {
+		t.Fatal("contact should not close before the idle timeout")
+	}
+
+	cwtest.FeedText(parser.HandleOutput, "CQ", start.Add(2*time.Minute))
+	if len(records) != 1 {
+		t.Errorf("received %d contacts after idle gap, want 1", len(records))
+	}
+}
+
+func TestParser_UnidentifiedTraffic(t *testing.T) {
+	parser, records := collect()
+
+	next := cwtest.FeedText(parser.HandleOutput, "CQ CQ DE K1ABC K ", time.Now())
+	cwtest.FeedText(parser.HandleOutput, "R R 73 BK ", next)
+	parser.Flush()
+
+	if len(*records) != 0 {
+		t.Errorf("received %d contacts, want 0 without a second station", len(*records))
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	tests := []struct {
+		text     string
+		wantTo   string
+		wantFrom string
+	}{
+		{"CQ CQ DE K1ABC K", "CQ", "K1ABC"},
+		{"K1ABC DE DL/W1AW/P K", "K1ABC", "DL/W1AW/P"},
+		{"UR RST 599 BK", "", ""},
+		{"DE W1AW", "", "W1AW"},
+	}
+
+	for _, tt := range tests {
+		to, from := identify(strings.Fields(tt.text))
+		if to != tt.wantTo || from != tt.wantFrom {
+			t.Errorf("identify(%q) = %q, %q; want %q, %q", tt.text, to, from, tt.wantTo, tt.wantFrom)
+		}
+	}
+}
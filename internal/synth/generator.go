@@ -0,0 +1,350 @@
+// internal/synth/generator.go
+package synth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+// Generator constants
+const (
+	// NoiseBandwidth is the reference bandwidth in Hz for SNR (the usual SSB channel convention)
+	NoiseBandwidth = 2500.0
+	// ChirpTimeConstant is how quickly the key-down frequency offset settles
+	ChirpTimeConstant = 0.01
+	// ImpulseDecay is the time constant of a QRN crash in seconds
+	ImpulseDecay = 0.002
+	// ImpulseLength is how many decay time constants an impulse lasts
+	ImpulseLength = 5
+	// MinIntervalFraction is the shortest a jittered interval may become, as a fraction of nominal
+	MinIntervalFraction = 0.2
+	// DefaultLeadIn is the silence before the first mark and after the last
+	DefaultLeadIn = 500 * time.Millisecond
+)
+
+var (
+	// ErrInvalidFadeDepth indicates fade depth must be between 0 and 1
+	ErrInvalidFadeDepth = errors.New("fade depth must be between 0.0 and 1.0")
+	// ErrInvalidFadePeriod indicates a fade period is required when fading is enabled
+	ErrInvalidFadePeriod = errors.New("fade period must be positive when fading is enabled")
+	// ErrInvalidJitter indicates timing jitter must be between 0 and 0.5
+	ErrInvalidJitter = errors.New("timing jitter must be between 0.0 and 0.5")
+	// ErrInvalidImpulseRate indicates the impulse rate must be non-negative
+	ErrInvalidImpulseRate = errors.New("impulse rate must be non-negative")
+	// ErrInvalidFist indicates a fist model with an impossible dah/dit ratio or weighting
+	ErrInvalidFist = errors.New("fist dah/dit ratio must exceed 1 and weighting must be between -0.5 and 0.5")
+	// ErrUnknownFist indicates a fist model name that is not defined
+	ErrUnknownFist = errors.New("unknown fist model")
+)
+
+// Fist models how a sender's timing departs from the ideal
+type Fist struct {
+	// DahDitRatio is the sender's dah length in dits
+	DahDitRatio float64
+	// Weighting is the fraction of a dit moved from each space to the preceding mark
+	Weighting float64
+	// DitJitter is the standard deviation of dit lengths as a fraction of nominal
+	DitJitter float64
+	// DahJitter is the standard deviation of dah lengths as a fraction of nominal
+	DahJitter float64
+	// SpaceJitter is the standard deviation of space lengths as a fraction of nominal
+	SpaceJitter float64
+}
+
+// Fist models by name
+var fists = map[string]Fist{
+	// Keyer or computer: perfect timing
+	"electronic": {DahDitRatio: cw.DahDitRatio},
+	// Hand-sent marks run heavy and long dahs and uneven spacing are common
+	"straight": {DahDitRatio: 3.4, Weighting: 0.15, DitJitter: 0.12, DahJitter: 0.12, SpaceJitter: 0.2},
+	// Semi-automatic key: machine dits, hand-made long dahs
+	"bug": {DahDitRatio: 3.8, DitJitter: 0.02, DahJitter: 0.15, SpaceJitter: 0.15},
+}
+
+// LookupFist returns the fist model with the given name
+func LookupFist(name string) (Fist, error) {
+	fist, ok := fists[strings.ToLower(name)]
+	if !ok {
+		return Fist{}, fmt.Errorf("%w: %q (valid: %s)", ErrUnknownFist, name, strings.Join(FistNames(), ", "))
+	}
+	return fist, nil
+}
+
+// FistNames returns the defined fist model names in sorted order
+func FistNames() []string {
+	names := make([]string, 0, len(fists))
+	for name := range fists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GeneratorConfig describes a synthetic CW signal and its impairments.
+// Zero values disable an impairment.
+type GeneratorConfig struct {
+	// Synth is the clean tone configuration
+	Synth Config
+	// WPM is the character speed
+	WPM int
+	// FarnsworthWPM is the spacing speed (0 = same as WPM)
+	FarnsworthWPM int
+	// Fist is the sender's timing model (zero = electronic)
+	Fist Fist
+	// Jitter is extra random timing error on every interval, as a fraction of nominal
+	Jitter float64
+	// Noise enables additive white Gaussian noise at SNR
+	Noise bool
+	// SNR is the signal-to-noise ratio in dB measured in NoiseBandwidth
+	SNR float64
+	// FadeDepth is the QSB depth (0 = none, 1 = fades to silence)
+	FadeDepth float64
+	// FadePeriod is the QSB cycle length
+	FadePeriod time.Duration
+	// ImpulseRate is the mean number of QRN crashes per second
+	ImpulseRate float64
+	// ImpulseAmplitude is the peak amplitude of a QRN crash
+	ImpulseAmplitude float64
+	// DriftRate is the tone frequency drift in Hz per second
+	DriftRate float64
+	// ChirpHz is the frequency offset at key-down, settling over ChirpTimeConstant
+	ChirpHz float64
+	// LeadIn is the silence before the first mark and after the last
+	LeadIn time.Duration
+	// Seed makes the random impairments reproducible
+	Seed uint64
+}
+
+// TruthCharacter is one sent character in the ground-truth transcript
+type TruthCharacter struct {
+	// Text is the character, or the prosign in angle brackets
+	Text string `json:"text"`
+	// Start is when the first mark began, in seconds from the start of the audio
+	Start float64 `json:"start"`
+	// End is when the last mark ended, in seconds from the start of the audio
+	End float64 `json:"end"`
+	// WordStart is true for the first character of each word
+	WordStart bool `json:"word_start"`
+}
+
+// Transcript is the ground truth for generated audio
+type Transcript struct {
+	// Text is the sent text, uppercased with single spaces between words
+	Text string `json:"text"`
+	// WPM is the character speed
+	WPM int `json:"wpm"`
+	// FarnsworthWPM is the spacing speed (0 = same as WPM)
+	FarnsworthWPM int `json:"farnsworth_wpm,omitempty"`
+	// SNR is the signal-to-noise ratio in dB, if noise was added
+	SNR *float64 `json:"snr,omitempty"`
+	// Duration is the audio length in seconds
+	Duration float64 `json:"duration"`
+	// Characters are the sent characters with their timing
+	Characters []TruthCharacter `json:"characters"`
+}
+
+// WriteJSON writes the transcript as indented JSON
+func (t Transcript) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(t); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return nil
+}
+
+// WriteFile writes the transcript as JSON to path
+func (t Transcript) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create transcript: %w", err)
+	}
+	if err := t.WriteJSON(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close transcript: %w", err)
+	}
+	return nil
+}
+
+// Signal is generated audio with its ground truth
+type Signal struct {
+	// Samples are the audio samples (-1.0 to 1.0 before clipping)
+	Samples []float32
+	// SampleRate is the sample rate in Hz
+	SampleRate float64
+	// Truth is what was sent and when
+	Truth Transcript
+}
+
+// Generate renders text as CW audio with the configured impairments
+func Generate(cfg GeneratorConfig, text string) (*Signal, error) {
+	synth, err := NewSynthesizer(cfg.Synth)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	encoder, err := cw.NewEncoder(cw.EncoderConfig{WPM: cfg.WPM, FarnsworthWPM: cfg.FarnsworthWPM})
+	if err != nil {
+		return nil, err
+	}
+	symbols, err := cw.ParseText(text)
+	if err != nil {
+		return nil, err
+	}
+
+	fist := cfg.Fist
+	if fist.DahDitRatio == 0 {
+		fist = fists["electronic"]
+	}
+	rng := rand.New(rand.NewPCG(cfg.Seed, cfg.Seed^0x9e3779b97f4a7c15))
+	keying, truth := cfg.key(symbols, encoder, fist, rng)
+
+	base := cfg.Synth.ToneFrequency
+	samples := synth.render(keying, func(t, sinceKeyDown float64) float64 {
+		return base + cfg.DriftRate*t + cfg.ChirpHz*math.Exp(-sinceKeyDown/ChirpTimeConstant)
+	})
+	sampleRate := cfg.Synth.SampleRate
+
+	if cfg.FadeDepth > 0 {
+		period := cfg.FadePeriod.Seconds()
+		for i := range samples {
+			t := float64(i) / sampleRate
+			samples[i] *= float32(1 - cfg.FadeDepth*0.5*(1-math.Cos(2*math.Pi*t/period)))
+		}
+	}
+	if cfg.ImpulseRate > 0 {
+		addImpulses(samples, sampleRate, cfg.ImpulseRate, cfg.ImpulseAmplitude, rng)
+	}
+	if cfg.Noise {
+		// Tone power A²/2 over noise power in NoiseBandwidth, spread evenly to Nyquist
+		signalPower := cfg.Synth.Amplitude * cfg.Synth.Amplitude / 2
+		noisePower := signalPower / math.Pow(10, cfg.SNR/10) * (sampleRate / NyquistDivisor) / NoiseBandwidth
+		sigma := math.Sqrt(noisePower)
+		for i := range samples {
+			samples[i] += float32(rng.NormFloat64() * sigma)
+		}
+		snr := cfg.SNR
+		truth.SNR = &snr
+	}
+
+	truth.Duration = float64(len(samples)) / sampleRate
+	return &Signal{Samples: samples, SampleRate: sampleRate, Truth: truth}, nil
+}
+
+// validate checks the impairment settings
+func (cfg GeneratorConfig) validate() error {
+	if cfg.FadeDepth < 0 || cfg.FadeDepth > 1 {
+		return ErrInvalidFadeDepth
+	}
+	if cfg.FadeDepth > 0 && cfg.FadePeriod <= 0 {
+		return ErrInvalidFadePeriod
+	}
+	if cfg.Jitter < 0 || cfg.Jitter > 0.5 {
+		return ErrInvalidJitter
+	}
+	if cfg.ImpulseRate < 0 {
+		return ErrInvalidImpulseRate
+	}
+	if cfg.Fist.DahDitRatio != 0 && (cfg.Fist.DahDitRatio <= 1 || math.Abs(cfg.Fist.Weighting) > 0.5) {
+		return ErrInvalidFist
+	}
+	return nil
+}
+
+// key builds keying intervals with fist and jitter applied, recording when each character was sent
+func (cfg GeneratorConfig) key(symbols []cw.Symbol, encoder *cw.Encoder, fist Fist, rng *rand.Rand) ([]cw.Keying, Transcript) {
+	dit := encoder.DitDuration()
+	spaceUnit := encoder.SpaceUnit()
+	leadIn := cfg.LeadIn
+	if leadIn == 0 {
+		leadIn = DefaultLeadIn
+	}
+
+	// vary scales a nominal interval by the fist's and the configured random error
+	vary := func(nominal time.Duration, spread float64) time.Duration {
+		scale := 1 + rng.NormFloat64()*math.Hypot(spread, cfg.Jitter)
+		return time.Duration(float64(nominal) * math.Max(scale, MinIntervalFraction))
+	}
+	weight := time.Duration(fist.Weighting * float64(dit))
+
+	truth := Transcript{WPM: cfg.WPM, FarnsworthWPM: cfg.FarnsworthWPM}
+	keying := []cw.Keying{{ToneOn: false, Duration: leadIn}}
+	elapsed := leadIn
+	var words []string
+	var word strings.Builder
+	wordStart := true
+	for _, symbol := range symbols {
+		if symbol.IsWordSpace() {
+			words = append(words, word.String())
+			word.Reset()
+			wordStart = true
+			continue
+		}
+		if len(truth.Characters) > 0 {
+			gap := spaceUnit * cw.InterCharSpaceRatio
+			if wordStart {
+				gap = spaceUnit * cw.WordSpaceRatio
+			}
+			gap = vary(gap-weight, fist.SpaceJitter)
+			keying = append(keying, cw.Keying{ToneOn: false, Duration: gap})
+			elapsed += gap
+		}
+
+		character := TruthCharacter{Text: symbol.Text, Start: elapsed.Seconds(), WordStart: wordStart}
+		for i, isDah := range symbol.Elements {
+			if i > 0 {
+				space := vary(dit*cw.IntraCharSpaceRatio-weight, fist.SpaceJitter)
+				keying = append(keying, cw.Keying{ToneOn: false, Duration: space})
+				elapsed += space
+			}
+			mark := vary(dit+weight, fist.DitJitter)
+			if isDah {
+				mark = vary(time.Duration(fist.DahDitRatio*float64(dit))+weight, fist.DahJitter)
+			}
+			keying = append(keying, cw.Keying{ToneOn: true, Duration: mark})
+			elapsed += mark
+		}
+		character.End = elapsed.Seconds()
+		truth.Characters = append(truth.Characters, character)
+		word.WriteString(symbol.Text)
+		wordStart = false
+	}
+	words = append(words, word.String())
+	keying = append(keying, cw.Keying{ToneOn: false, Duration: leadIn})
+
+	truth.Text = strings.Join(words, " ")
+	return keying, truth
+}
+
+// addImpulses adds QRN crashes at Poisson-distributed times
+func addImpulses(samples []float32, sampleRate, rate, amplitude float64, rng *rand.Rand) {
+	length := int(ImpulseDecay * ImpulseLength * sampleRate)
+	t := rng.ExpFloat64() / rate
+	for {
+		start := int(t * sampleRate)
+		if start >= len(samples) {
+			return
+		}
+		peak := amplitude * (0.5 + 0.5*rng.Float64())
+		for i := 0; i < length && start+i < len(samples); i++ {
+			decay := math.Exp(-float64(i) / (ImpulseDecay * sampleRate))
+			samples[start+i] += float32(peak * decay * (2*rng.Float64() - 1))
+		}
+		t += rng.ExpFloat64() / rate
+	}
+}
@@ -0,0 +1,203 @@
+package synth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testGeneratorConfig() GeneratorConfig {
+	return GeneratorConfig{Synth: testConfig(), WPM: 20, LeadIn: 100 * time.Millisecond, Seed: 1}
+}
+
+// power returns the mean square of samples
+func power(samples []float32) float64 {
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+	return sum / float64(len(samples))
+}
+
+func TestGenerate_Transcript(t *testing.T) {
+	signal, err := Generate(testGeneratorConfig(), "cq  de <SK>")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	truth := signal.Truth
+	if truth.Text != "CQ DE <SK>" {
+		t.Errorf("Text = %q, want %q", truth.Text, "CQ DE <SK>")
+	}
+	if len(truth.Characters) != 5 {
+		t.Fatalf("len(Characters) = %d, want 5", len(truth.Characters))
+	}
+
+	// C starts after the lead-in and lasts 11 dits at 60 ms
+	c := truth.Characters[0]
+	if math.Abs(c.Start-0.1) > 1e-9 || math.Abs(c.End-0.76) > 1e-9 {
+		t.Errorf("C timing = %.3f-%.3f, want 0.100-0.760", c.Start, c.End)
+	}
+	// Q follows a 3-dit gap; D follows a 7-dit word gap
+	if q := truth.Characters[1]; math.Abs(q.Start-0.94) > 1e-9 || q.WordStart {
+		t.Errorf("Q = %+v, want start 0.940 within the word", q)
+	}
+	if d := truth.Characters[2]; !d.WordStart || math.Abs(d.Start-(truth.Characters[1].End+0.42)) > 1e-9 {
+		t.Errorf("D = %+v, want a word start 420 ms after Q", d)
+	}
+	if sk := truth.Characters[4]; sk.Text != "<SK>" {
+		t.Errorf("last character = %q, want <SK>", sk.Text)
+	}
+
+	wantSamples := int(math.Round((truth.Characters[4].End + 0.1) * 8000))
+	if len(signal.Samples) != wantSamples {
+		t.Errorf("len(Samples) = %d, want %d", len(signal.Samples), wantSamples)
+	}
+	if math.Abs(truth.Duration-float64(wantSamples)/8000) > 1e-9 {
+		t.Errorf("Duration = %v, want %v", truth.Duration, float64(wantSamples)/8000)
+	}
+}
+
+func TestGenerate_Deterministic(t *testing.T) {
+	cfg := testGeneratorConfig()
+	cfg.Fist, _ = LookupFist("straight")
+	cfg.Noise = true
+	cfg.SNR = 10
+	cfg.ImpulseRate = 5
+	cfg.ImpulseAmplitude = 0.5
+
+	a, err := Generate(cfg, "TEST")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	b, _ := Generate(cfg, "TEST")
+	if len(a.Samples) != len(b.Samples) {
+		t.Fatal("same seed produced different lengths")
+	}
+	for i := range a.Samples {
+		if a.Samples[i] != b.Samples[i] {
+			t.Fatalf("same seed differs at sample %d", i)
+		}
+	}
+
+	cfg.Seed = 2
+	c, _ := Generate(cfg, "TEST")
+	if len(c.Samples) == len(a.Samples) && c.Samples[1000] == a.Samples[1000] {
+		t.Error("different seeds should produce different audio")
+	}
+}
+
+func TestGenerate_NoiseLevel(t *testing.T) {
+	cfg := testGeneratorConfig()
+	cfg.Noise = true
+	cfg.SNR = 0
+
+	signal, err := Generate(cfg, "E")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if signal.Truth.SNR == nil || *signal.Truth.SNR != 0 {
+		t.Errorf("Truth.SNR = %v, want 0", signal.Truth.SNR)
+	}
+
+	// Lead-in is pure noise: tone power spread over 4000 Hz instead of 2500 Hz
+	leadIn := signal.Samples[:700]
+	want := DefaultAmplitude * DefaultAmplitude / 2 * 4000 / NoiseBandwidth
+	if got := power(leadIn); math.Abs(got-want)/want > 0.2 {
+		t.Errorf("noise power = %.4f, want %.4f", got, want)
+	}
+}
+
+func TestGenerate_Fading(t *testing.T) {
+	cfg := testGeneratorConfig()
+	cfg.FadeDepth = 1
+	cfg.FadePeriod = 2 * time.Second
+
+	// A long dah run so the fade cycle is visible in the marks
+	signal, err := Generate(cfg, "TTTTTTTTTT")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	// Full depth at half the period: the first T is at full strength, the T nearest 1 s is near silent
+	markPower := func(c TruthCharacter) float64 {
+		return power(signal.Samples[int(c.Start*8000)+80 : int(c.End*8000)-80])
+	}
+	strong := markPower(signal.Truth.Characters[0])
+	nearest := signal.Truth.Characters[0]
+	for _, c := range signal.Truth.Characters {
+		if math.Abs((c.Start+c.End)/2-1) < math.Abs((nearest.Start+nearest.End)/2-1) {
+			nearest = c
+		}
+	}
+	faded := markPower(nearest)
+	if faded > strong*0.01 {
+		t.Errorf("faded power %.5f should be far below unfaded %.5f", faded, strong)
+	}
+}
+
+func TestGenerate_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*GeneratorConfig)
+		want   error
+	}{
+		{"fade depth", func(c *GeneratorConfig) { c.FadeDepth = 1.5 }, ErrInvalidFadeDepth},
+		{"fade period", func(c *GeneratorConfig) { c.FadeDepth = 0.5 }, ErrInvalidFadePeriod},
+		{"jitter", func(c *GeneratorConfig) { c.Jitter = 0.8 }, ErrInvalidJitter},
+		{"impulse rate", func(c *GeneratorConfig) { c.ImpulseRate = -1 }, ErrInvalidImpulseRate},
+		{"fist", func(c *GeneratorConfig) { c.Fist = Fist{DahDitRatio: 0.5} }, ErrInvalidFist},
+		{"synth", func(c *GeneratorConfig) { c.Synth.Amplitude = 0 }, ErrInvalidAmplitude},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testGeneratorConfig()
+			tt.modify(&cfg)
+			if _, err := Generate(cfg, "E"); !errors.Is(err, tt.want) {
+				t.Errorf("Generate() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupFist(t *testing.T) {
+	for _, name := range FistNames() {
+		fist, err := LookupFist(name)
+		if err != nil {
+			t.Errorf("LookupFist(%q) error = %v", name, err)
+		}
+		if fist.DahDitRatio <= 1 {
+			t.Errorf("fist %q has dah/dit ratio %v", name, fist.DahDitRatio)
+		}
+	}
+	if _, err := LookupFist("Bug"); err != nil {
+		t.Errorf("LookupFist should be case-insensitive: %v", err)
+	}
+	if _, err := LookupFist("paddle"); !errors.Is(err, ErrUnknownFist) {
+		t.Errorf("LookupFist(paddle) error = %v, want ErrUnknownFist", err)
+	}
+}
+
+func TestTranscript_WriteFile(t *testing.T) {
+	signal, err := Generate(testGeneratorConfig(), "K")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := signal.Truth.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var decoded Transcript
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded.Text != "K" || len(decoded.Characters) != 1 || decoded.SNR != nil {
+		t.Errorf("decoded transcript = %+v", decoded)
+	}
+
+	if err := signal.Truth.WriteFile(filepath.Join(t.TempDir(), "truth.json")); err != nil {
+		t.Errorf("WriteFile() error = %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+// internal/synth/synth.go
+// Package synth renders Morse keying to audio samples.
+package synth
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+// Synthesis defaults
+const (
+	// DefaultRiseTime is the rise and fall time of each mark; 5 ms keeps key clicks off adjacent channels
+	DefaultRiseTime = 5 * time.Millisecond
+	// DefaultAmplitude is the peak sample amplitude, leaving headroom for added noise
+	DefaultAmplitude = 0.5
+	// NyquistDivisor is the ratio of sample rate to the highest representable frequency
+	NyquistDivisor = 2.0
+)
+
+var (
+	// ErrInvalidSampleRate indicates sample rate must be positive
+	ErrInvalidSampleRate = errors.New("sample rate must be positive")
+	// ErrInvalidToneFrequency indicates tone frequency must be positive and below Nyquist
+	ErrInvalidToneFrequency = errors.New("tone frequency must be positive and below the Nyquist frequency")
+	// ErrInvalidAmplitude indicates amplitude must be between 0 and 1
+	ErrInvalidAmplitude = errors.New("amplitude must be between 0.0 and 1.0")
+	// ErrInvalidRiseTime indicates rise time must be non-negative
+	ErrInvalidRiseTime = errors.New("rise time must be non-negative")
+)
+
+// Config holds configuration for tone synthesis
+type Config struct {
+	// SampleRate is the output sample rate in Hz
+	SampleRate float64
+	// ToneFrequency is the sidetone pitch in Hz
+	ToneFrequency float64
+	// Amplitude is the peak sample amplitude (0.0-1.0)
+	Amplitude float64
+	// RiseTime is the raised-cosine rise and fall time of each mark (0 = hard keying)
+	RiseTime time.Duration
+}
+
+// Synthesizer renders keying intervals to a keyed sine tone
+type Synthesizer struct {
+	config Config
+}
+
+// NewSynthesizer creates a synthesizer after validating its configuration
+func NewSynthesizer(cfg Config) (*Synthesizer, error) {
+	if cfg.SampleRate <= 0 {
+		return nil, ErrInvalidSampleRate
+	}
+	if cfg.ToneFrequency <= 0 || cfg.ToneFrequency >= cfg.SampleRate/NyquistDivisor {
+		return nil, ErrInvalidToneFrequency
+	}
+	if cfg.Amplitude <= 0 || cfg.Amplitude > 1 {
+		return nil, ErrInvalidAmplitude
+	}
+	if cfg.RiseTime < 0 {
+		return nil, ErrInvalidRiseTime
+	}
+	return &Synthesizer{config: cfg}, nil
+}
+
+// Config returns the synthesizer configuration
+func (s *Synthesizer) Config() Config {
+	return s.config
+}
+
+// Render converts keying intervals to audio samples.
+// The oscillator runs continuously so every mark starts at a consistent phase;
+// interval boundaries are rounded to whole samples without accumulating error.
+func (s *Synthesizer) Render(keying []cw.Keying) []float32 {
+	return s.render(keying, func(_, _ float64) float64 { return s.config.ToneFrequency })
+}
+
+// toneFunc returns the instantaneous tone frequency at time t seconds,
+// sinceKeyDown seconds after the start of the current mark
+type toneFunc func(t, sinceKeyDown float64) float64
+
+// render keys a phase-continuous oscillator whose frequency follows tone
+func (s *Synthesizer) render(keying []cw.Keying, tone toneFunc) []float32 {
+	var total time.Duration
+	for _, k := range keying {
+		total += k.Duration
+	}
+	samples := make([]float32, s.sampleIndex(total))
+
+	phase := 0.0
+	var elapsed time.Duration
+	for _, k := range keying {
+		start := s.sampleIndex(elapsed)
+		elapsed += k.Duration
+		end := s.sampleIndex(elapsed)
+		if !k.ToneOn {
+			continue
+		}
+
+		length := end - start
+		ramp := s.sampleIndex(s.config.RiseTime)
+		// Marks shorter than two ramps rise and fall without a flat top
+		if ramp > length/2 {
+			ramp = length / 2
+		}
+		for i := 0; i < length; i++ {
+			t := float64(start+i) / s.config.SampleRate
+			frequency := tone(t, float64(i)/s.config.SampleRate)
+			// Advance the phase across the preceding space so marks stay coherent
+			if i == 0 {
+				phase = 2 * math.Pi * frequency * t
+			} else {
+				phase += 2 * math.Pi * frequency / s.config.SampleRate
+			}
+			gain := s.config.Amplitude * envelope(i, length, ramp)
+			samples[start+i] = float32(gain * math.Sin(phase))
+		}
+	}
+	return samples
+}
+
+// sampleIndex converts a time offset to a sample count
+func (s *Synthesizer) sampleIndex(offset time.Duration) int {
+	return int(math.Round(offset.Seconds() * s.config.SampleRate))
+}
+
+// envelope returns the raised-cosine keying envelope for sample i of a mark
+func envelope(i, length, ramp int) float64 {
+	if ramp == 0 {
+		return 1
+	}
+	switch {
+	case i < ramp:
+		return 0.5 * (1 - math.Cos(math.Pi*(float64(i)+0.5)/float64(ramp)))
+	case i >= length-ramp:
+		return 0.5 * (1 - math.Cos(math.Pi*(float64(length-i)-0.5)/float64(ramp)))
+	}
+	return 1
+}
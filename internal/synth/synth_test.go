@@ -0,0 +1,110 @@
+package synth
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+)
+
+func testConfig() Config {
+	return Config{SampleRate: 8000, ToneFrequency: 600, Amplitude: DefaultAmplitude, RiseTime: DefaultRiseTime}
+}
+
+func TestNewSynthesizer_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   error
+	}{
+		{"zero sample rate", func(c *Config) { c.SampleRate = 0 }, ErrInvalidSampleRate},
+		{"tone above Nyquist", func(c *Config) { c.ToneFrequency = 4000 }, ErrInvalidToneFrequency},
+		{"zero tone", func(c *Config) { c.ToneFrequency = 0 }, ErrInvalidToneFrequency},
+		{"amplitude above 1", func(c *Config) { c.Amplitude = 1.5 }, ErrInvalidAmplitude},
+		{"negative rise time", func(c *Config) { c.RiseTime = -time.Millisecond }, ErrInvalidRiseTime},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			tt.modify(&cfg)
+			if _, err := NewSynthesizer(cfg); !errors.Is(err, tt.want) {
+				t.Errorf("NewSynthesizer() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSynthesizer_Render(t *testing.T) {
+	synth, err := NewSynthesizer(testConfig())
+	if err != nil {
+		t.Fatalf("NewSynthesizer() error = %v", err)
+	}
+
+	keying := []cw.Keying{
+		{ToneOn: true, Duration: 60 * time.Millisecond},
+		{ToneOn: false, Duration: 60 * time.Millisecond},
+		{ToneOn: true, Duration: 180 * time.Millisecond},
+	}
+	samples := synth.Render(keying)
+	if len(samples) != 2400 {
+		t.Fatalf("len(samples) = %d, want 2400", len(samples))
+	}
+
+	// Silence between marks
+	for i := 480; i < 960; i++ {
+		if samples[i] != 0 {
+			t.Fatalf("samples[%d] = %v, want silence", i, samples[i])
+		}
+	}
+
+	// Ramps start near zero and the flat top reaches full amplitude
+	if math.Abs(float64(samples[0])) > 0.01 {
+		t.Errorf("first sample = %v, want near zero", samples[0])
+	}
+	var peak float64
+	for _, s := range samples[960:] {
+		peak = math.Max(peak, math.Abs(float64(s)))
+	}
+	if peak < DefaultAmplitude*0.99 || peak > DefaultAmplitude {
+		t.Errorf("peak = %v, want %v", peak, DefaultAmplitude)
+	}
+}
+
+func TestSynthesizer_ShortMarkRamp(t *testing.T) {
+	cfg := testConfig()
+	cfg.RiseTime = 50 * time.Millisecond
+	synth, _ := NewSynthesizer(cfg)
+
+	// A 10 ms mark is shorter than two ramps; it must still be symmetric and bounded
+	samples := synth.Render([]cw.Keying{{ToneOn: true, Duration: 10 * time.Millisecond}})
+	if len(samples) != 80 {
+		t.Fatalf("len(samples) = %d, want 80", len(samples))
+	}
+	for i, s := range samples {
+		if math.Abs(float64(s)) > DefaultAmplitude {
+			t.Fatalf("samples[%d] = %v exceeds amplitude", i, s)
+		}
+	}
+	if math.Abs(float64(samples[len(samples)-1])) > 0.05 {
+		t.Errorf("last sample = %v, want near zero", samples[len(samples)-1])
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	if got := envelope(5, 10, 0); got != 1 {
+		t.Errorf("hard keying envelope = %v, want 1", got)
+	}
+	// Rise and fall are mirror images
+	for i := 0; i < 4; i++ {
+		rise := envelope(i, 20, 4)
+		fall := envelope(19-i, 20, 4)
+		if math.Abs(rise-fall) > 1e-12 {
+			t.Errorf("envelope(%d) = %v, mirror = %v", i, rise, fall)
+		}
+	}
+	if got := envelope(10, 20, 4); got != 1 {
+		t.Errorf("flat top envelope = %v, want 1", got)
+	}
+}
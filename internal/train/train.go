@@ -0,0 +1,214 @@
+// internal/train/train.go
+// Package train labels the marks and spaces the decoder measures in recordings with
+// their true classes from the reference text, to train an element classifier.
+package train
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/bench"
+	"github.com/ColonelBlimp/cwdecoder/internal/classify"
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+	"github.com/ColonelBlimp/cwdecoder/internal/pipeline"
+	"github.com/ColonelBlimp/cwdecoder/internal/wav"
+)
+
+// Alignment costs, in units of |ln(measured / sent length)|
+const (
+	// SkipCost is the cost of a detected mark that was not sent, or a sent mark that was not detected
+	SkipCost = 1.0
+	// MaxMatchCost caps the cost of pairing a detected mark with a sent mark of the other kind
+	MaxMatchCost = 2.0
+)
+
+// Stats counts the marks seen while collecting a dataset
+type Stats struct {
+	// Recordings is the number of recordings decoded
+	Recordings int
+	// Sent is the number of marks in the reference texts
+	Sent int
+	// Detected is the number of marks the decoder measured
+	Detected int
+	// Labelled is the number of detected marks aligned with a sent mark
+	Labelled int
+}
+
+// sentMark is a mark of the reference text and the space that follows it
+type sentMark struct {
+	isDah    bool
+	hasSpace bool
+	space    cw.SpaceClass
+}
+
+// recordedSpace is a space the decoder classified after its detected mark
+type recordedSpace struct {
+	after    int
+	features cw.SpaceFeatures
+}
+
+// recorder is a classifier that records the decoder's features before delegating
+type recorder struct {
+	classifier cw.Classifier
+	marks      []cw.MarkFeatures
+	spaces     []recordedSpace
+}
+
+// ClassifyMark records the mark and classifies it with the wrapped classifier
+func (r *recorder) ClassifyMark(f cw.MarkFeatures) bool {
+	r.marks = append(r.marks, f)
+	return r.classifier.ClassifyMark(f)
+}
+
+// ClassifySpace records the space and classifies it with the wrapped classifier
+func (r *recorder) ClassifySpace(f cw.SpaceFeatures) cw.SpaceClass {
+	r.spaces = append(r.spaces, recordedSpace{after: len(r.marks) - 1, features: f})
+	return r.classifier.ClassifySpace(f)
+}
+
+// Collect decodes each recording with the ratio boundaries in settings, records the
+// features the decoder computed for every mark and space, and labels them by aligning
+// the detected marks with those of the reference text.
+func Collect(settings config.Settings, entries []bench.Entry) (classify.Dataset, Stats, error) {
+	rules := cw.RatioClassifier{
+		DitDahBoundary:    settings.DitDahBoundary,
+		InterCharBoundary: settings.InterCharBoundary,
+		CharWordBoundary:  settings.CharWordBoundary,
+	}
+
+	var data classify.Dataset
+	var stats Stats
+	for _, entry := range entries {
+		sent, err := sentMarks(entry.Reference)
+		if err != nil {
+			return classify.Dataset{}, Stats{}, fmt.Errorf("%s: %w", entry.Audio, err)
+		}
+		samples, sampleRate, err := wav.ReadFile(entry.Audio)
+		if err != nil {
+			return classify.Dataset{}, Stats{}, fmt.Errorf("%s: %w", entry.Audio, err)
+		}
+
+		rec := &recorder{classifier: rules}
+		settings.SampleRate = float64(sampleRate)
+		p, err := pipeline.NewWithClassifier(settings, rec)
+		if err != nil {
+			return classify.Dataset{}, Stats{}, fmt.Errorf("%s: %w", entry.Audio, err)
+		}
+		p.UseSampleClock(pipeline.SampleClockStart)
+		p.Process(samples)
+		p.Flush()
+
+		labelled := label(rec, sent)
+		data.Add(labelled)
+		stats.Recordings++
+		stats.Sent += len(sent)
+		stats.Detected += len(rec.marks)
+		stats.Labelled += len(labelled.Marks)
+	}
+	return data, stats, nil
+}
+
+// sentMarks returns the marks of text in order, each with the space sent after it
+func sentMarks(text string) ([]sentMark, error) {
+	symbols, err := cw.ParseText(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var marks []sentMark
+	for _, symbol := range symbols {
+		if symbol.IsWordSpace() {
+			marks[len(marks)-1].hasSpace = true
+			marks[len(marks)-1].space = cw.SpaceWord
+			continue
+		}
+		if len(marks) > 0 && !marks[len(marks)-1].hasSpace {
+			marks[len(marks)-1].hasSpace = true
+			marks[len(marks)-1].space = cw.SpaceInterChar
+		}
+		for i, isDah := range symbol.Elements {
+			marks = append(marks, sentMark{isDah: isDah, hasSpace: i < len(symbol.Elements)-1})
+		}
+	}
+	return marks, nil
+}
+
+// label pairs the recorded marks with sent marks and returns the labelled examples.
+// A space is labelled only when the marks on both sides of it were paired with
+// consecutive sent marks.
+func label(rec *recorder, sent []sentMark) classify.Dataset {
+	match := align(rec.marks, sent)
+
+	var data classify.Dataset
+	for i, j := range match {
+		if j >= 0 {
+			data.Marks = append(data.Marks, classify.MarkExample{Features: rec.marks[i], IsDah: sent[j].isDah})
+		}
+	}
+	for _, space := range rec.spaces {
+		i := space.after
+		if i < 0 || i+1 >= len(match) {
+			continue
+		}
+		j := match[i]
+		if j < 0 || match[i+1] != j+1 || !sent[j].hasSpace {
+			continue
+		}
+		data.Spaces = append(data.Spaces, classify.SpaceExample{Features: space.features, Class: sent[j].space})
+	}
+	return data
+}
+
+// align returns, for each detected mark, the index of the sent mark it pairs with or -1.
+// Marks are paired in order by minimum total cost, where a pair costs how far the
+// detected length is from the sent one and an unpaired mark on either side costs SkipCost.
+func align(detected []cw.MarkFeatures, sent []sentMark) []int {
+	n, m := len(detected), len(sent)
+	cost := make([][]float64, n+1)
+	for i := range cost {
+		cost[i] = make([]float64, m+1)
+		cost[i][0] = float64(i) * SkipCost
+	}
+	for j := range m + 1 {
+		cost[0][j] = float64(j) * SkipCost
+	}
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			cost[i][j] = min(
+				cost[i-1][j-1]+matchCost(detected[i-1], sent[j-1]),
+				cost[i-1][j]+SkipCost,
+				cost[i][j-1]+SkipCost,
+			)
+		}
+	}
+
+	match := make([]int, n)
+	for i := range match {
+		match[i] = -1
+	}
+	for i, j := n, m; i > 0 && j > 0; {
+		switch cost[i][j] {
+		case cost[i-1][j-1] + matchCost(detected[i-1], sent[j-1]):
+			match[i-1] = j - 1
+			i, j = i-1, j-1
+		case cost[i-1][j] + SkipCost:
+			i--
+		default:
+			j--
+		}
+	}
+	return match
+}
+
+// matchCost returns how poorly a detected mark fits a sent dit or dah
+func matchCost(detected cw.MarkFeatures, sent sentMark) float64 {
+	length := 1.0
+	if sent.isDah {
+		length = cw.DahDitRatio
+	}
+	if detected.Duration <= 0 {
+		return MaxMatchCost
+	}
+	return min(MaxMatchCost, math.Abs(math.Log(detected.Duration/length)))
+}
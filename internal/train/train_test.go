@@ -0,0 +1,163 @@
+package train
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/bench"
+	"github.com/ColonelBlimp/cwdecoder/internal/classify"
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/cw"
+	"github.com/ColonelBlimp/cwdecoder/internal/synth"
+	"github.com/ColonelBlimp/cwdecoder/internal/wav"
+)
+
+func TestSentMarks(t *testing.T) {
+	marks, err := sentMarks("AN T")
+	if err != nil {
+		t.Fatalf("sentMarks() error = %v", err)
+	}
+
+	want := []sentMark{
+		{isDah: false, hasSpace: true, space: cw.SpaceIntraChar},
+		{isDah: true, hasSpace: true, space: cw.SpaceInterChar},
+		{isDah: true, hasSpace: true, space: cw.SpaceIntraChar},
+		{isDah: false, hasSpace: true, space: cw.SpaceWord},
+		{isDah: true, hasSpace: false},
+	}
+	if len(marks) != len(want) {
+		t.Fatalf("sentMarks() returned %d marks, want %d", len(marks), len(want))
+	}
+	for i := range want {
+		if marks[i] != want[i] {
+			t.Errorf("mark %d = %+v, want %+v", i, marks[i], want[i])
+		}
+	}
+
+	if _, err := sentMarks("~"); err == nil {
+		t.Error("sentMarks() should fail for unencodable text")
+	}
+}
+
+func TestAlign(t *testing.T) {
+	sent, _ := sentMarks("AN")
+	dit, dah := cw.MarkFeatures{Duration: 1.1}, cw.MarkFeatures{Duration: 2.7}
+
+	tests := []struct {
+		name     string
+		detected []cw.MarkFeatures
+		want     []int
+	}{
+		{"exact", []cw.MarkFeatures{dit, dah, dah, dit}, []int{0, 1, 2, 3}},
+		{"false mark", []cw.MarkFeatures{dit, dah, {Duration: 0.2}, dah, dit}, []int{0, 1, -1, 2, 3}},
+		{"missed mark", []cw.MarkFeatures{dit, dah, dit}, []int{0, 1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := align(tt.detected, sent)
+			if len(got) != len(tt.want) {
+				t.Fatalf("align() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("align() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestLabel_SkipsSpacesAcrossUnpairedMarks(t *testing.T) {
+	sent, _ := sentMarks("AN")
+	rec := &recorder{
+		marks: []cw.MarkFeatures{{Duration: 1}, {Duration: 3}, {Duration: 0.2}, {Duration: 3}, {Duration: 1}},
+		spaces: []recordedSpace{
+			{after: 0, features: cw.SpaceFeatures{Duration: 1}},
+			{after: 1, features: cw.SpaceFeatures{Duration: 1}},
+			{after: 2, features: cw.SpaceFeatures{Duration: 2}},
+			{after: 3, features: cw.SpaceFeatures{Duration: 1}},
+		},
+	}
+
+	data := label(rec, sent)
+	if len(data.Marks) != 4 {
+		t.Errorf("labelled %d marks, want 4", len(data.Marks))
+	}
+	// Only the spaces inside A and inside N have paired marks on both sides
+	if len(data.Spaces) != 2 {
+		t.Fatalf("labelled %d spaces, want 2", len(data.Spaces))
+	}
+	for _, ex := range data.Spaces {
+		if ex.Class != cw.SpaceIntraChar {
+			t.Errorf("space labelled %v, want intra-char", ex.Class)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	const text = "CQ CQ DE W1AW W1AW K"
+	signal, err := synth.Generate(synth.GeneratorConfig{
+		Synth: synth.Config{SampleRate: 8000, ToneFrequency: 600, Amplitude: synth.DefaultAmplitude, RiseTime: synth.DefaultRiseTime},
+		WPM:   20,
+		Fist:  synth.Fist{DahDitRatio: 3, DitJitter: 0.1, DahJitter: 0.1, SpaceJitter: 0.1},
+		Seed:  7,
+	}, text)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "cq.wav")
+	if err := wav.WriteFile(path, signal.Samples, int(signal.SampleRate)); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	settings := config.Settings{
+		ToneFrequency:     600,
+		BlockSize:         128,
+		OverlapPct:        50,
+		Threshold:         0.4,
+		Hysteresis:        2,
+		AGCEnabled:        true,
+		AGCDecay:          0.9995,
+		AGCAttack:         0.1,
+		AGCWarmupBlocks:   10,
+		WPM:               20,
+		AdaptiveTiming:    true,
+		AdaptiveSmoothing: 0.1,
+		DitDahBoundary:    2.0,
+		InterCharBoundary: 2.0,
+		CharWordBoundary:  5.0,
+		KeyMode:           "electronic",
+		Alphabet:          "latin",
+		MorseCode:         "international",
+	}
+	data, stats, err := Collect(settings, []bench.Entry{{Audio: path, Reference: text}})
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	if stats.Recordings != 1 || stats.Sent == 0 || stats.Labelled != stats.Sent || stats.Detected != stats.Sent {
+		t.Errorf("stats = %+v, want every sent mark detected and labelled", stats)
+	}
+	// Every space but the last is measured between paired marks
+	if len(data.Spaces) != stats.Sent-1 {
+		t.Errorf("labelled %d spaces, want %d", len(data.Spaces), stats.Sent-1)
+	}
+	var words int
+	for _, ex := range data.Spaces {
+		if ex.Class == cw.SpaceWord {
+			words++
+		}
+	}
+	if words != 5 {
+		t.Errorf("labelled %d word spaces, want 5", words)
+	}
+
+	model, err := classify.Train(data, classify.TrainOptions{})
+	if err != nil {
+		t.Fatalf("Train() error = %v", err)
+	}
+	if marks, spaces := classify.Accuracy(model, data); marks < 0.99 || spaces < 0.99 {
+		t.Errorf("trained accuracy = %.3f marks, %.3f spaces, want >= 0.99", marks, spaces)
+	}
+}
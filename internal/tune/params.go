@@ -0,0 +1,142 @@
+// internal/tune/params.go
+// Package tune searches detection and timing settings for the lowest character
+// error rate over a labelled corpus.
+package tune
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+)
+
+// ErrUnknownParam indicates a parameter name that cannot be tuned
+var ErrUnknownParam = errors.New("unknown tunable parameter")
+
+// Param is one tunable setting and the range searched
+type Param struct {
+	// Key is the config file key
+	Key string
+	// Min is the lowest value searched
+	Min float64
+	// Max is the highest value searched
+	Max float64
+	// Integer rounds values to whole numbers
+	Integer bool
+	// PowerOfTwo searches the base-2 exponent (Min and Max are exponents)
+	PowerOfTwo bool
+
+	get func(s *config.Settings) float64
+	set func(s *config.Settings, v float64)
+}
+
+// Get returns the parameter's position in settings (the exponent for PowerOfTwo)
+func (p Param) Get(s *config.Settings) float64 {
+	v := p.get(s)
+	if p.PowerOfTwo {
+		return math.Log2(v)
+	}
+	return v
+}
+
+// Set stores a position in settings, clamped to the range and rounded as needed
+func (p Param) Set(s *config.Settings, v float64) {
+	v = p.clamp(v)
+	if p.PowerOfTwo {
+		v = math.Exp2(v)
+	}
+	p.set(s, v)
+}
+
+// Value returns the config value for settings (the actual block size for PowerOfTwo)
+func (p Param) Value(s *config.Settings) any {
+	v := p.get(s)
+	if p.Integer || p.PowerOfTwo {
+		return int(v)
+	}
+	return v
+}
+
+// clamp limits v to the range and rounds discrete parameters
+func (p Param) clamp(v float64) float64 {
+	v = math.Max(p.Min, math.Min(p.Max, v))
+	if p.Integer || p.PowerOfTwo {
+		v = math.Round(v)
+	}
+	return v
+}
+
+// span returns the width of the searched range
+func (p Param) span() float64 {
+	return p.Max - p.Min
+}
+
+// Params are the tunable settings; ranges stay inside config validation limits
+var Params = []Param{
+	{Key: "threshold", Min: 0.05, Max: 0.95,
+		get: func(s *config.Settings) float64 { return s.Threshold },
+		set: func(s *config.Settings, v float64) { s.Threshold = v }},
+	{Key: "hysteresis", Min: config.MinHysteresis, Max: 10, Integer: true,
+		get: func(s *config.Settings) float64 { return float64(s.Hysteresis) },
+		set: func(s *config.Settings, v float64) { s.Hysteresis = int(v) }},
+	{Key: "block_size", Min: 6, Max: 11, PowerOfTwo: true, // 64 to 2048 samples
+		get: func(s *config.Settings) float64 { return float64(s.BlockSize) },
+		set: func(s *config.Settings, v float64) { s.BlockSize = int(v) }},
+	{Key: "overlap_pct", Min: config.MinOverlapPct, Max: 90, Integer: true,
+		get: func(s *config.Settings) float64 { return float64(s.OverlapPct) },
+		set: func(s *config.Settings, v float64) { s.OverlapPct = int(v) }},
+	{Key: "agc_decay", Min: config.MinAGCDecay, Max: config.MaxAGCDecay,
+		get: func(s *config.Settings) float64 { return s.AGCDecay },
+		set: func(s *config.Settings, v float64) { s.AGCDecay = v }},
+	{Key: "agc_attack", Min: 0.01, Max: config.MaxAGCAttack,
+		get: func(s *config.Settings) float64 { return s.AGCAttack },
+		set: func(s *config.Settings, v float64) { s.AGCAttack = v }},
+	{Key: "agc_warmup_blocks", Min: 0, Max: 50, Integer: true,
+		get: func(s *config.Settings) float64 { return float64(s.AGCWarmupBlocks) },
+		set: func(s *config.Settings, v float64) { s.AGCWarmupBlocks = int(v) }},
+	{Key: "adaptive_smoothing", Min: 0.01, Max: 0.5,
+		get: func(s *config.Settings) float64 { return s.AdaptiveSmoothing },
+		set: func(s *config.Settings, v float64) { s.AdaptiveSmoothing = v }},
+	{Key: "dit_dah_boundary", Min: 1.5, Max: 3.0,
+		get: func(s *config.Settings) float64 { return s.DitDahBoundary },
+		set: func(s *config.Settings, v float64) { s.DitDahBoundary = v }},
+	{Key: "inter_char_boundary", Min: config.MinInterCharBoundary, Max: 3.5,
+		get: func(s *config.Settings) float64 { return s.InterCharBoundary },
+		set: func(s *config.Settings, v float64) { s.InterCharBoundary = v }},
+	{Key: "char_word_boundary", Min: 3.5, Max: 8.0,
+		get: func(s *config.Settings) float64 { return s.CharWordBoundary },
+		set: func(s *config.Settings, v float64) { s.CharWordBoundary = v }},
+}
+
+// ParamNames returns the keys of all tunable parameters
+func ParamNames() []string {
+	names := make([]string, len(Params))
+	for i, p := range Params {
+		names[i] = p.Key
+	}
+	return names
+}
+
+// SelectParams returns the parameters named in keys, or all of them if keys is empty
+func SelectParams(keys []string) ([]Param, error) {
+	if len(keys) == 0 {
+		return Params, nil
+	}
+	selected := make([]Param, 0, len(keys))
+	for _, key := range keys {
+		found := false
+		for _, p := range Params {
+			if p.Key == key {
+				selected = append(selected, p)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %q (valid: %s)", ErrUnknownParam, key, strings.Join(ParamNames(), ", "))
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,67 @@
+package tune
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+)
+
+func TestSelectParams(t *testing.T) {
+	all, err := SelectParams(nil)
+	if err != nil || len(all) != len(Params) {
+		t.Errorf("SelectParams(nil) = %d params, %v", len(all), err)
+	}
+
+	selected, err := SelectParams([]string{"hysteresis", "threshold"})
+	if err != nil {
+		t.Fatalf("SelectParams() error = %v", err)
+	}
+	if len(selected) != 2 || selected[0].Key != "hysteresis" || selected[1].Key != "threshold" {
+		t.Errorf("SelectParams() = %+v", selected)
+	}
+
+	if _, err := SelectParams([]string{"wpm"}); !errors.Is(err, ErrUnknownParam) {
+		t.Errorf("SelectParams(wpm) error = %v, want ErrUnknownParam", err)
+	}
+}
+
+func TestParam_SetClampsAndRounds(t *testing.T) {
+	params, _ := SelectParams([]string{"hysteresis", "block_size", "threshold"})
+	hysteresis, blockSize, threshold := params[0], params[1], params[2]
+
+	var s config.Settings
+	hysteresis.Set(&s, 3.6)
+	if s.Hysteresis != 4 {
+		t.Errorf("Hysteresis = %d, want 4", s.Hysteresis)
+	}
+	hysteresis.Set(&s, 100)
+	if s.Hysteresis != 10 {
+		t.Errorf("Hysteresis = %d, want clamp to 10", s.Hysteresis)
+	}
+
+	blockSize.Set(&s, 8.4)
+	if s.BlockSize != 256 || blockSize.Get(&s) != 8 || blockSize.Value(&s) != 256 {
+		t.Errorf("BlockSize = %d (position %v), want 256", s.BlockSize, blockSize.Get(&s))
+	}
+
+	threshold.Set(&s, 0)
+	if s.Threshold != 0.05 {
+		t.Errorf("Threshold = %v, want clamp to 0.05", s.Threshold)
+	}
+	if threshold.Value(&s) != 0.05 {
+		t.Errorf("Value() = %v, want 0.05", threshold.Value(&s))
+	}
+}
+
+func TestParams_RangesPassValidation(t *testing.T) {
+	for _, p := range Params {
+		for _, position := range []float64{p.Min, p.Max} {
+			s := validSettings()
+			p.Set(&s, position)
+			if err := s.Validate(); err != nil {
+				t.Errorf("%s at %v fails validation: %v", p.Key, position, err)
+			}
+		}
+	}
+}
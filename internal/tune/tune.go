@@ -0,0 +1,253 @@
+// internal/tune/tune.go
+package tune
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/bench"
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/wav"
+)
+
+// Search methods
+const (
+	// MethodCoordinate varies one parameter at a time, halving the step when nothing improves
+	MethodCoordinate = "coordinate"
+	// MethodRandom samples the whole space uniformly
+	MethodRandom = "random"
+)
+
+// Search defaults
+const (
+	// DefaultMaxEvaluations is the default budget of corpus decodes
+	DefaultMaxEvaluations = 200
+	// InitialStep is the first coordinate step as a fraction of each range
+	InitialStep = 0.25
+	// MinStep is the coordinate step below which the search stops
+	MinStep = 0.02
+)
+
+var (
+	// ErrInvalidMethod indicates an unknown search method
+	ErrInvalidMethod = errors.New("search method must be coordinate or random")
+	// ErrNoParams indicates there is nothing to tune
+	ErrNoParams = errors.New("no parameters to tune")
+)
+
+// Objective scores settings; lower is better. Invalid settings should return +Inf.
+type Objective func(settings config.Settings) (float64, error)
+
+// Options controls a search
+type Options struct {
+	// Method is MethodCoordinate or MethodRandom
+	Method string
+	// Params are the settings searched
+	Params []Param
+	// MaxEvaluations bounds the number of objective calls
+	MaxEvaluations int
+	// Seed makes random search reproducible
+	Seed uint64
+	// Progress is called after each improvement (may be nil)
+	Progress func(evaluations int, score float64, settings config.Settings)
+}
+
+// Result is the outcome of a search
+type Result struct {
+	// Settings are the best settings found
+	Settings config.Settings
+	// Score is the objective at Settings
+	Score float64
+	// InitialScore is the objective at the starting settings
+	InitialScore float64
+	// Evaluations is the number of objective calls made
+	Evaluations int
+}
+
+// Values returns the tuned parameters as config keys and values
+func (r Result) Values(params []Param) map[string]any {
+	values := make(map[string]any, len(params))
+	for _, p := range params {
+		values[p.Key] = p.Value(&r.Settings)
+	}
+	return values
+}
+
+// searcher tracks the incumbent and the evaluation budget
+type searcher struct {
+	objective Objective
+	options   Options
+	best      config.Settings
+	bestScore float64
+	evals     int
+}
+
+// evaluate scores a candidate and adopts it if strictly better
+func (s *searcher) evaluate(candidate config.Settings) (bool, error) {
+	s.evals++
+	if candidate.Validate() != nil {
+		return false, nil
+	}
+	score, err := s.objective(candidate)
+	if err != nil {
+		return false, err
+	}
+	if score >= s.bestScore {
+		return false, nil
+	}
+	s.best = candidate
+	s.bestScore = score
+	if s.options.Progress != nil {
+		s.options.Progress(s.evals, score, candidate)
+	}
+	return true, nil
+}
+
+// exhausted reports whether the evaluation budget is spent
+func (s *searcher) exhausted() bool {
+	return s.evals >= s.options.MaxEvaluations
+}
+
+// Search minimizes objective starting from start
+func Search(start config.Settings, objective Objective, options Options) (Result, error) {
+	if len(options.Params) == 0 {
+		return Result{}, ErrNoParams
+	}
+	if options.MaxEvaluations <= 0 {
+		options.MaxEvaluations = DefaultMaxEvaluations
+	}
+
+	initial, err := objective(start)
+	if err != nil {
+		return Result{}, err
+	}
+	s := &searcher{objective: objective, options: options, best: start, bestScore: initial, evals: 1}
+
+	switch options.Method {
+	case MethodCoordinate, "":
+		err = s.coordinate()
+	case MethodRandom:
+		err = s.random()
+	default:
+		return Result{}, fmt.Errorf("%w: %q", ErrInvalidMethod, options.Method)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Settings: s.best, Score: s.bestScore, InitialScore: initial, Evaluations: s.evals}, nil
+}
+
+// coordinate steps each parameter up and down, following a direction while it
+// keeps improving, and halves the step after a pass without improvement
+func (s *searcher) coordinate() error {
+	for step := InitialStep; step >= MinStep && s.bestScore > 0; {
+		improved := false
+		for _, p := range s.options.Params {
+			for _, direction := range []float64{1, -1} {
+				for !s.exhausted() {
+					candidate := s.best
+					current := p.Get(&candidate)
+					delta := direction * step * p.span()
+					if p.Integer || p.PowerOfTwo {
+						// Discrete parameters move at least one unit
+						delta = direction * math.Max(1, math.Round(math.Abs(delta)))
+					}
+					p.Set(&candidate, current+delta)
+					if p.Get(&candidate) == current {
+						break // at the edge of the range
+					}
+					better, err := s.evaluate(candidate)
+					if err != nil {
+						return err
+					}
+					if !better {
+						break
+					}
+					improved = true
+				}
+			}
+		}
+		if s.exhausted() {
+			return nil
+		}
+		if !improved {
+			step /= 2
+		}
+	}
+	return nil
+}
+
+// random samples every parameter uniformly until the budget is spent
+func (s *searcher) random() error {
+	rng := rand.New(rand.NewPCG(s.options.Seed, s.options.Seed^0x9e3779b97f4a7c15))
+	for !s.exhausted() && s.bestScore > 0 {
+		candidate := s.best
+		for _, p := range s.options.Params {
+			p.Set(&candidate, p.Min+rng.Float64()*p.span())
+		}
+		if _, err := s.evaluate(candidate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clip is a preloaded recording
+type clip struct {
+	samples    []float32
+	sampleRate float64
+	reference  string
+}
+
+// CorpusObjective loads the corpus once and returns an objective that decodes every
+// recording (in parallel) as bench does and returns the corpus character error rate
+func CorpusObjective(entries []bench.Entry) (Objective, error) {
+	clips := make([]clip, len(entries))
+	for i, entry := range entries {
+		samples, sampleRate, err := wav.ReadFile(entry.Audio)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Audio, err)
+		}
+		clips[i] = clip{samples: samples, sampleRate: float64(sampleRate), reference: entry.Reference}
+	}
+
+	return func(settings config.Settings) (float64, error) {
+		results := make([]bench.Errors, len(clips))
+		errs := make([]error, len(clips))
+		sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+		var wg sync.WaitGroup
+		for i, c := range clips {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				decoded, err := bench.Decode(settings, c.samples, c.sampleRate)
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				results[i] = bench.CharacterErrors(c.reference, bench.NormalizeDecoded(decoded))
+			}()
+		}
+		wg.Wait()
+
+		var total, reference int
+		for i, result := range results {
+			if errs[i] != nil {
+				// Settings the pipeline rejects (e.g. tone above Nyquist) cannot win
+				return math.Inf(1), nil
+			}
+			total += result.Total()
+			reference += result.Reference
+		}
+		if reference == 0 {
+			return float64(total), nil
+		}
+		return float64(total) / float64(reference), nil
+	}, nil
+}
@@ -0,0 +1,188 @@
+package tune
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ColonelBlimp/cwdecoder/internal/bench"
+	"github.com/ColonelBlimp/cwdecoder/internal/config"
+	"github.com/ColonelBlimp/cwdecoder/internal/synth"
+	"github.com/ColonelBlimp/cwdecoder/internal/wav"
+)
+
+// validSettings returns valid decoder settings for 8 kHz test audio at 20 WPM
+func validSettings() config.Settings {
+	return config.Settings{
+		SampleRate:        8000,
+		Channels:          1,
+		Format:            "S16_LE",
+		AudioSource:       "device",
+		BufferSize:        1024,
+		ToneFrequency:     600,
+		BlockSize:         128,
+		OverlapPct:        50,
+		Threshold:         0.4,
+		Hysteresis:        2,
+		AGCEnabled:        true,
+		AGCDecay:          0.9995,
+		AGCAttack:         0.1,
+		AGCWarmupBlocks:   10,
+		WPM:               20,
+		AdaptiveTiming:    true,
+		AdaptiveSmoothing: 0.1,
+		DitDahBoundary:    2.0,
+		InterCharBoundary: 2.0,
+		CharWordBoundary:  5.0,
+		KeyMode:           "electronic",
+		Alphabet:          "latin",
+		MorseCode:         "international",
+		SCPMaxDistance:    2,
+	}
+}
+
+// bowl is a synthetic objective with its minimum at threshold 0.3, char_word_boundary 6
+func bowl(s config.Settings) (float64, error) {
+	return math.Pow(s.Threshold-0.3, 2) + math.Pow(s.CharWordBoundary-6, 2)/10, nil
+}
+
+func TestSearch_Coordinate(t *testing.T) {
+	params, _ := SelectParams([]string{"threshold", "char_word_boundary"})
+	result, err := Search(validSettings(), bowl, Options{Method: MethodCoordinate, Params: params})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if math.Abs(result.Settings.Threshold-0.3) > 0.05 || math.Abs(result.Settings.CharWordBoundary-6) > 0.2 {
+		t.Errorf("best = threshold %v, char_word_boundary %v; want near 0.3, 6",
+			result.Settings.Threshold, result.Settings.CharWordBoundary)
+	}
+	if result.Score >= result.InitialScore {
+		t.Errorf("Score %v should improve on %v", result.Score, result.InitialScore)
+	}
+	if result.Evaluations > DefaultMaxEvaluations {
+		t.Errorf("Evaluations = %d exceeds budget", result.Evaluations)
+	}
+
+	values := result.Values(params)
+	if values["threshold"] != result.Settings.Threshold || len(values) != 2 {
+		t.Errorf("Values() = %v", values)
+	}
+}
+
+func TestSearch_RandomRespectsBudget(t *testing.T) {
+	params, _ := SelectParams([]string{"threshold", "char_word_boundary"})
+	calls := 0
+	counting := func(s config.Settings) (float64, error) {
+		calls++
+		return bowl(s)
+	}
+	var improvements int
+	result, err := Search(validSettings(), counting, Options{
+		Method: MethodRandom, Params: params, MaxEvaluations: 50, Seed: 3,
+		Progress: func(int, float64, config.Settings) { improvements++ },
+	})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if calls != 50 || result.Evaluations != 50 {
+		t.Errorf("objective called %d times (%d evaluations), want 50", calls, result.Evaluations)
+	}
+	if improvements == 0 || result.Score >= result.InitialScore {
+		t.Errorf("random search made %d improvements, score %v from %v", improvements, result.Score, result.InitialScore)
+	}
+}
+
+func TestSearch_Errors(t *testing.T) {
+	if _, err := Search(validSettings(), bowl, Options{}); !errors.Is(err, ErrNoParams) {
+		t.Errorf("no params error = %v, want ErrNoParams", err)
+	}
+	if _, err := Search(validSettings(), bowl, Options{Method: "annealing", Params: Params}); !errors.Is(err, ErrInvalidMethod) {
+		t.Errorf("bad method error = %v, want ErrInvalidMethod", err)
+	}
+
+	failing := errors.New("decode failed")
+	_, err := Search(validSettings(), func(config.Settings) (float64, error) { return 0, failing }, Options{Params: Params})
+	if !errors.Is(err, failing) {
+		t.Errorf("objective error = %v, want %v", err, failing)
+	}
+}
+
+// writeCorpus writes clean 20 WPM recordings of texts with their references and loads them
+func writeCorpus(t *testing.T, texts ...string) []bench.Entry {
+	t.Helper()
+	dir := t.TempDir()
+	for i, text := range texts {
+		signal, err := synth.Generate(synth.GeneratorConfig{
+			Synth: synth.Config{SampleRate: 8000, ToneFrequency: 600, Amplitude: synth.DefaultAmplitude, RiseTime: synth.DefaultRiseTime},
+			WPM:   20,
+		}, text)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		base := filepath.Join(dir, string(rune('a'+i)))
+		if err := wav.WriteFile(base+".wav", signal.Samples, 8000); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		if err := os.WriteFile(base+".txt", []byte(text), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	entries, err := bench.LoadCorpus([]string{dir})
+	if err != nil {
+		t.Fatalf("LoadCorpus() error = %v", err)
+	}
+	return entries
+}
+
+func TestCorpusObjective_TunesWordBoundary(t *testing.T) {
+	entries := writeCorpus(t, "CQ CQ DE W1AW K", "UR RST 599 5NN BK")
+	objective, err := CorpusObjective(entries)
+	if err != nil {
+		t.Fatalf("CorpusObjective() error = %v", err)
+	}
+
+	// With fixed timing and an 8-dit word boundary, every 7-dit word space is missed
+	start := validSettings()
+	start.AdaptiveTiming = false
+	start.CharWordBoundary = 8
+	params, _ := SelectParams([]string{"char_word_boundary"})
+
+	result, err := Search(start, objective, Options{Params: params, MaxEvaluations: 20})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if result.InitialScore == 0 {
+		t.Fatal("starting settings should produce errors")
+	}
+	if result.Score != 0 || result.Settings.CharWordBoundary >= 7 {
+		t.Errorf("tuned CER = %v at char_word_boundary %v, want 0 below 7", result.Score, result.Settings.CharWordBoundary)
+	}
+}
+
+func TestCorpusObjective_ScoresAsBench(t *testing.T) {
+	entries := writeCorpus(t, "CQ CQ DE W1AW K")
+	objective, err := CorpusObjective(entries)
+	if err != nil {
+		t.Fatalf("CorpusObjective() error = %v", err)
+	}
+
+	// Alone, an 8-dit word boundary misses every word space; the ensemble outvotes it
+	settings := validSettings()
+	settings.AdaptiveTiming = false
+	settings.CharWordBoundary = 8
+	settings.Ensemble = []map[string]any{{"char_word_boundary": 5.0}, {"char_word_boundary": 5.5}}
+
+	score, err := objective(settings)
+	if err != nil {
+		t.Fatalf("objective() error = %v", err)
+	}
+	report, err := bench.Run(settings, entries)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if score != report.CER {
+		t.Errorf("objective() = %v, want bench's CER %v", score, report.CER)
+	}
+}
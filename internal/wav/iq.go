@@ -0,0 +1,165 @@
+// internal/wav/iq.go
+package wav
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// auxiCentreOffset is where the centre frequency sits in a binary auxi chunk,
+// after the recording's start and stop times (two Windows SYSTEMTIMEs)
+const auxiCentreOffset = 32
+
+// ErrNotIQ indicates a WAV file without the two channels of an I/Q recording
+var ErrNotIQ = errors.New("I/Q recording must have two channels")
+
+// auxiXMLCentre finds the centre frequency in the XML auxi chunk SDR Console writes
+var auxiXMLCentre = regexp.MustCompile(`(?:RadioCenterFreq|CenterFrequency|CenterFreq)="([0-9.]+)"`)
+
+// IQReaderBufferSize is the read buffer of an IQReader in bytes
+const IQReaderBufferSize = 1 << 16
+
+// IQReader reads a recording of complex baseband samples from an SDR, the in-phase
+// component in the left channel and the quadrature component in the right, a block
+// at a time so recordings need not fit in memory
+type IQReader struct {
+	// SampleRate is the complex sample rate in Hz
+	SampleRate int
+	// CentreFrequency is the frequency the receiver was tuned to in Hz,
+	// from the recording's auxi chunk, or 0 if the recording does not say
+	CentreFrequency float64
+	// SwapIQ reads I from the right channel and Q from the left, for recordings
+	// whose spectrum is inverted
+	SwapIQ bool
+
+	rs         io.ReadSeeker
+	closer     io.Closer // the file opened by OpenIQFile, if any
+	br         *bufio.Reader
+	format     format
+	dataOffset int64
+	dataSize   int64
+	remaining  int64
+	buf        []byte
+}
+
+// NewIQReader reads the header of a two-channel WAV stream and returns a reader of
+// its I/Q samples (-1.0 to 1.0). The centre frequency is read from an auxi chunk in
+// the binary layout of SpectraVue and HDSDR or the XML of SDR Console.
+func NewIQReader(rs io.ReadSeeker) (*IQReader, error) {
+	h, err := readHeader(bufio.NewReader(rs))
+	if err != nil {
+		return nil, err
+	}
+	if h.format.channels != 2 {
+		return nil, fmt.Errorf("%w, got %d", ErrNotIQ, h.format.channels)
+	}
+
+	r := &IQReader{
+		SampleRate:      h.format.sampleRate,
+		CentreFrequency: auxiCentreFrequency(h.auxi),
+		rs:              rs,
+		br:              bufio.NewReaderSize(rs, IQReaderBufferSize),
+		format:          h.format,
+		dataOffset:      h.dataOffset,
+		dataSize:        h.dataSize,
+	}
+	if err := r.Rewind(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// OpenIQFile opens the I/Q WAV file at path. The reader must be closed.
+func OpenIQFile(path string) (*IQReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open WAV file: %w", err)
+	}
+	r, err := NewIQReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	r.closer = f
+	return r, nil
+}
+
+// Read reads up to len(i) frames into i and q, returning the number read.
+// At the end of the recording it returns 0 and io.EOF.
+func (r *IQReader) Read(i, q []float32) (int, error) {
+	width := r.format.bitsPerSample / 8
+	frameSize := 2 * width
+	size := min(int64(min(len(i), len(q))*frameSize), r.remaining)
+	size -= size % int64(frameSize)
+	if size == 0 {
+		return 0, io.EOF
+	}
+
+	if int64(len(r.buf)) < size {
+		r.buf = make([]byte, size)
+	}
+	n, err := io.ReadFull(r.br, r.buf[:size])
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		// The data chunk is longer than the file, as when a recording was cut short
+		r.remaining = 0
+	case err != nil:
+		return 0, fmt.Errorf("failed to read WAV data: %w", err)
+	default:
+		r.remaining -= int64(n)
+	}
+
+	frames := n / frameSize
+	if frames == 0 {
+		return 0, io.EOF
+	}
+	left, right := i, q
+	if r.SwapIQ {
+		left, right = q, i
+	}
+	for k := range frames {
+		offset := k * frameSize
+		left[k] = float32(decodeSample(r.buf[offset:offset+width], r.format.tag))
+		right[k] = float32(decodeSample(r.buf[offset+width:offset+frameSize], r.format.tag))
+	}
+	return frames, nil
+}
+
+// Rewind starts reading again from the first frame
+func (r *IQReader) Rewind() error {
+	if _, err := r.rs.Seek(r.dataOffset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek WAV data: %w", err)
+	}
+	r.br.Reset(r.rs)
+	r.remaining = r.dataSize
+	return nil
+}
+
+// Close closes the file the reader was opened from, if any
+func (r *IQReader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// auxiCentreFrequency returns the centre frequency an auxi chunk records, or 0
+func auxiCentreFrequency(auxi []byte) float64 {
+	if match := auxiXMLCentre.FindSubmatch(auxi); match != nil {
+		hz, err := strconv.ParseFloat(string(match[1]), 64)
+		if err == nil {
+			return hz
+		}
+		return 0
+	}
+	if len(auxi) >= auxiCentreOffset+4 {
+		return float64(binary.LittleEndian.Uint32(auxi[auxiCentreOffset:]))
+	}
+	return 0
+}
@@ -0,0 +1,161 @@
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// auxiChunk wraps an auxi chunk body with its header and any pad byte
+func auxiChunk(body []byte) []byte {
+	chunk := append([]byte("auxi"), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(len(body)))
+	chunk = append(chunk, body...)
+	if len(body)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// readAll reads every frame of r
+func readAll(t *testing.T, r *IQReader) ([]float32, []float32) {
+	t.Helper()
+	var i, q []float32
+	bufI, bufQ := make([]float32, 3), make([]float32, 3)
+	for {
+		n, err := r.Read(bufI, bufQ)
+		if err == io.EOF {
+			return i, q
+		}
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		i, q = append(i, bufI[:n]...), append(q, bufQ[:n]...)
+	}
+}
+
+func TestIQReader_BinaryAuxi(t *testing.T) {
+	body := make([]byte, 64)
+	binary.LittleEndian.PutUint32(body[auxiCentreOffset:], 7030000)
+	// Frames (I, Q): (0.5, -0.5) and (-1, 0.25)
+	data := []byte{0x00, 0x40, 0x00, 0xc0, 0x00, 0x80, 0x00, 0x20}
+	stream := buildWAV(fmtBody(FormatPCM, 2, 48000, 16), auxiChunk(body), data)
+
+	r, err := NewIQReader(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("NewIQReader() error = %v", err)
+	}
+	if r.SampleRate != 48000 || r.CentreFrequency != 7030000 {
+		t.Errorf("NewIQReader() = %d Hz centred on %v Hz, want 48000 Hz centred on 7030000 Hz",
+			r.SampleRate, r.CentreFrequency)
+	}
+	i, q := readAll(t, r)
+	wantI, wantQ := []float32{0.5, -1}, []float32{-0.5, 0.25}
+	if len(i) != len(wantI) {
+		t.Fatalf("read %d frames, want %d", len(i), len(wantI))
+	}
+	for n := range wantI {
+		if math.Abs(float64(i[n]-wantI[n])) > 1e-6 || math.Abs(float64(q[n]-wantQ[n])) > 1e-6 {
+			t.Errorf("frame %d = (%v, %v), want (%v, %v)", n, i[n], q[n], wantI[n], wantQ[n])
+		}
+	}
+}
+
+func TestIQReader_BlocksRewindAndSwap(t *testing.T) {
+	// Frames (n, -n) for n = 1 to 7, with a chunk after the data
+	var data bytes.Buffer
+	for n := 1; n <= 7; n++ {
+		_ = binary.Write(&data, binary.LittleEndian, [2]float32{float32(n), -float32(n)})
+	}
+	stream := buildWAV(fmtBody(FormatFloat, 2, 48000, 32), nil, data.Bytes())
+	stream = append(stream, auxiChunk([]byte("LIST"))...)
+
+	r, err := NewIQReader(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("NewIQReader() error = %v", err)
+	}
+	for pass := range 2 {
+		i, q := readAll(t, r)
+		if len(i) != 7 || i[6] != 7 || q[6] != -7 {
+			t.Errorf("pass %d read I %v Q %v, want 1 to 7 and their negatives", pass, i, q)
+		}
+		if err := r.Rewind(); err != nil {
+			t.Fatalf("Rewind() error = %v", err)
+		}
+	}
+
+	r.SwapIQ = true
+	if i, q := readAll(t, r); i[0] != -1 || q[0] != 1 {
+		t.Errorf("swapped frame 0 = (%v, %v), want (-1, 1)", i[0], q[0])
+	}
+}
+
+func TestIQReader_Truncated(t *testing.T) {
+	stream := buildWAV(fmtBody(FormatPCM, 2, 48000, 16), nil, make([]byte, 16))
+	// Cut the file one and a half frames short of its data chunk
+	r, err := NewIQReader(bytes.NewReader(stream[:len(stream)-6]))
+	if err != nil {
+		t.Fatalf("NewIQReader() error = %v", err)
+	}
+	if i, _ := readAll(t, r); len(i) != 2 {
+		t.Errorf("read %d frames, want the 2 complete frames", len(i))
+	}
+}
+
+func TestIQReader_XMLAuxi(t *testing.T) {
+	xml := []byte(`<?xml version="1.0"?><SDR-XML-Root><Definition RadioCenterFreq="14060000" SampleRate="96000"/></SDR-XML-Root>`)
+	stream := buildWAV(fmtBody(FormatFloat, 2, 96000, 32), auxiChunk(xml), make([]byte, 16))
+
+	r, err := NewIQReader(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("NewIQReader() error = %v", err)
+	}
+	if i, _ := readAll(t, r); r.CentreFrequency != 14060000 || len(i) != 2 {
+		t.Errorf("NewIQReader() = %d frames centred on %v Hz, want 2 frames centred on 14060000 Hz",
+			len(i), r.CentreFrequency)
+	}
+}
+
+func TestIQReader_NoMetadata(t *testing.T) {
+	stream := buildWAV(fmtBody(FormatPCM, 2, 48000, 16), nil, make([]byte, 8))
+	r, err := NewIQReader(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("NewIQReader() error = %v", err)
+	}
+	if r.CentreFrequency != 0 {
+		t.Errorf("CentreFrequency = %v, want 0 without an auxi chunk", r.CentreFrequency)
+	}
+}
+
+func TestIQReader_Mono(t *testing.T) {
+	stream := buildWAV(fmtBody(FormatPCM, 1, 48000, 16), nil, make([]byte, 8))
+	if _, err := NewIQReader(bytes.NewReader(stream)); !errors.Is(err, ErrNotIQ) {
+		t.Errorf("NewIQReader() of mono error = %v, want %v", err, ErrNotIQ)
+	}
+}
+
+func TestOpenIQFile(t *testing.T) {
+	if _, err := OpenIQFile(filepath.Join(t.TempDir(), "missing.wav")); err == nil {
+		t.Error("OpenIQFile() should fail for a missing file")
+	}
+
+	path := filepath.Join(t.TempDir(), "iq.wav")
+	if err := os.WriteFile(path, buildWAV(fmtBody(FormatPCM, 2, 48000, 16), nil, make([]byte, 8)), 0644); err != nil {
+		t.Fatalf("failed to write recording: %v", err)
+	}
+	r, err := OpenIQFile(path)
+	if err != nil {
+		t.Fatalf("OpenIQFile() error = %v", err)
+	}
+	if i, _ := readAll(t, r); len(i) != 2 {
+		t.Errorf("read %d frames, want 2", len(i))
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
@@ -0,0 +1,270 @@
+// internal/wav/read.go
+package wav
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// format is the decoded fmt chunk
+type format struct {
+	tag           uint16
+	channels      int
+	sampleRate    int
+	bitsPerSample int
+}
+
+// Read decodes a WAV stream to mono samples (-1.0 to 1.0) and its sample rate.
+// Integer PCM of 8 to 32 bits and 32-bit float are supported; channels are averaged.
+func Read(r io.Reader) ([]float32, int, error) {
+	br := bufio.NewReader(r)
+	h, err := readHeader(br)
+	if err != nil {
+		return nil, 0, err
+	}
+	data, err := io.ReadAll(io.LimitReader(br, h.dataSize))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read WAV data: %w", err)
+	}
+	return readSamples(data, h.format), h.format.sampleRate, nil
+}
+
+// header is a WAV stream's format and metadata, up to the samples of its first data chunk
+type header struct {
+	format     format
+	auxi       []byte // SDR recording metadata, if any
+	dataOffset int64  // where the samples start in the stream
+	dataSize   int64
+}
+
+// readHeader reads a WAV stream's chunks up to the samples of the first data chunk,
+// leaving br at the first sample
+func readHeader(br *bufio.Reader) (*header, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(br, riff[:]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotWAV, err)
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, ErrNotWAV
+	}
+	offset := int64(len(riff))
+
+	var fmtChunk *format
+	var auxi []byte
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(br, chunk[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil, ErrNoData
+			}
+			return nil, fmt.Errorf("failed to read WAV chunk: %w", err)
+		}
+		id := string(chunk[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		offset += int64(len(chunk))
+
+		switch id {
+		case "fmt ":
+			body := make([]byte, size)
+			if _, err := io.ReadFull(br, body); err != nil {
+				return nil, fmt.Errorf("failed to read WAV format: %w", err)
+			}
+			parsed, err := parseFormat(body)
+			if err != nil {
+				return nil, err
+			}
+			fmtChunk = parsed
+		case "data":
+			if fmtChunk == nil {
+				return nil, ErrNoData
+			}
+			return &header{format: *fmtChunk, auxi: auxi, dataOffset: offset, dataSize: size}, nil
+		case "auxi":
+			auxi = make([]byte, size)
+			if _, err := io.ReadFull(br, auxi); err != nil {
+				return nil, fmt.Errorf("failed to read WAV chunk %q: %w", id, err)
+			}
+		default:
+			// Skip LIST, fact and other metadata chunks
+			if _, err := io.CopyN(io.Discard, br, size); err != nil {
+				return nil, fmt.Errorf("failed to skip WAV chunk %q: %w", id, err)
+			}
+		}
+		offset += size
+		// Chunks are word aligned
+		if size%2 == 1 {
+			if _, err := br.Discard(1); err != nil {
+				return nil, ErrNoData
+			}
+			offset++
+		}
+	}
+}
+
+// ReaderBufferSize is the read buffer of a Reader in bytes
+const ReaderBufferSize = 1 << 16
+
+// Reader decodes a WAV stream to mono samples a block at a time, so recordings
+// need not fit in memory
+type Reader struct {
+	// SampleRate is the sample rate in Hz
+	SampleRate int
+
+	br        *bufio.Reader
+	closer    io.Closer // the file opened by OpenFile, if any
+	format    format
+	remaining int64
+	buf       []byte
+}
+
+// NewReader reads the header of a WAV stream and returns a reader of its samples,
+// decoded as Read does
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReaderSize(r, ReaderBufferSize)
+	h, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{
+		SampleRate: h.format.sampleRate,
+		br:         br,
+		format:     h.format,
+		remaining:  h.dataSize,
+	}, nil
+}
+
+// OpenFile opens the WAV file at path. The reader must be closed.
+func OpenFile(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open WAV file: %w", err)
+	}
+	r, err := NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	r.closer = f
+	return r, nil
+}
+
+// Read reads up to len(samples) samples, returning the number read.
+// At the end of the stream it returns 0 and io.EOF.
+func (r *Reader) Read(samples []float32) (int, error) {
+	frameSize := r.format.bitsPerSample / 8 * r.format.channels
+	size := min(int64(len(samples)*frameSize), r.remaining)
+	size -= size % int64(frameSize)
+	if size == 0 {
+		return 0, io.EOF
+	}
+
+	if int64(len(r.buf)) < size {
+		r.buf = make([]byte, size)
+	}
+	n, err := io.ReadFull(r.br, r.buf[:size])
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		// The data chunk is longer than the stream, as when a recording was cut short
+		r.remaining = 0
+	case err != nil:
+		return 0, fmt.Errorf("failed to read WAV data: %w", err)
+	default:
+		r.remaining -= int64(n)
+	}
+
+	frames := n / frameSize
+	if frames == 0 {
+		return 0, io.EOF
+	}
+	decodeFrames(samples[:frames], r.buf, r.format)
+	return frames, nil
+}
+
+// Close closes the file the reader was opened from, if any
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// ReadFile decodes the WAV file at path
+func ReadFile(path string) ([]float32, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open WAV file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	return Read(f)
+}
+
+// parseFormat decodes a fmt chunk body
+func parseFormat(body []byte) (*format, error) {
+	if len(body) < fmtChunkSize {
+		return nil, fmt.Errorf("%w: short fmt chunk", ErrUnsupportedFormat)
+	}
+	f := &format{
+		tag:           binary.LittleEndian.Uint16(body[0:2]),
+		channels:      int(binary.LittleEndian.Uint16(body[2:4])),
+		sampleRate:    int(binary.LittleEndian.Uint32(body[4:8])),
+		bitsPerSample: int(binary.LittleEndian.Uint16(body[14:16])),
+	}
+	// WAVE_FORMAT_EXTENSIBLE carries the real format tag at the start of its sub-format GUID
+	if f.tag == FormatExtensible && len(body) >= 26 {
+		f.tag = binary.LittleEndian.Uint16(body[24:26])
+	}
+
+	switch {
+	case f.channels < 1 || f.sampleRate <= 0:
+		return nil, fmt.Errorf("%w: %d channels at %d Hz", ErrUnsupportedFormat, f.channels, f.sampleRate)
+	case f.tag == FormatPCM && f.bitsPerSample%8 == 0 && f.bitsPerSample >= 8 && f.bitsPerSample <= 32:
+	case f.tag == FormatFloat && f.bitsPerSample == 32:
+	default:
+		return nil, fmt.Errorf("%w: format %d with %d bits", ErrUnsupportedFormat, f.tag, f.bitsPerSample)
+	}
+	return f, nil
+}
+
+// readSamples decodes interleaved frames and averages their channels
+func readSamples(data []byte, f format) []float32 {
+	samples := make([]float32, len(data)/(f.bitsPerSample/8*f.channels))
+	decodeFrames(samples, data, f)
+	return samples
+}
+
+// decodeFrames decodes the whole frames of data into samples, averaging their channels
+func decodeFrames(samples []float32, data []byte, f format) {
+	width := f.bitsPerSample / 8
+	frameSize := width * f.channels
+	for i := range samples {
+		var sum float64
+		for c := 0; c < f.channels; c++ {
+			offset := i*frameSize + c*width
+			sum += decodeSample(data[offset:offset+width], f.tag)
+		}
+		samples[i] = float32(sum / float64(f.channels))
+	}
+}
+
+// decodeSample converts one little-endian sample to -1.0 to 1.0
+func decodeSample(b []byte, tag uint16) float64 {
+	if tag == FormatFloat {
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	}
+	if len(b) == 1 {
+		// 8-bit PCM is unsigned
+		return (float64(b[0]) - 128) / 128
+	}
+	// Sign-extend the little-endian integer from its top byte
+	var value int64
+	for i := len(b) - 1; i >= 0; i-- {
+		value = value<<8 | int64(b[i])
+	}
+	bits := uint(len(b) * 8)
+	value = value << (64 - bits) >> (64 - bits)
+	return float64(value) / float64(int64(1)<<(bits-1))
+}
@@ -0,0 +1,164 @@
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// buildWAV assembles a WAV stream from a fmt chunk body, extra chunks and sample data
+func buildWAV(fmtBody []byte, extra []byte, data []byte) []byte {
+	var body bytes.Buffer
+	body.WriteString("WAVE")
+	body.WriteString("fmt ")
+	_ = binary.Write(&body, binary.LittleEndian, uint32(len(fmtBody)))
+	body.Write(fmtBody)
+	body.Write(extra)
+	body.WriteString("data")
+	_ = binary.Write(&body, binary.LittleEndian, uint32(len(data)))
+	body.Write(data)
+
+	var out bytes.Buffer
+	out.WriteString("RIFF")
+	_ = binary.Write(&out, binary.LittleEndian, uint32(body.Len()))
+	out.Write(body.Bytes())
+	return out.Bytes()
+}
+
+// fmtBody encodes a basic fmt chunk
+func fmtBody(tag, channels uint16, sampleRate uint32, bits uint16) []byte {
+	var b bytes.Buffer
+	blockAlign := channels * bits / 8
+	for _, field := range []any{tag, channels, sampleRate, sampleRate * uint32(blockAlign), blockAlign, bits} {
+		_ = binary.Write(&b, binary.LittleEndian, field)
+	}
+	return b.Bytes()
+}
+
+func TestRead_RoundTrip(t *testing.T) {
+	want := []float32{0, 0.25, -0.5, 0.999}
+	path := filepath.Join(t.TempDir(), "tone.wav")
+	if err := WriteFile(path, want, 8000); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	samples, sampleRate, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if sampleRate != 8000 || len(samples) != len(want) {
+		t.Fatalf("ReadFile() = %d samples at %d Hz", len(samples), sampleRate)
+	}
+	for i := range want {
+		if math.Abs(float64(samples[i]-want[i])) > 1e-4 {
+			t.Errorf("samples[%d] = %v, want %v", i, samples[i], want[i])
+		}
+	}
+}
+
+func TestRead_StereoAndMetadata(t *testing.T) {
+	// Two 8-bit stereo frames: (255, 1) averages to zero, (192, 192) to +0.5
+	list := append([]byte("LIST"), 3, 0, 0, 0, 'a', 'b', 'c', 0) // odd size with pad byte
+	data := []byte{255, 1, 192, 192}
+	stream := buildWAV(fmtBody(FormatPCM, 2, 11025, 8), list, data)
+
+	samples, sampleRate, err := Read(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if sampleRate != 11025 || len(samples) != 2 {
+		t.Fatalf("Read() = %v at %d Hz", samples, sampleRate)
+	}
+	if math.Abs(float64(samples[0])) > 1e-6 || math.Abs(float64(samples[1])-0.5) > 1e-6 {
+		t.Errorf("samples = %v, want [0 0.5]", samples)
+	}
+}
+
+func TestRead_Float32And24Bit(t *testing.T) {
+	var floatData bytes.Buffer
+	_ = binary.Write(&floatData, binary.LittleEndian, []float32{0.75, -0.125})
+	samples, _, err := Read(bytes.NewReader(buildWAV(fmtBody(FormatFloat, 1, 8000, 32), nil, floatData.Bytes())))
+	if err != nil {
+		t.Fatalf("Read(float) error = %v", err)
+	}
+	if samples[0] != 0.75 || samples[1] != -0.125 {
+		t.Errorf("float samples = %v", samples)
+	}
+
+	// 24-bit: 0x400000 = +0.5, 0xC00000 = -0.5
+	data := []byte{0x00, 0x00, 0x40, 0x00, 0x00, 0xC0}
+	samples, _, err = Read(bytes.NewReader(buildWAV(fmtBody(FormatPCM, 1, 8000, 24), nil, data)))
+	if err != nil {
+		t.Fatalf("Read(24-bit) error = %v", err)
+	}
+	if samples[0] != 0.5 || samples[1] != -0.5 {
+		t.Errorf("24-bit samples = %v, want [0.5 -0.5]", samples)
+	}
+}
+
+func TestRead_Errors(t *testing.T) {
+	if _, _, err := Read(bytes.NewReader([]byte("not a wav file at all"))); !errors.Is(err, ErrNotWAV) {
+		t.Errorf("Read(text) error = %v, want ErrNotWAV", err)
+	}
+	if _, _, err := Read(bytes.NewReader(buildWAV(fmtBody(2, 1, 8000, 4), nil, nil))); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Read(ADPCM) error = %v, want ErrUnsupportedFormat", err)
+	}
+	if _, _, err := Read(bytes.NewReader(append([]byte("RIFF"), 4, 0, 0, 0, 'W', 'A', 'V', 'E'))); !errors.Is(err, ErrNoData) {
+		t.Errorf("Read(empty) error = %v, want ErrNoData", err)
+	}
+}
+
+func TestReader_Blocks(t *testing.T) {
+	// Three 8-bit stereo frames averaging to 0, +0.5 and -0.5, the last cut short
+	data := []byte{255, 1, 192, 192, 64, 64}
+	stream := buildWAV(fmtBody(FormatPCM, 2, 11025, 8), nil, data)
+
+	r, err := NewReader(bytes.NewReader(stream[:len(stream)-1]))
+	if err != nil {
+		t.Fatalf("NewReader() error = %v", err)
+	}
+	if r.SampleRate != 11025 {
+		t.Errorf("SampleRate = %d, want 11025", r.SampleRate)
+	}
+	var samples []float32
+	buf := make([]float32, 1)
+	for {
+		n, err := r.Read(buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		samples = append(samples, buf[:n]...)
+	}
+	if len(samples) != 2 || math.Abs(float64(samples[1])-0.5) > 1e-6 {
+		t.Errorf("read %v, want the 2 complete frames [0 0.5]", samples)
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	if _, err := OpenFile(filepath.Join(t.TempDir(), "missing.wav")); err == nil {
+		t.Error("OpenFile() should fail for a missing file")
+	}
+
+	path := filepath.Join(t.TempDir(), "tone.wav")
+	if err := WriteFile(path, []float32{0.25, -0.25}, 8000); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	r, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	buf := make([]float32, 4)
+	if n, err := r.Read(buf); err != nil || n != 2 {
+		t.Errorf("Read() = %d, %v, want 2 samples", n, err)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+// internal/wav/wav.go
+// Package wav reads PCM and float WAV files, including SDR I/Q recordings, and writes
+// mono 16-bit PCM WAV files.
+package wav
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// WAV format constants
+const (
+	// BitsPerSample is the sample width written by this package
+	BitsPerSample = 16
+	// NumChannels is the channel count written by this package (mono)
+	NumChannels = 1
+	// FormatPCM is the WAVE format tag for integer PCM
+	FormatPCM = 1
+	// FormatFloat is the WAVE format tag for IEEE float samples
+	FormatFloat = 3
+	// FormatExtensible is the WAVE format tag whose real format is in the extension
+	FormatExtensible = 0xFFFE
+	// headerSize is the size of the RIFF, fmt and data chunk headers
+	headerSize = 44
+	// fmtChunkSize is the size of a PCM fmt chunk body
+	fmtChunkSize = 16
+)
+
+var (
+	// ErrInvalidSampleRate indicates sample rate must be positive
+	ErrInvalidSampleRate = errors.New("sample rate must be positive")
+	// ErrTooLarge indicates the audio does not fit in a WAV file
+	ErrTooLarge = errors.New("audio too large for a WAV file")
+	// ErrNotWAV indicates the input is not a RIFF/WAVE stream
+	ErrNotWAV = errors.New("not a WAV file")
+	// ErrUnsupportedFormat indicates a WAV encoding this package cannot decode
+	ErrUnsupportedFormat = errors.New("unsupported WAV format")
+	// ErrNoData indicates the WAV stream has no fmt or data chunk
+	ErrNoData = errors.New("WAV file has no audio data")
+)
+
+// Write encodes samples (-1.0 to 1.0, clipped outside that range) as a WAV stream
+func Write(w io.Writer, samples []float32, sampleRate int) error {
+	if sampleRate <= 0 {
+		return ErrInvalidSampleRate
+	}
+	bytesPerSample := BitsPerSample / 8
+	dataSize := uint64(len(samples)) * uint64(bytesPerSample*NumChannels)
+	if dataSize > math.MaxUint32-headerSize {
+		return ErrTooLarge
+	}
+
+	bw := bufio.NewWriter(w)
+	header := []any{
+		[4]byte{'R', 'I', 'F', 'F'},
+		uint32(headerSize - 8 + dataSize),
+		[4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '},
+		uint32(fmtChunkSize),
+		uint16(FormatPCM),
+		uint16(NumChannels),
+		uint32(sampleRate),
+		uint32(sampleRate * bytesPerSample * NumChannels), // byte rate
+		uint16(bytesPerSample * NumChannels),              // block align
+		uint16(BitsPerSample),
+		[4]byte{'d', 'a', 't', 'a'},
+		uint32(dataSize),
+	}
+	for _, field := range header {
+		if err := binary.Write(bw, binary.LittleEndian, field); err != nil {
+			return fmt.Errorf("failed to write WAV header: %w", err)
+		}
+	}
+
+	buf := make([]byte, bytesPerSample)
+	for _, sample := range samples {
+		binary.LittleEndian.PutUint16(buf, uint16(toPCM16(sample)))
+		if _, err := bw.Write(buf); err != nil {
+			return fmt.Errorf("failed to write WAV data: %w", err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write WAV data: %w", err)
+	}
+	return nil
+}
+
+// WriteFile writes samples to a WAV file at path
+func WriteFile(path string, samples []float32, sampleRate int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create WAV file: %w", err)
+	}
+	if err := Write(f, samples, sampleRate); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close WAV file: %w", err)
+	}
+	return nil
+}
+
+// toPCM16 converts a float sample to 16-bit PCM with clipping
+func toPCM16(sample float32) int16 {
+	switch {
+	case sample >= 1:
+		return math.MaxInt16
+	case sample <= -1:
+		return -math.MaxInt16
+	}
+	return int16(math.Round(float64(sample) * math.MaxInt16))
+}
@@ -0,0 +1,75 @@
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite_Header(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, []float32{0, 0.5, -0.5}, 8000); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	data := buf.Bytes()
+	if len(data) != headerSize+6 {
+		t.Fatalf("len = %d, want %d", len(data), headerSize+6)
+	}
+	if string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" || string(data[36:40]) != "data" {
+		t.Error("missing RIFF/WAVE/data tags")
+	}
+	if got := binary.LittleEndian.Uint32(data[4:8]); got != 36+6 {
+		t.Errorf("RIFF size = %d, want 42", got)
+	}
+	if got := binary.LittleEndian.Uint32(data[24:28]); got != 8000 {
+		t.Errorf("sample rate = %d, want 8000", got)
+	}
+	if got := binary.LittleEndian.Uint32(data[28:32]); got != 16000 {
+		t.Errorf("byte rate = %d, want 16000", got)
+	}
+	if got := binary.LittleEndian.Uint32(data[40:44]); got != 6 {
+		t.Errorf("data size = %d, want 6", got)
+	}
+}
+
+func TestWrite_InvalidSampleRate(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, nil, 0); !errors.Is(err, ErrInvalidSampleRate) {
+		t.Errorf("Write() error = %v, want ErrInvalidSampleRate", err)
+	}
+}
+
+func TestToPCM16(t *testing.T) {
+	tests := []struct {
+		sample float32
+		want   int16
+	}{
+		{0, 0},
+		{0.5, 16384},
+		{1, math.MaxInt16},
+		{2, math.MaxInt16},
+		{-2, -math.MaxInt16},
+	}
+	for _, tt := range tests {
+		if got := toPCM16(tt.sample); got != tt.want {
+			t.Errorf("toPCM16(%v) = %d, want %d", tt.sample, got, tt.want)
+		}
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.wav")
+	if err := WriteFile(path, make([]float32, 100), 48000); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Size() != headerSize+200 {
+		t.Errorf("file size = %d, want %d", info.Size(), headerSize+200)
+	}
+}